@@ -0,0 +1,197 @@
+// Command openapi-ts writes a typed TypeScript client for the frontend from
+// the OpenAPI document.
+//
+//	go run ./cmd/openapi-ts -out ../lib/api/generated.ts
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"myway-backend/internal/openapi"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	out := flag.String("out", "../lib/api/generated.ts", "output file")
+	flag.Parse()
+
+	source := generate(openapi.Document())
+	if err := os.WriteFile(*out, []byte(source), 0o644); err != nil {
+		log.Fatalf("Failed to write client: %v", err)
+	}
+	log.Printf("Wrote %s", *out)
+}
+
+type object = map[string]interface{}
+
+func generate(doc object) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by backend-go/cmd/openapi-ts. DO NOT EDIT.\n")
+	b.WriteString("// Regenerate with: cd backend-go && go run ./cmd/openapi-ts\n\n")
+	b.WriteString("import apiClient from '../axios-client';\n\n")
+	b.WriteString("export interface Page<T> {\n    items: T[];\n    total: number;\n    nextCursor: string | null;\n}\n\n")
+
+	schemas := doc["components"].(object)["schemas"].(object)
+	for _, name := range sortedKeys(schemas) {
+		fmt.Fprintf(&b, "export interface %s %s\n\n", name, tsObject(schemas[name].(object), ""))
+	}
+
+	paths := doc["paths"].(object)
+	for _, path := range sortedKeys(paths) {
+		item := paths[path].(object)
+		if _, unversioned := item["servers"]; unversioned {
+			// Operational endpoints live outside the API base URL.
+			continue
+		}
+		for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+			if op, ok := item[method].(object); ok {
+				writeOperation(&b, method, path, op)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func writeOperation(b *strings.Builder, method, path string, op object) {
+	var args, queryFields []string
+	url := "'" + path + "'"
+
+	params, _ := op["parameters"].([]interface{})
+	for _, p := range params {
+		param := p.(object)
+		name := param["name"].(string)
+		switch param["in"] {
+		case "path":
+			args = append(args, name+": string")
+			url = strings.Replace(url, "{"+name+"}", "${"+name+"}", 1)
+		case "query":
+			queryFields = append(queryFields, fmt.Sprintf("%s?: %s", name, tsType(param["schema"].(object), "    ")))
+		}
+	}
+	if strings.Contains(url, "${") {
+		url = "`" + strings.Trim(url, "'") + "`"
+	}
+
+	hasBody := false
+	if body, ok := op["requestBody"].(object); ok {
+		schema := body["content"].(object)["application/json"].(object)["schema"].(object)
+		args = append(args, "body: "+tsType(schema, "    "))
+		hasBody = true
+	}
+	if len(queryFields) > 0 {
+		args = append(args, "query: { "+strings.Join(queryFields, "; ")+" } = {}")
+	}
+
+	response := "unknown"
+	for code, r := range op["responses"].(object) {
+		if code == "default" {
+			continue
+		}
+		if content, ok := r.(object)["content"].(object)["application/json"].(object); ok {
+			response = tsType(content["schema"].(object), "")
+		} else {
+			response = "string"
+		}
+	}
+
+	var call []string
+	call = append(call, url)
+	if hasBody {
+		call = append(call, "body")
+	}
+	if len(queryFields) > 0 {
+		if !hasBody && (method == "post" || method == "put" || method == "patch") {
+			call = append(call, "undefined")
+		}
+		call = append(call, "{ params: query }")
+	}
+
+	if summary, ok := op["summary"].(string); ok && summary != "" {
+		fmt.Fprintf(b, "/** %s */\n", summary)
+	}
+	fmt.Fprintf(b, "export const %s = (%s) =>\n    apiClient.%s<%s>(%s).then((res) => res.data);\n\n",
+		op["operationId"], strings.Join(args, ", "), method, response, strings.Join(call, ", "))
+}
+
+func tsType(schema object, indent string) string {
+	var t string
+	switch {
+	case schema["$ref"] != nil:
+		t = strings.TrimPrefix(schema["$ref"].(string), "#/components/schemas/")
+	case schema["allOf"] != nil:
+		t = tsType(schema["allOf"].([]interface{})[0].(object), indent)
+	case isPage(schema):
+		items := schema["properties"].(object)["items"].(object)["items"].(object)
+		t = "Page<" + tsType(items, indent) + ">"
+	default:
+		switch schema["type"] {
+		case "string":
+			t = "string"
+		case "integer", "number":
+			t = "number"
+		case "boolean":
+			t = "boolean"
+		case "array":
+			t = tsType(schema["items"].(object), indent) + "[]"
+		case "object":
+			if additional, ok := schema["additionalProperties"].(object); ok {
+				t = "Record<string, " + tsType(additional, indent) + ">"
+			} else if _, ok := schema["properties"]; ok {
+				t = tsObject(schema, indent)
+			} else {
+				t = "Record<string, unknown>"
+			}
+		default:
+			t = "unknown"
+		}
+	}
+	if schema["nullable"] == true {
+		t += " | null"
+	}
+	return t
+}
+
+func tsObject(schema object, indent string) string {
+	props, _ := schema["properties"].(object)
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]string); ok {
+		for _, name := range list {
+			required[name] = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range sortedKeys(props) {
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s    %s%s: %s;\n", indent, name, optional, tsType(props[name].(object), indent+"    "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func isPage(schema object) bool {
+	props, ok := schema["properties"].(object)
+	if !ok || len(props) != 3 {
+		return false
+	}
+	_, hasItems := props["items"]
+	_, hasCursor := props["nextCursor"]
+	return hasItems && hasCursor
+}
+
+func sortedKeys(m object) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -7,7 +7,6 @@ import (
 	"myway-backend/internal/models"
 	"time"
 
-	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -144,6 +143,7 @@ func main() {
 		CreatedBy:        teacher.ID.String(),
 		Status:           "READY",
 		RequiresApproval: false,
+		ReviewStatus:     "PUBLISHED",
 		PublishedAt:     timePtr(time.Now()),
 	}
 	var existingStudyPack models.StudyPack
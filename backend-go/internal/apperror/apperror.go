@@ -0,0 +1,155 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Code identifies a class of domain error independently of the transport.
+type Code string
+
+const (
+	CodeValidation    Code = "VALIDATION"
+	CodeUnauthorized  Code = "UNAUTHORIZED"
+	CodeForbidden     Code = "FORBIDDEN"
+	CodeNotFound      Code = "NOT_FOUND"
+	CodeConflict      Code = "CONFLICT"
+	CodeUnprocessable Code = "UNPROCESSABLE"
+	CodeUnavailable   Code = "UNAVAILABLE"
+	CodeUpstream      Code = "UPSTREAM"
+	CodeInternal      Code = "INTERNAL"
+)
+
+var statusByCode = map[Code]int{
+	CodeValidation:    http.StatusBadRequest,
+	CodeUnauthorized:  http.StatusUnauthorized,
+	CodeForbidden:     http.StatusForbidden,
+	CodeNotFound:      http.StatusNotFound,
+	CodeConflict:      http.StatusConflict,
+	CodeUnprocessable: http.StatusUnprocessableEntity,
+	CodeUnavailable:   http.StatusServiceUnavailable,
+	CodeUpstream:      http.StatusBadGateway,
+	CodeInternal:      http.StatusInternalServerError,
+}
+
+// FieldError describes a problem with a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error that is safe to render to clients. The wrapped
+// cause is kept for logging and is never serialized.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status associated with the error code.
+func (e *Error) Status() int {
+	if status, ok := statusByCode[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Body returns the JSON error envelope. "error" stays a plain message so
+// existing clients reading response.data.error keep working.
+func (e *Error) Body() map[string]interface{} {
+	body := map[string]interface{}{
+		"error": e.Message,
+		"code":  e.Code,
+	}
+	if len(e.Fields) > 0 {
+		body["fields"] = e.Fields
+	}
+	return body
+}
+
+// WithField appends a field-level detail and returns the error for chaining.
+func (e *Error) WithField(field, message string) *Error {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+	return e
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: message, Fields: fields}
+}
+
+// InvalidField is shorthand for a validation error on a single field.
+func InvalidField(field, message string) *Error {
+	return Validation(message, FieldError{Field: field, Message: message})
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func Unprocessable(message string) *Error {
+	return New(CodeUnprocessable, message)
+}
+
+func Unavailable(message string) *Error {
+	return New(CodeUnavailable, message)
+}
+
+func Upstream(message string, err error) *Error {
+	return Wrap(CodeUpstream, message, err)
+}
+
+func Internal(message string, err error) *Error {
+	return Wrap(CodeInternal, message, err)
+}
+
+// FromDB maps a lookup error to NOT_FOUND when the record is missing and to
+// INTERNAL otherwise.
+func FromDB(err error, notFoundMessage string) *Error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Wrap(CodeNotFound, notFoundMessage, err)
+	}
+	return Internal("Database error", err)
+}
+
+// As converts any error into an *Error, treating unknown errors as INTERNAL.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("Internal server error", err)
+}
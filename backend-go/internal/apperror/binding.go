@@ -0,0 +1,70 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// UseJSONFieldNames makes validator report fields by their json tag so that
+// field-level details match the request payload.
+func UseJSONFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// FromBinding converts errors returned by gin's ShouldBind* helpers into a
+// VALIDATION error without leaking decoder internals.
+func FromBinding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		appErr := Validation("Request validation failed")
+		for _, fe := range validationErrs {
+			appErr.WithField(fe.Field(), describeTag(fe))
+		}
+		return appErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return Validation("Request validation failed", FieldError{Field: field, Message: "has an invalid type"})
+	}
+
+	if errors.Is(err, io.EOF) {
+		return Wrap(CodeValidation, "Request body is required", err)
+	}
+
+	return Wrap(CodeValidation, "Malformed request body", err)
+}
+
+func describeTag(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "uuid":
+		return "must be a valid UUID"
+	default:
+		return "is invalid"
+	}
+}
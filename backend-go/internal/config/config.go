@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,8 +15,26 @@ type Config struct {
 	Port         string
 	GeminiAPIKey string
 	GinMode      string
+
+	// LLMProvider picks the model behind the AI features: "gemini" (the
+	// default, needs GeminiAPIKey) or "fake" for tests and local runs.
+	LLMProvider string
+
+	// LegacyAPISunset is the YYYY-MM-DD date after which the unversioned
+	// route aliases are removed; it is advertised in the Sunset header.
+	LegacyAPISunset string
+
+	// IdempotencyTTL is how long a stored Idempotency-Key response is
+	// replayed before the key may be reused.
+	IdempotencyTTL time.Duration
+
+	// TrashRetention is how long deleted organizations, courses and modules
+	// stay restorable before they are purged.
+	TrashRetention time.Duration
 }
 
+const DefaultLegacyAPISunset = "2027-04-30"
+
 func LoadConfig() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
@@ -27,6 +47,11 @@ func LoadConfig() *Config {
 		Port:         getEnv("PORT", "3000"),
 		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
 		GinMode:      getEnv("GIN_MODE", "debug"),
+		LLMProvider:  getEnv("LLM_PROVIDER", "gemini"),
+
+		LegacyAPISunset: getEnv("LEGACY_API_SUNSET", DefaultLegacyAPISunset),
+		IdempotencyTTL:  getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		TrashRetention:  time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
 	}
 }
 
@@ -36,3 +61,29 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -1,10 +1,12 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"myway-backend/internal/models"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -12,6 +14,10 @@ import (
 
 var DB *gorm.DB
 
+// CourseCodeIndex keeps course codes unique within an organization,
+// ignoring case. Trashed courses are left out.
+const CourseCodeIndex = "idx_courses_org_code"
+
 func Connect(databaseURL string) error {
 	var err error
 	DB, err = gorm.Open(postgres.Open(databaseURL), &gorm.Config{
@@ -33,30 +39,86 @@ func AutoMigrate() error {
 		&models.User{},
 		&models.RefreshToken{},
 		&models.Organization{},
+		&models.OrgSettings{},
 		&models.OrgMembership{},
+		&models.Term{},
 		&models.Course{},
 		&models.Enrollment{},
 		&models.Module{},
 		&models.Material{},
 		&models.StudyPack{},
 		&models.Summary{},
+		&models.StudyPackRevision{},
+		&models.StudyPackReviewer{},
+		&models.ReviewComment{},
 		&models.Quiz{},
 		&models.QuizQuestion{},
 		&models.Flashcard{},
 		&models.QuizAttempt{},
+		&models.QuizReveal{},
 		&models.FlashcardSession{},
+		&models.FlashcardReviewState{},
+		&models.MaterialActivity{},
+		&models.VideoHeartbeat{},
+		&models.CompletionCriteria{},
 		&models.ProgressEvent{},
 		&models.Assignment{},
+		&models.AssignmentExtension{},
 		&models.Submission{},
+		&models.SubmissionAttempt{},
+		&models.GradingSuggestion{},
+		&models.PeerReview{},
+		&models.Rubric{},
+		&models.RubricVersion{},
+		&models.GradeCategory{},
+		&models.GradeOverride{},
 		&models.Thread{},
 		&models.Reply{},
 		&models.DailyOrgMetric{},
 		&models.CourseMetric{},
+		&models.IdempotencyKey{},
+		&models.TrashItem{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to auto-migrate: %w", err)
 	}
 
+	// Codes that clash from before the index existed keep one course and
+	// get the others' IDs appended
+	var renamed []struct {
+		ID   string
+		Code string
+	}
+	if err := DB.Raw(`UPDATE courses SET code = courses.code || '-' || LEFT(courses.id::text, 8)
+		FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY org_id, LOWER(code) ORDER BY id) AS n
+			FROM courses WHERE deleted_at IS NULL) AS ranked
+		WHERE courses.id = ranked.id AND ranked.n > 1
+		RETURNING courses.id, courses.code`).Scan(&renamed).Error; err != nil {
+		return fmt.Errorf("failed to rename duplicate course codes: %w", err)
+	}
+	for _, course := range renamed {
+		log.Printf("Renamed course %s to %s; its code clashed with another course in its organization", course.ID, course.Code)
+	}
+	if err := DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS " + CourseCodeIndex + " ON courses (org_id, LOWER(code)) WHERE deleted_at IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to create course code index: %w", err)
+	}
+
+	// Packs published before the review workflow existed
+	if err := DB.Exec("UPDATE study_packs SET review_status = 'PUBLISHED' WHERE status = 'READY' AND review_status = 'DRAFT'").Error; err != nil {
+		return fmt.Errorf("failed to backfill study pack review status: %w", err)
+	}
+
+	// Submissions made before attempts were kept become their first attempt
+	if err := DB.Exec(`INSERT INTO submission_attempts (submission_id, number, file_url, submitted_at, is_late, late_penalty_percent, score, raw_score, feedback)
+		SELECT s.id, 1, s.file_url, s.submitted_at, s.is_late, s.late_penalty_percent,
+			CASE WHEN s.status = 'GRADED' AND s.grade ~ '^[0-9]+$' THEN s.grade::int END,
+			CASE WHEN s.status = 'GRADED' AND s.grade ~ '^[0-9]+$' THEN s.grade::int END,
+			CASE WHEN s.status = 'GRADED' THEN s.feedback END
+		FROM submissions s
+		WHERE NOT EXISTS (SELECT 1 FROM submission_attempts a WHERE a.submission_id = s.id)`).Error; err != nil {
+		return fmt.Errorf("failed to backfill submission attempts: %w", err)
+	}
+
 	log.Println("Database migration completed")
 	return nil
 }
@@ -64,3 +126,10 @@ func AutoMigrate() error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// IsUniqueViolation reports whether err is Postgres rejecting a duplicate
+// in the named unique index.
+func IsUniqueViolation(err error, index string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == index
+}
@@ -0,0 +1,501 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"myway-backend/internal/gating"
+	"myway-backend/internal/gradeassist"
+	"myway-backend/internal/models"
+	"myway-backend/internal/rubric"
+
+	"github.com/google/uuid"
+)
+
+type Course struct {
+	ID            uuid.UUID       `json:"id" binding:"required"`
+	OrgID         uuid.UUID       `json:"orgId" binding:"required"`
+	Code          string          `json:"code" binding:"required"`
+	Title         string          `json:"title" binding:"required"`
+	Description   string          `json:"description" binding:"required"`
+	CreatedBy     uuid.UUID       `json:"createdBy" binding:"required"`
+	Status        string          `json:"status" binding:"required"`
+	CoverImageURL *string         `json:"coverImageUrl"`
+	Metadata      json.RawMessage `json:"metadata"`
+	TermID        *uuid.UUID      `json:"termId"`
+	ClonedFrom    *uuid.UUID      `json:"clonedFrom"`
+	Modules       []Module        `json:"modules,omitempty"`
+	Assignments   []Assignment    `json:"assignments,omitempty"`
+}
+
+// NewCourse includes modules and assignments when they were preloaded.
+func NewCourse(c models.Course) Course {
+	return Course{
+		ID:            c.ID,
+		OrgID:         c.OrgID,
+		Code:          c.Code,
+		Title:         c.Title,
+		Description:   c.Description,
+		CreatedBy:     c.CreatedBy,
+		Status:        c.Status,
+		CoverImageURL: c.CoverImageURL,
+		Metadata:      rawJSONPtr(c.Metadata),
+		TermID:        c.TermID,
+		ClonedFrom:    c.ClonedFrom,
+		Modules:       mapSlice(c.Modules, NewModule),
+		Assignments:   mapSlice(c.Assignments, NewAssignment),
+	}
+}
+
+type Term struct {
+	ID         uuid.UUID  `json:"id" binding:"required"`
+	OrgID      uuid.UUID  `json:"orgId" binding:"required"`
+	Name       string     `json:"name" binding:"required"`
+	StartsOn   time.Time  `json:"startsOn" binding:"required"`
+	EndsOn     time.Time  `json:"endsOn" binding:"required"`
+	Archived   bool       `json:"archived" binding:"required"`
+	ArchivedAt *time.Time `json:"archivedAt"`
+}
+
+func NewTerm(t models.Term) Term {
+	return Term{
+		ID:         t.ID,
+		OrgID:      t.OrgID,
+		Name:       t.Name,
+		StartsOn:   t.StartsOn,
+		EndsOn:     t.EndsOn,
+		Archived:   t.ArchivedAt != nil,
+		ArchivedAt: t.ArchivedAt,
+	}
+}
+
+func NewTerms(items []models.Term) []Term {
+	return mapSlice(items, NewTerm)
+}
+
+type Enrollment struct {
+	ID        uuid.UUID `json:"id" binding:"required"`
+	CourseID  uuid.UUID `json:"courseId" binding:"required"`
+	UserID    uuid.UUID `json:"userId" binding:"required"`
+	Role      string    `json:"role" binding:"required"`
+	CreatedAt time.Time `json:"createdAt" binding:"required"`
+	Course    *Course   `json:"course,omitempty"`
+}
+
+func NewEnrollment(e models.Enrollment) Enrollment {
+	out := Enrollment{ID: e.ID, CourseID: e.CourseID, UserID: e.UserID, Role: e.Role, CreatedAt: e.CreatedAt}
+	if e.Course.ID != uuid.Nil {
+		course := NewCourse(e.Course)
+		out.Course = &course
+	}
+	return out
+}
+
+type Module struct {
+	ID         uuid.UUID  `json:"id" binding:"required"`
+	CourseID   uuid.UUID  `json:"courseId" binding:"required"`
+	Title      string     `json:"title" binding:"required"`
+	Order      int        `json:"order" binding:"required"`
+	LockedRule *string    `json:"lockedRule"`
+	Materials  []Material `json:"materials,omitempty"`
+
+	// Locked is true when the module's rule keeps the caller out. Its
+	// materials are then withheld and LockRequirements lists the
+	// conditions still unmet.
+	Locked           bool                `json:"locked" binding:"required"`
+	LockRequirements []gating.UnlockRule `json:"lockRequirements,omitempty"`
+}
+
+func NewModule(m models.Module) Module {
+	return Module{
+		ID:         m.ID,
+		CourseID:   m.CourseID,
+		Title:      m.Title,
+		Order:      m.Order,
+		LockedRule: m.LockedRule,
+		Materials:  mapSlice(m.Materials, NewMaterial),
+	}
+}
+
+func NewModules(items []models.Module) []Module {
+	return mapSlice(items, NewModule)
+}
+
+// Lock withholds the module's materials and records what it is waiting
+// for.
+func (m *Module) Lock(unmet []gating.UnlockRule) {
+	m.Locked = true
+	m.LockRequirements = unmet
+	m.Materials = nil
+}
+
+type Material struct {
+	ID             uuid.UUID   `json:"id" binding:"required"`
+	ModuleID       uuid.UUID   `json:"moduleId" binding:"required"`
+	Type           string      `json:"type" binding:"required"`
+	Title          string      `json:"title" binding:"required"`
+	SourceURL      *string     `json:"sourceUrl"`
+	FileURL        *string     `json:"fileUrl"`
+	TranscriptText *string     `json:"transcriptText,omitempty"`
+	DurationSec    *float64    `json:"durationSec"`
+	Position       int         `json:"position" binding:"required"`
+	StudyPacks     []StudyPack `json:"studyPacks,omitempty"`
+}
+
+func NewMaterial(m models.Material) Material {
+	return Material{
+		ID:             m.ID,
+		ModuleID:       m.ModuleID,
+		Type:           m.Type,
+		Title:          m.Title,
+		SourceURL:      m.SourceURL,
+		FileURL:        m.FileURL,
+		TranscriptText: m.TranscriptText,
+		DurationSec:    m.DurationSec,
+		Position:       m.Position,
+		StudyPacks:     mapSlice(m.StudyPacks, NewStudyPack),
+	}
+}
+
+// MaterialRef names the material an item belongs to.
+type MaterialRef struct {
+	ID    uuid.UUID `json:"id" binding:"required"`
+	Type  string    `json:"type" binding:"required"`
+	Title string    `json:"title" binding:"required"`
+}
+
+func newMaterialRef(m models.Material) *MaterialRef {
+	if m.ID == uuid.Nil {
+		return nil
+	}
+	return &MaterialRef{ID: m.ID, Type: m.Type, Title: m.Title}
+}
+
+type Assignment struct {
+	ID                uuid.UUID  `json:"id" binding:"required"`
+	CourseID          uuid.UUID  `json:"courseId" binding:"required"`
+	Title             string     `json:"title" binding:"required"`
+	DueAt             time.Time  `json:"dueAt" binding:"required"`
+	Points            int        `json:"points" binding:"required"`
+	Instructions      string     `json:"instructions" binding:"required"`
+	Status            string     `json:"status" binding:"required"`
+	LatePolicy        string     `json:"latePolicy" binding:"required"`
+	LatePenaltyPerDay int        `json:"latePenaltyPerDay" binding:"required"`
+	MaxAttempts       *int       `json:"maxAttempts"`
+	RubricID          *uuid.UUID `json:"rubricId"`
+	GradeCategoryID   *uuid.UUID `json:"gradeCategoryId"`
+	// Peer review settings; PeerReviewCount is null when it is off.
+	PeerReviewCount       *int       `json:"peerReviewCount"`
+	PeerReviewDueAt       *time.Time `json:"peerReviewDueAt"`
+	PeerReviewWeight      int        `json:"peerReviewWeight" binding:"required"`
+	PeerReviewsAssignedAt *time.Time `json:"peerReviewsAssignedAt"`
+}
+
+func NewAssignment(a models.Assignment) Assignment {
+	return Assignment{
+		ID:                    a.ID,
+		CourseID:              a.CourseID,
+		Title:                 a.Title,
+		DueAt:                 a.DueAt,
+		Points:                a.Points,
+		Instructions:          a.Instructions,
+		Status:                a.Status,
+		LatePolicy:            a.LatePolicy,
+		LatePenaltyPerDay:     a.LatePenaltyPerDay,
+		MaxAttempts:           a.MaxAttempts,
+		RubricID:              a.RubricID,
+		GradeCategoryID:       a.GradeCategoryID,
+		PeerReviewCount:       a.PeerReviewCount,
+		PeerReviewDueAt:       a.PeerReviewDueAt,
+		PeerReviewWeight:      a.PeerReviewWeight,
+		PeerReviewsAssignedAt: a.PeerReviewsAssignedAt,
+	}
+}
+
+// AssignmentExtension is a later due date granted to one student.
+type AssignmentExtension struct {
+	ID           uuid.UUID `json:"id" binding:"required"`
+	AssignmentID uuid.UUID `json:"assignmentId" binding:"required"`
+	UserID       uuid.UUID `json:"userId" binding:"required"`
+	StudentName  string    `json:"studentName,omitempty"`
+	DueAt        time.Time `json:"dueAt" binding:"required"`
+	Reason       *string   `json:"reason"`
+	GrantedBy    uuid.UUID `json:"grantedBy" binding:"required"`
+	UpdatedAt    time.Time `json:"updatedAt" binding:"required"`
+}
+
+func NewAssignmentExtension(e models.AssignmentExtension) AssignmentExtension {
+	return AssignmentExtension{
+		ID:           e.ID,
+		AssignmentID: e.AssignmentID,
+		UserID:       e.UserID,
+		StudentName:  e.User.Name,
+		DueAt:        e.DueAt,
+		Reason:       e.Reason,
+		GrantedBy:    e.GrantedBy,
+		UpdatedAt:    e.UpdatedAt,
+	}
+}
+
+func NewAssignmentExtensions(items []models.AssignmentExtension) []AssignmentExtension {
+	return mapSlice(items, NewAssignmentExtension)
+}
+
+type Submission struct {
+	ID           uuid.UUID `json:"id" binding:"required"`
+	AssignmentID uuid.UUID `json:"assignmentId" binding:"required"`
+	UserID       uuid.UUID `json:"userId" binding:"required"`
+	Status       string    `json:"status" binding:"required"`
+	FileURL      *string   `json:"fileUrl"`
+	SubmittedAt  time.Time `json:"submittedAt" binding:"required"`
+	Grade        *string   `json:"grade"`
+	Feedback     *string   `json:"feedback"`
+	IsLate       bool      `json:"isLate" binding:"required"`
+	// LatePenaltyPercent is deducted from the score when it is graded.
+	LatePenaltyPercent  int                 `json:"latePenaltyPercent" binding:"required"`
+	ResubmitComment     *string             `json:"resubmitComment"`
+	ResubmitRequestedAt *time.Time          `json:"resubmitRequestedAt"`
+	Attempts            []SubmissionAttempt `json:"attempts,omitempty"`
+}
+
+func NewSubmission(s models.Submission) Submission {
+	return Submission{
+		ID:                  s.ID,
+		AssignmentID:        s.AssignmentID,
+		UserID:              s.UserID,
+		Status:              s.Status,
+		FileURL:             s.FileURL,
+		SubmittedAt:         s.SubmittedAt,
+		Grade:               s.Grade,
+		Feedback:            s.Feedback,
+		IsLate:              s.IsLate,
+		LatePenaltyPercent:  s.LatePenaltyPercent,
+		ResubmitComment:     s.ResubmitComment,
+		ResubmitRequestedAt: s.ResubmitRequestedAt,
+		Attempts:            mapSlice(s.Attempts, NewSubmissionAttempt),
+	}
+}
+
+// SubmissionAttempt is one hand-in, newest last in a submission's history.
+type SubmissionAttempt struct {
+	ID                 uuid.UUID `json:"id" binding:"required"`
+	Number             int       `json:"number" binding:"required"`
+	FileURL            *string   `json:"fileUrl"`
+	Text               *string   `json:"text"`
+	SubmittedAt        time.Time `json:"submittedAt" binding:"required"`
+	IsLate             bool      `json:"isLate" binding:"required"`
+	LatePenaltyPercent int       `json:"latePenaltyPercent" binding:"required"`
+	RawScore           *int      `json:"rawScore"`
+	// Set for peer-reviewed assignments, whose raw score blends the two
+	TeacherScore *int       `json:"teacherScore"`
+	PeerScore    *int       `json:"peerScore"`
+	Score        *int       `json:"score"`
+	Feedback     *string    `json:"feedback"`
+	GradedAt     *time.Time `json:"gradedAt"`
+	// Rubric breakdown, for attempts graded with a rubric
+	RubricVersionID *uuid.UUID    `json:"rubricVersionId"`
+	RubricGrade     *rubric.Grade `json:"rubricGrade"`
+	// The AI grading suggestion the grade was based on
+	GradingSuggestionID *uuid.UUID `json:"gradingSuggestionId"`
+}
+
+func NewSubmissionAttempt(a models.SubmissionAttempt) SubmissionAttempt {
+	return SubmissionAttempt{
+		ID:                  a.ID,
+		Number:              a.Number,
+		FileURL:             a.FileURL,
+		Text:                a.Text,
+		SubmittedAt:         a.SubmittedAt,
+		IsLate:              a.IsLate,
+		LatePenaltyPercent:  a.LatePenaltyPercent,
+		RawScore:            a.RawScore,
+		Score:               a.Score,
+		Feedback:            a.Feedback,
+		GradedAt:            a.GradedAt,
+		RubricVersionID:     a.RubricVersionID,
+		RubricGrade:         DecodeRubricGrade(a.RubricGrade),
+		GradingSuggestionID: a.GradingSuggestionID,
+	}
+}
+
+func NewSubmissionAttempts(items []models.SubmissionAttempt) []SubmissionAttempt {
+	return mapSlice(items, NewSubmissionAttempt)
+}
+
+// Rubric is a rubric with the criteria of its current version.
+type Rubric struct {
+	ID          uuid.UUID          `json:"id" binding:"required"`
+	OrgID       uuid.UUID          `json:"orgId" binding:"required"`
+	Title       string             `json:"title" binding:"required"`
+	Description *string            `json:"description"`
+	Version     int                `json:"version" binding:"required"`
+	Criteria    []rubric.Criterion `json:"criteria" binding:"required"`
+	MaxPoints   int                `json:"maxPoints" binding:"required"`
+	CreatedBy   uuid.UUID          `json:"createdBy" binding:"required"`
+	CreatedAt   time.Time          `json:"createdAt" binding:"required"`
+	UpdatedAt   time.Time          `json:"updatedAt" binding:"required"`
+}
+
+// NewRubric builds a Rubric from the rubric and its current version.
+func NewRubric(r models.Rubric, current models.RubricVersion) Rubric {
+	criteria := DecodeRubricCriteria(current.Criteria)
+	return Rubric{
+		ID:          r.ID,
+		OrgID:       r.OrgID,
+		Title:       r.Title,
+		Description: r.Description,
+		Version:     r.Version,
+		Criteria:    criteria,
+		MaxPoints:   rubric.MaxPoints(criteria),
+		CreatedBy:   r.CreatedBy,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+// RubricVersion is the criteria of one version of a rubric.
+type RubricVersion struct {
+	ID        uuid.UUID          `json:"id" binding:"required"`
+	Number    int                `json:"number" binding:"required"`
+	Criteria  []rubric.Criterion `json:"criteria" binding:"required"`
+	MaxPoints int                `json:"maxPoints" binding:"required"`
+	CreatedBy uuid.UUID          `json:"createdBy" binding:"required"`
+	CreatedAt time.Time          `json:"createdAt" binding:"required"`
+}
+
+func NewRubricVersion(v models.RubricVersion) RubricVersion {
+	criteria := DecodeRubricCriteria(v.Criteria)
+	return RubricVersion{
+		ID:        v.ID,
+		Number:    v.Number,
+		Criteria:  criteria,
+		MaxPoints: rubric.MaxPoints(criteria),
+		CreatedBy: v.CreatedBy,
+		CreatedAt: v.CreatedAt,
+	}
+}
+
+func NewRubricVersions(items []models.RubricVersion) []RubricVersion {
+	return mapSlice(items, NewRubricVersion)
+}
+
+// DecodeRubricCriteria reads a RubricVersion's criteria column.
+func DecodeRubricCriteria(value string) []rubric.Criterion {
+	criteria := []rubric.Criterion{}
+	if err := json.Unmarshal([]byte(value), &criteria); err != nil {
+		return []rubric.Criterion{}
+	}
+	return criteria
+}
+
+// DecodeRubricGrade reads a SubmissionAttempt's rubric grade column.
+func DecodeRubricGrade(value *string) *rubric.Grade {
+	if value == nil {
+		return nil
+	}
+	var grade rubric.Grade
+	if err := json.Unmarshal([]byte(*value), &grade); err != nil {
+		return nil
+	}
+	return &grade
+}
+
+// GradingSuggestion is an AI-drafted grade for an attempt. Prompt and
+// Output are the exchange with the model it came from.
+type GradingSuggestion struct {
+	ID              uuid.UUID                         `json:"id" binding:"required"`
+	AttemptID       uuid.UUID                         `json:"attemptId" binding:"required"`
+	RubricVersionID *uuid.UUID                        `json:"rubricVersionId"`
+	Criteria        []gradeassist.CriterionSuggestion `json:"criteria"`
+	RubricGrade     *rubric.Grade                     `json:"rubricGrade"`
+	Score           int                               `json:"score" binding:"required"`
+	Justification   *string                           `json:"justification"`
+	Feedback        string                            `json:"feedback" binding:"required"`
+	Text            string                            `json:"text" binding:"required"`
+	TextSource      string                            `json:"textSource" binding:"required"`
+	Status          string                            `json:"status" binding:"required"`
+	Provider        string                            `json:"provider" binding:"required"`
+	Model           string                            `json:"model" binding:"required"`
+	Prompt          string                            `json:"prompt" binding:"required"`
+	Output          string                            `json:"output" binding:"required"`
+	RequestedBy     uuid.UUID                         `json:"requestedBy" binding:"required"`
+	ReviewedBy      *uuid.UUID                        `json:"reviewedBy"`
+	ReviewedAt      *time.Time                        `json:"reviewedAt"`
+	CreatedAt       time.Time                         `json:"createdAt" binding:"required"`
+}
+
+func NewGradingSuggestion(s models.GradingSuggestion) GradingSuggestion {
+	var criteria []gradeassist.CriterionSuggestion
+	if s.Criteria != nil {
+		_ = json.Unmarshal([]byte(*s.Criteria), &criteria)
+	}
+	return GradingSuggestion{
+		ID:              s.ID,
+		AttemptID:       s.AttemptID,
+		RubricVersionID: s.RubricVersionID,
+		Criteria:        criteria,
+		RubricGrade:     DecodeRubricGrade(s.RubricGrade),
+		Score:           s.Score,
+		Justification:   s.Justification,
+		Feedback:        s.Feedback,
+		Text:            s.Text,
+		TextSource:      s.TextSource,
+		Status:          s.Status,
+		Provider:        s.Provider,
+		Model:           s.Model,
+		Prompt:          s.Prompt,
+		Output:          s.Output,
+		RequestedBy:     s.RequestedBy,
+		ReviewedBy:      s.ReviewedBy,
+		ReviewedAt:      s.ReviewedAt,
+		CreatedAt:       s.CreatedAt,
+	}
+}
+
+func NewGradingSuggestions(items []models.GradingSuggestion) []GradingSuggestion {
+	return mapSlice(items, NewGradingSuggestion)
+}
+
+type Thread struct {
+	ID        uuid.UUID `json:"id" binding:"required"`
+	CourseID  uuid.UUID `json:"courseId" binding:"required"`
+	Title     string    `json:"title" binding:"required"`
+	Body      string    `json:"body" binding:"required"`
+	CreatedAt time.Time `json:"createdAt" binding:"required"`
+	Creator   *UserRef  `json:"creator"`
+	Replies   []Reply   `json:"replies,omitempty"`
+}
+
+func NewThread(t models.Thread) Thread {
+	return Thread{
+		ID:        t.ID,
+		CourseID:  t.CourseID,
+		Title:     t.Title,
+		Body:      t.Body,
+		CreatedAt: t.CreatedAt,
+		Creator:   NewUserRef(t.Creator),
+		Replies:   mapSlice(t.Replies, NewReply),
+	}
+}
+
+type Reply struct {
+	ID        uuid.UUID `json:"id" binding:"required"`
+	ThreadID  uuid.UUID `json:"threadId" binding:"required"`
+	Body      string    `json:"body" binding:"required"`
+	CreatedAt time.Time `json:"createdAt" binding:"required"`
+	Creator   *UserRef  `json:"creator"`
+}
+
+func NewReply(r models.Reply) Reply {
+	return Reply{
+		ID:        r.ID,
+		ThreadID:  r.ThreadID,
+		Body:      r.Body,
+		CreatedAt: r.CreatedAt,
+		Creator:   NewUserRef(r.Creator),
+	}
+}
+
+func NewEnrollments(items []models.Enrollment) []Enrollment {
+	return mapSlice(items, NewEnrollment)
+}
@@ -0,0 +1,159 @@
+// Package dto holds the JSON shapes the API returns for models. Handlers
+// convert models here instead of serializing GORM structs, which have no
+// JSON tags and carry fields such as password hashes and answer keys.
+//
+// Fields tagged binding:"required" are always present; the tag only feeds
+// the OpenAPI schema.
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"myway-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Detail selects how much of a model the caller may see.
+type Detail int
+
+const (
+	// Learner hides answer keys and explanations.
+	Learner Detail = iota
+	// Instructor includes everything.
+	Instructor
+)
+
+// UserRef identifies a user without account details.
+type UserRef struct {
+	ID   uuid.UUID `json:"id" binding:"required"`
+	Name string    `json:"name" binding:"required"`
+	Role string    `json:"role" binding:"required"`
+}
+
+// NewUserRef returns nil when the user was not loaded.
+func NewUserRef(u models.User) *UserRef {
+	if u.ID == uuid.Nil {
+		return nil
+	}
+	return &UserRef{ID: u.ID, Name: u.Name, Role: u.Role}
+}
+
+type Organization struct {
+	ID        uuid.UUID `json:"id" binding:"required"`
+	Name      string    `json:"name" binding:"required"`
+	Plan      string    `json:"plan" binding:"required"`
+	CreatedAt time.Time `json:"createdAt" binding:"required"`
+}
+
+func NewOrganization(o models.Organization) Organization {
+	return Organization{ID: o.ID, Name: o.Name, Plan: o.Plan, CreatedAt: o.CreatedAt}
+}
+
+type OrgSettings struct {
+	OrgID             uuid.UUID `json:"orgId" binding:"required"`
+	RequireReview     bool      `json:"requireReview" binding:"required"`
+	RequiredApprovals int       `json:"requiredApprovals" binding:"required"`
+	AllowSelfApproval bool      `json:"allowSelfApproval" binding:"required"`
+	// CourseCodePrefixes lists the prefixes course codes must start with;
+	// empty allows any code.
+	CourseCodePrefixes  []string `json:"courseCodePrefixes" binding:"required"`
+	DefaultCourseStatus string   `json:"defaultCourseStatus" binding:"required"`
+}
+
+func NewOrgSettings(s models.OrgSettings) OrgSettings {
+	return OrgSettings{
+		OrgID:               s.OrgID,
+		RequireReview:       s.RequireReview,
+		RequiredApprovals:   s.RequiredApprovals,
+		AllowSelfApproval:   s.AllowSelfApproval,
+		CourseCodePrefixes:  CourseCodePrefixes(s),
+		DefaultCourseStatus: s.DefaultCourseStatus,
+	}
+}
+
+// CourseCodePrefixes decodes the organization's allowed course-code
+// prefixes.
+func CourseCodePrefixes(s models.OrgSettings) []string {
+	prefixes := []string{}
+	if s.CourseCodePrefixes != nil {
+		if err := json.Unmarshal([]byte(*s.CourseCodePrefixes), &prefixes); err != nil {
+			return []string{}
+		}
+	}
+	return prefixes
+}
+
+type Membership struct {
+	ID           uuid.UUID     `json:"id" binding:"required"`
+	OrgID        uuid.UUID     `json:"orgId" binding:"required"`
+	Role         string        `json:"role" binding:"required"`
+	Status       string        `json:"status" binding:"required"`
+	Organization *Organization `json:"organization,omitempty"`
+}
+
+func NewMembership(m models.OrgMembership) Membership {
+	out := Membership{ID: m.ID, OrgID: m.OrgID, Role: m.Role, Status: m.Status}
+	if m.Organization.ID != uuid.Nil {
+		org := NewOrganization(m.Organization)
+		out.Organization = &org
+	}
+	return out
+}
+
+// DailyMetric is one day of an organization's activity.
+type DailyMetric struct {
+	Date           time.Time `json:"date" binding:"required"`
+	DAU            int       `json:"dau" binding:"required"`
+	WAU            int       `json:"wau" binding:"required"`
+	ActivationRate float64   `json:"activationRate" binding:"required"`
+	Retention7d    float64   `json:"retention7d" binding:"required"`
+	RunsCount      int       `json:"runsCount" binding:"required"`
+	QuizzesTaken   int       `json:"quizzesTaken" binding:"required"`
+}
+
+func NewDailyMetric(m models.DailyOrgMetric) DailyMetric {
+	return DailyMetric{
+		Date:           m.Date,
+		DAU:            m.DAU,
+		WAU:            m.WAU,
+		ActivationRate: m.ActivationRate,
+		Retention7d:    m.Retention7d,
+		RunsCount:      m.RunsCount,
+		QuizzesTaken:   m.QuizzesTaken,
+	}
+}
+
+func NewDailyMetrics(items []models.DailyOrgMetric) []DailyMetric {
+	return mapSlice(items, NewDailyMetric)
+}
+
+// rawJSON passes a jsonb column through unchanged, or null when empty.
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(value)
+}
+
+func rawJSONPtr(value *string) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return rawJSON(*value)
+}
+
+// mapSlice converts a slice of models, returning an empty slice rather than
+// nil so lists serialize as [].
+func mapSlice[M, D any](items []M, convert func(M) D) []D {
+	out := make([]D, len(items))
+	for i, item := range items {
+		out[i] = convert(item)
+	}
+	return out
+}
+
+func NewMemberships(items []models.OrgMembership) []Membership {
+	return mapSlice(items, NewMembership)
+}
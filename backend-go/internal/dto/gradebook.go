@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"time"
+
+	"myway-backend/internal/gradebook"
+	"myway-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// GradeCategory is a weighted group of a course's graded work.
+type GradeCategory struct {
+	ID         uuid.UUID `json:"id" binding:"required"`
+	CourseID   uuid.UUID `json:"courseId" binding:"required"`
+	Name       string    `json:"name" binding:"required"`
+	Weight     float64   `json:"weight" binding:"required"`
+	DropLowest int       `json:"dropLowest" binding:"required"`
+	Position   int       `json:"position" binding:"required"`
+}
+
+func NewGradeCategory(c models.GradeCategory) GradeCategory {
+	return GradeCategory{
+		ID:         c.ID,
+		CourseID:   c.CourseID,
+		Name:       c.Name,
+		Weight:     c.Weight,
+		DropLowest: c.DropLowest,
+		Position:   c.Position,
+	}
+}
+
+func NewGradeCategories(items []models.GradeCategory) []GradeCategory {
+	return mapSlice(items, NewGradeCategory)
+}
+
+// GradeOverride replaces a student's grade on one item, or their final
+// grade when ItemID is null.
+type GradeOverride struct {
+	ID           uuid.UUID  `json:"id" binding:"required"`
+	UserID       uuid.UUID  `json:"userId" binding:"required"`
+	ItemID       *uuid.UUID `json:"itemId"`
+	Percent      *float64   `json:"percent"`
+	Letter       *string    `json:"letter"`
+	Reason       *string    `json:"reason"`
+	OverriddenBy uuid.UUID  `json:"overriddenBy" binding:"required"`
+	UpdatedAt    time.Time  `json:"updatedAt" binding:"required"`
+}
+
+func NewGradeOverride(o models.GradeOverride) GradeOverride {
+	return GradeOverride{
+		ID:           o.ID,
+		UserID:       o.UserID,
+		ItemID:       o.ItemID,
+		Percent:      o.Percent,
+		Letter:       o.Letter,
+		Reason:       o.Reason,
+		OverriddenBy: o.OverriddenBy,
+		UpdatedAt:    o.UpdatedAt,
+	}
+}
+
+func NewGradeOverrides(items []models.GradeOverride) []GradeOverride {
+	return mapSlice(items, NewGradeOverride)
+}
+
+// GradebookItem is an assignment or published quiz of the course. Points
+// is null for quizzes, which are worth what their questions are worth.
+type GradebookItem struct {
+	ID         uuid.UUID  `json:"id" binding:"required"`
+	Type       string     `json:"type" binding:"required"` // ASSIGNMENT, QUIZ
+	Title      string     `json:"title" binding:"required"`
+	CategoryID *uuid.UUID `json:"categoryId"`
+	Points     *float64   `json:"points"`
+	DueAt      *time.Time `json:"dueAt"`
+}
+
+// GradebookScore is a student's grade on one item. Earned, Possible and
+// Percent are null until the item is graded.
+type GradebookScore struct {
+	ItemID     uuid.UUID `json:"itemId" binding:"required"`
+	Earned     *float64  `json:"earned"`
+	Possible   *float64  `json:"possible"`
+	Percent    *float64  `json:"percent"`
+	Dropped    bool      `json:"dropped" binding:"required"`
+	Overridden bool      `json:"overridden" binding:"required"`
+}
+
+type GradebookCategoryScore struct {
+	CategoryID uuid.UUID `json:"categoryId" binding:"required"`
+	Percent    *float64  `json:"percent"`
+}
+
+// GradebookRow is one student's grades. Percent and Letter are the final
+// grade, after any override; the computed ones are kept alongside.
+type GradebookRow struct {
+	UserID          uuid.UUID                `json:"userId" binding:"required"`
+	Name            string                   `json:"name" binding:"required"`
+	Email           string                   `json:"email" binding:"required"`
+	Scores          []GradebookScore         `json:"scores" binding:"required"`
+	Categories      []GradebookCategoryScore `json:"categories" binding:"required"`
+	ComputedPercent *float64                 `json:"computedPercent"`
+	ComputedLetter  *string                  `json:"computedLetter"`
+	Percent         *float64                 `json:"percent"`
+	Letter          *string                  `json:"letter"`
+	Overrides       []GradeOverride          `json:"overrides,omitempty"`
+}
+
+// Gradebook is a course's grading scheme and the grades of its students.
+type Gradebook struct {
+	CourseID   uuid.UUID       `json:"courseId" binding:"required"`
+	Categories []GradeCategory `json:"categories" binding:"required"`
+	Items      []GradebookItem `json:"items" binding:"required"`
+	Scale      gradebook.Scale `json:"scale" binding:"required"`
+	Rows       []GradebookRow  `json:"rows" binding:"required"`
+}
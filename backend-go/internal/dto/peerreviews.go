@@ -0,0 +1,107 @@
+package dto
+
+import (
+	"time"
+
+	"myway-backend/internal/models"
+	"myway-backend/internal/rubric"
+
+	"github.com/google/uuid"
+)
+
+// PeerReview is one student's review of another's submission. Reviewer
+// and the exclusion reason are only shown to teachers.
+type PeerReview struct {
+	ID              uuid.UUID     `json:"id" binding:"required"`
+	AssignmentID    uuid.UUID     `json:"assignmentId" binding:"required"`
+	SubmissionID    uuid.UUID     `json:"submissionId" binding:"required"`
+	AttemptID       uuid.UUID     `json:"attemptId" binding:"required"`
+	RubricVersionID uuid.UUID     `json:"rubricVersionId" binding:"required"`
+	Reviewer        *UserRef      `json:"reviewer,omitempty"`
+	Status          string        `json:"status" binding:"required"`
+	RubricGrade     *rubric.Grade `json:"rubricGrade"`
+	Score           *int          `json:"score"`
+	Comment         *string       `json:"comment"`
+	SubmittedAt     *time.Time    `json:"submittedAt"`
+	Excluded        bool          `json:"excluded" binding:"required"`
+	ExcludedReason  *string       `json:"excludedReason,omitempty"`
+}
+
+// NewPeerReview is the teacher's view of a review.
+func NewPeerReview(r models.PeerReview) PeerReview {
+	review := NewAnonymousPeerReview(r)
+	review.Reviewer = NewUserRef(r.Reviewer)
+	review.ExcludedReason = r.ExcludedReason
+	return review
+}
+
+// NewAnonymousPeerReview is a review as its author sees it.
+func NewAnonymousPeerReview(r models.PeerReview) PeerReview {
+	return PeerReview{
+		ID:              r.ID,
+		AssignmentID:    r.AssignmentID,
+		SubmissionID:    r.SubmissionID,
+		AttemptID:       r.AttemptID,
+		RubricVersionID: r.RubricVersionID,
+		Status:          r.Status,
+		RubricGrade:     DecodeRubricGrade(r.RubricGrade),
+		Score:           r.Score,
+		Comment:         r.Comment,
+		SubmittedAt:     r.SubmittedAt,
+		Excluded:        r.Excluded,
+	}
+}
+
+// PeerReviewTask is a review assigned to the caller: the work to review,
+// without its author, and the rubric to review it with.
+type PeerReviewTask struct {
+	ID           uuid.UUID          `json:"id" binding:"required"`
+	AssignmentID uuid.UUID          `json:"assignmentId" binding:"required"`
+	Title        string             `json:"title" binding:"required"`
+	Instructions string             `json:"instructions" binding:"required"`
+	Points       int                `json:"points" binding:"required"`
+	ReviewDueAt  time.Time          `json:"reviewDueAt" binding:"required"`
+	Status       string             `json:"status" binding:"required"`
+	FileURL      *string            `json:"fileUrl"`
+	Text         *string            `json:"text"`
+	Criteria     []rubric.Criterion `json:"criteria" binding:"required"`
+	RubricGrade  *rubric.Grade      `json:"rubricGrade"`
+	Score        *int               `json:"score"`
+	Comment      *string            `json:"comment"`
+	SubmittedAt  *time.Time         `json:"submittedAt"`
+}
+
+// PeerReviewSubmission is one submission in a teacher's peer review
+// overview. Score is what grading it now would give.
+type PeerReviewSubmission struct {
+	SubmissionID uuid.UUID    `json:"submissionId" binding:"required"`
+	UserID       uuid.UUID    `json:"userId" binding:"required"`
+	Name         string       `json:"name" binding:"required"`
+	AttemptID    uuid.UUID    `json:"attemptId" binding:"required"`
+	Reviews      []PeerReview `json:"reviews" binding:"required"`
+	PeerScore    *int         `json:"peerScore"`
+	TeacherScore *int         `json:"teacherScore"`
+	Score        *int         `json:"score"`
+}
+
+// PeerReviewOverview is an assignment's peer review settings and every
+// review handed out.
+type PeerReviewOverview struct {
+	AssignmentID         uuid.UUID              `json:"assignmentId" binding:"required"`
+	ReviewsPerSubmission int                    `json:"reviewsPerSubmission" binding:"required"`
+	ReviewDueAt          time.Time              `json:"reviewDueAt" binding:"required"`
+	Weight               int                    `json:"weight" binding:"required"`
+	AssignedAt           *time.Time             `json:"assignedAt"`
+	Submissions          []PeerReviewSubmission `json:"submissions" binding:"required"`
+}
+
+// ReviewerCalibration compares a reviewer's scores with the teacher's on
+// the same submissions. Bias is positive for a generous reviewer.
+type ReviewerCalibration struct {
+	Reviewer     UserRef  `json:"reviewer" binding:"required"`
+	Assigned     int      `json:"assigned" binding:"required"`
+	Submitted    int      `json:"submitted" binding:"required"`
+	Compared     int      `json:"compared" binding:"required"`
+	Bias         *float64 `json:"bias"`
+	MeanAbsError *float64 `json:"meanAbsError"`
+}
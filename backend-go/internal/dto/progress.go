@@ -0,0 +1,117 @@
+package dto
+
+import (
+	"myway-backend/internal/models"
+	"myway-backend/internal/progress"
+	"myway-backend/internal/watch"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModuleProgress is one learner's completion of a module. A module is
+// completed once all of its materials are.
+type ModuleProgress struct {
+	ModuleID           uuid.UUID          `json:"moduleId" binding:"required"`
+	Title              string             `json:"title" binding:"required"`
+	Completed          bool               `json:"completed" binding:"required"`
+	ProgressPercentage float64            `json:"progressPercentage" binding:"required"`
+	CompletedMaterials int                `json:"completedMaterials" binding:"required"`
+	TotalMaterials     int                `json:"totalMaterials" binding:"required"`
+	Materials          []MaterialProgress `json:"materials" binding:"required"`
+}
+
+func NewModuleProgress(m models.Module, materials []MaterialProgress, rollup progress.Rollup) ModuleProgress {
+	return ModuleProgress{
+		ModuleID:           m.ID,
+		Title:              m.Title,
+		Completed:          rollup.Total > 0 && rollup.Completed == rollup.Total,
+		ProgressPercentage: rollup.Percent,
+		CompletedMaterials: rollup.Completed,
+		TotalMaterials:     rollup.Total,
+		Materials:          materials,
+	}
+}
+
+// MaterialProgress is one learner's state and completion for a material.
+// ProgressPercentage gives partial credit toward the unmet conditions.
+type MaterialProgress struct {
+	MaterialID         uuid.UUID `json:"materialId" binding:"required"`
+	Title              string    `json:"title" binding:"required"`
+	Type               string    `json:"type" binding:"required"`
+	Completed          bool      `json:"completed" binding:"required"`
+	ProgressPercentage float64   `json:"progressPercentage" binding:"required"`
+	Unmet              []string  `json:"unmet" binding:"required"`
+	Viewed             bool      `json:"viewed" binding:"required"`
+	WatchedPercent     int       `json:"watchedPercent" binding:"required"`
+	BestQuizScore      *int      `json:"bestQuizScore"`
+	Flashcards         int       `json:"flashcards" binding:"required"`
+	MasteredFlashcards int       `json:"masteredFlashcards" binding:"required"`
+}
+
+func NewMaterialProgress(m models.Material, state progress.State, result progress.Result) MaterialProgress {
+	return MaterialProgress{
+		MaterialID:         m.ID,
+		Title:              m.Title,
+		Type:               m.Type,
+		Completed:          result.Completed,
+		ProgressPercentage: result.Percent,
+		Unmet:              result.Unmet,
+		Viewed:             state.Viewed,
+		WatchedPercent:     state.WatchedPercent,
+		BestQuizScore:      state.BestQuizScore,
+		Flashcards:         state.Flashcards,
+		MasteredFlashcards: state.MasteredFlashcards,
+	}
+}
+
+// CompletionCriteria is the completion rule for one material type.
+// Custom is false while the organization uses the default.
+type CompletionCriteria struct {
+	MaterialType       string `json:"materialType" binding:"required"`
+	RequireView        bool   `json:"requireView" binding:"required"`
+	MinWatchPercent    int    `json:"minWatchPercent" binding:"required"`
+	MinQuizScore       int    `json:"minQuizScore" binding:"required"`
+	MinMasteredPercent int    `json:"minMasteredPercent" binding:"required"`
+	Custom             bool   `json:"custom" binding:"required"`
+}
+
+func NewCompletionCriteria(materialType string, c progress.Criteria, custom bool) CompletionCriteria {
+	return CompletionCriteria{
+		MaterialType:       materialType,
+		RequireView:        c.RequireView,
+		MinWatchPercent:    c.MinWatchPercent,
+		MinQuizScore:       c.MinQuizScore,
+		MinMasteredPercent: c.MinMasteredPercent,
+		Custom:             custom,
+	}
+}
+
+// VideoWatch is how much of a video one learner has watched, from the
+// heartbeats accepted so far.
+type VideoWatch struct {
+	MaterialID         uuid.UUID        `json:"materialId" binding:"required"`
+	DurationSec        *float64         `json:"durationSec"`
+	WatchedSeconds     float64          `json:"watchedSeconds" binding:"required"`
+	FurthestSeconds    float64          `json:"furthestSeconds" binding:"required"`
+	WatchedPercent     int              `json:"watchedPercent" binding:"required"`
+	Intervals          []watch.Interval `json:"intervals" binding:"required"`
+	LastHeartbeatAt    *time.Time       `json:"lastHeartbeatAt"`
+	RejectedHeartbeats int              `json:"rejectedHeartbeats" binding:"required"`
+}
+
+func NewVideoWatch(m models.Material, a models.MaterialActivity, intervals []watch.Interval) VideoWatch {
+	if intervals == nil {
+		intervals = []watch.Interval{}
+	}
+	return VideoWatch{
+		MaterialID:         m.ID,
+		DurationSec:        m.DurationSec,
+		WatchedSeconds:     a.WatchedSeconds,
+		FurthestSeconds:    a.FurthestSeconds,
+		WatchedPercent:     a.WatchedPercent,
+		Intervals:          intervals,
+		LastHeartbeatAt:    a.LastHeartbeatAt,
+		RejectedHeartbeats: a.RejectedHeartbeats,
+	}
+}
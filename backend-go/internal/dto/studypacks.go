@@ -0,0 +1,386 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"myway-backend/internal/models"
+	"myway-backend/internal/studypack"
+
+	"github.com/google/uuid"
+)
+
+type StudyPack struct {
+	ID               uuid.UUID  `json:"id" binding:"required"`
+	MaterialID       uuid.UUID  `json:"materialId" binding:"required"`
+	Status           string     `json:"status" binding:"required"`
+	CreatedAt        time.Time  `json:"createdAt" binding:"required"`
+	PublishedAt      *time.Time `json:"publishedAt"`
+	RequiresApproval bool       `json:"requiresApproval" binding:"required"`
+	ReviewStatus     string     `json:"reviewStatus" binding:"required"`
+}
+
+func NewStudyPack(s models.StudyPack) StudyPack {
+	return StudyPack{
+		ID:               s.ID,
+		MaterialID:       s.MaterialID,
+		Status:           s.Status,
+		CreatedAt:        s.CreatedAt,
+		PublishedAt:      s.PublishedAt,
+		RequiresApproval: s.RequiresApproval,
+		ReviewStatus:     s.ReviewStatus,
+	}
+}
+
+type Quiz struct {
+	ID                uuid.UUID      `json:"id" binding:"required"`
+	StudyPackID       uuid.UUID      `json:"studyPackId" binding:"required"`
+	Version           int            `json:"version" binding:"required"`
+	TimeLimitSec      *int           `json:"timeLimitSec"`
+	MaxAttempts       *int           `json:"maxAttempts"`
+	ShuffleQuestions  bool           `json:"shuffleQuestions" binding:"required"`
+	ShuffleOptions    bool           `json:"shuffleOptions" binding:"required"`
+	RevealAfterSubmit bool           `json:"revealAfterSubmit" binding:"required"`
+	RevealAt          *time.Time     `json:"revealAt"`
+	Questions         []QuizQuestion `json:"questions" binding:"required"`
+}
+
+func NewQuiz(q models.Quiz, detail Detail) Quiz {
+	return Quiz{
+		ID:                q.ID,
+		StudyPackID:       q.StudyPackID,
+		Version:           q.Version,
+		TimeLimitSec:      q.TimeLimitSec,
+		MaxAttempts:       q.MaxAttempts,
+		ShuffleQuestions:  q.ShuffleQuestions,
+		ShuffleOptions:    q.ShuffleOptions,
+		RevealAfterSubmit: q.RevealAfterSubmit,
+		RevealAt:          q.RevealAt,
+		Questions: mapSlice(q.Questions, func(question models.QuizQuestion) QuizQuestion {
+			return NewQuizQuestion(question, detail)
+		}),
+	}
+}
+
+// QuizQuestion omits answerKey and explanation for learners.
+type QuizQuestion struct {
+	ID          uuid.UUID       `json:"id" binding:"required"`
+	QuizID      uuid.UUID       `json:"quizId" binding:"required"`
+	Type        string          `json:"type" binding:"required"`
+	Prompt      string          `json:"prompt" binding:"required"`
+	Options     json.RawMessage `json:"options" binding:"required"`
+	Points      int             `json:"points" binding:"required"`
+	AnswerKey   json.RawMessage `json:"answerKey,omitempty"`
+	Explanation *string         `json:"explanation,omitempty"`
+}
+
+func NewQuizQuestion(q models.QuizQuestion, detail Detail) QuizQuestion {
+	out := QuizQuestion{
+		ID:      q.ID,
+		QuizID:  q.QuizID,
+		Type:    q.Type,
+		Prompt:  q.Prompt,
+		Options: rawJSON(q.Options),
+		Points:  q.Points,
+	}
+	if detail == Instructor {
+		out.AnswerKey = rawJSON(q.AnswerKey)
+		out.Explanation = q.Explanation
+	}
+	return out
+}
+
+type Flashcard struct {
+	ID          uuid.UUID       `json:"id" binding:"required"`
+	StudyPackID uuid.UUID       `json:"studyPackId" binding:"required"`
+	Front       string          `json:"front" binding:"required"`
+	Back        string          `json:"back" binding:"required"`
+	Tags        json.RawMessage `json:"tags"`
+}
+
+func NewFlashcard(f models.Flashcard) Flashcard {
+	return Flashcard{ID: f.ID, StudyPackID: f.StudyPackID, Front: f.Front, Back: f.Back, Tags: rawJSONPtr(f.Tags)}
+}
+
+// FlashcardSchedule is the caller's spaced repetition state for a card.
+type FlashcardSchedule struct {
+	FlashcardID    uuid.UUID `json:"flashcardId" binding:"required"`
+	Ease           float64   `json:"ease" binding:"required"`
+	IntervalDays   int       `json:"intervalDays" binding:"required"`
+	Repetitions    int       `json:"repetitions" binding:"required"`
+	Lapses         int       `json:"lapses" binding:"required"`
+	DueAt          time.Time `json:"dueAt" binding:"required"`
+	LastGrade      string    `json:"lastGrade" binding:"required"`
+	LastReviewedAt time.Time `json:"lastReviewedAt" binding:"required"`
+}
+
+func NewFlashcardSchedule(s models.FlashcardReviewState) FlashcardSchedule {
+	return FlashcardSchedule{
+		FlashcardID:    s.FlashcardID,
+		Ease:           s.Ease,
+		IntervalDays:   s.IntervalDays,
+		Repetitions:    s.Repetitions,
+		Lapses:         s.Lapses,
+		DueAt:          s.DueAt,
+		LastGrade:      s.LastGrade,
+		LastReviewedAt: s.LastReviewedAt,
+	}
+}
+
+// DueFlashcard is a card in the review queue. Schedule is null for cards
+// the caller has never reviewed.
+type DueFlashcard struct {
+	ID          uuid.UUID          `json:"id" binding:"required"`
+	StudyPackID uuid.UUID          `json:"studyPackId" binding:"required"`
+	CourseID    uuid.UUID          `json:"courseId" binding:"required"`
+	Front       string             `json:"front" binding:"required"`
+	Back        string             `json:"back" binding:"required"`
+	Tags        json.RawMessage    `json:"tags"`
+	Material    *MaterialRef       `json:"material,omitempty"`
+	IsNew       bool               `json:"isNew" binding:"required"`
+	Schedule    *FlashcardSchedule `json:"schedule"`
+}
+
+// NewDueFlashcard expects StudyPack.Material.Module to be preloaded.
+func NewDueFlashcard(f models.Flashcard, state *models.FlashcardReviewState) DueFlashcard {
+	out := DueFlashcard{
+		ID:          f.ID,
+		StudyPackID: f.StudyPackID,
+		CourseID:    f.StudyPack.Material.Module.CourseID,
+		Front:       f.Front,
+		Back:        f.Back,
+		Tags:        rawJSONPtr(f.Tags),
+		Material:    newMaterialRef(f.StudyPack.Material),
+		IsNew:       state == nil,
+	}
+	if state != nil {
+		schedule := NewFlashcardSchedule(*state)
+		out.Schedule = &schedule
+	}
+	return out
+}
+
+// QuizAttempt leaves out per-question results unless they may be revealed.
+type QuizAttempt struct {
+	ID              uuid.UUID       `json:"id" binding:"required"`
+	QuizID          uuid.UUID       `json:"quizId" binding:"required"`
+	UserID          uuid.UUID       `json:"userId" binding:"required"`
+	Status          string          `json:"status" binding:"required"`
+	QuizVersion     int             `json:"quizVersion" binding:"required"`
+	Score           int             `json:"score" binding:"required"`
+	PointsEarned    float64         `json:"pointsEarned" binding:"required"`
+	PointsPossible  float64         `json:"pointsPossible" binding:"required"`
+	Answers         json.RawMessage `json:"answers" binding:"required"`
+	QuestionResults json.RawMessage `json:"questionResults,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt" binding:"required"`
+	SubmittedAt     *time.Time      `json:"submittedAt"`
+	Material        *MaterialRef    `json:"material,omitempty"`
+}
+
+func NewQuizAttempt(a models.QuizAttempt, revealResults bool) QuizAttempt {
+	out := QuizAttempt{
+		ID:             a.ID,
+		QuizID:         a.QuizID,
+		UserID:         a.UserID,
+		Status:         a.Status,
+		QuizVersion:    a.QuizVersion,
+		Score:          a.Score,
+		PointsEarned:   a.PointsEarned,
+		PointsPossible: a.PointsPossible,
+		Answers:        rawJSON(a.Answers),
+		CreatedAt:      a.CreatedAt,
+		SubmittedAt:    a.SubmittedAt,
+		Material:       newMaterialRef(a.Quiz.StudyPack.Material),
+	}
+	if revealResults && a.QuestionResults != nil {
+		out.QuestionResults = rawJSONPtr(a.QuestionResults)
+	}
+	return out
+}
+
+type FlashcardSession struct {
+	ID           uuid.UUID    `json:"id" binding:"required"`
+	StudyPackID  uuid.UUID    `json:"studyPackId" binding:"required"`
+	UserID       uuid.UUID    `json:"userId" binding:"required"`
+	KnownCount   int          `json:"knownCount" binding:"required"`
+	UnknownCount int          `json:"unknownCount" binding:"required"`
+	DurationSec  int          `json:"durationSec" binding:"required"`
+	CreatedAt    time.Time    `json:"createdAt" binding:"required"`
+	Material     *MaterialRef `json:"material,omitempty"`
+}
+
+func NewFlashcardSession(s models.FlashcardSession) FlashcardSession {
+	return FlashcardSession{
+		ID:           s.ID,
+		StudyPackID:  s.StudyPackID,
+		UserID:       s.UserID,
+		KnownCount:   s.KnownCount,
+		UnknownCount: s.UnknownCount,
+		DurationSec:  s.DurationSec,
+		CreatedAt:    s.CreatedAt,
+		Material:     newMaterialRef(s.StudyPack.Material),
+	}
+}
+
+// StudyPackRevision is one entry in a study pack's history. Snapshot is
+// only included when a single revision is requested.
+type StudyPackRevision struct {
+	ID           uuid.UUID           `json:"id" binding:"required"`
+	StudyPackID  uuid.UUID           `json:"studyPackId" binding:"required"`
+	Number       int                 `json:"number" binding:"required"`
+	AuthorType   string              `json:"authorType" binding:"required"`
+	Author       *UserRef            `json:"author"`
+	Notes        *string             `json:"notes"`
+	Published    bool                `json:"published" binding:"required"`
+	RestoredFrom *int                `json:"restoredFrom"`
+	CreatedAt    time.Time           `json:"createdAt" binding:"required"`
+	Snapshot     *studypack.Snapshot `json:"snapshot,omitempty"`
+}
+
+func NewStudyPackRevision(r models.StudyPackRevision, withSnapshot bool) StudyPackRevision {
+	out := StudyPackRevision{
+		ID:           r.ID,
+		StudyPackID:  r.StudyPackID,
+		Number:       r.Number,
+		AuthorType:   r.AuthorType,
+		Author:       NewUserRef(r.Author),
+		Notes:        r.Notes,
+		Published:    r.Published,
+		RestoredFrom: r.RestoredFrom,
+		CreatedAt:    r.CreatedAt,
+	}
+	if withSnapshot {
+		var snapshot studypack.Snapshot
+		json.Unmarshal([]byte(r.Snapshot), &snapshot)
+		out.Snapshot = &snapshot
+	}
+	return out
+}
+
+// StudyPackReview is a study pack's place in the review workflow. Status
+// is the generation status and ReviewStatus the workflow state.
+type StudyPackReview struct {
+	StudyPackID  uuid.UUID           `json:"studyPackId" binding:"required"`
+	MaterialID   uuid.UUID           `json:"materialId" binding:"required"`
+	Material     *MaterialRef        `json:"material,omitempty"`
+	Status       string              `json:"status" binding:"required"`
+	ReviewStatus string              `json:"reviewStatus" binding:"required"`
+	SubmittedBy  *uuid.UUID          `json:"submittedBy"`
+	SubmittedAt  *time.Time          `json:"submittedAt"`
+	PublishedAt  *time.Time          `json:"publishedAt"`
+	Reviewers    []StudyPackReviewer `json:"reviewers" binding:"required"`
+	OpenComments int64               `json:"openComments" binding:"required"`
+}
+
+// NewStudyPackReview needs Reviewers.Reviewer preloaded; Material is
+// included when loaded.
+func NewStudyPackReview(s models.StudyPack, openComments int64) StudyPackReview {
+	return StudyPackReview{
+		StudyPackID:  s.ID,
+		MaterialID:   s.MaterialID,
+		Material:     newMaterialRef(s.Material),
+		Status:       s.Status,
+		ReviewStatus: s.ReviewStatus,
+		SubmittedBy:  s.SubmittedBy,
+		SubmittedAt:  s.SubmittedAt,
+		PublishedAt:  s.PublishedAt,
+		Reviewers:    mapSlice(s.Reviewers, NewStudyPackReviewer),
+		OpenComments: openComments,
+	}
+}
+
+type StudyPackReviewer struct {
+	ReviewerID uuid.UUID  `json:"reviewerId" binding:"required"`
+	Reviewer   *UserRef   `json:"reviewer"`
+	Decision   string     `json:"decision" binding:"required"`
+	DecidedAt  *time.Time `json:"decidedAt"`
+	AssignedAt time.Time  `json:"assignedAt" binding:"required"`
+}
+
+func NewStudyPackReviewer(r models.StudyPackReviewer) StudyPackReviewer {
+	return StudyPackReviewer{
+		ReviewerID: r.ReviewerID,
+		Reviewer:   NewUserRef(r.Reviewer),
+		Decision:   r.Decision,
+		DecidedAt:  r.DecidedAt,
+		AssignedAt: r.CreatedAt,
+	}
+}
+
+type ReviewComment struct {
+	ID            uuid.UUID  `json:"id" binding:"required"`
+	StudyPackID   uuid.UUID  `json:"studyPackId" binding:"required"`
+	Author        *UserRef   `json:"author"`
+	Target        string     `json:"target" binding:"required"`
+	KeyPointIndex *int       `json:"keyPointIndex"`
+	QuestionID    *uuid.UUID `json:"questionId"`
+	Body          string     `json:"body" binding:"required"`
+	ResolvedAt    *time.Time `json:"resolvedAt"`
+	ResolvedBy    *uuid.UUID `json:"resolvedBy"`
+	CreatedAt     time.Time  `json:"createdAt" binding:"required"`
+}
+
+func NewReviewComment(r models.ReviewComment) ReviewComment {
+	return ReviewComment{
+		ID:            r.ID,
+		StudyPackID:   r.StudyPackID,
+		Author:        NewUserRef(r.Author),
+		Target:        r.Target,
+		KeyPointIndex: r.KeyPointIndex,
+		QuestionID:    r.QuestionID,
+		Body:          r.Body,
+		ResolvedAt:    r.ResolvedAt,
+		ResolvedBy:    r.ResolvedBy,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+func NewReviewComments(items []models.ReviewComment) []ReviewComment {
+	return mapSlice(items, NewReviewComment)
+}
+
+// StudyPackContent is a study pack with everything a learner studies from.
+type StudyPackContent struct {
+	ID         uuid.UUID        `json:"id" binding:"required"`
+	MaterialID uuid.UUID        `json:"materialId" binding:"required"`
+	Status     string           `json:"status" binding:"required"`
+	Summary    StudyPackSummary `json:"summary" binding:"required"`
+	Quizzes    []Quiz           `json:"quizzes" binding:"required"`
+	Flashcards []Flashcard      `json:"flashcards" binding:"required"`
+	Material   Material         `json:"material" binding:"required"`
+}
+
+type StudyPackSummary struct {
+	Content map[string]interface{} `json:"content"`
+}
+
+// NewStudyPackContent shows each quiz at the detail returned by quizDetail.
+func NewStudyPackContent(s models.StudyPack, quizDetail func(models.Quiz) Detail) StudyPackContent {
+	var summary map[string]interface{}
+	if s.Summary != nil {
+		json.Unmarshal([]byte(s.Summary.Content), &summary)
+	}
+	return StudyPackContent{
+		ID:         s.ID,
+		MaterialID: s.MaterialID,
+		Status:     s.Status,
+		Summary:    StudyPackSummary{Content: summary},
+		Quizzes: mapSlice(s.Quizzes, func(q models.Quiz) Quiz {
+			return NewQuiz(q, quizDetail(q))
+		}),
+		Flashcards: mapSlice(s.Flashcards, NewFlashcard),
+		Material:   NewMaterial(s.Material),
+	}
+}
+
+func NewFlashcards(items []models.Flashcard) []Flashcard {
+	return mapSlice(items, NewFlashcard)
+}
+
+// NewQuizAttempts is for the attempt owner's history, so results are shown
+// for the quizzes whose answers have been revealed to them.
+func NewQuizAttempts(items []models.QuizAttempt, revealed map[uuid.UUID]bool) []QuizAttempt {
+	return mapSlice(items, func(a models.QuizAttempt) QuizAttempt {
+		return NewQuizAttempt(a, a.Quiz.RevealAfterSubmit && revealed[a.QuizID])
+	})
+}
@@ -0,0 +1,250 @@
+// Package gating parses and evaluates the unlock rules stored in
+// Module.LockedRule. A rule is a JSON tree of conditions:
+//
+//	{"type": "AND", "rules": [
+//	  {"type": "AFTER_DATE", "date": "2026-02-01T00:00:00Z"},
+//	  {"type": "OR", "rules": [
+//	    {"type": "MODULE_COMPLETED", "moduleId": "..."},
+//	    {"type": "QUIZ_SCORE", "quizId": "...", "minScore": 80}
+//	  ]}
+//	]}
+package gating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule types.
+const (
+	AfterDate           = "AFTER_DATE"
+	ModuleCompleted     = "MODULE_COMPLETED"
+	QuizScore           = "QUIZ_SCORE"
+	AssignmentSubmitted = "ASSIGNMENT_SUBMITTED"
+	And                 = "AND"
+	Or                  = "OR"
+)
+
+// MaxDepth bounds how deeply AND and OR may nest.
+const MaxDepth = 5
+
+// UnlockRule is one node of a rule tree. Only the fields of its type are
+// set.
+type UnlockRule struct {
+	Type         string       `json:"type" binding:"required"`
+	Date         *time.Time   `json:"date,omitempty"`
+	ModuleID     *uuid.UUID   `json:"moduleId,omitempty"`
+	QuizID       *uuid.UUID   `json:"quizId,omitempty"`
+	MinScore     *int         `json:"minScore,omitempty"`
+	AssignmentID *uuid.UUID   `json:"assignmentId,omitempty"`
+	Rules        []UnlockRule `json:"rules,omitempty"`
+}
+
+// Parse decodes and validates a rule. Unknown fields are rejected so typos
+// do not silently unlock a module.
+func Parse(raw string) (*UnlockRule, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.DisallowUnknownFields()
+	var rule UnlockRule
+	if err := decoder.Decode(&rule); err != nil {
+		return nil, fmt.Errorf("rule is not valid JSON: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("rule must be a single JSON object")
+	}
+	if err := rule.validate(1); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r UnlockRule) validate(depth int) error {
+	switch r.Type {
+	case AfterDate:
+		if r.Date == nil {
+			return fmt.Errorf("%s needs a date", r.Type)
+		}
+	case ModuleCompleted:
+		if r.ModuleID == nil {
+			return fmt.Errorf("%s needs a moduleId", r.Type)
+		}
+	case QuizScore:
+		if r.QuizID == nil || r.MinScore == nil {
+			return fmt.Errorf("%s needs a quizId and minScore", r.Type)
+		}
+		if *r.MinScore < 0 || *r.MinScore > 100 {
+			return fmt.Errorf("minScore must be from 0 to 100")
+		}
+	case AssignmentSubmitted:
+		if r.AssignmentID == nil {
+			return fmt.Errorf("%s needs an assignmentId", r.Type)
+		}
+	case And, Or:
+		if len(r.Rules) == 0 {
+			return fmt.Errorf("%s needs at least one rule", r.Type)
+		}
+		if depth >= MaxDepth {
+			return fmt.Errorf("rules may nest at most %d levels deep", MaxDepth)
+		}
+		for _, child := range r.Rules {
+			if err := child.validate(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	if len(r.Rules) > 0 {
+		return fmt.Errorf("only AND and OR take rules")
+	}
+	return nil
+}
+
+// References lists the modules, quizzes and assignments a rule depends on.
+type References struct {
+	Modules     []uuid.UUID
+	Quizzes     []uuid.UUID
+	Assignments []uuid.UUID
+}
+
+func (r UnlockRule) References() References {
+	var refs References
+	r.collect(&refs)
+	return refs
+}
+
+func (r UnlockRule) collect(refs *References) {
+	switch r.Type {
+	case ModuleCompleted:
+		refs.Modules = append(refs.Modules, *r.ModuleID)
+	case QuizScore:
+		refs.Quizzes = append(refs.Quizzes, *r.QuizID)
+	case AssignmentSubmitted:
+		refs.Assignments = append(refs.Assignments, *r.AssignmentID)
+	case And, Or:
+		for _, child := range r.Rules {
+			child.collect(refs)
+		}
+	}
+}
+
+// Rewrite returns a copy of the rule for a copied course: module, quiz and
+// assignment IDs found in ids are replaced by their copies and dates move
+// by shift.
+func (r UnlockRule) Rewrite(ids map[uuid.UUID]uuid.UUID, shift time.Duration) UnlockRule {
+	out := r
+	if r.Date != nil {
+		date := r.Date.Add(shift)
+		out.Date = &date
+	}
+	out.ModuleID = remap(r.ModuleID, ids)
+	out.QuizID = remap(r.QuizID, ids)
+	out.AssignmentID = remap(r.AssignmentID, ids)
+	if r.Rules != nil {
+		out.Rules = make([]UnlockRule, len(r.Rules))
+		for i, child := range r.Rules {
+			out.Rules[i] = child.Rewrite(ids, shift)
+		}
+	}
+	return out
+}
+
+func remap(id *uuid.UUID, ids map[uuid.UUID]uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if copied, ok := ids[*id]; ok {
+		return &copied
+	}
+	return id
+}
+
+// Facts are what a learner has done, as far as rules can see.
+type Facts struct {
+	Now                  time.Time
+	CompletedModules     map[uuid.UUID]bool
+	BestQuizScores       map[uuid.UUID]int
+	SubmittedAssignments map[uuid.UUID]bool
+}
+
+// Evaluate reports whether the rule is satisfied and, if not, the plain
+// conditions still standing in the way. An unmet OR reports all of its
+// alternatives.
+func (r UnlockRule) Evaluate(f Facts) (bool, []UnlockRule) {
+	switch r.Type {
+	case AfterDate:
+		return leaf(r, !f.Now.Before(*r.Date))
+	case ModuleCompleted:
+		return leaf(r, f.CompletedModules[*r.ModuleID])
+	case QuizScore:
+		best, ok := f.BestQuizScores[*r.QuizID]
+		return leaf(r, ok && best >= *r.MinScore)
+	case AssignmentSubmitted:
+		return leaf(r, f.SubmittedAssignments[*r.AssignmentID])
+	case And:
+		unmet := []UnlockRule{}
+		for _, child := range r.Rules {
+			if ok, missing := child.Evaluate(f); !ok {
+				unmet = append(unmet, missing...)
+			}
+		}
+		return len(unmet) == 0, unmet
+	case Or:
+		unmet := []UnlockRule{}
+		for _, child := range r.Rules {
+			ok, missing := child.Evaluate(f)
+			if ok {
+				return true, []UnlockRule{}
+			}
+			unmet = append(unmet, missing...)
+		}
+		return false, unmet
+	}
+	return false, []UnlockRule{r}
+}
+
+func leaf(r UnlockRule, met bool) (bool, []UnlockRule) {
+	if met {
+		return true, []UnlockRule{}
+	}
+	return false, []UnlockRule{r}
+}
+
+// FindCycle returns a module that, through MODULE_COMPLETED conditions,
+// waits on itself. dependsOn maps each module to the modules its rule
+// references.
+func FindCycle(dependsOn map[uuid.UUID][]uuid.UUID) (uuid.UUID, bool) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[uuid.UUID]int{}
+	var visit func(uuid.UUID) bool
+	visit = func(id uuid.UUID) bool {
+		switch state[id] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[id] = visiting
+		for _, next := range dependsOn[id] {
+			if visit(next) {
+				return true
+			}
+		}
+		state[id] = done
+		return false
+	}
+	for id := range dependsOn {
+		if visit(id) {
+			return id, true
+		}
+	}
+	return uuid.Nil, false
+}
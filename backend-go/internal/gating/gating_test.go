@@ -0,0 +1,96 @@
+package gating
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	invalid := []string{
+		`{"type": "AFTER_DATE"}`,
+		`{"type": "QUIZ_SCORE", "quizId": "6a1f0c5e-8a4e-4d7e-9b43-2f1d2b8e9c10", "minScore": 120}`,
+		`{"type": "AND", "rules": []}`,
+		`{"type": "MODULE_COMPLETED", "moduleId": "6a1f0c5e-8a4e-4d7e-9b43-2f1d2b8e9c10", "rules": [{"type": "AND"}]}`,
+		`{"type": "AFTER_DATE", "date": "2026-01-01T00:00:00Z", "typo": true}`,
+		`{"type": "NEVER"}`,
+		`{"type": "OR", "rules": [{"type": "OR", "rules": [{"type": "OR", "rules": [{"type": "OR", "rules": [{"type": "OR", "rules": [{"type": "AFTER_DATE", "date": "2026-01-01T00:00:00Z"}]}]}]}]}]}`,
+	}
+	for _, raw := range invalid {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%s) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	prior, quiz, assignment := uuid.New(), uuid.New(), uuid.New()
+	raw := fmt.Sprintf(`{"type": "AND", "rules": [
+		{"type": "AFTER_DATE", "date": "2026-02-01T00:00:00Z"},
+		{"type": "OR", "rules": [
+			{"type": "MODULE_COMPLETED", "moduleId": %q},
+			{"type": "QUIZ_SCORE", "quizId": %q, "minScore": 80}
+		]},
+		{"type": "ASSIGNMENT_SUBMITTED", "assignmentId": %q}
+	]}`, prior, quiz, assignment)
+	rule, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := rule.References(); len(refs.Modules) != 1 || len(refs.Quizzes) != 1 || len(refs.Assignments) != 1 {
+		t.Errorf("References = %+v", refs)
+	}
+
+	after := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		facts Facts
+		open  bool
+		unmet int
+	}{
+		{"nothing done", Facts{Now: after.AddDate(0, 0, -7)}, false, 4},
+		{"quiz too low", Facts{Now: after, BestQuizScores: map[uuid.UUID]int{quiz: 70}, SubmittedAssignments: map[uuid.UUID]bool{assignment: true}}, false, 2},
+		{"quiz passed", Facts{Now: after, BestQuizScores: map[uuid.UUID]int{quiz: 80}, SubmittedAssignments: map[uuid.UUID]bool{assignment: true}}, true, 0},
+		{"module completed", Facts{Now: after, CompletedModules: map[uuid.UUID]bool{prior: true}, SubmittedAssignments: map[uuid.UUID]bool{assignment: true}}, true, 0},
+	}
+	for _, tc := range cases {
+		open, unmet := rule.Evaluate(tc.facts)
+		if open != tc.open || len(unmet) != tc.unmet {
+			t.Errorf("%s: open=%v with %d unmet, want open=%v with %d", tc.name, open, len(unmet), tc.open, tc.unmet)
+		}
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	if _, found := FindCycle(map[uuid.UUID][]uuid.UUID{a: {b}, b: {c}}); found {
+		t.Error("chain reported as a cycle")
+	}
+	if _, found := FindCycle(map[uuid.UUID][]uuid.UUID{a: {b}, b: {c}, c: {a}}); !found {
+		t.Error("cycle not found")
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	module, copied := uuid.New(), uuid.New()
+	raw := fmt.Sprintf(`{"type": "AND", "rules": [
+		{"type": "AFTER_DATE", "date": "2026-02-01T00:00:00Z"},
+		{"type": "MODULE_COMPLETED", "moduleId": %q}
+	]}`, module)
+	rule, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritten := rule.Rewrite(map[uuid.UUID]uuid.UUID{module: copied}, 7*24*time.Hour)
+	if want := time.Date(2026, 2, 8, 0, 0, 0, 0, time.UTC); !rewritten.Rules[0].Date.Equal(want) {
+		t.Errorf("date = %v, want %v", rewritten.Rules[0].Date, want)
+	}
+	if *rewritten.Rules[1].ModuleID != copied {
+		t.Errorf("moduleId = %v, want %v", *rewritten.Rules[1].ModuleID, copied)
+	}
+	if *rule.Rules[1].ModuleID != module {
+		t.Error("Rewrite modified the original rule")
+	}
+}
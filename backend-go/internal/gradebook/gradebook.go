@@ -0,0 +1,228 @@
+// Package gradebook turns a student's graded work in a course into
+// category averages, a final percentage and a letter grade.
+package gradebook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Category groups gradebook items, such as homework or exams. Weight is
+// relative: categories without any graded item are left out and the rest
+// are scaled to add up to 100%.
+type Category struct {
+	ID     string
+	Weight float64
+	// DropLowest is how many of the lowest graded items are ignored. At
+	// least one item always counts.
+	DropLowest int
+}
+
+// Item is one piece of graded work. Ungraded items count towards nothing.
+type Item struct {
+	ID         string
+	CategoryID string
+	Earned     float64
+	Possible   float64
+	Graded     bool
+}
+
+// Percent is the item's score as a percentage of what it is worth.
+func (i Item) Percent() float64 {
+	if i.Possible <= 0 {
+		return 0
+	}
+	return i.Earned / i.Possible * 100
+}
+
+// CategoryResult is a student's standing in one category.
+type CategoryResult struct {
+	ID string
+	// Percent is nil until an item in the category is graded.
+	Percent *float64
+	// Dropped lists the IDs of the items left out by DropLowest.
+	Dropped []string
+}
+
+// Result is a student's standing in a course.
+type Result struct {
+	Categories []CategoryResult
+	// Percent is nil until something that counts has been graded.
+	Percent *float64
+	Letter  string
+}
+
+// Compute grades items against categories. With no categories every graded
+// item counts, weighted by its points; otherwise each category averages its
+// items by points, items outside the categories are ignored, and the final
+// percentage is the weighted average of the categories.
+func Compute(categories []Category, items []Item, scale Scale) Result {
+	var result Result
+	if len(categories) == 0 {
+		result.Percent = pointsPercent(gradedItems(items))
+	} else {
+		var weighted, weights float64
+		for _, category := range categories {
+			categoryResult := computeCategory(category, items)
+			result.Categories = append(result.Categories, categoryResult)
+			if categoryResult.Percent != nil && category.Weight > 0 {
+				weighted += *categoryResult.Percent * category.Weight
+				weights += category.Weight
+			}
+		}
+		if weights > 0 {
+			percent := weighted / weights
+			result.Percent = &percent
+		}
+	}
+	if result.Percent != nil {
+		rounded := Round(*result.Percent)
+		result.Percent = &rounded
+		result.Letter = scale.Letter(rounded)
+	}
+	return result
+}
+
+func computeCategory(category Category, items []Item) CategoryResult {
+	result := CategoryResult{ID: category.ID, Dropped: []string{}}
+	var graded []Item
+	for _, item := range gradedItems(items) {
+		if item.CategoryID == category.ID {
+			graded = append(graded, item)
+		}
+	}
+
+	// Lowest percentage first; ties drop the item worth less.
+	slices.SortStableFunc(graded, func(a, b Item) int {
+		if a.Percent() != b.Percent() {
+			if a.Percent() < b.Percent() {
+				return -1
+			}
+			return 1
+		}
+		if a.Possible != b.Possible {
+			if a.Possible < b.Possible {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	drop := min(category.DropLowest, max(len(graded)-1, 0))
+	for _, item := range graded[:drop] {
+		result.Dropped = append(result.Dropped, item.ID)
+	}
+	if percent := pointsPercent(graded[drop:]); percent != nil {
+		rounded := Round(*percent)
+		result.Percent = &rounded
+	}
+	return result
+}
+
+func gradedItems(items []Item) []Item {
+	var graded []Item
+	for _, item := range items {
+		if item.Graded && item.Possible > 0 {
+			graded = append(graded, item)
+		}
+	}
+	return graded
+}
+
+func pointsPercent(items []Item) *float64 {
+	var earned, possible float64
+	for _, item := range items {
+		earned += item.Earned
+		possible += item.Possible
+	}
+	if possible <= 0 {
+		return nil
+	}
+	percent := earned / possible * 100
+	return &percent
+}
+
+// Round rounds a percentage to two decimal places.
+func Round(percent float64) float64 {
+	return math.Round(percent*100) / 100
+}
+
+// LetterGrade is one step of a letter scale: percentages of at least Min
+// earn Letter.
+type LetterGrade struct {
+	Letter string  `json:"letter" binding:"required"`
+	Min    float64 `json:"min"`
+}
+
+// Scale maps percentages to letters, highest Min first.
+type Scale []LetterGrade
+
+// DefaultScale is used by courses without their own scale.
+var DefaultScale = Scale{
+	{Letter: "A", Min: 90},
+	{Letter: "B", Min: 80},
+	{Letter: "C", Min: 70},
+	{Letter: "D", Min: 60},
+	{Letter: "F", Min: 0},
+}
+
+// Letter returns the letter for a percentage, or "" if it is below every
+// step.
+func (s Scale) Letter(percent float64) string {
+	for _, grade := range s {
+		if percent >= grade.Min {
+			return grade.Letter
+		}
+	}
+	return ""
+}
+
+// Normalize trims letters and sorts the scale highest Min first.
+func (s Scale) Normalize() Scale {
+	out := make(Scale, len(s))
+	for i, grade := range s {
+		out[i] = LetterGrade{Letter: strings.TrimSpace(grade.Letter), Min: grade.Min}
+	}
+	slices.SortStableFunc(out, func(a, b LetterGrade) int {
+		switch {
+		case a.Min > b.Min:
+			return -1
+		case a.Min < b.Min:
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// Validate checks a normalized scale: named letters, each used once, with
+// distinct minimums between 0 and 100, the lowest being 0 so that every
+// percentage gets a letter.
+func (s Scale) Validate() error {
+	if len(s) == 0 {
+		return errors.New("a scale needs at least one letter")
+	}
+	letters := make(map[string]bool)
+	for i, grade := range s {
+		if grade.Letter == "" {
+			return errors.New("every step needs a letter")
+		}
+		if letters[grade.Letter] {
+			return fmt.Errorf("letter %q appears twice", grade.Letter)
+		}
+		letters[grade.Letter] = true
+		if grade.Min < 0 || grade.Min > 100 {
+			return fmt.Errorf("the minimum for %q must be between 0 and 100", grade.Letter)
+		}
+		if i > 0 && grade.Min == s[i-1].Min {
+			return fmt.Errorf("%q and %q have the same minimum", s[i-1].Letter, grade.Letter)
+		}
+	}
+	if s[len(s)-1].Min != 0 {
+		return errors.New("the lowest letter must start at 0")
+	}
+	return nil
+}
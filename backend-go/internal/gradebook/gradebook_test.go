@@ -0,0 +1,79 @@
+package gradebook
+
+import "testing"
+
+func TestComputeWeightsCategories(t *testing.T) {
+	categories := []Category{
+		{ID: "homework", Weight: 40, DropLowest: 1},
+		{ID: "exams", Weight: 60},
+		{ID: "quizzes", Weight: 20},
+	}
+	items := []Item{
+		{ID: "hw1", CategoryID: "homework", Earned: 2, Possible: 10, Graded: true},
+		{ID: "hw2", CategoryID: "homework", Earned: 9, Possible: 10, Graded: true},
+		{ID: "hw3", CategoryID: "homework", Earned: 16, Possible: 20, Graded: true},
+		{ID: "hw4", CategoryID: "homework", Possible: 10},
+		{ID: "midterm", CategoryID: "exams", Earned: 70, Possible: 100, Graded: true},
+		{ID: "stray", Earned: 0, Possible: 50, Graded: true},
+	}
+
+	result := Compute(categories, items, DefaultScale)
+
+	homework := result.Categories[0]
+	if len(homework.Dropped) != 1 || homework.Dropped[0] != "hw1" {
+		t.Errorf("expected hw1 dropped, got %v", homework.Dropped)
+	}
+	// (9 + 16) / (10 + 20)
+	if homework.Percent == nil || *homework.Percent != 83.33 {
+		t.Errorf("homework percent = %v, want 83.33", homework.Percent)
+	}
+	if result.Categories[2].Percent != nil {
+		t.Errorf("quizzes have nothing graded, got %v", *result.Categories[2].Percent)
+	}
+	// (83.33.. * 40 + 70 * 60) / 100, quizzes left out
+	if result.Percent == nil || *result.Percent != 75.33 {
+		t.Fatalf("final percent = %v, want 75.33", result.Percent)
+	}
+	if result.Letter != "C" {
+		t.Errorf("letter = %q, want C", result.Letter)
+	}
+}
+
+func TestComputeKeepsOneItem(t *testing.T) {
+	categories := []Category{{ID: "exams", Weight: 1, DropLowest: 3}}
+	items := []Item{{ID: "final", CategoryID: "exams", Earned: 45, Possible: 50, Graded: true}}
+	result := Compute(categories, items, DefaultScale)
+	if len(result.Categories[0].Dropped) != 0 || *result.Percent != 90 || result.Letter != "A" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestComputeWithoutCategoriesUsesPoints(t *testing.T) {
+	items := []Item{
+		{ID: "a", Earned: 5, Possible: 10, Graded: true},
+		{ID: "b", Earned: 30, Possible: 30, Graded: true},
+	}
+	result := Compute(nil, items, DefaultScale)
+	if *result.Percent != 87.5 || result.Letter != "B" {
+		t.Errorf("got %v %q, want 87.5 B", *result.Percent, result.Letter)
+	}
+	if empty := Compute(nil, nil, DefaultScale); empty.Percent != nil || empty.Letter != "" {
+		t.Errorf("nothing graded should have no grade, got %+v", empty)
+	}
+}
+
+func TestScale(t *testing.T) {
+	scale := Scale{{Letter: "Pass", Min: 50}, {Letter: " Fail ", Min: 0}, {Letter: "Merit", Min: 75}}.Normalize()
+	if err := scale.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if scale[0].Letter != "Merit" || scale.Letter(49.99) != "Fail" || scale.Letter(50) != "Pass" {
+		t.Errorf("unexpected scale %+v", scale)
+	}
+	if err := (Scale{{Letter: "A", Min: 50}}).Validate(); err == nil {
+		t.Error("scale without a 0 step accepted")
+	}
+	if err := (Scale{{Letter: "A", Min: 50}, {Letter: "A", Min: 0}}).Validate(); err == nil {
+		t.Error("duplicate letter accepted")
+	}
+}
@@ -0,0 +1,126 @@
+// Package grading scores quiz answers. Each question type has a strategy
+// that returns the fraction of the question's points earned, so types that
+// allow partial credit and types that don't are totalled the same way.
+package grading
+
+import (
+	"encoding/json"
+	"math"
+	"myway-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeMCQ         = "MCQ"
+	TypeMultiSelect = "MULTI_SELECT"
+	TypeTrueFalse   = "TRUE_FALSE"
+	TypeNumeric     = "NUMERIC"
+	TypeOrdering    = "ORDERING"
+	TypeMatching    = "MATCHING"
+	TypeFillBlank   = "FILL_BLANK"
+	TypeShortAnswer = "SHORT_ANSWER"
+)
+
+// Strategy grades one question type. Credit compares a decoded answer key
+// with a decoded answer and returns a value from 0 to 1.
+type Strategy interface {
+	Credit(key, answer interface{}) float64
+}
+
+// StrategyFunc adapts a function to Strategy.
+type StrategyFunc func(key, answer interface{}) float64
+
+func (f StrategyFunc) Credit(key, answer interface{}) float64 {
+	return f(key, answer)
+}
+
+var strategies = map[string]Strategy{
+	TypeMCQ:         StrategyFunc(singleChoice),
+	TypeMultiSelect: StrategyFunc(multiSelect),
+	TypeTrueFalse:   StrategyFunc(trueFalse),
+	TypeNumeric:     StrategyFunc(numeric),
+	TypeOrdering:    StrategyFunc(ordering),
+	TypeMatching:    StrategyFunc(matching),
+	TypeFillBlank:   StrategyFunc(fillBlank),
+	TypeShortAnswer: StrategyFunc(shortAnswer),
+}
+
+// Types lists the supported question types.
+func Types() []string {
+	return []string{TypeMCQ, TypeMultiSelect, TypeTrueFalse, TypeNumeric, TypeOrdering, TypeMatching, TypeFillBlank, TypeShortAnswer}
+}
+
+// Supports reports whether a question type has a strategy.
+func Supports(questionType string) bool {
+	_, ok := strategies[questionType]
+	return ok
+}
+
+// For returns the strategy for a question type. Unknown types are graded as
+// single choice, which is how every question was graded before types existed.
+func For(questionType string) Strategy {
+	if strategy, ok := strategies[questionType]; ok {
+		return strategy
+	}
+	return strategies[TypeMCQ]
+}
+
+// QuestionResult is the outcome for one question. It is stored on the
+// attempt, so the JSON names are part of the API.
+type QuestionResult struct {
+	QuestionID uuid.UUID `json:"questionId"`
+	Answered   bool      `json:"answered"`
+	Correct    bool      `json:"correct"`
+	Points     float64   `json:"points"`
+	Earned     float64   `json:"earned"`
+}
+
+// Report is the outcome for a whole quiz, in question order.
+type Report struct {
+	Questions []QuestionResult
+	Earned    float64
+	Possible  float64
+}
+
+// Percentage is the share of possible points earned, rounded down.
+func (r Report) Percentage() int {
+	if r.Possible <= 0 {
+		return 0
+	}
+	return int(math.Floor(r.Earned / r.Possible * 100))
+}
+
+// Grade scores one question. A nil answer counts as unanswered.
+func Grade(question models.QuizQuestion, answer interface{}) QuestionResult {
+	result := QuestionResult{
+		QuestionID: question.ID,
+		Answered:   answer != nil,
+		Points:     float64(question.Points),
+	}
+	if !result.Answered {
+		return result
+	}
+
+	var key interface{}
+	if err := json.Unmarshal([]byte(question.AnswerKey), &key); err != nil {
+		return result
+	}
+
+	credit := math.Max(0, math.Min(1, For(question.Type).Credit(key, answer)))
+	result.Correct = credit == 1
+	result.Earned = math.Round(credit*result.Points*100) / 100
+	return result
+}
+
+// GradeQuiz scores every question against answers keyed by question ID.
+func GradeQuiz(questions []models.QuizQuestion, answers map[string]interface{}) Report {
+	report := Report{Questions: make([]QuestionResult, len(questions))}
+	for i, question := range questions {
+		result := Grade(question, answers[question.ID.String()])
+		report.Questions[i] = result
+		report.Earned += result.Earned
+		report.Possible += result.Points
+	}
+	return report
+}
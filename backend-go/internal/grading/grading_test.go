@@ -0,0 +1,139 @@
+package grading
+
+import (
+	"encoding/json"
+	"testing"
+
+	"myway-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    string
+		key    string
+		answer string
+		want   float64
+	}{
+		{"mcq correct", TypeMCQ, `"Paris"`, `"Paris"`, 1},
+		{"mcq wrong", TypeMCQ, `"Paris"`, `"Lyon"`, 0},
+		{"unknown type falls back to mcq", "LEGACY", `"Paris"`, `" Paris "`, 1},
+
+		{"multi all correct", TypeMultiSelect, `["a","b"]`, `["b","a"]`, 1},
+		{"multi partial", TypeMultiSelect, `["a","b"]`, `["a"]`, 0.5},
+		{"multi wrong pick cancels", TypeMultiSelect, `["a","b"]`, `["a","c"]`, 0},
+		{"multi everything", TypeMultiSelect, `["a","b"]`, `["a","b","c","d"]`, 0},
+
+		{"true false bool", TypeTrueFalse, `true`, `true`, 1},
+		{"true false string", TypeTrueFalse, `false`, `"False"`, 1},
+		{"true false wrong", TypeTrueFalse, `true`, `false`, 0},
+
+		{"numeric exact", TypeNumeric, `42`, `42`, 1},
+		{"numeric within tolerance", TypeNumeric, `{"value":3.14,"tolerance":0.01}`, `"3.15"`, 1},
+		{"numeric outside tolerance", TypeNumeric, `{"value":3.14,"tolerance":0.01}`, `3.2`, 0},
+
+		{"ordering exact", TypeOrdering, `["a","b","c"]`, `["a","b","c"]`, 1},
+		{"ordering partial", TypeOrdering, `["a","b","c","d"]`, `["a","b","d","c"]`, 0.5},
+
+		{"matching all", TypeMatching, `{"dog":"bark","cat":"meow"}`, `{"dog":"bark","cat":"meow"}`, 1},
+		{"matching half", TypeMatching, `{"dog":"bark","cat":"meow"}`, `{"dog":"meow","cat":"meow"}`, 0.5},
+
+		{"fill blanks with alternatives", TypeFillBlank, `["H2O",["oxygen","O2"]]`, `["h2o","O2"]`, 1},
+		{"fill one of two blanks", TypeFillBlank, `["H2O","oxygen"]`, `["h2o","hydrogen"]`, 0.5},
+
+		{"short answer normalised", TypeShortAnswer, `"Photosynthesis"`, `"  photosynthesis. "`, 1},
+		{"short answer synonym", TypeShortAnswer, `{"answer":"car","synonyms":["automobile"]}`, `"Automobile"`, 1},
+		{"short answer wrong", TypeShortAnswer, `{"answer":"car","synonyms":["automobile"]}`, `"bus"`, 0},
+		{"short answer blank", TypeShortAnswer, `"car"`, `""`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key, answer interface{}
+			if err := json.Unmarshal([]byte(tt.key), &key); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.answer), &answer); err != nil {
+				t.Fatal(err)
+			}
+			if got := For(tt.typ).Credit(key, answer); got != tt.want {
+				t.Errorf("credit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeQuizWeighsPoints(t *testing.T) {
+	easy := models.QuizQuestion{ID: uuid.New(), Type: TypeMCQ, AnswerKey: `"a"`, Points: 1}
+	hard := models.QuizQuestion{ID: uuid.New(), Type: TypeMultiSelect, AnswerKey: `["a","b"]`, Points: 4}
+	skipped := models.QuizQuestion{ID: uuid.New(), Type: TypeMCQ, AnswerKey: `"a"`, Points: 1}
+
+	report := GradeQuiz([]models.QuizQuestion{easy, hard, skipped}, map[string]interface{}{
+		easy.ID.String(): "a",
+		hard.ID.String(): []interface{}{"a"},
+	})
+
+	if report.Earned != 3 || report.Possible != 6 {
+		t.Fatalf("earned %v of %v, want 3 of 6", report.Earned, report.Possible)
+	}
+	if report.Percentage() != 50 {
+		t.Errorf("percentage = %d, want 50", report.Percentage())
+	}
+	if !report.Questions[0].Correct || report.Questions[1].Correct || report.Questions[2].Answered {
+		t.Errorf("unexpected per-question results: %+v", report.Questions)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     string
+		options []string
+		key     string
+		field   string
+	}{
+		{"mcq ok", TypeMCQ, []string{"Paris", "Lyon"}, `"Paris"`, ""},
+		{"mcq key not an option", TypeMCQ, []string{"Paris", "Lyon"}, `"Nice"`, "answerKey"},
+		{"mcq one option", TypeMCQ, []string{"Paris"}, `"Paris"`, "options"},
+		{"duplicate options", TypeMCQ, []string{"a", " a"}, `"a"`, "options"},
+		{"multi ok", TypeMultiSelect, []string{"a", "b", "c"}, `["a","c"]`, ""},
+		{"multi empty key", TypeMultiSelect, []string{"a", "b"}, `[]`, "answerKey"},
+		{"true false ok", TypeTrueFalse, nil, `false`, ""},
+		{"true false not bool", TypeTrueFalse, nil, `"maybe"`, "answerKey"},
+		{"numeric tolerance", TypeNumeric, nil, `{"value":3.14,"tolerance":0.01}`, ""},
+		{"numeric negative tolerance", TypeNumeric, nil, `{"value":3,"tolerance":-1}`, "answerKey"},
+		{"numeric with options", TypeNumeric, []string{"1"}, `1`, "options"},
+		{"ordering ok", TypeOrdering, []string{"b", "a"}, `["a","b"]`, ""},
+		{"ordering missing item", TypeOrdering, []string{"a", "b", "c"}, `["a","b"]`, "answerKey"},
+		{"matching ok", TypeMatching, []string{"bark", "meow"}, `{"dog":"bark","cat":"meow"}`, ""},
+		{"matching unknown choice", TypeMatching, []string{"bark"}, `{"cat":"meow"}`, "answerKey"},
+		{"fill blank ok", TypeFillBlank, nil, `["H2O",["oxygen","O2"]]`, ""},
+		{"fill blank empty alternative", TypeFillBlank, nil, `["H2O",[]]`, "answerKey"},
+		{"short answer synonyms", TypeShortAnswer, nil, `{"answer":"car","synonyms":["automobile"]}`, ""},
+		{"short answer punctuation only", TypeShortAnswer, nil, `"?!"`, "answerKey"},
+		{"unknown type", "ESSAY", nil, `"x"`, "type"},
+		{"missing key", TypeMCQ, []string{"a", "b"}, `null`, "answerKey"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key interface{}
+			if err := json.Unmarshal([]byte(tt.key), &key); err != nil {
+				t.Fatal(err)
+			}
+			err := Validate(tt.typ, tt.options, key)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok || fieldErr.Field != tt.field {
+				t.Fatalf("error = %v, want one on %s", err, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package grading
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// singleChoice: the key is the correct option, or a list of options that are
+// all accepted.
+func singleChoice(key, answer interface{}) float64 {
+	given, ok := toString(answer)
+	if !ok {
+		return 0
+	}
+	for _, accepted := range acceptedStrings(key) {
+		if strings.TrimSpace(accepted) == strings.TrimSpace(given) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// multiSelect: the key is the list of correct options. Each correct pick
+// earns an equal share and each wrong pick takes one share away, so
+// selecting everything earns nothing.
+func multiSelect(key, answer interface{}) float64 {
+	correct, ok := toStrings(key)
+	if !ok {
+		return 0
+	}
+	picked, ok := toStrings(answer)
+	if !ok {
+		return 0
+	}
+	if len(correct) == 0 {
+		if len(picked) == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	want := make(map[string]bool, len(correct))
+	for _, option := range correct {
+		want[strings.TrimSpace(option)] = true
+	}
+
+	hits, misses := 0, 0
+	seen := map[string]bool{}
+	for _, option := range picked {
+		option = strings.TrimSpace(option)
+		if seen[option] {
+			continue
+		}
+		seen[option] = true
+		if want[option] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	return float64(hits-misses) / float64(len(want))
+}
+
+func trueFalse(key, answer interface{}) float64 {
+	want, ok := toBool(key)
+	if !ok {
+		return 0
+	}
+	given, ok := toBool(answer)
+	if !ok || given != want {
+		return 0
+	}
+	return 1
+}
+
+// numeric: the key is a number, or {"value": n, "tolerance": t} to accept
+// anything within t of n.
+func numeric(key, answer interface{}) float64 {
+	var value, tolerance float64
+	switch k := key.(type) {
+	case map[string]interface{}:
+		v, ok := toFloat(k["value"])
+		if !ok {
+			return 0
+		}
+		value = v
+		if t, ok := toFloat(k["tolerance"]); ok {
+			tolerance = math.Abs(t)
+		}
+	default:
+		v, ok := toFloat(key)
+		if !ok {
+			return 0
+		}
+		value = v
+	}
+
+	given, ok := toFloat(answer)
+	if !ok {
+		return 0
+	}
+	// Allow for float rounding when the tolerance is exactly hit
+	if math.Abs(given-value) <= tolerance+1e-9 {
+		return 1
+	}
+	return 0
+}
+
+// ordering: the key is the items in the correct order. Credit is the share
+// of items in the right position.
+func ordering(key, answer interface{}) float64 {
+	correct, ok := toStrings(key)
+	if !ok || len(correct) == 0 {
+		return 0
+	}
+	given, ok := toStrings(answer)
+	if !ok {
+		return 0
+	}
+
+	right := 0
+	for i, item := range correct {
+		if i < len(given) && strings.TrimSpace(given[i]) == strings.TrimSpace(item) {
+			right++
+		}
+	}
+	return float64(right) / float64(len(correct))
+}
+
+// matching: the key maps each left-hand item to its match. Credit is the
+// share of pairs matched correctly.
+func matching(key, answer interface{}) float64 {
+	pairs, ok := key.(map[string]interface{})
+	if !ok || len(pairs) == 0 {
+		return 0
+	}
+	given, ok := answer.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+
+	right := 0
+	for left, want := range pairs {
+		wantStr, ok := toString(want)
+		if !ok {
+			continue
+		}
+		if got, ok := toString(given[left]); ok && strings.TrimSpace(got) == strings.TrimSpace(wantStr) {
+			right++
+		}
+	}
+	return float64(right) / float64(len(pairs))
+}
+
+// fillBlank: the key has one entry per blank, either the expected text or a
+// list of accepted texts. Blanks are compared like short answers. Credit is
+// the share of blanks filled correctly.
+func fillBlank(key, answer interface{}) float64 {
+	blanks, ok := key.([]interface{})
+	if !ok {
+		// A single blank may be keyed by a bare string
+		blanks = []interface{}{key}
+	}
+	if len(blanks) == 0 {
+		return 0
+	}
+
+	given, ok := toStrings(answer)
+	if !ok {
+		text, isText := toString(answer)
+		if !isText {
+			return 0
+		}
+		given = []string{text}
+	}
+
+	right := 0
+	for i, blank := range blanks {
+		if i < len(given) && matchesAny(given[i], acceptedStrings(blank)) {
+			right++
+		}
+	}
+	return float64(right) / float64(len(blanks))
+}
+
+// shortAnswer: the key is the expected text, a list of accepted texts, or
+// {"answer": "...", "synonyms": [...]}. Comparison ignores case,
+// punctuation and extra whitespace.
+func shortAnswer(key, answer interface{}) float64 {
+	given, ok := toString(answer)
+	if !ok {
+		return 0
+	}
+	if matchesAny(given, acceptedStrings(key)) {
+		return 1
+	}
+	return 0
+}
+
+// acceptedStrings flattens the accepted-answer shapes used by the text
+// strategies.
+func acceptedStrings(key interface{}) []string {
+	switch k := key.(type) {
+	case map[string]interface{}:
+		var accepted []string
+		if answer, ok := toString(k["answer"]); ok {
+			accepted = append(accepted, answer)
+		}
+		for _, field := range []string{"answers", "synonyms"} {
+			if list, ok := toStrings(k[field]); ok {
+				accepted = append(accepted, list...)
+			}
+		}
+		return accepted
+	case []interface{}:
+		list, _ := toStrings(k)
+		return list
+	default:
+		if s, ok := toString(k); ok {
+			return []string{s}
+		}
+		return nil
+	}
+}
+
+func matchesAny(given string, accepted []string) bool {
+	normalized := Normalize(given)
+	if normalized == "" {
+		return false
+	}
+	for _, candidate := range accepted {
+		if Normalize(candidate) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize lowercases text, drops punctuation and collapses whitespace.
+func Normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func toString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	default:
+		return "", false
+	}
+}
+
+func toStrings(v interface{}) ([]string, bool) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		s, ok := toString(item)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch t := v.(type) {
+	case bool:
+		return t, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "true", "t", "yes":
+			return true, true
+		case "false", "f", "no":
+			return false, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,188 @@
+package grading
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError reports an authored question that its type cannot grade.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func invalid(field, format string, args ...interface{}) error {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks that a question's options and decoded answer key have the
+// shape its type expects, so that a learner can earn full credit.
+func Validate(questionType string, options []string, key interface{}) error {
+	if !Supports(questionType) {
+		return invalid("type", "type must be one of %s", strings.Join(Types(), ", "))
+	}
+	if key == nil {
+		return invalid("answerKey", "answerKey is required")
+	}
+
+	choices := make(map[string]bool, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return invalid("options", "options must not be blank")
+		}
+		if choices[option] {
+			return invalid("options", "option %q is listed twice", option)
+		}
+		choices[option] = true
+	}
+	needChoices := func(min int) error {
+		if len(options) < min {
+			return invalid("options", "%s questions need at least %d options", questionType, min)
+		}
+		return nil
+	}
+	noChoices := func() error {
+		if len(options) > 0 {
+			return invalid("options", "%s questions have no options", questionType)
+		}
+		return nil
+	}
+	inChoices := func(values []string) error {
+		for _, value := range values {
+			if !choices[strings.TrimSpace(value)] {
+				return invalid("answerKey", "%q is not one of the options", value)
+			}
+		}
+		return nil
+	}
+
+	switch questionType {
+	case TypeMCQ:
+		if err := needChoices(2); err != nil {
+			return err
+		}
+		accepted := acceptedStrings(key)
+		if _, isMap := key.(map[string]interface{}); isMap || len(accepted) == 0 {
+			return invalid("answerKey", "answerKey must be the correct option")
+		}
+		return inChoices(accepted)
+
+	case TypeMultiSelect:
+		if err := needChoices(2); err != nil {
+			return err
+		}
+		correct, ok := toStrings(key)
+		if !ok || len(correct) == 0 {
+			return invalid("answerKey", "answerKey must list the correct options")
+		}
+		if hasDuplicates(correct) {
+			return invalid("answerKey", "answerKey lists an option twice")
+		}
+		return inChoices(correct)
+
+	case TypeTrueFalse:
+		if len(options) != 0 && len(options) != 2 {
+			return invalid("options", "TRUE_FALSE questions have no options or exactly two")
+		}
+		if _, ok := toBool(key); !ok {
+			return invalid("answerKey", "answerKey must be true or false")
+		}
+
+	case TypeNumeric:
+		if err := noChoices(); err != nil {
+			return err
+		}
+		if k, ok := key.(map[string]interface{}); ok {
+			if _, ok := toFloat(k["value"]); !ok {
+				return invalid("answerKey", "answerKey.value must be a number")
+			}
+			if t, present := k["tolerance"]; present {
+				if tolerance, ok := toFloat(t); !ok || tolerance < 0 {
+					return invalid("answerKey", "answerKey.tolerance must be a number of at least 0")
+				}
+			}
+		} else if _, ok := toFloat(key); !ok {
+			return invalid("answerKey", "answerKey must be a number or {\"value\", \"tolerance\"}")
+		}
+
+	case TypeOrdering:
+		if err := needChoices(2); err != nil {
+			return err
+		}
+		order, ok := toStrings(key)
+		if !ok || len(order) != len(options) || hasDuplicates(order) {
+			return invalid("answerKey", "answerKey must list every option once, in the correct order")
+		}
+		return inChoices(order)
+
+	case TypeMatching:
+		pairs, ok := key.(map[string]interface{})
+		if !ok || len(pairs) == 0 {
+			return invalid("answerKey", "answerKey must map each item to its match")
+		}
+		for left, right := range pairs {
+			match, ok := right.(string)
+			if strings.TrimSpace(left) == "" || !ok || strings.TrimSpace(match) == "" {
+				return invalid("answerKey", "answerKey must map each item to its match")
+			}
+			if len(options) > 0 {
+				if err := inChoices([]string{match}); err != nil {
+					return err
+				}
+			}
+		}
+
+	case TypeFillBlank:
+		if err := noChoices(); err != nil {
+			return err
+		}
+		blanks, ok := key.([]interface{})
+		if !ok {
+			blanks = []interface{}{key}
+		}
+		if len(blanks) == 0 {
+			return invalid("answerKey", "answerKey needs one entry per blank")
+		}
+		for i, blank := range blanks {
+			if !hasText(acceptedStrings(blank)) {
+				return invalid("answerKey", "blank %d has no accepted answer", i+1)
+			}
+		}
+
+	case TypeShortAnswer:
+		if err := noChoices(); err != nil {
+			return err
+		}
+		if !hasText(acceptedStrings(key)) {
+			return invalid("answerKey", "answerKey needs at least one accepted answer")
+		}
+	}
+	return nil
+}
+
+func hasDuplicates(values []string) bool {
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if seen[value] {
+			return true
+		}
+		seen[value] = true
+	}
+	return false
+}
+
+// hasText reports whether any accepted answer survives normalisation.
+func hasText(accepted []string) bool {
+	for _, text := range accepted {
+		if Normalize(text) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,71 +1,107 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
-	"io"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/llm"
 	"myway-backend/internal/models"
+	"myway-backend/internal/review"
+	"myway-backend/internal/studypack"
 	"net/http"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// AIHandler serves the AI features. LLM is nil when no provider is
+// configured, and those features answer 503.
 type AIHandler struct {
-	GeminiAPIKey string
+	LLM llm.Provider
 }
 
-func NewAIHandler(geminiAPIKey string) *AIHandler {
-	return &AIHandler{GeminiAPIKey: geminiAPIKey}
+func NewAIHandler(provider llm.Provider) *AIHandler {
+	return &AIHandler{LLM: provider}
 }
 
 func (h *AIHandler) GetStudyPack(c *gin.Context) {
 	materialID, err := uuid.Parse(c.Param("materialId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		respondError(c, apperror.InvalidField("materialId", "Invalid material ID"))
 		return
 	}
 
+	userID := c.MustGet("userID").(uuid.UUID)
+
 	var studyPack models.StudyPack
 	if err := database.GetDB().
 		Preload("Summary").
-		Preload("Quizzes.Questions").
-		Preload("Flashcards").
-		Preload("Material").
+		Preload("Quizzes.Questions", byPosition).
+		Preload("Flashcards", byPosition).
+		Preload("Material.Module.Course").
 		Where("material_id = ?", materialID).
 		Order("created_at DESC").
 		First(&studyPack).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Study pack not found or not ready"})
+		respondError(c, apperror.FromDB(err, "Study pack not found or not ready"))
 		return
 	}
 
-	// Parse summary content from JSON
-	var summaryContent map[string]interface{}
-	if studyPack.Summary != nil {
-		json.Unmarshal([]byte(studyPack.Summary.Content), &summaryContent)
+	course := studyPack.Material.Module.Course
+	if course.ID == uuid.Nil {
+		respondError(c, apperror.NotFound("Study pack not found or not ready"))
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":         studyPack.ID,
-		"materialId": studyPack.MaterialID,
-		"status":     studyPack.Status,
-		"summary":    gin.H{"content": summaryContent},
-		"quizzes":    studyPack.Quizzes,
-		"flashcards": studyPack.Flashcards,
-		"material":   studyPack.Material,
-	})
+	// Instructors see answer keys; students only for quizzes whose answers
+	// are revealed to them, see revealQuizAnswers.
+	if requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER") == nil {
+		c.JSON(http.StatusOK, dto.NewStudyPackContent(studyPack, func(models.Quiz) dto.Detail {
+			return dto.Instructor
+		}))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "STUDENT"); err != nil {
+		respondError(c, err)
+		return
+	}
+	if studyPack.Status != "READY" {
+		respondError(c, apperror.NotFound("Study pack not found or not ready"))
+		return
+	}
+	if err := requireModuleUnlocked(database.GetDB(), userID, studyPack.Material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	revealed := make(map[uuid.UUID]bool, len(studyPack.Quizzes))
+	for i := range studyPack.Quizzes {
+		quiz := &studyPack.Quizzes[i]
+		shown, err := revealQuizAnswers(database.GetDB(), quiz, course.ID, userID)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to check answer reveal", err))
+			return
+		}
+		revealed[quiz.ID] = shown
+	}
+
+	c.JSON(http.StatusOK, dto.NewStudyPackContent(studyPack, func(quiz models.Quiz) dto.Detail {
+		if revealed[quiz.ID] {
+			return dto.Instructor
+		}
+		return dto.Learner
+	}))
 }
 
 type ApproveStudyPackRequest struct {
 	Summary       string   `json:"summary" binding:"required"`
 	KeyPoints     []string `json:"keyPoints"`
 	KeyPointsText string   `json:"keyPointsText"`
+	Notes         string   `json:"notes"`
 }
 
 type RegenerateStudyPackRequest struct {
@@ -73,20 +109,14 @@ type RegenerateStudyPackRequest struct {
 }
 
 func (h *AIHandler) GetReviewDraft(c *gin.Context) {
-	_, ok := h.requireInstructor(c)
+	_, material, ok := h.requireInstructor(c)
 	if !ok {
 		return
 	}
 
-	materialID, err := uuid.Parse(c.Param("materialId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
-		return
-	}
-
-	studyPack, err := h.getLatestStudyPackByMaterial(materialID)
+	studyPack, err := h.getLatestStudyPackByMaterial(material.ID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Study pack draft not found"})
+		respondError(c, apperror.FromDB(err, "Study pack draft not found"))
 		return
 	}
 
@@ -105,25 +135,19 @@ func (h *AIHandler) GetReviewDraft(c *gin.Context) {
 }
 
 func (h *AIHandler) ApproveStudyPack(c *gin.Context) {
-	userID, ok := h.requireInstructor(c)
+	userID, material, ok := h.requireInstructor(c)
 	if !ok {
 		return
 	}
 
-	materialID, err := uuid.Parse(c.Param("materialId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
-		return
-	}
-
 	var req ApproveStudyPackRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	if strings.TrimSpace(req.Summary) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Summary is required"})
+		respondError(c, apperror.InvalidField("summary", "Summary is required"))
 		return
 	}
 
@@ -132,47 +156,54 @@ func (h *AIHandler) ApproveStudyPack(c *gin.Context) {
 		keyPoints = parseKeyPointsText(req.KeyPointsText)
 	}
 	if len(keyPoints) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one key point is required"})
+		respondError(c, apperror.InvalidField("keyPoints", "At least one key point is required"))
 		return
 	}
 
-	studyPack, err := h.getLatestStudyPackByMaterial(materialID)
+	studyPack, err := h.getLatestStudyPackByMaterial(material.ID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Study pack draft not found"})
+		respondError(c, apperror.FromDB(err, "Study pack draft not found"))
 		return
 	}
 
-	contentJSON, _ := json.Marshal(map[string]interface{}{
-		"summary": req.Summary,
-		"bullets": keyPoints,
-	})
-
 	db := database.GetDB()
-	if studyPack.Summary == nil {
-		summary := models.Summary{
-			StudyPackID: studyPack.ID,
-			Content:     string(contentJSON),
-		}
-		if err := db.Create(&summary).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save summary"})
-			return
+	policy, err := reviewPolicy(db, material.Module.Course.OrgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	content := studypack.SummaryContent(req.Summary, keyPoints)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
 		}
-	} else {
-		if err := db.Model(&models.Summary{}).Where("id = ?", studyPack.Summary.ID).Update("content", string(contentJSON)).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update summary"})
-			return
+		if _, err := nextReviewStatus(current, review.Publish, policy); err != nil {
+			return err
 		}
-	}
 
-	now := time.Now()
-	approvedBy := userID.String()
-	if err := db.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Updates(map[string]interface{}{
-		"status":            "READY",
-		"published_at":      &now,
-		"requires_approval": false,
-		"approved_by":       &approvedBy,
-	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve study pack"})
+		existing := ""
+		if studyPack.Summary != nil {
+			existing = studyPack.Summary.Content
+		}
+		if !studypack.SameJSON(existing, content) {
+			if !review.Editable(current.ReviewStatus) {
+				return apperror.Conflict("Approved content cannot be changed; withdraw the study pack from review to edit it")
+			}
+			if err := saveSummary(tx, studyPack.ID, content); err != nil {
+				return err
+			}
+		}
+		_, err = publishStudyPack(tx, models.StudyPackRevision{
+			StudyPackID: studyPack.ID,
+			AuthorType:  revisionAuthorInstructor,
+			AuthorID:    &userID,
+			Notes:       &req.Notes,
+		})
+		return err
+	}); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -189,43 +220,32 @@ func (h *AIHandler) ApproveStudyPack(c *gin.Context) {
 }
 
 func (h *AIHandler) RegenerateStudyPack(c *gin.Context) {
-	userID, ok := h.requireInstructor(c)
+	userID, material, ok := h.requireInstructor(c)
 	if !ok {
 		return
 	}
 
-	materialID, err := uuid.Parse(c.Param("materialId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
-		return
-	}
-
 	var req RegenerateStudyPackRequest
 	_ = c.ShouldBindJSON(&req)
 
 	db := database.GetDB()
 
-	var material models.Material
-	if err := db.First(&material, materialID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
-		return
-	}
-
-	studyPack, err := h.getLatestStudyPackByMaterial(materialID)
+	studyPack, err := h.getLatestStudyPackByMaterial(material.ID)
+	created := err != nil
 	if err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load study pack"})
+			respondError(c, apperror.Internal("Failed to load study pack", err))
 			return
 		}
 
 		newPack := models.StudyPack{
-			MaterialID:       materialID,
+			MaterialID:       material.ID,
 			CreatedBy:        userID.String(),
 			Status:           "PROCESSING",
 			RequiresApproval: true,
 		}
 		if err := db.Create(&newPack).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study pack"})
+			respondError(c, apperror.Internal("Failed to create study pack", err))
 			return
 		}
 
@@ -250,35 +270,43 @@ func (h *AIHandler) RegenerateStudyPack(c *gin.Context) {
 		"bullets": generatedKeyPoints,
 	})
 
-	var existingSummary models.Summary
-	if err := db.Where("study_pack_id = ?", studyPack.ID).First(&existingSummary).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			summary := models.Summary{
-				StudyPackID: studyPack.ID,
-				Content:     string(contentJSON),
-			}
-			if err := db.Create(&summary).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create regenerated summary"})
-				return
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		if !review.Editable(current.ReviewStatus) {
+			return apperror.Conflict("Study packs can only be regenerated as drafts, after changes are requested or once unpublished")
+		}
+		// Keep what is about to be replaced if the pack has no history yet
+		if !created {
+			if err := recordBaselineRevision(tx, current); err != nil {
+				return err
 			}
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load summary"})
-			return
 		}
-	} else {
-		if err := db.Model(&existingSummary).Update("content", string(contentJSON)).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update summary"})
-			return
+
+		if err := saveSummary(tx, studyPack.ID, string(contentJSON)); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Updates(map[string]interface{}{
+			"status":            "GENERATED",
+			"review_status":     review.Draft,
+			"requires_approval": true,
+			"approved_by":       nil,
+			"published_at":      nil,
+		}).Error; err != nil {
+			return err
 		}
-	}
 
-	if err := db.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Updates(map[string]interface{}{
-		"status":            "GENERATED",
-		"requires_approval": true,
-		"approved_by":       nil,
-		"published_at":      nil,
-	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update study pack status"})
+		_, err = recordRevision(tx, models.StudyPackRevision{
+			StudyPackID: studyPack.ID,
+			AuthorType:  revisionAuthorAI,
+			AuthorID:    &userID,
+			Notes:       &req.Notes,
+		})
+		return err
+	}); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -295,22 +323,33 @@ func (h *AIHandler) RegenerateStudyPack(c *gin.Context) {
 	})
 }
 
-func (h *AIHandler) requireInstructor(c *gin.Context) (uuid.UUID, bool) {
+// requireInstructor loads the material in the materialId path parameter
+// and checks that the caller teaches in its course's organization.
+func (h *AIHandler) requireInstructor(c *gin.Context) (uuid.UUID, *models.Material, bool) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	var user models.User
-	if err := database.GetDB().Select("id", "role").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-		return uuid.Nil, false
+	materialID, err := uuid.Parse(c.Param("materialId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("materialId", "Invalid material ID"))
+		return uuid.Nil, nil, false
 	}
 
-	role := strings.ToUpper(strings.TrimSpace(user.Role))
-	if role != "TEACHER" && role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only instructors can perform this action"})
-		return uuid.Nil, false
+	var material models.Material
+	if err := database.GetDB().Preload("Module.Course").First(&material, "id = ?", materialID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Material not found"))
+		return uuid.Nil, nil, false
+	}
+	course := material.Module.Course
+	if course.ID == uuid.Nil {
+		respondError(c, apperror.NotFound("Material not found"))
+		return uuid.Nil, nil, false
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return uuid.Nil, nil, false
 	}
 
-	return userID, true
+	return userID, &material, true
 }
 
 func (h *AIHandler) getLatestStudyPackByMaterial(materialID uuid.UUID) (*models.StudyPack, error) {
@@ -394,24 +433,28 @@ type TutorChatRequest struct {
 func (h *AIHandler) TutorChat(c *gin.Context) {
 	var req TutorChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	query := strings.TrimSpace(req.Query)
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		respondError(c, apperror.InvalidField("query", "query is required"))
 		return
 	}
 
-	if strings.TrimSpace(h.GeminiAPIKey) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Gemini API key is not configured"})
+	if h.LLM == nil {
+		respondError(c, apperror.Unavailable("No AI provider is configured"))
 		return
 	}
 
-	answer, err := h.generateTutorAnswerWithGemini(req.CourseID, query)
+	answer, err := h.LLM.Generate(c.Request.Context(), llm.Request{
+		Prompt:          tutorPrompt(req.CourseID, query),
+		Temperature:     0.4,
+		MaxOutputTokens: 900,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Gemini request failed"})
+		respondError(c, apperror.Upstream("AI request failed", err))
 		return
 	}
 	answer = sanitizeTutorAnswer(answer)
@@ -420,15 +463,13 @@ func (h *AIHandler) TutorChat(c *gin.Context) {
 		"answer":                 answer,
 		"sourceReferences":       []string{},
 		"analyzedMaterialsCount": 1,
-		"provider":               "gemini",
-		"model":                  "gemini-3-flash-preview",
+		"provider":               h.LLM.Name(),
+		"model":                  h.LLM.Model(),
 	})
 }
 
-func (h *AIHandler) generateTutorAnswerWithGemini(courseID, query string) (string, error) {
-	modelName := "gemini-3-flash-preview"
-
-	prompt := strings.TrimSpace(`You are MyWay AI Tutor.
+func tutorPrompt(courseID, query string) string {
+	return strings.TrimSpace(`You are MyWay AI Tutor.
 
 Rules:
 - Explain clearly and practically.
@@ -442,85 +483,6 @@ Rules:
 
 Course context: ` + courseID + `
 User question: ` + query)
-
-	type geminiPart struct {
-		Text string `json:"text"`
-	}
-	type geminiContent struct {
-		Role  string       `json:"role,omitempty"`
-		Parts []geminiPart `json:"parts"`
-	}
-	type geminiGenerationConfig struct {
-		Temperature     float64 `json:"temperature,omitempty"`
-		MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
-	}
-	type geminiRequest struct {
-		Contents         []geminiContent         `json:"contents"`
-		GenerationConfig geminiGenerationConfig `json:"generationConfig,omitempty"`
-	}
-
-	requestBody := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Role:  "user",
-				Parts: []geminiPart{{Text: prompt}},
-			},
-		},
-		GenerationConfig: geminiGenerationConfig{
-			Temperature:     0.4,
-			MaxOutputTokens: 900,
-		},
-	}
-
-	payload, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
-	}
-
-	url := "https://generativelanguage.googleapis.com/v1beta/models/" + modelName + ":generateContent?key=" + h.GeminiAPIKey
-	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
-	if err != nil {
-		return "", err
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 400 {
-		return "", errors.New("gemini returned non-2xx response")
-	}
-
-	type geminiResponse struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-	}
-
-	var parsed geminiResponse
-	if err := json.Unmarshal(body, &parsed); err != nil {
-		return "", err
-	}
-
-	for _, candidate := range parsed.Candidates {
-		for _, part := range candidate.Content.Parts {
-			text := strings.TrimSpace(part.Text)
-			if text != "" {
-				return text, nil
-			}
-		}
-	}
-
-	return "", errors.New("empty response from gemini")
 }
 
 func sanitizeTutorAnswer(input string) string {
@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
+	"math"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/grading"
 	"myway-backend/internal/models"
+	"myway-backend/internal/watch"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AnalyticsHandler struct{}
@@ -20,21 +27,39 @@ func NewAnalyticsHandler() *AnalyticsHandler {
 func (h *AnalyticsHandler) GetStudentDashboard(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	// Get enrollments with progress
+	// Get enrollments with progress, skipping trashed courses
+	db := database.GetDB()
 	var enrollments []models.Enrollment
-	database.GetDB().
+	if err := db.
 		Preload("Course").
 		Where("user_id = ?", userID).
-		Find(&enrollments)
+		Where("course_id IN (?)", db.Model(&models.Course{}).Select("id")).
+		Find(&enrollments).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch enrollments", err))
+		return
+	}
 
 	// Get recent quiz attempts with trend
 	var quizAttempts []models.QuizAttempt
-	database.GetDB().
+	if err := database.GetDB().
 		Preload("Quiz.StudyPack.Material").
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND status = ?", userID, "SUBMITTED").
 		Order("created_at DESC").
 		Limit(20).
-		Find(&quizAttempts)
+		Find(&quizAttempts).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch quiz attempts", err))
+		return
+	}
+	// Per-question results only for quizzes whose answers were revealed
+	var revealedQuizIDs []uuid.UUID
+	if err := db.Model(&models.QuizReveal{}).Where("user_id = ?", userID).Pluck("quiz_id", &revealedQuizIDs).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch quiz attempts", err))
+		return
+	}
+	revealed := make(map[uuid.UUID]bool, len(revealedQuizIDs))
+	for _, quizID := range revealedQuizIDs {
+		revealed[quizID] = true
+	}
 
 	// Calculate average score and trend
 	var avgScore float64
@@ -67,25 +92,57 @@ func (h *AnalyticsHandler) GetStudentDashboard(c *gin.Context) {
 
 	// Calculate overall progress
 	var progressEvents []models.ProgressEvent
-	database.GetDB().
+	if err := database.GetDB().
 		Where("user_id = ?", userID).
-		Find(&progressEvents)
+		Find(&progressEvents).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch progress events", err))
+		return
+	}
 
-	// Get next step recommendation
+	// Progress per enrolled course; the next step is the first material
+	// not yet completed
+	courseProgress := make([]gin.H, 0, len(enrollments))
 	nextStep := "Continue with your current course"
-	if len(enrollments) > 0 {
-		nextStep = "Complete assignments in " + enrollments[0].Course.Title
+	foundNext := false
+	for _, enrollment := range enrollments {
+		course, err := loadCourseTree(db, enrollment.CourseID)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to fetch course", err))
+			return
+		}
+		completion, err := loadCourseCompletion(db, course, []uuid.UUID{userID})
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to calculate progress", err))
+			return
+		}
+		modules, rollup := completion.evaluate(userID)
+		courseProgress = append(courseProgress, gin.H{
+			"courseId":           course.ID,
+			"courseTitle":        course.Title,
+			"progressPercentage": rollup.Percent,
+			"completedMaterials": rollup.Completed,
+			"totalMaterials":     rollup.Total,
+		})
+		for _, module := range modules {
+			for _, material := range module.Materials {
+				if !foundNext && !material.Completed {
+					nextStep = "Continue with " + material.Title + " in " + course.Title
+					foundNext = true
+				}
+			}
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"enrolledCourses": enrollments,
-		"recentActivity":  quizAttempts[:min(10, len(quizAttempts))],
-		"totalAttempts":   len(quizAttempts),
-		"avgScore":        avgScore,
-		"lastScore":       lastScore,
-		"scoreTrend":      scoreTrend,
-		"weakTopics":      weakTopics,
-		"nextStep":        nextStep,
+		"enrolledCourses":     dto.NewEnrollments(enrollments),
+		"courseProgress":      courseProgress,
+		"recentActivity":      dto.NewQuizAttempts(quizAttempts[:min(10, len(quizAttempts))], revealed),
+		"totalAttempts":       len(quizAttempts),
+		"avgScore":            avgScore,
+		"lastScore":           lastScore,
+		"scoreTrend":          scoreTrend,
+		"weakTopics":          weakTopics,
+		"nextStep":            nextStep,
 		"totalProgressEvents": len(progressEvents),
 	})
 }
@@ -101,12 +158,17 @@ func (h *AnalyticsHandler) GetTeacherDashboard(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
 	// Get created courses
+	db := database.GetDB()
 	var courses []models.Course
-	database.GetDB().
+	if err := db.
 		Preload("Enrollments.User").
 		Preload("Modules").
+		Preload("Modules.Materials").
 		Where("created_by = ?", userID).
-		Find(&courses)
+		Find(&courses).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch courses", err))
+		return
+	}
 
 	// Build cohort list with progress and scores
 	cohorts := make([]gin.H, 0)
@@ -114,25 +176,35 @@ func (h *AnalyticsHandler) GetTeacherDashboard(c *gin.Context) {
 	atRiskCount := 0
 
 	for _, course := range courses {
+		var studentIDs []uuid.UUID
 		for _, enrollment := range course.Enrollments {
 			if enrollment.Role == "STUDENT" {
-				totalStudents++
+				studentIDs = append(studentIDs, enrollment.UserID)
+			}
+		}
+		completion, err := loadCourseCompletion(db, course, studentIDs)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to calculate progress", err))
+			return
+		}
 
-				// Calculate student progress
-				var progressEvents []models.ProgressEvent
-				database.GetDB().
-					Where("user_id = ? AND course_id = ?", enrollment.UserID, course.ID.String()).
-					Find(&progressEvents)
+		for _, enrollment := range course.Enrollments {
+			if enrollment.Role == "STUDENT" {
+				totalStudents++
 
 				// Get quiz scores
 				var quizAttempts []models.QuizAttempt
-				database.GetDB().
+				if err := db.
 					Joins("JOIN quizzes ON quiz_attempts.quiz_id = quizzes.id").
 					Joins("JOIN study_packs ON quizzes.study_pack_id = study_packs.id").
 					Joins("JOIN materials ON study_packs.material_id = materials.id").
 					Joins("JOIN modules ON materials.module_id = modules.id").
 					Where("modules.course_id = ? AND quiz_attempts.user_id = ?", course.ID, enrollment.UserID).
-					Find(&quizAttempts)
+					Where("quiz_attempts.status = ?", "SUBMITTED").
+					Find(&quizAttempts).Error; err != nil {
+					respondError(c, apperror.Internal("Failed to fetch quiz attempts", err))
+					return
+				}
 
 				var avgScore float64
 				if len(quizAttempts) > 0 {
@@ -143,25 +215,23 @@ func (h *AnalyticsHandler) GetTeacherDashboard(c *gin.Context) {
 					avgScore = float64(total) / float64(len(quizAttempts))
 				}
 
-				progressPercentage := float64(len(progressEvents)) * 5.0 // Simplified
-				if progressPercentage > 100 {
-					progressPercentage = 100
-				}
-
-				atRisk := avgScore < 60 || progressPercentage < 30
+				_, rollup := completion.evaluate(enrollment.UserID)
+				atRisk := avgScore < 60 || rollup.Percent < 30
 				if atRisk {
 					atRiskCount++
 				}
 
 				cohorts = append(cohorts, gin.H{
-					"studentId":        enrollment.UserID,
-					"studentName":      enrollment.User.Name,
-					"courseId":         course.ID,
-					"courseTitle":      course.Title,
-					"progress":         progressPercentage,
-					"avgScore":         avgScore,
-					"atRisk":           atRisk,
-					"quizAttempts":     len(quizAttempts),
+					"studentId":          enrollment.UserID,
+					"studentName":        enrollment.User.Name,
+					"courseId":           course.ID,
+					"courseTitle":        course.Title,
+					"progress":           rollup.Percent,
+					"completedMaterials": rollup.Completed,
+					"totalMaterials":     rollup.Total,
+					"avgScore":           avgScore,
+					"atRisk":             atRisk,
+					"quizAttempts":       len(quizAttempts),
 				})
 			}
 		}
@@ -171,13 +241,17 @@ func (h *AnalyticsHandler) GetTeacherDashboard(c *gin.Context) {
 	weakTopics := make(map[string]int)
 	for _, course := range courses {
 		var quizAttempts []models.QuizAttempt
-		database.GetDB().
+		if err := database.GetDB().
 			Joins("JOIN quizzes ON quiz_attempts.quiz_id = quizzes.id").
 			Joins("JOIN study_packs ON quizzes.study_pack_id = study_packs.id").
 			Joins("JOIN materials ON study_packs.material_id = materials.id").
 			Joins("JOIN modules ON materials.module_id = modules.id").
 			Where("modules.course_id = ?", course.ID).
-			Find(&quizAttempts)
+			Where("quiz_attempts.status = ?", "SUBMITTED").
+			Find(&quizAttempts).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to fetch quiz attempts", err))
+			return
+		}
 
 		for _, attempt := range quizAttempts {
 			if attempt.Score < 70 {
@@ -204,41 +278,53 @@ func (h *AnalyticsHandler) GetOrganizerDashboard(c *gin.Context) {
 	// Get active users (users with activity in last 7 days)
 	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
 	var activeUsers []models.User
-	database.GetDB().
+	if err := database.GetDB().
 		Joins("JOIN org_memberships ON users.id = org_memberships.user_id").
 		Joins("LEFT JOIN progress_events ON users.id = progress_events.user_id").
 		Where("org_memberships.org_id = ? AND (progress_events.created_at > ? OR users.last_login > ?)", orgID, sevenDaysAgo, sevenDaysAgo).
 		Group("users.id").
-		Find(&activeUsers)
+		Find(&activeUsers).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count active users", err))
+		return
+	}
 
 	// Count study packs generated
 	var studyPacksCount int64
-	database.GetDB().
+	if err := database.GetDB().
 		Model(&models.StudyPack{}).
 		Joins("JOIN materials ON study_packs.material_id = materials.id").
 		Joins("JOIN modules ON materials.module_id = modules.id").
 		Joins("JOIN courses ON modules.course_id = courses.id").
 		Where("courses.org_id = ? AND study_packs.status = ?", orgID, "READY").
-		Count(&studyPacksCount)
+		Count(&studyPacksCount).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count study packs", err))
+		return
+	}
 
 	// Count quizzes taken
 	var quizzesTakenCount int64
-	database.GetDB().
+	if err := database.GetDB().
 		Model(&models.QuizAttempt{}).
 		Joins("JOIN quizzes ON quiz_attempts.quiz_id = quizzes.id").
 		Joins("JOIN study_packs ON quizzes.study_pack_id = study_packs.id").
 		Joins("JOIN materials ON study_packs.material_id = materials.id").
 		Joins("JOIN modules ON materials.module_id = modules.id").
 		Joins("JOIN courses ON modules.course_id = courses.id").
-		Where("courses.org_id = ?", orgID).
-		Count(&quizzesTakenCount)
+		Where("courses.org_id = ? AND quiz_attempts.status = ?", orgID, "SUBMITTED").
+		Count(&quizzesTakenCount).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count quiz attempts", err))
+		return
+	}
 
 	// Calculate retention (users active in last 7 days / total users)
 	var totalUsers int64
-	database.GetDB().
+	if err := database.GetDB().
 		Model(&models.OrgMembership{}).
 		Where("org_id = ? AND status = ?", orgID, "Active").
-		Count(&totalUsers)
+		Count(&totalUsers).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count members", err))
+		return
+	}
 
 	retentionRate := 0.0
 	if totalUsers > 0 {
@@ -247,125 +333,231 @@ func (h *AnalyticsHandler) GetOrganizerDashboard(c *gin.Context) {
 
 	// Get daily metrics
 	var dailyMetrics []models.DailyOrgMetric
-	database.GetDB().
+	if err := database.GetDB().
 		Where("org_id = ?", orgID).
 		Order("date DESC").
 		Limit(30).
-		Find(&dailyMetrics)
+		Find(&dailyMetrics).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch daily metrics", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"activeUsers":      len(activeUsers),
-		"totalUsers":       totalUsers,
+		"activeUsers":         len(activeUsers),
+		"totalUsers":          totalUsers,
 		"studyPacksGenerated": studyPacksCount,
-		"quizzesTaken":     quizzesTakenCount,
-		"retentionRate":    retentionRate,
-		"dailyMetrics":     dailyMetrics,
+		"quizzesTaken":        quizzesTakenCount,
+		"retentionRate":       retentionRate,
+		"dailyMetrics":        dto.NewDailyMetrics(dailyMetrics),
+	})
+}
+
+// retentionBuckets is how many equal parts of a video engagement
+// retention is reported for.
+const retentionBuckets = 10
+
+// GetVideoEngagement summarizes how the learners who started a video
+// watched it. Retention is the share of viewers who watched each tenth.
+func (h *AnalyticsHandler) GetVideoEngagement(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	material, ok := loadVideo(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	criteria, _, err := loadCompletionCriteria(db, material.Module.Course.OrgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load completion criteria", err))
+		return
+	}
+	var activities []models.MaterialActivity
+	if err := db.Where("material_id = ? AND watched_seconds > 0", material.ID).Find(&activities).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load watch progress", err))
+		return
+	}
+	var rejected int64
+	if err := db.Model(&models.VideoHeartbeat{}).Where("material_id = ? AND accepted = ?", material.ID, false).Count(&rejected).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count heartbeats", err))
+		return
+	}
+
+	minWatch := criteria["VIDEO"].MinWatchPercent
+	completed := 0
+	totalPercent := 0
+	totalSeconds := 0.0
+	watchedBuckets := make([]int, retentionBuckets)
+	for _, activity := range activities {
+		totalPercent += activity.WatchedPercent
+		totalSeconds += activity.WatchedSeconds
+		if activity.WatchedPercent >= minWatch {
+			completed++
+		}
+		if material.DurationSec == nil {
+			continue
+		}
+		intervals, err := decodeIntervals(activity.WatchedIntervals)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to read watched intervals", err))
+			return
+		}
+		for i, watched := range watch.Retention(intervals, *material.DurationSec, retentionBuckets) {
+			if watched {
+				watchedBuckets[i]++
+			}
+		}
+	}
+
+	averagePercent := 0.0
+	retention := make([]float64, retentionBuckets)
+	if viewers := len(activities); viewers > 0 {
+		averagePercent = math.Round(float64(totalPercent)/float64(viewers)*10) / 10
+		for i, count := range watchedBuckets {
+			retention[i] = math.Round(float64(count)/float64(viewers)*1000) / 10
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"materialId":            material.ID,
+		"durationSec":           material.DurationSec,
+		"viewers":               len(activities),
+		"completedViewers":      completed,
+		"averageWatchedPercent": averagePercent,
+		"totalWatchSeconds":     math.Round(totalSeconds),
+		"rejectedHeartbeats":    rejected,
+		"retention":             retention,
 	})
 }
 
 type RecordQuizAttemptRequest struct {
-	QuizID  string            `json:"quizId" binding:"required"`
-	Answers map[string]string `json:"answers" binding:"required"`
+	QuizID  string                 `json:"quizId" binding:"required"`
+	Answers map[string]interface{} `json:"answers" binding:"required"`
 }
 
 func (h *AnalyticsHandler) RecordQuizAttempt(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	var req RecordQuizAttemptRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	quizID, err := uuid.Parse(req.QuizID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		respondError(c, apperror.InvalidField("quizId", "Invalid quiz ID"))
 		return
 	}
 
 	// Get quiz with questions
 	var quiz models.Quiz
-	if err := database.GetDB().Preload("Questions").First(&quiz, quizID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+	if err := database.GetDB().Preload("Questions", byPosition).Preload("StudyPack.Material.Module").First(&quiz, quizID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Quiz not found"))
+		return
+	}
+	if err := requireModuleUnlocked(database.GetDB(), userID, quiz.StudyPack.Material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireCourseOpen(database.GetDB(), quiz.StudyPack.Material.Module.CourseID); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Calculate score (proper JSON comparison)
-	score := 0
+	// Timing can only be enforced through an attempt session
+	if quiz.TimeLimitSec != nil {
+		respondError(c, apperror.Conflict("This quiz is timed; start an attempt with POST /quizzes/:id/attempts"))
+		return
+	}
+	// Keep only answers to questions in this quiz
 	answersMap := make(map[string]interface{})
-	
 	for _, question := range quiz.Questions {
 		questionIDStr := question.ID.String()
 		if userAnswer, ok := req.Answers[questionIDStr]; ok {
 			answersMap[questionIDStr] = userAnswer
-			
-			// Parse answer key (could be string or array for MCQ)
-			var answerKey interface{}
-			json.Unmarshal([]byte(question.AnswerKey), &answerKey)
-			
-			// Compare answers
-			if compareAnswers(userAnswer, answerKey) {
-				score++
-			}
 		}
 	}
-
-	percentage := 0
-	if len(quiz.Questions) > 0 {
-		percentage = int(float64(score) / float64(len(quiz.Questions)) * 100)
+	if len(answersMap) == 0 {
+		respondError(c, apperror.InvalidField("answers", "Answer at least one question of this quiz"))
+		return
 	}
+	report := grading.GradeQuiz(quiz.Questions, answersMap)
+	resultsJSON, _ := json.Marshal(report.Questions)
+	results := string(resultsJSON)
 
 	// Marshal answers to JSON
 	answersJSON, _ := json.Marshal(answersMap)
+	questionOrder := make([]string, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		questionOrder[i] = question.ID.String()
+	}
+	orderJSON, _ := json.Marshal(questionOrder)
+	order := string(orderJSON)
 
 	// Create quiz attempt
+	now := time.Now()
 	attempt := models.QuizAttempt{
-		QuizID:  quizID,
-		UserID:  userID,
-		Score:   percentage,
-		Answers: string(answersJSON),
+		QuizID:          quizID,
+		UserID:          userID,
+		Score:           report.Percentage(),
+		Answers:         string(answersJSON),
+		Status:          "SUBMITTED",
+		QuizVersion:     quiz.Version,
+		QuestionOrder:   &order,
+		SubmittedAt:     &now,
+		PointsEarned:    report.Earned,
+		PointsPossible:  report.Possible,
+		QuestionResults: &results,
+	}
+
+	// Get course from quiz
+	var studyPack models.StudyPack
+	if err := database.GetDB().Preload("Material.Module.Course").First(&studyPack, quiz.StudyPackID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Study pack not found"))
+		return
+	}
+	if studyPack.Material.Module.Course.ID == uuid.Nil {
+		respondError(c, apperror.NotFound("Quiz not found"))
+		return
 	}
 
 	// Record progress event
 	progressEvent := models.ProgressEvent{
 		UserID:    userID,
-		CourseID:  "", // Will be set from quiz
+		CourseID:  studyPack.Material.Module.Course.ID.String(),
 		EventType: "QUIZ_ATTEMPT",
 		Payload:   string(answersJSON),
 	}
 
-	// Get course from quiz
-	var studyPack models.StudyPack
-	if err := database.GetDB().Preload("Material.Module.Course").First(&studyPack, quiz.StudyPackID).Error; err == nil {
-		if studyPack.Material.Module.Course.ID != uuid.Nil {
-			progressEvent.CourseID = studyPack.Material.Module.Course.ID.String()
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Serialize with answer reveals, as StartAttempt does
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Quiz{}, "id = ?", quiz.ID).Error; err != nil {
+			return err
 		}
-	}
-	database.GetDB().Create(&progressEvent)
-
-	if err := database.GetDB().Create(&attempt).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record attempt"})
+		if err := requireAnswersHidden(tx, quiz.ID, userID); err != nil {
+			return err
+		}
+		if quiz.MaxAttempts != nil {
+			var used int64
+			if err := tx.Model(&models.QuizAttempt{}).Where("quiz_id = ? AND user_id = ?", quizID, userID).Count(&used).Error; err != nil {
+				return err
+			}
+			if used >= int64(*quiz.MaxAttempts) {
+				return apperror.Conflict("You have used all attempts for this quiz")
+			}
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Create(&progressEvent).Error
+	}); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, attempt)
-}
-
-func compareAnswers(userAnswer interface{}, correctAnswer interface{}) bool {
-	// Simple string comparison for MVP
-	userStr := ""
-	correctStr := ""
-	
-	if str, ok := userAnswer.(string); ok {
-		userStr = str
-	}
-	if str, ok := correctAnswer.(string); ok {
-		correctStr = str
-	}
-	
-	// Remove quotes if present
-	if len(correctStr) > 0 && correctStr[0] == '"' {
-		correctStr = correctStr[1 : len(correctStr)-1]
+	revealed, err := revealQuizAnswers(database.GetDB(), &quiz, quiz.StudyPack.Material.Module.CourseID, userID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to check answer reveal", err))
+		return
 	}
-	
-	return userStr == correctStr
+	c.JSON(http.StatusOK, dto.NewQuizAttempt(attempt, revealed))
 }
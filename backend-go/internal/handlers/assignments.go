@@ -1,8 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/lateness"
 	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/peerreview"
+	"myway-backend/internal/rubric"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +19,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AssignmentHandler struct{}
@@ -24,6 +35,14 @@ type CreateAssignmentRequest struct {
 	DueAt        time.Time `json:"dueAt" binding:"required"`
 	Points       int       `json:"points" binding:"required"`
 	Instructions string    `json:"instructions" binding:"required"`
+	// LatePolicy defaults to ACCEPT: late work is taken and flagged.
+	LatePolicy        *string `json:"latePolicy" binding:"omitempty,oneof=REJECT ACCEPT PENALTY"`
+	LatePenaltyPerDay *int    `json:"latePenaltyPerDay" binding:"omitempty,min=0,max=100"`
+	// MaxAttempts limits how many times a student may hand in; unlimited
+	// when omitted.
+	MaxAttempts *int `json:"maxAttempts" binding:"omitempty,min=1"`
+	// CategoryID files the assignment under a gradebook category.
+	CategoryID *string `json:"categoryId"`
 }
 
 func (h *AssignmentHandler) CreateAssignment(c *gin.Context) {
@@ -31,40 +50,51 @@ func (h *AssignmentHandler) CreateAssignment(c *gin.Context) {
 
 	var req CreateAssignmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Instructions) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and instructions are required"})
+		appErr := apperror.Validation("Title and instructions are required")
+		if strings.TrimSpace(req.Title) == "" {
+			appErr.WithField("title", "is required")
+		}
+		if strings.TrimSpace(req.Instructions) == "" {
+			appErr.WithField("instructions", "is required")
+		}
+		respondError(c, appErr)
 		return
 	}
 
 	if req.Points <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Points must be greater than 0"})
+		respondError(c, apperror.InvalidField("points", "Points must be greater than 0"))
 		return
 	}
 
 	courseID, err := uuid.Parse(req.CourseID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
 	var course models.Course
 	if err := database.GetDB().First(&course, courseID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		respondError(c, apperror.FromDB(err, "Course not found"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, course.OrgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
 		return
 	}
 
 	if membership.Role != "TEACHER" && membership.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only teachers or organizers can create assignments"})
+		respondError(c, apperror.Forbidden("Only teachers or organizers can create assignments"))
+		return
+	}
+	if err := requireCourseOpen(database.GetDB(), course.ID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -75,20 +105,36 @@ func (h *AssignmentHandler) CreateAssignment(c *gin.Context) {
 		Points:       req.Points,
 		Instructions: req.Instructions,
 		Status:       "ACTIVE",
+		LatePolicy:   lateness.Accept,
+		MaxAttempts:  req.MaxAttempts,
+	}
+	if req.LatePolicy != nil {
+		assignment.LatePolicy = *req.LatePolicy
+	}
+	if req.LatePenaltyPerDay != nil {
+		assignment.LatePenaltyPerDay = *req.LatePenaltyPerDay
+	}
+	if err := validateLatePolicy(assignment); err != nil {
+		respondError(c, err)
+		return
+	}
+	if assignment.GradeCategoryID, err = parseGradeCategory(database.GetDB(), course.ID, req.CategoryID); err != nil {
+		respondError(c, err)
+		return
 	}
 
 	if err := database.GetDB().Create(&assignment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create assignment"})
+		respondError(c, apperror.Internal("Failed to create assignment", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, assignment)
+	c.JSON(http.StatusCreated, dto.NewAssignment(assignment))
 }
 
 func (h *AssignmentHandler) GetAssignmentsByCourse(c *gin.Context) {
 	courseID, err := uuid.Parse(c.Param("courseId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
@@ -96,28 +142,47 @@ func (h *AssignmentHandler) GetAssignmentsByCourse(c *gin.Context) {
 
 	var course models.Course
 	if err := database.GetDB().First(&course, courseID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		respondError(c, apperror.FromDB(err, "Course not found"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, course.OrgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
+		return
+	}
+	if err := requireCourseVisible(userID, course); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	var assignments []models.Assignment
-	if err := database.GetDB().
-		Where("course_id = ? AND status = ?", courseID, "ACTIVE").
-		Order("due_at ASC").
-		Find(&assignments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assignments"})
+	query, err := pagination.Parse(c, assignmentListSpec)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(query.Status) == 0 {
+		query.Status = []string{"ACTIVE"}
+	}
+
+	page, err := query.Find(database.GetDB().Where("course_id = ?", courseID), &models.Assignment{})
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch assignments", err))
 		return
 	}
+	assignments := page.Items
+
+	assignmentIDs := make([]uuid.UUID, len(assignments))
+	for i, a := range assignments {
+		assignmentIDs[i] = a.ID
+	}
 
 	// Get user's submissions to determine status
 	var submissions []models.Submission
-	database.GetDB().Where("user_id = ?", userID).Find(&submissions)
+	if err := database.GetDB().Where("user_id = ? AND assignment_id IN ?", userID, assignmentIDs).Find(&submissions).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch submissions", err))
+		return
+	}
 	submissionMap := make(map[uuid.UUID]models.Submission)
 	for _, sub := range submissions {
 		submissionMap[sub.AssignmentID] = sub
@@ -125,6 +190,12 @@ func (h *AssignmentHandler) GetAssignmentsByCourse(c *gin.Context) {
 
 	isTeacherView := membership.Role == "TEACHER" || membership.Role == "ORGANIZER"
 
+	extensions, err := loadExtensions(database.GetDB(), userID, assignmentIDs)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch extensions", err))
+		return
+	}
+
 	// Build response with status
 	result := make([]gin.H, len(assignments))
 	for i, assignment := range assignments {
@@ -143,37 +214,61 @@ func (h *AssignmentHandler) GetAssignmentsByCourse(c *gin.Context) {
 		}
 
 		result[i] = gin.H{
-			"id":           assignment.ID,
-			"title":        assignment.Title,
-			"dueAt":        assignment.DueAt,
-			"points":       assignment.Points,
-			"instructions": assignment.Instructions,
-			"status":       status,
-			"submission":   nil,
+			"id":                assignment.ID,
+			"title":             assignment.Title,
+			"dueAt":             assignment.DueAt,
+			"extendedDueAt":     nil,
+			"points":            assignment.Points,
+			"instructions":      assignment.Instructions,
+			"status":            status,
+			"latePolicy":        assignment.LatePolicy,
+			"latePenaltyPerDay": assignment.LatePenaltyPerDay,
+			"maxAttempts":       assignment.MaxAttempts,
+			"rubricId":          assignment.RubricID,
+			"gradeCategoryId":   assignment.GradeCategoryID,
+			"peerReviewCount":   assignment.PeerReviewCount,
+			"peerReviewDueAt":   assignment.PeerReviewDueAt,
+			"peerReviewWeight":  assignment.PeerReviewWeight,
+			"submission":        nil,
+		}
+		if extension, ok := extensions[assignment.ID]; ok {
+			result[i]["extendedDueAt"] = extension.DueAt
 		}
 
 		if !isTeacherView {
 			if sub, exists := submissionMap[assignment.ID]; exists {
-				result[i]["submission"] = sub
+				result[i]["submission"] = dto.NewSubmission(sub)
 			}
 		} else {
 			var submissionCount int64
-			database.GetDB().Model(&models.Submission{}).Where("assignment_id = ?", assignment.ID).Count(&submissionCount)
+			if err := database.GetDB().Model(&models.Submission{}).Where("assignment_id = ?", assignment.ID).Count(&submissionCount).Error; err != nil {
+				respondError(c, apperror.Internal("Failed to count submissions", err))
+				return
+			}
 			result[i]["submissionCount"] = submissionCount
 		}
 
-		if sub, exists := submissionMap[assignment.ID]; exists && !isTeacherView {
-			result[i]["submission"] = sub
-		}
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, pagination.Page[gin.H]{Items: result, Total: page.Total, NextCursor: page.NextCursor})
+}
+
+var assignmentListSpec = pagination.Spec[models.Assignment]{
+	Sorts: map[string]pagination.SortField[models.Assignment]{
+		"dueAt":  {Column: "due_at", Kind: pagination.KindTime, Value: func(m models.Assignment) interface{} { return m.DueAt }},
+		"title":  {Column: "title", Value: func(m models.Assignment) interface{} { return m.Title }},
+		"points": {Column: "points", Kind: pagination.KindInt, Value: func(m models.Assignment) interface{} { return m.Points }},
+	},
+	DefaultSort:  "dueAt",
+	ID:           func(m models.Assignment) uuid.UUID { return m.ID },
+	StatusColumn: "status",
+	DateColumn:   "due_at",
 }
 
 func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
 	assignmentID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid assignment ID"))
 		return
 	}
 
@@ -181,31 +276,56 @@ func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
 
 	var assignment models.Assignment
 	if err := database.GetDB().
-		Preload("Submissions").
+		Preload("Submissions.Attempts", byNumber).
 		Preload("Course").
 		First(&assignment, assignmentID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		respondError(c, apperror.FromDB(err, "Assignment not found"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, assignment.Course.OrgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
+		return
+	}
+
+	if err := requireCourseVisible(userID, assignment.Course); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	isTeacherView := membership.Role == "TEACHER" || membership.Role == "ORGANIZER"
 
 	response := gin.H{
-		"id":           assignment.ID,
-		"courseId":     assignment.CourseID,
-		"title":        assignment.Title,
-		"dueAt":        assignment.DueAt,
-		"points":       assignment.Points,
-		"instructions": assignment.Instructions,
-		"status":       assignment.Status,
-		"submission":   nil,
-		"submissions":  []gin.H{},
+		"id":                assignment.ID,
+		"courseId":          assignment.CourseID,
+		"title":             assignment.Title,
+		"dueAt":             assignment.DueAt,
+		"extendedDueAt":     nil,
+		"points":            assignment.Points,
+		"instructions":      assignment.Instructions,
+		"status":            assignment.Status,
+		"latePolicy":        assignment.LatePolicy,
+		"latePenaltyPerDay": assignment.LatePenaltyPerDay,
+		"maxAttempts":       assignment.MaxAttempts,
+		"rubricId":          assignment.RubricID,
+		"gradeCategoryId":   assignment.GradeCategoryID,
+		"peerReviewCount":   assignment.PeerReviewCount,
+		"peerReviewDueAt":   assignment.PeerReviewDueAt,
+		"peerReviewWeight":  assignment.PeerReviewWeight,
+		"rubric":            nil,
+		"submission":        nil,
+		"submissions":       []gin.H{},
+	}
+
+	// Students see the rubric they will be graded with.
+	if assignment.RubricID != nil {
+		rubricRow, current, err := loadRubric(database.GetDB(), *assignment.RubricID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		response["rubric"] = dto.NewRubric(*rubricRow, *current)
 	}
 
 	if isTeacherView {
@@ -217,7 +337,10 @@ func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
 		userNameMap := make(map[uuid.UUID]string)
 		if len(userIDs) > 0 {
 			var users []models.User
-			database.GetDB().Where("id IN ?", userIDs).Find(&users)
+			if err := database.GetDB().Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+				respondError(c, apperror.Internal("Failed to fetch students", err))
+				return
+			}
 			for _, u := range users {
 				userNameMap[u.ID] = u.Name
 			}
@@ -226,14 +349,18 @@ func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
 		safeSubmissions := make([]gin.H, 0, len(assignment.Submissions))
 		for _, s := range assignment.Submissions {
 			safeSubmissions = append(safeSubmissions, gin.H{
-				"id":          s.ID,
-				"userId":      s.UserID,
-				"studentName": userNameMap[s.UserID],
-				"status":      s.Status,
-				"fileUrl":     s.FileURL,
-				"submittedAt": s.SubmittedAt,
-				"grade":       s.Grade,
-				"feedback":    s.Feedback,
+				"id":                 s.ID,
+				"userId":             s.UserID,
+				"studentName":        userNameMap[s.UserID],
+				"status":             s.Status,
+				"fileUrl":            s.FileURL,
+				"submittedAt":        s.SubmittedAt,
+				"grade":              s.Grade,
+				"feedback":           s.Feedback,
+				"isLate":             s.IsLate,
+				"latePenaltyPercent": s.LatePenaltyPercent,
+				"attemptCount":       len(s.Attempts),
+				"resubmitComment":    s.ResubmitComment,
 			})
 		}
 
@@ -242,15 +369,28 @@ func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
 		return
 	}
 
+	extensions, err := loadExtensions(database.GetDB(), userID, []uuid.UUID{assignment.ID})
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch extension", err))
+		return
+	}
+	if extension, ok := extensions[assignment.ID]; ok {
+		response["extendedDueAt"] = extension.DueAt
+	}
+
 	for _, s := range assignment.Submissions {
 		if s.UserID == userID {
 			response["submission"] = gin.H{
-				"id":          s.ID,
-				"status":      s.Status,
-				"fileUrl":     s.FileURL,
-				"submittedAt": s.SubmittedAt,
-				"grade":       s.Grade,
-				"feedback":    s.Feedback,
+				"id":                 s.ID,
+				"status":             s.Status,
+				"fileUrl":            s.FileURL,
+				"submittedAt":        s.SubmittedAt,
+				"grade":              s.Grade,
+				"feedback":           s.Feedback,
+				"isLate":             s.IsLate,
+				"latePenaltyPercent": s.LatePenaltyPercent,
+				"resubmitComment":    s.ResubmitComment,
+				"attempts":           dto.NewSubmissionAttempts(s.Attempts),
 			}
 			break
 		}
@@ -261,12 +401,16 @@ func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
 
 type SubmitAssignmentRequest struct {
 	FileURL *string `json:"fileUrl"`
+	// Text is a written answer, or the text the student pastes from their
+	// document. It is not checked against FileURL. AI grading suggestions
+	// read it and label it as student-provided.
+	Text *string `json:"text"`
 }
 
 func (h *AssignmentHandler) SubmitAssignment(c *gin.Context) {
 	assignmentID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid assignment ID"))
 		return
 	}
 
@@ -274,125 +418,462 @@ func (h *AssignmentHandler) SubmitAssignment(c *gin.Context) {
 
 	var req SubmitAssignmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	// Check if assignment exists
 	var assignment models.Assignment
-	if err := database.GetDB().First(&assignment, assignmentID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+	if err := database.GetDB().Preload("Course").First(&assignment, assignmentID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Assignment not found"))
 		return
 	}
-
-	// Check if submission already exists
-	var existingSubmission models.Submission
-	if err := database.GetDB().Where("assignment_id = ? AND user_id = ?", assignmentID, userID).First(&existingSubmission).Error; err == nil {
-		// Update existing submission
-		existingSubmission.Status = "SUBMITTED"
-		existingSubmission.SubmittedAt = time.Now()
-		if req.FileURL != nil {
-			existingSubmission.FileURL = req.FileURL
-		}
-		if err := database.GetDB().Save(&existingSubmission).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update submission"})
-			return
-		}
-		c.JSON(http.StatusOK, existingSubmission)
+	if err := requireCourseVisible(userID, assignment.Course); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireEnrolledStudent(database.GetDB(), assignment.CourseID, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireCourseOpen(database.GetDB(), assignment.CourseID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if assignment.Status == "ARCHIVED" {
+		respondError(c, apperror.Conflict("This assignment is archived"))
 		return
 	}
 
-	// Create new submission
-	submission := models.Submission{
-		AssignmentID: assignmentID,
-		UserID:       userID,
-		Status:       "SUBMITTED",
-		SubmittedAt:  time.Now(),
-		FileURL:      req.FileURL,
+	now := time.Now()
+	late, err := evaluateLateness(database.GetDB(), assignment, userID, now)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to check due date", err))
+		return
+	}
+	if late.Rejected {
+		respondError(c, apperror.Unprocessable("The due date has passed and this assignment does not accept late work"))
+		return
 	}
 
-	if err := database.GetDB().Create(&submission).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submission"})
+	var submission models.Submission
+	status := http.StatusOK
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Serialize hand-ins on this assignment so attempts are numbered
+		// and counted once.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Assignment{}, "id = ?", assignment.ID).Error; err != nil {
+			return err
+		}
+		err := tx.Where("assignment_id = ? AND user_id = ?", assignmentID, userID).First(&submission).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			submission = models.Submission{AssignmentID: assignmentID, UserID: userID, Status: "SUBMITTED", SubmittedAt: now}
+			if err := tx.Create(&submission).Error; err != nil {
+				return err
+			}
+			status = http.StatusCreated
+		} else if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.SubmissionAttempt{}).Where("submission_id = ?", submission.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		// A resubmission request always allows one more attempt.
+		if assignment.MaxAttempts != nil && int(count) >= *assignment.MaxAttempts && submission.Status != "RE_SUBMIT_REQUESTED" {
+			return apperror.Conflict(fmt.Sprintf("All %d attempts have been used", *assignment.MaxAttempts))
+		}
+
+		attempt := models.SubmissionAttempt{
+			SubmissionID:       submission.ID,
+			Number:             int(count) + 1,
+			FileURL:            req.FileURL,
+			Text:               emptyToNil(req.Text),
+			SubmittedAt:        now,
+			IsLate:             late.Late,
+			LatePenaltyPercent: late.PenaltyPercent,
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&submission).Updates(map[string]interface{}{
+			"status":                "SUBMITTED",
+			"file_url":              attempt.FileURL,
+			"submitted_at":          attempt.SubmittedAt,
+			"is_late":               attempt.IsLate,
+			"late_penalty_percent":  attempt.LatePenaltyPercent,
+			"resubmit_comment":      nil,
+			"resubmit_requested_at": nil,
+		}).Error
+	}); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, submission)
+	if err := database.GetDB().Preload("Attempts", byNumber).First(&submission, submission.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load submission", err))
+		return
+	}
+	c.JSON(status, dto.NewSubmission(submission))
 }
 
 type GradeSubmissionRequest struct {
-	Score    int    `json:"score" binding:"required"`
-	Feedback string `json:"feedback"`
+	// Score grades assignments without a rubric; Criteria, one level per
+	// rubric criterion, grades those with one.
+	Score    *int               `json:"score" binding:"omitempty,min=0"`
+	Criteria []rubric.Selection `json:"criteria"`
+	Feedback string             `json:"feedback"`
+	// AttemptID defaults to the latest attempt.
+	AttemptID *uuid.UUID `json:"attemptId"`
+	// SuggestionID names the AI grading suggestion this grade is based on.
+	SuggestionID *uuid.UUID `json:"suggestionId"`
 }
 
 func (h *AssignmentHandler) GradeSubmission(c *gin.Context) {
 	graderID := c.MustGet("userID").(uuid.UUID)
 	submissionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid submission ID"))
 		return
 	}
 
 	var req GradeSubmissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if req.Score < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Score must be >= 0"})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	var submission models.Submission
 	if err := database.GetDB().Preload("Assignment.Course").First(&submission, submissionID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		respondError(c, apperror.FromDB(err, "Submission not found"))
 		return
 	}
 
 	course := submission.Assignment.Course
 	if course.ID == uuid.Nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve submission course"})
+		respondError(c, apperror.Internal("Failed to resolve submission course", nil))
 		return
 	}
 
 	// RBAC: only TEACHER or ORGANIZER in course organization can grade
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", graderID, course.OrgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
 		return
 	}
 	if membership.Role != "TEACHER" && membership.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only teachers or organizers can grade submissions"})
+		respondError(c, apperror.Forbidden("Only teachers or organizers can grade submissions"))
 		return
 	}
 
-	if req.Score > submission.Assignment.Points {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Score cannot exceed assignment max points"})
+	assignment := submission.Assignment
+	if assignment.RubricID != nil {
+		if req.Score != nil {
+			respondError(c, apperror.InvalidField("score", "This assignment is graded with a rubric; select a level per criterion instead"))
+			return
+		}
+		if len(req.Criteria) == 0 {
+			respondError(c, apperror.InvalidField("criteria", "Select a level for each rubric criterion"))
+			return
+		}
+	} else {
+		if len(req.Criteria) > 0 {
+			respondError(c, apperror.InvalidField("criteria", "This assignment has no rubric"))
+			return
+		}
+		if req.Score == nil {
+			respondError(c, apperror.InvalidField("score", "Score is required"))
+			return
+		}
+		if *req.Score > assignment.Points {
+			respondError(c, apperror.InvalidField("score", "Score cannot exceed assignment max points"))
+			return
+		}
+	}
+
+	var attempt models.SubmissionAttempt
+	var grade *rubric.Grade
+	var score int
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("submission_id = ?", submission.ID)
+		if req.AttemptID != nil {
+			query = query.Where("id = ?", *req.AttemptID)
+		} else {
+			query = query.Order("number DESC")
+		}
+		if err := query.First(&attempt).Error; err != nil {
+			return apperror.FromDB(err, "Attempt not found")
+		}
+
+		rawScore := 0
+		attempt.RubricVersionID = nil
+		attempt.RubricGrade = nil
+		if assignment.RubricID != nil {
+			// Shares the lock UpdateRubric takes, so the version graded
+			// with cannot be edited in place underneath us.
+			if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id").First(&models.Rubric{}, "id = ?", *assignment.RubricID).Error; err != nil {
+				return err
+			}
+			_, current, err := loadRubric(tx, *assignment.RubricID)
+			if err != nil {
+				return err
+			}
+			graded, err := rubric.Score(dto.DecodeRubricCriteria(current.Criteria), req.Criteria, assignment.Points)
+			if err != nil {
+				return apperror.InvalidField("criteria", err.Error())
+			}
+			encoded, err := json.Marshal(graded)
+			if err != nil {
+				return err
+			}
+			encodedGrade := string(encoded)
+			grade = &graded
+			rawScore = graded.Score
+			attempt.RubricVersionID = &current.ID
+			attempt.RubricGrade = &encodedGrade
+		} else {
+			rawScore = *req.Score
+		}
+
+		// Peer-reviewed work blends the teacher's score with the reviews.
+		attempt.TeacherScore = nil
+		attempt.PeerScore = nil
+		if assignment.PeerReviewsAssignedAt != nil {
+			if assignment.PeerReviewWeight > 0 && assignment.PeerReviewDueAt != nil && time.Now().Before(*assignment.PeerReviewDueAt) {
+				return apperror.Conflict(fmt.Sprintf("Peer reviews are open until %s; grade once they close", assignment.PeerReviewDueAt.Format(time.RFC3339)))
+			}
+			peer, err := peerScore(tx, attempt.ID)
+			if err != nil {
+				return err
+			}
+			teacher := rawScore
+			attempt.TeacherScore = &teacher
+			attempt.PeerScore = peer
+			rawScore = peerreview.Blend(teacher, peer, assignment.PeerReviewWeight)
+		}
+
+		// Late penalties were fixed when the attempt was handed in.
+		score = lateness.Apply(rawScore, attempt.LatePenaltyPercent)
+		now := time.Now()
+		attempt.RawScore = &rawScore
+		attempt.Score = &score
+		attempt.Feedback = nil
+		if feedback := strings.TrimSpace(req.Feedback); feedback != "" {
+			attempt.Feedback = &feedback
+		}
+		attempt.GradedBy = &graderID
+		attempt.GradedAt = &now
+		attempt.GradingSuggestionID = req.SuggestionID
+		if req.SuggestionID != nil {
+			if err := reviewGradingSuggestion(tx, *req.SuggestionID, attempt, req, graderID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&attempt).Updates(map[string]interface{}{
+			"raw_score":             attempt.RawScore,
+			"teacher_score":         attempt.TeacherScore,
+			"peer_score":            attempt.PeerScore,
+			"score":                 attempt.Score,
+			"feedback":              attempt.Feedback,
+			"graded_by":             attempt.GradedBy,
+			"graded_at":             attempt.GradedAt,
+			"rubric_version_id":     attempt.RubricVersionID,
+			"rubric_grade":          attempt.RubricGrade,
+			"grading_suggestion_id": attempt.GradingSuggestionID,
+		}).Error; err != nil {
+			return err
+		}
+
+		return syncSubmissionGrade(tx, &submission)
+	}); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	gradeValue := strconv.Itoa(req.Score)
-	submission.Status = "GRADED"
+	c.JSON(http.StatusOK, gin.H{
+		"id":                  submission.ID,
+		"assignmentId":        submission.AssignmentID,
+		"userId":              submission.UserID,
+		"attemptId":           attempt.ID,
+		"attemptNumber":       attempt.Number,
+		"status":              submission.Status,
+		"score":               score,
+		"rawScore":            *attempt.RawScore,
+		"teacherScore":        attempt.TeacherScore,
+		"peerScore":           attempt.PeerScore,
+		"isLate":              attempt.IsLate,
+		"latePenaltyPercent":  attempt.LatePenaltyPercent,
+		"maxPoints":           assignment.Points,
+		"feedback":            attempt.Feedback,
+		"rubricVersionId":     attempt.RubricVersionID,
+		"rubricGrade":         grade,
+		"gradingSuggestionId": attempt.GradingSuggestionID,
+	})
+}
+
+// syncSubmissionGrade gives the submission the grade and feedback of its
+// latest graded attempt, so re-grading an older attempt does not replace
+// the grade of a newer one. The submission counts as GRADED once its
+// latest attempt is.
+func syncSubmissionGrade(tx *gorm.DB, submission *models.Submission) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(submission, "id = ?", submission.ID).Error; err != nil {
+		return err
+	}
+	var latest, graded models.SubmissionAttempt
+	if err := tx.Where("submission_id = ?", submission.ID).Order("number DESC").First(&latest).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("submission_id = ? AND graded_at IS NOT NULL", submission.ID).Order("number DESC").First(&graded).Error; err != nil {
+		return err
+	}
+
+	gradeValue := strconv.Itoa(*graded.Score)
 	submission.Grade = &gradeValue
-	if strings.TrimSpace(req.Feedback) == "" {
-		submission.Feedback = nil
-	} else {
-		feedback := strings.TrimSpace(req.Feedback)
-		submission.Feedback = &feedback
+	submission.Feedback = graded.Feedback
+	if latest.ID == graded.ID {
+		submission.Status = "GRADED"
+	}
+	return tx.Model(submission).Updates(map[string]interface{}{
+		"status":   submission.Status,
+		"grade":    submission.Grade,
+		"feedback": submission.Feedback,
+	}).Error
+}
+
+type UpdateAssignmentRequest struct {
+	Title             *string    `json:"title" binding:"omitempty,min=1"`
+	DueAt             *time.Time `json:"dueAt"`
+	Points            *int       `json:"points" binding:"omitempty,min=1"`
+	Instructions      *string    `json:"instructions" binding:"omitempty,min=1"`
+	LatePolicy        *string    `json:"latePolicy" binding:"omitempty,oneof=REJECT ACCEPT PENALTY"`
+	LatePenaltyPerDay *int       `json:"latePenaltyPerDay" binding:"omitempty,min=0,max=100"`
+	// MaxAttempts of 0 removes the limit.
+	MaxAttempts *int `json:"maxAttempts" binding:"omitempty,min=0"`
+	// CategoryID of "" takes the assignment out of its gradebook category.
+	CategoryID *string `json:"categoryId"`
+}
+
+// UpdateAssignment changes only the fields present in the request.
+// Submissions already handed in keep the lateness they were given.
+func (h *AssignmentHandler) UpdateAssignment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
 	}
 
-	if err := database.GetDB().Save(&submission).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grade submission"})
+	var req UpdateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":           submission.ID,
-		"assignmentId": submission.AssignmentID,
-		"userId":       submission.UserID,
-		"status":       submission.Status,
-		"score":        req.Score,
-		"maxPoints":    submission.Assignment.Points,
-		"feedback":     submission.Feedback,
-	})
+	if req.Title != nil {
+		assignment.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.DueAt != nil {
+		assignment.DueAt = *req.DueAt
+	}
+	if req.Points != nil {
+		assignment.Points = *req.Points
+	}
+	if req.Instructions != nil {
+		assignment.Instructions = strings.TrimSpace(*req.Instructions)
+	}
+	if req.LatePolicy != nil {
+		assignment.LatePolicy = *req.LatePolicy
+	}
+	if req.LatePenaltyPerDay != nil {
+		assignment.LatePenaltyPerDay = *req.LatePenaltyPerDay
+	}
+	if req.MaxAttempts != nil {
+		assignment.MaxAttempts = req.MaxAttempts
+		if *req.MaxAttempts == 0 {
+			assignment.MaxAttempts = nil
+		}
+	}
+	if assignment.Title == "" || assignment.Instructions == "" {
+		respondError(c, apperror.Validation("Title and instructions are required"))
+		return
+	}
+	if err := validateLatePolicy(*assignment); err != nil {
+		respondError(c, err)
+		return
+	}
+	if assignment.PeerReviewDueAt != nil && !assignment.PeerReviewDueAt.After(assignment.DueAt) {
+		respondError(c, apperror.InvalidField("dueAt", "The assignment must be due before its peer review window closes"))
+		return
+	}
+	if req.CategoryID != nil {
+		categoryID, err := parseGradeCategory(database.GetDB(), assignment.CourseID, req.CategoryID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		assignment.GradeCategoryID = categoryID
+	}
+
+	if err := database.GetDB().Model(assignment).Updates(map[string]interface{}{
+		"title":                assignment.Title,
+		"due_at":               assignment.DueAt,
+		"points":               assignment.Points,
+		"instructions":         assignment.Instructions,
+		"late_policy":          assignment.LatePolicy,
+		"late_penalty_per_day": assignment.LatePenaltyPerDay,
+		"max_attempts":         assignment.MaxAttempts,
+		"grade_category_id":    assignment.GradeCategoryID,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update assignment", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAssignment(*assignment))
+}
+
+// ArchiveAssignment hides an assignment from the default listing and stops
+// it taking submissions. Existing submissions and grades are kept.
+func (h *AssignmentHandler) ArchiveAssignment(c *gin.Context) {
+	h.setAssignmentStatus(c, "ARCHIVED")
+}
+
+// UnarchiveAssignment makes an archived assignment active again.
+func (h *AssignmentHandler) UnarchiveAssignment(c *gin.Context) {
+	h.setAssignmentStatus(c, "ACTIVE")
+}
+
+func (h *AssignmentHandler) setAssignmentStatus(c *gin.Context, status string) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Model(assignment).Update("status", status).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update assignment", err))
+		return
+	}
+	assignment.Status = status
+
+	c.JSON(http.StatusOK, dto.NewAssignment(*assignment))
+}
+
+// loadInstructorAssignment loads the assignment named by :id if the caller
+// teaches or organizes its course's organization, responding with an error
+// otherwise.
+func loadInstructorAssignment(c *gin.Context, userID uuid.UUID) (*models.Assignment, bool) {
+	assignmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid assignment ID"))
+		return nil, false
+	}
+	var assignment models.Assignment
+	if err := database.GetDB().Preload("Course").First(&assignment, assignmentID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Assignment not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return &assignment, true
 }
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
 	jwtutil "myway-backend/pkg/jwt"
 	"net/http"
@@ -10,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
@@ -35,21 +39,24 @@ func NewAuthHandler(jwtSecret string) *AuthHandler {
 func (h *AuthHandler) SignUp(c *gin.Context) {
 	var req SignUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	// Check if user exists
 	var existingUser models.User
 	if err := database.GetDB().Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		respondError(c, apperror.Conflict("User already exists").WithField("email", "is already registered"))
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		respondError(c, apperror.Internal("Failed to check existing user", err))
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		respondError(c, apperror.Internal("Failed to hash password", err))
 		return
 	}
 
@@ -66,20 +73,20 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 	}
 
 	if err := database.GetDB().Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		respondError(c, apperror.Internal("Failed to create user", err))
 		return
 	}
 
 	// Generate tokens
 	accessToken, err := jwtutil.GenerateToken(user.ID, user.Email, h.JWTSecret)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, apperror.Internal("Failed to generate token", err))
 		return
 	}
 
 	refreshToken, err := jwtutil.GenerateRefreshToken(user.ID, user.Email, h.JWTSecret)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		respondError(c, apperror.Internal("Failed to generate refresh token", err))
 		return
 	}
 
@@ -90,7 +97,7 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
 	}
 	if err := database.GetDB().Create(&refreshTokenModel).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store refresh token"})
+		respondError(c, apperror.Internal("Failed to store refresh token", err))
 		return
 	}
 
@@ -109,38 +116,41 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 func (h *AuthHandler) SignIn(c *gin.Context) {
 	var req SignInRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	// Find user
 	var user models.User
 	if err := database.GetDB().Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		respondError(c, apperror.Unauthorized("Invalid credentials"))
 		return
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		respondError(c, apperror.Unauthorized("Invalid credentials"))
 		return
 	}
 
 	// Update last login
 	now := time.Now()
 	user.LastLogin = &now
-	database.GetDB().Save(&user)
+	if err := database.GetDB().Model(&user).Update("last_login", now).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update last login", err))
+		return
+	}
 
 	// Generate tokens
 	accessToken, err := jwtutil.GenerateToken(user.ID, user.Email, h.JWTSecret)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, apperror.Internal("Failed to generate token", err))
 		return
 	}
 
 	refreshToken, err := jwtutil.GenerateRefreshToken(user.ID, user.Email, h.JWTSecret)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		respondError(c, apperror.Internal("Failed to generate refresh token", err))
 		return
 	}
 
@@ -151,7 +161,7 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
 	}
 	if err := database.GetDB().Create(&refreshTokenModel).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store refresh token"})
+		respondError(c, apperror.Internal("Failed to store refresh token", err))
 		return
 	}
 
@@ -172,7 +182,7 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 
 	var user models.User
 	if err := database.GetDB().Preload("Memberships.Organization").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		respondError(c, apperror.FromDB(err, "User not found"))
 		return
 	}
 
@@ -181,7 +191,7 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 		"email":       user.Email,
 		"name":        user.Name,
 		"role":        user.Role,
-		"memberships": user.Memberships,
+		"memberships": dto.NewMemberships(user.Memberships),
 	})
 }
 
@@ -192,35 +202,35 @@ type RefreshTokenRequest struct {
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	// Validate refresh token
 	claims, err := jwtutil.ValidateToken(req.RefreshToken, h.JWTSecret)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		respondError(c, apperror.Unauthorized("Invalid refresh token"))
 		return
 	}
 
 	// Check if token exists in database
 	var refreshToken models.RefreshToken
 	if err := database.GetDB().Where("token = ? AND user_id = ? AND expires_at > ?", req.RefreshToken, claims.UserID, time.Now()).First(&refreshToken).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token not found or expired"})
+		respondError(c, apperror.Unauthorized("Refresh token not found or expired"))
 		return
 	}
 
 	// Get user
 	var user models.User
 	if err := database.GetDB().First(&user, claims.UserID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		respondError(c, apperror.FromDB(err, "User not found"))
 		return
 	}
 
 	// Generate new access token
 	accessToken, err := jwtutil.GenerateToken(user.ID, user.Email, h.JWTSecret)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, apperror.Internal("Failed to generate token", err))
 		return
 	}
 
@@ -236,12 +246,15 @@ type LogoutRequest struct {
 func (h *AuthHandler) Logout(c *gin.Context) {
 	var req LogoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	// Delete refresh token
-	database.GetDB().Where("token = ?", req.RefreshToken).Delete(&models.RefreshToken{})
+	if err := database.GetDB().Where("token = ?", req.RefreshToken).Delete(&models.RefreshToken{}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to revoke refresh token", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
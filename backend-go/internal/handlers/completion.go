@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"myway-backend/internal/progress"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// materialTypes are the material types completion criteria can be set for.
+var materialTypes = []string{"VIDEO", "TEXT", "DOC"}
+
+type userMaterial struct {
+	UserID     uuid.UUID
+	MaterialID uuid.UUID
+}
+
+// courseCompletion holds everything needed to evaluate a course's
+// materials for a set of learners, loaded with one query per source.
+type courseCompletion struct {
+	course     models.Course
+	criteria   map[string]progress.Criteria
+	activity   map[userMaterial]models.MaterialActivity
+	hasQuiz    map[uuid.UUID]bool
+	bestScores map[userMaterial]int
+	flashcards map[uuid.UUID]int
+	mastered   map[userMaterial]int
+}
+
+// loadCourseTree loads a course with its modules in order and their
+// materials.
+func loadCourseTree(db *gorm.DB, courseID uuid.UUID) (models.Course, error) {
+	var course models.Course
+	err := db.
+		Preload("Modules", byOrder).
+		Preload("Modules.Materials", byPosition).
+		First(&course, courseID).Error
+	return course, err
+}
+
+// loadCompletionCriteria returns the organization's criteria for every
+// material type, with defaults for types it has not configured, and which
+// types are configured.
+func loadCompletionCriteria(db *gorm.DB, orgID uuid.UUID) (map[string]progress.Criteria, map[string]bool, error) {
+	var rows []models.CompletionCriteria
+	if err := db.Where("org_id = ?", orgID).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	criteria := make(map[string]progress.Criteria, len(materialTypes))
+	for _, materialType := range materialTypes {
+		criteria[materialType] = progress.DefaultCriteria(materialType)
+	}
+	custom := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		criteria[row.MaterialType] = criteriaOf(row)
+		custom[row.MaterialType] = true
+	}
+	return criteria, custom, nil
+}
+
+func criteriaOf(row models.CompletionCriteria) progress.Criteria {
+	return progress.Criteria{
+		RequireView:        row.RequireView,
+		MinWatchPercent:    row.MinWatchPercent,
+		MinQuizScore:       row.MinQuizScore,
+		MinMasteredPercent: row.MinMasteredPercent,
+	}
+}
+
+// loadCourseCompletion loads the completion inputs for userIDs in a course
+// loaded by loadCourseTree. Only published study packs count.
+func loadCourseCompletion(db *gorm.DB, course models.Course, userIDs []uuid.UUID) (*courseCompletion, error) {
+	criteria, _, err := loadCompletionCriteria(db, course.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	cc := &courseCompletion{
+		course:     course,
+		criteria:   criteria,
+		activity:   map[userMaterial]models.MaterialActivity{},
+		hasQuiz:    map[uuid.UUID]bool{},
+		bestScores: map[userMaterial]int{},
+		flashcards: map[uuid.UUID]int{},
+		mastered:   map[userMaterial]int{},
+	}
+
+	var materialIDs []uuid.UUID
+	for _, module := range course.Modules {
+		for _, material := range module.Materials {
+			materialIDs = append(materialIDs, material.ID)
+		}
+	}
+	if len(materialIDs) == 0 {
+		return cc, nil
+	}
+
+	readyPacks := db.Model(&models.StudyPack{}).Select("id").
+		Where("material_id IN ? AND status = ?", materialIDs, "READY")
+
+	var quizMaterials []uuid.UUID
+	if err := db.Model(&models.QuizQuestion{}).
+		Joins("JOIN quizzes ON quizzes.id = quiz_questions.quiz_id").
+		Joins("JOIN study_packs ON study_packs.id = quizzes.study_pack_id").
+		Where("quizzes.study_pack_id IN (?)", readyPacks).
+		Distinct().Pluck("study_packs.material_id", &quizMaterials).Error; err != nil {
+		return nil, err
+	}
+	for _, materialID := range quizMaterials {
+		cc.hasQuiz[materialID] = true
+	}
+
+	var cardCounts []struct {
+		MaterialID uuid.UUID
+		Count      int
+	}
+	if err := db.Model(&models.Flashcard{}).
+		Joins("JOIN study_packs ON study_packs.id = flashcards.study_pack_id").
+		Where("flashcards.study_pack_id IN (?)", readyPacks).
+		Select("study_packs.material_id, COUNT(*) AS count").
+		Group("study_packs.material_id").
+		Scan(&cardCounts).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range cardCounts {
+		cc.flashcards[row.MaterialID] = row.Count
+	}
+
+	if len(userIDs) == 0 {
+		return cc, nil
+	}
+
+	var activity []models.MaterialActivity
+	if err := db.Where("material_id IN ? AND user_id IN ?", materialIDs, userIDs).Find(&activity).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range activity {
+		cc.activity[userMaterial{row.UserID, row.MaterialID}] = row
+	}
+
+	var scores []struct {
+		UserID     uuid.UUID
+		MaterialID uuid.UUID
+		Best       int
+	}
+	if err := db.Model(&models.QuizAttempt{}).
+		Joins("JOIN quizzes ON quizzes.id = quiz_attempts.quiz_id").
+		Joins("JOIN study_packs ON study_packs.id = quizzes.study_pack_id").
+		Where("quizzes.study_pack_id IN (?)", readyPacks).
+		Where("quiz_attempts.user_id IN ? AND quiz_attempts.status = ?", userIDs, "SUBMITTED").
+		Select("quiz_attempts.user_id, study_packs.material_id, MAX(quiz_attempts.score) AS best").
+		Group("quiz_attempts.user_id, study_packs.material_id").
+		Scan(&scores).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range scores {
+		cc.bestScores[userMaterial{row.UserID, row.MaterialID}] = row.Best
+	}
+
+	var mastered []struct {
+		UserID     uuid.UUID
+		MaterialID uuid.UUID
+		Count      int
+	}
+	if err := db.Model(&models.FlashcardReviewState{}).
+		Joins("JOIN flashcards ON flashcards.id = flashcard_review_states.flashcard_id").
+		Joins("JOIN study_packs ON study_packs.id = flashcards.study_pack_id").
+		Where("flashcards.study_pack_id IN (?)", readyPacks).
+		Where("flashcard_review_states.user_id IN ? AND flashcard_review_states.interval_days >= ?", userIDs, progress.MasteredIntervalDays).
+		Select("flashcard_review_states.user_id, study_packs.material_id, COUNT(*) AS count").
+		Group("flashcard_review_states.user_id, study_packs.material_id").
+		Scan(&mastered).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range mastered {
+		cc.mastered[userMaterial{row.UserID, row.MaterialID}] = row.Count
+	}
+	return cc, nil
+}
+
+// state is what userID has done with a material.
+func (cc *courseCompletion) state(userID uuid.UUID, material models.Material) progress.State {
+	key := userMaterial{userID, material.ID}
+	activity := cc.activity[key]
+	state := progress.State{
+		Viewed:             activity.ViewedAt != nil,
+		WatchedPercent:     activity.WatchedPercent,
+		HasQuiz:            cc.hasQuiz[material.ID],
+		Flashcards:         cc.flashcards[material.ID],
+		MasteredFlashcards: cc.mastered[key],
+	}
+	if best, ok := cc.bestScores[key]; ok {
+		state.BestQuizScore = &best
+	}
+	return state
+}
+
+// evaluate is userID's progress through every module of the course, with
+// the course rollup.
+func (cc *courseCompletion) evaluate(userID uuid.UUID) ([]dto.ModuleProgress, progress.Rollup) {
+	modules := make([]dto.ModuleProgress, 0, len(cc.course.Modules))
+	var all []progress.Result
+	for _, module := range cc.course.Modules {
+		materials := make([]dto.MaterialProgress, 0, len(module.Materials))
+		results := make([]progress.Result, 0, len(module.Materials))
+		for _, material := range module.Materials {
+			state := cc.state(userID, material)
+			result := progress.Evaluate(cc.criteriaFor(material.Type), state)
+			materials = append(materials, dto.NewMaterialProgress(material, state, result))
+			results = append(results, result)
+		}
+		modules = append(modules, dto.NewModuleProgress(module, materials, progress.Summarize(results)))
+		all = append(all, results...)
+	}
+	return modules, progress.Summarize(all)
+}
+
+func (cc *courseCompletion) criteriaFor(materialType string) progress.Criteria {
+	if criteria, ok := cc.criteria[materialType]; ok {
+		return criteria
+	}
+	return progress.DefaultCriteria(materialType)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"fmt"
+	"myway-backend/internal/models"
+	"myway-backend/internal/review"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// copyOptions control how copyModule copies a module.
+type copyOptions struct {
+	Title string
+	Order int
+	// KeepLockRule keeps the module's unlock rule. Rules reference modules,
+	// quizzes and assignments by ID, so they only make sense in the course
+	// they were written for.
+	KeepLockRule bool
+	// Policy is the review policy of the organization receiving the copy.
+	Policy review.Policy
+	// Shift moves the copied quizzes' scheduled answer reveals.
+	Shift time.Duration
+	// CopiedBy is recorded as the author of the copied study packs' first
+	// revision.
+	CopiedBy uuid.UUID
+	// IDs, when set, collects the IDs of copied modules and quizzes, keyed
+	// by the original's ID. Quizzes are filed under the copies of their
+	// gradebook categories found in it, and under none otherwise.
+	IDs map[uuid.UUID]uuid.UUID
+}
+
+// loadModuleForCopy loads a module with everything copyModule copies.
+func loadModuleForCopy(db *gorm.DB, moduleID uuid.UUID) (models.Module, error) {
+	var module models.Module
+	err := db.
+		Preload("Materials", byPosition).
+		Preload("Materials.StudyPacks", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Materials.StudyPacks.Summary").
+		Preload("Materials.StudyPacks.Quizzes", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Materials.StudyPacks.Quizzes.Questions", byPosition).
+		Preload("Materials.StudyPacks.Flashcards", byPosition).
+		First(&module, "id = ?", moduleID).Error
+	return module, err
+}
+
+// copyModule deep-copies a module loaded by loadModuleForCopy into
+// courseID: its materials in order, and their generated study packs with
+// summaries, quizzes and flashcards. Learner activity, revisions and review
+// threads stay with the original.
+func copyModule(tx *gorm.DB, source models.Module, courseID uuid.UUID, opts copyOptions) (*models.Module, error) {
+	module := models.Module{
+		CourseID: courseID,
+		Title:    opts.Title,
+		Order:    opts.Order,
+	}
+	if opts.KeepLockRule {
+		module.LockedRule = source.LockedRule
+	}
+	if err := tx.Create(&module).Error; err != nil {
+		return nil, err
+	}
+	if opts.IDs != nil {
+		opts.IDs[source.ID] = module.ID
+	}
+
+	for _, sourceMaterial := range source.Materials {
+		material := models.Material{
+			ModuleID:       module.ID,
+			Type:           sourceMaterial.Type,
+			Title:          sourceMaterial.Title,
+			SourceURL:      sourceMaterial.SourceURL,
+			FileURL:        sourceMaterial.FileURL,
+			TranscriptText: sourceMaterial.TranscriptText,
+			DurationSec:    sourceMaterial.DurationSec,
+			Position:       sourceMaterial.Position,
+		}
+		if err := tx.Create(&material).Error; err != nil {
+			return nil, err
+		}
+		for _, studyPack := range sourceMaterial.StudyPacks {
+			if err := copyStudyPack(tx, studyPack, material.ID, opts); err != nil {
+				return nil, err
+			}
+		}
+		module.Materials = append(module.Materials, material)
+	}
+	return &module, nil
+}
+
+// copyStudyPack copies a generated study pack's content. Packs still being
+// generated, or that failed, have nothing to copy. A published pack stays
+// published unless the receiving organization requires review; packs in
+// the middle of a review start over as drafts since reviewers are not
+// copied.
+func copyStudyPack(tx *gorm.DB, source models.StudyPack, materialID uuid.UUID, opts copyOptions) error {
+	if source.Status != "READY" && source.Status != "GENERATED" {
+		return nil
+	}
+
+	studyPack := models.StudyPack{
+		MaterialID:   materialID,
+		CreatedBy:    source.CreatedBy,
+		Status:       source.Status,
+		ReviewStatus: source.ReviewStatus,
+		PublishedAt:  source.PublishedAt,
+		ApprovedBy:   source.ApprovedBy,
+	}
+	keep := []string{review.Published, review.Unpublished, review.Draft}
+	if !slices.Contains(keep, source.ReviewStatus) || (source.ReviewStatus == review.Published && opts.Policy.RequireReview) {
+		studyPack.ReviewStatus = review.Draft
+		studyPack.Status = "GENERATED"
+		studyPack.PublishedAt = nil
+	}
+	if err := tx.Create(&studyPack).Error; err != nil {
+		return err
+	}
+
+	if source.Summary != nil {
+		if err := tx.Create(&models.Summary{StudyPackID: studyPack.ID, Content: source.Summary.Content}).Error; err != nil {
+			return err
+		}
+	}
+	for _, sourceQuiz := range source.Quizzes {
+		quiz := models.Quiz{
+			StudyPackID:       studyPack.ID,
+			Metadata:          sourceQuiz.Metadata,
+			TimeLimitSec:      sourceQuiz.TimeLimitSec,
+			MaxAttempts:       sourceQuiz.MaxAttempts,
+			ShuffleQuestions:  sourceQuiz.ShuffleQuestions,
+			ShuffleOptions:    sourceQuiz.ShuffleOptions,
+			RevealAfterSubmit: sourceQuiz.RevealAfterSubmit,
+			GradeCategoryID:   mappedID(opts.IDs, sourceQuiz.GradeCategoryID),
+		}
+		if sourceQuiz.RevealAt != nil {
+			revealAt := sourceQuiz.RevealAt.Add(opts.Shift)
+			quiz.RevealAt = &revealAt
+		}
+		if err := tx.Create(&quiz).Error; err != nil {
+			return err
+		}
+		if opts.IDs != nil {
+			opts.IDs[sourceQuiz.ID] = quiz.ID
+		}
+		// Create skips false booleans in favour of the column default.
+		if !sourceQuiz.RevealAfterSubmit {
+			if err := tx.Model(&quiz).UpdateColumn("reveal_after_submit", false).Error; err != nil {
+				return err
+			}
+		}
+		for _, sourceQuestion := range sourceQuiz.Questions {
+			question := models.QuizQuestion{
+				QuizID:      quiz.ID,
+				Type:        sourceQuestion.Type,
+				Prompt:      sourceQuestion.Prompt,
+				Options:     sourceQuestion.Options,
+				AnswerKey:   sourceQuestion.AnswerKey,
+				Explanation: sourceQuestion.Explanation,
+				Points:      sourceQuestion.Points,
+				Position:    sourceQuestion.Position,
+			}
+			if err := tx.Create(&question).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, sourceCard := range source.Flashcards {
+		flashcard := models.Flashcard{
+			StudyPackID: studyPack.ID,
+			Front:       sourceCard.Front,
+			Back:        sourceCard.Back,
+			Tags:        sourceCard.Tags,
+			Position:    sourceCard.Position,
+		}
+		if err := tx.Create(&flashcard).Error; err != nil {
+			return err
+		}
+	}
+
+	notes := fmt.Sprintf("Copied from study pack %s", source.ID)
+	_, err := recordRevision(tx, models.StudyPackRevision{
+		StudyPackID: studyPack.ID,
+		AuthorType:  revisionAuthorInstructor,
+		AuthorID:    &opts.CopiedBy,
+		Notes:       &notes,
+		Published:   studyPack.Status == "READY",
+	})
+	return err
+}
+
+// mappedID returns the copy of id recorded in ids, or nil.
+func mappedID(ids map[uuid.UUID]uuid.UUID, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if copied, ok := ids[*id]; ok {
+		return &copied
+	}
+	return nil
+}
+
+// nextModuleOrder is the order that places a new module last in a course.
+func nextModuleOrder(db *gorm.DB, courseID uuid.UUID) (int, error) {
+	var order int
+	err := db.Model(&models.Module{}).Where("course_id = ?", courseID).
+		Select(`COALESCE(MAX("order") + 1, 0)`).Scan(&order).Error
+	return order, err
+}
+
+// nextMaterialPosition is the position that places a new material last in
+// a module.
+func nextMaterialPosition(db *gorm.DB, moduleID uuid.UUID) (int, error) {
+	var position int
+	err := db.Model(&models.Material{}).Where("module_id = ?", moduleID).
+		Select("COALESCE(MAX(position) + 1, 0)").Scan(&position).Error
+	return position, err
+}
+
+// sortByPosition orders materials loaded without byPosition.
+func sortByPosition(materials []models.Material) {
+	slices.SortStableFunc(materials, func(a, b models.Material) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+}
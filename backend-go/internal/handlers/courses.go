@@ -1,10 +1,16 @@
 package handlers
 
 import (
-	"errors"
+	"encoding/json"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/trash"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -22,13 +28,19 @@ type CreateCourseRequest struct {
 	Code        string `json:"code" binding:"required"`
 	Title       string `json:"title" binding:"required"`
 	Description string `json:"description" binding:"required"`
+	// TermID places the course in one of the organization's terms.
+	TermID *uuid.UUID `json:"termId"`
+	// Status defaults to the organization's defaultCourseStatus.
+	Status        *string                `json:"status" binding:"omitempty,oneof=DRAFT PUBLISHED"`
+	CoverImageURL *string                `json:"coverImageUrl" binding:"omitempty,url"`
+	Metadata      map[string]interface{} `json:"metadata"`
 }
 
 func (h *CourseHandler) CreateCourse(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	var req CreateCourseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
@@ -36,267 +48,588 @@ func (h *CourseHandler) CreateCourse(c *gin.Context) {
 	// Get membership for the target organization
 	orgID, err := uuid.Parse(req.OrgID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		respondError(c, apperror.InvalidField("orgId", "Invalid organization ID"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, orgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
 		return
 	}
 
 	if membership.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only organizers can create courses"})
+		respondError(c, apperror.Forbidden("Only organizers can create courses"))
+		return
+	}
+
+	if err := database.GetDB().Select("id").First(&models.Organization{}, "id = ?", orgID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Organization not found"))
+		return
+	}
+
+	if req.TermID != nil {
+		if _, err := loadOpenTerm(database.GetDB(), orgID, *req.TermID); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	settings, err := loadOrgSettings(database.GetDB(), orgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load organization settings", err))
+		return
+	}
+	code, err := validateCourseCode(database.GetDB(), settings, req.Code, uuid.Nil)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	metadata, err := encodeCourseMetadata(req.Metadata)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
 	course := models.Course{
-		OrgID:       orgID,
-		Code:        req.Code,
-		Title:       req.Title,
-		Description: req.Description,
-		CreatedBy:   userID,
+		OrgID:         orgID,
+		Code:          code,
+		Title:         req.Title,
+		Description:   req.Description,
+		CreatedBy:     userID,
+		Status:        settings.DefaultCourseStatus,
+		CoverImageURL: emptyToNil(req.CoverImageURL),
+		Metadata:      metadata,
+		TermID:        req.TermID,
+	}
+	if req.Status != nil {
+		course.Status = *req.Status
 	}
 
 	if err := database.GetDB().Create(&course).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create course"})
+		if database.IsUniqueViolation(err, database.CourseCodeIndex) {
+			respondError(c, courseCodeTaken(code))
+			return
+		}
+		respondError(c, apperror.Internal("Failed to create course", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, course)
+	c.JSON(http.StatusCreated, dto.NewCourse(course))
 }
 
 func (h *CourseHandler) GetCourse(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
 	courseID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
 		return
 	}
 
 	var course models.Course
 	if err := database.GetDB().
+		Preload("Modules", byOrder).
+		Preload("Modules.Materials", byPosition).
 		Preload("Modules.Materials.StudyPacks").
 		Preload("Assignments").
 		First(&course, courseID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireCourseVisible(userID, course); err != nil {
+		respondError(c, err)
+		return
+	}
+	locks, err := loadModuleLocks(database.GetDB(), course, userID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to evaluate module locks", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, course)
+	result := dto.NewCourse(course)
+	applyModuleLocks(result.Modules, locks)
+	c.JSON(http.StatusOK, result)
 }
 
 func (h *CourseHandler) GetCoursesByOrg(c *gin.Context) {
 	orgID, err := uuid.Parse(c.Param("orgId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		respondError(c, apperror.InvalidField("orgId", "Invalid organization ID"))
 		return
 	}
 
-	var courses []models.Course
-
 	// Check if user is a member of the organization
 	userID := c.MustGet("userID").(uuid.UUID)
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, orgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
+		return
+	}
+
+	query, err := pagination.Parse(c, courseListSpec)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if err := database.GetDB().Where("org_id = ?", orgID).Find(&courses).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch courses"})
+	base := database.GetDB().Where("org_id = ?", orgID)
+	if raw := c.Query("termId"); raw != "" {
+		termID, err := uuid.Parse(raw)
+		if err != nil {
+			respondError(c, apperror.InvalidField("termId", "Invalid term ID"))
+			return
+		}
+		base = base.Where("term_id = ?", termID)
+	}
+	if membership.Role == "STUDENT" {
+		base = base.Where("status <> ?", "DRAFT")
+	}
+
+	page, err := query.Find(base, &models.Course{})
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch courses", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, courses)
+	c.JSON(http.StatusOK, pagination.Map(page, dto.NewCourse))
+}
+
+var courseListSpec = pagination.Spec[models.Course]{
+	Sorts: map[string]pagination.SortField[models.Course]{
+		"title": {Column: "title", Value: func(m models.Course) interface{} { return m.Title }},
+		"code":  {Column: "code", Value: func(m models.Course) interface{} { return m.Code }},
+	},
+	DefaultSort:   "title",
+	ID:            func(m models.Course) uuid.UUID { return m.ID },
+	StatusColumn:  "status",
+	CreatorColumn: "created_by",
 }
 
 func (h *CourseHandler) DeleteCourse(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	courseID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
 		return
 	}
 
 	var course models.Course
 	if err := database.GetDB().First(&course, courseID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch course"})
+		respondError(c, apperror.FromDB(err, "Course not found"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, course.OrgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
 		return
 	}
 	if membership.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only organizers can delete courses"})
+		respondError(c, apperror.Forbidden("Only organizers can delete courses"))
 		return
 	}
 
-	tx := database.GetDB().Begin()
-	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+	if _, err := trash.Move(database.GetDB(), trash.EntityCourse, course.ID, userID); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	var assignmentIDs []uuid.UUID
-	if err := tx.Model(&models.Assignment{}).Where("course_id = ?", courseID).Pluck("id", &assignmentIDs).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare assignment cleanup"})
+	c.JSON(http.StatusOK, gin.H{"message": "Course moved to trash"})
+}
+
+type CloneCourseRequest struct {
+	Code        string     `json:"code" binding:"required"`
+	Title       *string    `json:"title" binding:"omitempty,min=1"`
+	Description *string    `json:"description"`
+	TermID      *uuid.UUID `json:"termId"`
+	// ShiftDays moves assignment due dates, quiz answer reveals and
+	// AFTER_DATE unlock dates. It defaults to the days between the source's
+	// and the new term's starts.
+	ShiftDays *int `json:"shiftDays"`
+}
+
+// CloneCourse starts a new offering of a course: modules, materials, study
+// packs and active assignments are copied, while enrollments, submissions,
+// attempts and discussions stay with the original.
+func (h *CourseHandler) CloneCourse(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
 		return
 	}
 
-	var threadIDs []uuid.UUID
-	if err := tx.Model(&models.Thread{}).Where("course_id = ?", courseID).Pluck("id", &threadIDs).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare discussion cleanup"})
+	db := database.GetDB()
+	var course models.Course
+	if err := db.Preload("Term").First(&course, courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "ORGANIZER"); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	var moduleIDs []uuid.UUID
-	if err := tx.Model(&models.Module{}).Where("course_id = ?", courseID).Pluck("id", &moduleIDs).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare module cleanup"})
+	var req CloneCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
-	var materialIDs []uuid.UUID
-	if len(moduleIDs) > 0 {
-		if err := tx.Model(&models.Material{}).Where("module_id IN ?", moduleIDs).Pluck("id", &materialIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare material cleanup"})
+	var shift time.Duration
+	if req.TermID != nil {
+		term, err := loadOpenTerm(db, course.OrgID, *req.TermID)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
+		if course.Term != nil {
+			shift = term.StartsOn.Sub(course.Term.StartsOn)
+		}
+	}
+	if req.ShiftDays != nil {
+		shift = time.Duration(*req.ShiftDays) * 24 * time.Hour
 	}
 
-	var studyPackIDs []uuid.UUID
-	if len(materialIDs) > 0 {
-		if err := tx.Model(&models.StudyPack{}).Where("material_id IN ?", materialIDs).Pluck("id", &studyPackIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare study pack cleanup"})
-			return
-		}
+	settings, err := loadOrgSettings(db, course.OrgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load organization settings", err))
+		return
+	}
+	code, err := validateCourseCode(db, settings, req.Code, uuid.Nil)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	var quizIDs []uuid.UUID
-	if len(studyPackIDs) > 0 {
-		if err := tx.Model(&models.Quiz{}).Where("study_pack_id IN ?", studyPackIDs).Pluck("id", &quizIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare quiz cleanup"})
+	clone := models.Course{
+		OrgID:         course.OrgID,
+		Code:          code,
+		Title:         course.Title,
+		Description:   course.Description,
+		CreatedBy:     userID,
+		Status:        settings.DefaultCourseStatus,
+		CoverImageURL: course.CoverImageURL,
+		Metadata:      course.Metadata,
+		TermID:        req.TermID,
+		ClonedFrom:    &course.ID,
+		GradeScale:    course.GradeScale,
+	}
+	if req.Title != nil {
+		clone.Title = *req.Title
+	}
+	if req.Description != nil {
+		clone.Description = *req.Description
+	}
+
+	policy, err := reviewPolicy(db, course.OrgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+	var moduleIDs []uuid.UUID
+	if err := db.Model(&models.Module{}).Where("course_id = ?", course.ID).Scopes(byOrder).Pluck("id", &moduleIDs).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load modules", err))
+		return
+	}
+	sources := make([]models.Module, 0, len(moduleIDs))
+	for _, moduleID := range moduleIDs {
+		source, err := loadModuleForCopy(db, moduleID)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to load modules", err))
 			return
 		}
+		sources = append(sources, source)
+	}
+	var assignments []models.Assignment
+	if err := db.Where("course_id = ? AND status = ?", course.ID, "ACTIVE").Order("due_at, id").Find(&assignments).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load assignments", err))
+		return
+	}
+	var categories []models.GradeCategory
+	if err := db.Where("course_id = ?", course.ID).Scopes(byPosition).Find(&categories).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load grade categories", err))
+		return
 	}
 
-	if len(quizIDs) > 0 {
-		if err := tx.Where("quiz_id IN ?", quizIDs).Delete(&models.QuizAttempt{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quiz attempts"})
-			return
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&clone).Error; err != nil {
+			return err
 		}
-		if err := tx.Where("quiz_id IN ?", quizIDs).Delete(&models.QuizQuestion{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quiz questions"})
-			return
+
+		ids := map[uuid.UUID]uuid.UUID{}
+		for _, source := range categories {
+			category := models.GradeCategory{
+				CourseID:   clone.ID,
+				Name:       source.Name,
+				Weight:     source.Weight,
+				DropLowest: source.DropLowest,
+				Position:   source.Position,
+			}
+			if err := tx.Create(&category).Error; err != nil {
+				return err
+			}
+			ids[source.ID] = category.ID
 		}
-		if err := tx.Where("id IN ?", quizIDs).Delete(&models.Quiz{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quizzes"})
-			return
+		for _, source := range assignments {
+			assignment := models.Assignment{
+				CourseID:          clone.ID,
+				Title:             source.Title,
+				DueAt:             source.DueAt.Add(shift),
+				Points:            source.Points,
+				Instructions:      source.Instructions,
+				Status:            "ACTIVE",
+				LatePolicy:        source.LatePolicy,
+				LatePenaltyPerDay: source.LatePenaltyPerDay,
+				MaxAttempts:       source.MaxAttempts,
+				RubricID:          source.RubricID,
+				GradeCategoryID:   mappedID(ids, source.GradeCategoryID),
+				PeerReviewCount:   source.PeerReviewCount,
+				PeerReviewWeight:  source.PeerReviewWeight,
+			}
+			if source.PeerReviewDueAt != nil {
+				dueAt := source.PeerReviewDueAt.Add(shift)
+				assignment.PeerReviewDueAt = &dueAt
+			}
+			if err := tx.Create(&assignment).Error; err != nil {
+				return err
+			}
+			ids[source.ID] = assignment.ID
+			clone.Assignments = append(clone.Assignments, assignment)
 		}
-	}
 
-	if len(studyPackIDs) > 0 {
-		if err := tx.Where("study_pack_id IN ?", studyPackIDs).Delete(&models.FlashcardSession{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flashcard sessions"})
-			return
+		for _, source := range sources {
+			module, err := copyModule(tx, source, clone.ID, copyOptions{
+				Title:    source.Title,
+				Order:    source.Order,
+				Policy:   policy,
+				Shift:    shift,
+				CopiedBy: userID,
+				IDs:      ids,
+			})
+			if err != nil {
+				return err
+			}
+			clone.Modules = append(clone.Modules, *module)
+		}
+
+		// Unlock rules can only be copied once everything they point at
+		// has been.
+		for i, source := range sources {
+			rule := parseLockedRule(source)
+			if rule == nil {
+				continue
+			}
+			raw, err := json.Marshal(rule.Rewrite(ids, shift))
+			if err != nil {
+				return err
+			}
+			lockedRule := string(raw)
+			clone.Modules[i].LockedRule = &lockedRule
+			if err := tx.Model(&clone.Modules[i]).Update("locked_rule", lockedRule).Error; err != nil {
+				return err
+			}
 		}
-		if err := tx.Where("study_pack_id IN ?", studyPackIDs).Delete(&models.Flashcard{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flashcards"})
+		return nil
+	}); err != nil {
+		if database.IsUniqueViolation(err, database.CourseCodeIndex) {
+			respondError(c, courseCodeTaken(code))
 			return
 		}
-		if err := tx.Where("study_pack_id IN ?", studyPackIDs).Delete(&models.Summary{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete summaries"})
+		respondError(c, apperror.Internal("Failed to clone course", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewCourse(clone))
+}
+
+type UpdateCourseRequest struct {
+	Code        *string `json:"code" binding:"omitempty,min=1"`
+	Title       *string `json:"title" binding:"omitempty,min=1"`
+	Description *string `json:"description"`
+	// Status controls who sees the course: drafts are hidden from
+	// students, and archived courses are read-only.
+	Status *string `json:"status" binding:"omitempty,oneof=DRAFT PUBLISHED ARCHIVED"`
+	// An empty CoverImageURL removes the cover image.
+	CoverImageURL *string `json:"coverImageUrl" binding:"omitempty,url"`
+	// Metadata replaces the course's metadata.
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// UpdateCourse changes only the fields present in the request. It serves
+// both PUT and PATCH.
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
+		return
+	}
+
+	var req UpdateCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	db := database.GetDB()
+	var course models.Course
+	if err := db.First(&course, courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	updates := make(map[string]interface{})
+	code := course.Code
+	if req.Code != nil && *req.Code != course.Code {
+		settings, err := loadOrgSettings(db, course.OrgID)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to load organization settings", err))
 			return
 		}
-		if err := tx.Where("id IN ?", studyPackIDs).Delete(&models.StudyPack{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete study packs"})
+		code, err = validateCourseCode(db, settings, *req.Code, course.ID)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
+		updates["code"] = code
 	}
-
-	if len(materialIDs) > 0 {
-		if err := tx.Where("id IN ?", materialIDs).Delete(&models.Material{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete materials"})
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+	if req.CoverImageURL != nil {
+		updates["cover_image_url"] = emptyToNil(req.CoverImageURL)
+	}
+	if req.Metadata != nil {
+		metadata, err := encodeCourseMetadata(req.Metadata)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
+		updates["metadata"] = metadata
 	}
 
-	if len(moduleIDs) > 0 {
-		if err := tx.Where("id IN ?", moduleIDs).Delete(&models.Module{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete modules"})
+	if len(updates) > 0 {
+		if err := db.Model(&course).Updates(updates).Error; err != nil {
+			if database.IsUniqueViolation(err, database.CourseCodeIndex) {
+				respondError(c, courseCodeTaken(code))
+				return
+			}
+			respondError(c, apperror.Internal("Failed to update course", err))
 			return
 		}
 	}
+	if err := db.First(&course, course.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load course", err))
+		return
+	}
 
-	if len(assignmentIDs) > 0 {
-		if err := tx.Where("assignment_id IN ?", assignmentIDs).Delete(&models.Submission{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete submissions"})
-			return
+	c.JSON(http.StatusOK, dto.NewCourse(course))
+}
+
+// courseCodeTaken is the error for a code another live course of the
+// organization uses. The database index backs this up when two writes race.
+func courseCodeTaken(code string) error {
+	return apperror.Conflict("Another course in this organization already uses code " + code)
+}
+
+// validateCourseCode trims a course code and checks it against the
+// organization's prefix rules and its other courses' codes, ignoring case.
+// excludeID is the course being renamed, if any.
+func validateCourseCode(db *gorm.DB, settings models.OrgSettings, code string, excludeID uuid.UUID) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", apperror.InvalidField("code", "is required")
+	}
+	if prefixes := dto.CourseCodePrefixes(settings); len(prefixes) > 0 {
+		allowed := false
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(strings.ToUpper(code), strings.ToUpper(prefix)) {
+				allowed = true
+				break
+			}
 		}
-		if err := tx.Where("id IN ?", assignmentIDs).Delete(&models.Assignment{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete assignments"})
-			return
+		if !allowed {
+			return "", apperror.InvalidField("code", "Course codes in this organization must start with one of: "+strings.Join(prefixes, ", "))
 		}
 	}
 
-	if len(threadIDs) > 0 {
-		if err := tx.Where("thread_id IN ?", threadIDs).Delete(&models.Reply{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete replies"})
-			return
-		}
-		if err := tx.Where("id IN ?", threadIDs).Delete(&models.Thread{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete threads"})
-			return
+	var count int64
+	if err := db.Model(&models.Course{}).
+		Where("org_id = ? AND LOWER(code) = LOWER(?) AND id <> ?", settings.OrgID, code, excludeID).
+		Count(&count).Error; err != nil {
+		return "", apperror.Internal("Failed to check course code", err)
+	}
+	if count > 0 {
+		return "", courseCodeTaken(code)
+	}
+	return code, nil
+}
+
+// requireOrgRole returns FORBIDDEN unless the user is an active member of
+// the organization with one of the given roles.
+func requireOrgRole(userID, orgID uuid.UUID, roles ...string) error {
+	var membership models.OrgMembership
+	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, orgID, "Active").First(&membership).Error; err != nil {
+		return apperror.Forbidden("You do not have access to this organization")
+	}
+	for _, role := range roles {
+		if membership.Role == role {
+			return nil
 		}
 	}
+	return apperror.Forbidden("You do not have permission to do this in this organization")
+}
 
-	if err := tx.Where("course_id = ?", courseID).Delete(&models.Enrollment{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete enrollments"})
-		return
+// requireCourseVisible hides draft courses from everyone but the teachers
+// and organizers of their organization.
+func requireCourseVisible(userID uuid.UUID, course models.Course) error {
+	if course.Status != "DRAFT" {
+		return nil
 	}
-	if err := tx.Where("course_id = ?", courseID).Delete(&models.CourseMetric{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete course metrics"})
-		return
+	if requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER") != nil {
+		return apperror.NotFound("Course not found")
 	}
+	return nil
+}
 
-	if err := tx.Delete(&course).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete course"})
-		return
+// requireEnrolledStudent checks that the user is enrolled in the course as
+// a student.
+func requireEnrolledStudent(db *gorm.DB, courseID, userID uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.Enrollment{}).Where("course_id = ? AND user_id = ? AND role = ?", courseID, userID, "STUDENT").Count(&count).Error; err != nil {
+		return apperror.Internal("Failed to check enrollment", err)
+	}
+	if count == 0 {
+		return apperror.Forbidden("Only students enrolled in this course can do this")
 	}
+	return nil
+}
 
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit course deletion"})
-		return
+func encodeCourseMetadata(metadata map[string]interface{}) (*string, error) {
+	if metadata == nil {
+		return nil, nil
 	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, apperror.InvalidField("metadata", "Metadata must be a JSON object")
+	}
+	value := string(encoded)
+	return &value, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Course deleted successfully"})
+func emptyToNil(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
 }
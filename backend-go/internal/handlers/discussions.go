@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,13 +28,13 @@ func (h *DiscussionHandler) CreateThread(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	var req CreateThreadRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	courseID, err := uuid.Parse(req.CourseID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
@@ -43,41 +46,91 @@ func (h *DiscussionHandler) CreateThread(c *gin.Context) {
 	}
 
 	if err := database.GetDB().Create(&thread).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create thread"})
+		respondError(c, apperror.Internal("Failed to create thread", err))
 		return
 	}
 
 	// Load creator info
-	database.GetDB().Preload("Creator").First(&thread, thread.ID)
+	if err := database.GetDB().Preload("Creator").First(&thread, thread.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load thread", err))
+		return
+	}
 
-	c.JSON(http.StatusCreated, thread)
+	c.JSON(http.StatusCreated, dto.NewThread(thread))
 }
 
 func (h *DiscussionHandler) GetThreadsByCourse(c *gin.Context) {
 	courseID, err := uuid.Parse(c.Param("courseId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
-	var threads []models.Thread
-	if err := database.GetDB().
-		Preload("Creator").
-		Preload("Replies.Creator").
-		Where("course_id = ?", courseID).
-		Order("created_at DESC").
-		Find(&threads).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch threads"})
+	query, err := pagination.Parse(c, threadListSpec)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, threads)
+	page, err := query.Find(database.GetDB().Where("course_id = ?", courseID), &models.Thread{}, "Creator")
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch threads", err))
+		return
+	}
+
+	// Replies are loaded per thread via GetThread; the list only carries counts.
+	threadIDs := make([]uuid.UUID, len(page.Items))
+	for i, t := range page.Items {
+		threadIDs[i] = t.ID
+	}
+	replyCounts := make(map[uuid.UUID]int64, len(threadIDs))
+	if len(threadIDs) > 0 {
+		var rows []struct {
+			ThreadID uuid.UUID
+			Count    int64
+		}
+		if err := database.GetDB().
+			Model(&models.Reply{}).
+			Select("thread_id, COUNT(*) AS count").
+			Where("thread_id IN ?", threadIDs).
+			Group("thread_id").
+			Scan(&rows).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to count replies", err))
+			return
+		}
+		for _, row := range rows {
+			replyCounts[row.ThreadID] = row.Count
+		}
+	}
+
+	c.JSON(http.StatusOK, pagination.Map(page, func(t models.Thread) gin.H {
+		return gin.H{
+			"id":         t.ID,
+			"courseId":   t.CourseID,
+			"title":      t.Title,
+			"body":       t.Body,
+			"createdAt":  t.CreatedAt,
+			"creator":    dto.NewUserRef(t.Creator),
+			"replyCount": replyCounts[t.ID],
+		}
+	}))
+}
+
+var threadListSpec = pagination.Spec[models.Thread]{
+	Sorts: map[string]pagination.SortField[models.Thread]{
+		"createdAt": {Column: "created_at", Kind: pagination.KindTime, Value: func(m models.Thread) interface{} { return m.CreatedAt }},
+		"title":     {Column: "title", Value: func(m models.Thread) interface{} { return m.Title }},
+	},
+	DefaultSort:   "-createdAt",
+	ID:            func(m models.Thread) uuid.UUID { return m.ID },
+	CreatorColumn: "created_by",
+	DateColumn:    "created_at",
 }
 
 func (h *DiscussionHandler) GetThread(c *gin.Context) {
 	threadID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid thread ID"))
 		return
 	}
 
@@ -87,11 +140,11 @@ func (h *DiscussionHandler) GetThread(c *gin.Context) {
 		Preload("Replies.Creator").
 		Preload("Course").
 		First(&thread, threadID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+		respondError(c, apperror.FromDB(err, "Thread not found"))
 		return
 	}
 
-	c.JSON(http.StatusOK, thread)
+	c.JSON(http.StatusOK, dto.NewThread(thread))
 }
 
 type CreateReplyRequest struct {
@@ -107,13 +160,13 @@ func (h *DiscussionHandler) CreateReply(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	threadID, err := uuid.Parse(c.Param("threadId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		respondError(c, apperror.InvalidField("threadId", "Invalid thread ID"))
 		return
 	}
 
 	var req CreateReplyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
@@ -125,13 +178,13 @@ func (h *DiscussionHandler) CreateReplyByBody(c *gin.Context) {
 
 	var req CreateReplyByBodyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	threadID, err := uuid.Parse(req.ThreadID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		respondError(c, apperror.InvalidField("threadId", "Invalid thread ID"))
 		return
 	}
 
@@ -142,7 +195,7 @@ func (h *DiscussionHandler) createReply(c *gin.Context, userID uuid.UUID, thread
 	// Verify thread exists
 	var thread models.Thread
 	if err := database.GetDB().First(&thread, threadID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Thread not found"})
+		respondError(c, apperror.FromDB(err, "Thread not found"))
 		return
 	}
 
@@ -153,12 +206,15 @@ func (h *DiscussionHandler) createReply(c *gin.Context, userID uuid.UUID, thread
 	}
 
 	if err := database.GetDB().Create(&reply).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reply"})
+		respondError(c, apperror.Internal("Failed to create reply", err))
 		return
 	}
 
 	// Load creator info
-	database.GetDB().Preload("Creator").First(&reply, reply.ID)
+	if err := database.GetDB().Preload("Creator").First(&reply, reply.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load reply", err))
+		return
+	}
 
-	c.JSON(http.StatusCreated, reply)
+	c.JSON(http.StatusCreated, dto.NewReply(reply))
 }
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"myway-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError hands err to the error middleware and stops the handler chain.
+func respondError(c *gin.Context, err error) {
+	middleware.AbortWithError(c, err)
+}
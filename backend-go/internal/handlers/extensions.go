@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/lateness"
+	"myway-backend/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExtensionRequest struct {
+	DueAt  time.Time `json:"dueAt" binding:"required"`
+	Reason *string   `json:"reason"`
+}
+
+// GetExtensions lists the due-date extensions granted on an assignment.
+func (h *AssignmentHandler) GetExtensions(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+
+	var extensions []models.AssignmentExtension
+	if err := database.GetDB().Preload("User").
+		Where("assignment_id = ?", assignment.ID).
+		Order("due_at, id").
+		Find(&extensions).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch extensions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAssignmentExtensions(extensions))
+}
+
+// GrantExtension gives one student a later due date, replacing any
+// extension they already have.
+func (h *AssignmentHandler) GrantExtension(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+	studentID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("userId", "Invalid user ID"))
+		return
+	}
+
+	var req ExtensionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if !req.DueAt.After(assignment.DueAt) {
+		respondError(c, apperror.InvalidField("dueAt", "An extension must end after the assignment's due date"))
+		return
+	}
+	if err := requireOrgRole(studentID, assignment.Course.OrgID, "STUDENT"); err != nil {
+		respondError(c, apperror.InvalidField("userId", "Extensions can only be granted to students of this organization"))
+		return
+	}
+
+	extension := models.AssignmentExtension{
+		AssignmentID: assignment.ID,
+		UserID:       studentID,
+		DueAt:        req.DueAt,
+		GrantedBy:    userID,
+	}
+	if req.Reason != nil && strings.TrimSpace(*req.Reason) != "" {
+		reason := strings.TrimSpace(*req.Reason)
+		extension.Reason = &reason
+	}
+	if err := database.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "assignment_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"due_at", "reason", "granted_by", "updated_at"}),
+	}).Create(&extension).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to grant extension", err))
+		return
+	}
+	if err := database.GetDB().Preload("User").
+		Where("assignment_id = ? AND user_id = ?", assignment.ID, studentID).
+		First(&extension).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load extension", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAssignmentExtension(extension))
+}
+
+// RevokeExtension returns a student to the assignment's own due date.
+func (h *AssignmentHandler) RevokeExtension(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+	studentID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("userId", "Invalid user ID"))
+		return
+	}
+
+	result := database.GetDB().Where("assignment_id = ? AND user_id = ?", assignment.ID, studentID).Delete(&models.AssignmentExtension{})
+	if result.Error != nil {
+		respondError(c, apperror.Internal("Failed to revoke extension", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondError(c, apperror.NotFound("Extension not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Extension revoked"})
+}
+
+// loadExtensions returns userID's extensions on the given assignments,
+// keyed by assignment.
+func loadExtensions(db *gorm.DB, userID uuid.UUID, assignmentIDs []uuid.UUID) (map[uuid.UUID]models.AssignmentExtension, error) {
+	extensions := map[uuid.UUID]models.AssignmentExtension{}
+	if len(assignmentIDs) == 0 {
+		return extensions, nil
+	}
+	var rows []models.AssignmentExtension
+	if err := db.Where("user_id = ? AND assignment_id IN ?", userID, assignmentIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		extensions[row.AssignmentID] = row
+	}
+	return extensions, nil
+}
+
+// evaluateLateness checks a submission made now against the student's due
+// date, extended or not.
+func evaluateLateness(db *gorm.DB, assignment models.Assignment, userID uuid.UUID, now time.Time) (lateness.Result, error) {
+	extensions, err := loadExtensions(db, userID, []uuid.UUID{assignment.ID})
+	if err != nil {
+		return lateness.Result{}, err
+	}
+	due := assignment.DueAt
+	if extension, ok := extensions[assignment.ID]; ok {
+		due = extension.DueAt
+	}
+	policy := lateness.Policy{Type: assignment.LatePolicy, PenaltyPerDay: assignment.LatePenaltyPerDay}
+	return lateness.Evaluate(policy, due, now), nil
+}
+
+func validateLatePolicy(assignment models.Assignment) error {
+	if assignment.LatePolicy == lateness.Penalty && assignment.LatePenaltyPerDay <= 0 {
+		return apperror.InvalidField("latePenaltyPerDay", "A PENALTY late policy needs a penalty above 0")
+	}
+	return nil
+}
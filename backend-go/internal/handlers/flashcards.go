@@ -2,12 +2,21 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/srs"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type FlashcardHandler struct{}
@@ -17,42 +26,65 @@ func NewFlashcardHandler() *FlashcardHandler {
 }
 
 func (h *FlashcardHandler) GetFlashcardsByStudyPack(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
 	studyPackID, err := uuid.Parse(c.Param("studyPackId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study pack ID"})
+		respondError(c, apperror.InvalidField("studyPackId", "Invalid study pack ID"))
+		return
+	}
+
+	db := database.GetDB()
+	var studyPack models.StudyPack
+	if err := db.Preload("Material").First(&studyPack, "id = ?", studyPackID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Study pack not found"))
+		return
+	}
+	if err := requireModuleUnlocked(db, userID, studyPack.Material.ModuleID); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	var flashcards []models.Flashcard
-	if err := database.GetDB().
+	if err := db.
 		Where("study_pack_id = ?", studyPackID).
+		Scopes(byPosition).
 		Find(&flashcards).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flashcards"})
+		respondError(c, apperror.Internal("Failed to fetch flashcards", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, flashcards)
+	c.JSON(http.StatusOK, dto.NewFlashcards(flashcards))
 }
 
 type FlashcardSessionRequest struct {
-	StudyPackID uuid.UUID                    `json:"studyPackId" binding:"required"`
-	Responses   map[uuid.UUID]string `json:"responses" binding:"required"` // flashcardID -> "known" or "unknown"
-	DurationSec int                          `json:"durationSec" binding:"required"`
+	StudyPackID uuid.UUID            `json:"studyPackId" binding:"required"`
+	Responses   map[uuid.UUID]string `json:"responses" binding:"required"` // flashcardID -> again/hard/good/easy, or known/unknown
+	DurationSec int                  `json:"durationSec" binding:"required"`
 }
 
 func (h *FlashcardHandler) RecordSession(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	var req FlashcardSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
+	grades := make(map[uuid.UUID]srs.Grade, len(req.Responses))
+	for flashcardID, response := range req.Responses {
+		grade, err := srs.ParseGrade(response)
+		if err != nil {
+			respondError(c, apperror.InvalidField("responses", "Responses must be again, hard, good, easy, known or unknown"))
+			return
+		}
+		grades[flashcardID] = grade
+	}
+
 	// Count known/unknown
 	knownCount := 0
 	unknownCount := 0
-	for _, response := range req.Responses {
-		if response == "known" {
+	for _, grade := range grades {
+		if grade.Passed() {
 			knownCount++
 		} else {
 			unknownCount++
@@ -68,11 +100,6 @@ func (h *FlashcardHandler) RecordSession(c *gin.Context) {
 		DurationSec:  req.DurationSec,
 	}
 
-	if err := database.GetDB().Create(&session).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record session"})
-		return
-	}
-
 	// Record progress event
 	responsesJSON, _ := json.Marshal(req.Responses)
 	progressEvent := models.ProgressEvent{
@@ -84,30 +111,297 @@ func (h *FlashcardHandler) RecordSession(c *gin.Context) {
 
 	// Get study pack to find course
 	var studyPack models.StudyPack
-	if err := database.GetDB().Preload("Material.Module.Course").First(&studyPack, req.StudyPackID).Error; err == nil {
-		if studyPack.Material.Module.Course.ID != uuid.Nil {
-			progressEvent.CourseID = studyPack.Material.Module.Course.ID.String()
+	if err := database.GetDB().Preload("Material.Module.Course").First(&studyPack, req.StudyPackID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Study pack not found"))
+		return
+	}
+	if studyPack.Material.Module.Course.ID != uuid.Nil {
+		progressEvent.CourseID = studyPack.Material.Module.Course.ID.String()
+	}
+	if err := requireModuleUnlocked(database.GetDB(), userID, studyPack.Material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if len(grades) > 0 {
+		flashcardIDs := make([]uuid.UUID, 0, len(grades))
+		for flashcardID := range grades {
+			flashcardIDs = append(flashcardIDs, flashcardID)
+		}
+		var count int64
+		if err := database.GetDB().Model(&models.Flashcard{}).
+			Where("id IN ? AND study_pack_id = ?", flashcardIDs, studyPack.ID).
+			Count(&count).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to check flashcards", err))
+			return
+		}
+		if int(count) != len(flashcardIDs) {
+			respondError(c, apperror.InvalidField("responses", "Every response must be for a flashcard in this study pack"))
+			return
 		}
 	}
 
-	database.GetDB().Create(&progressEvent)
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		if _, err := reviewFlashcards(tx, userID, grades, time.Now()); err != nil {
+			return err
+		}
+		return tx.Create(&progressEvent).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to record session", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewFlashcardSession(session))
+}
+
+type ReviewFlashcardRequest struct {
+	Grade string `json:"grade" binding:"required,oneof=again hard good easy"`
+}
+
+// ReviewFlashcard grades one card and reschedules it for the caller.
+func (h *FlashcardHandler) ReviewFlashcard(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	flashcardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid flashcard ID"))
+		return
+	}
+
+	var req ReviewFlashcardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
 
-	c.JSON(http.StatusOK, session)
+	var flashcard models.Flashcard
+	if err := database.GetDB().Preload("StudyPack.Material.Module.Course").First(&flashcard, "id = ?", flashcardID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Flashcard not found"))
+		return
+	}
+	course := flashcard.StudyPack.Material.Module.Course
+	if course.ID == uuid.Nil {
+		respondError(c, apperror.NotFound("Flashcard not found"))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireModuleUnlocked(database.GetDB(), userID, flashcard.StudyPack.Material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var states []models.FlashcardReviewState
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		states, err = reviewFlashcards(tx, userID, map[uuid.UUID]srs.Grade{flashcard.ID: srs.Grade(req.Grade)}, time.Now())
+		return err
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to record review", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewFlashcardSchedule(states[0]))
+}
+
+// GetDueFlashcards builds today's review queue across the caller's enrolled
+// courses: cards due by the end of the day (UTC), oldest first, followed by
+// cards never reviewed, up to the daily new-card limit.
+func (h *FlashcardHandler) GetDueFlashcards(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	reviewLimit, err := queryLimit(c, "reviewLimit", defaultReviewLimit, maxReviewLimit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	newLimit, err := queryLimit(c, "newLimit", defaultNewCardLimit, maxReviewLimit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	startOfDay := now.Truncate(24 * time.Hour)
+	endOfDay := startOfDay.Add(24 * time.Hour)
+	db := database.GetDB()
+	locked, err := lockedModuleIDs(db, userID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to evaluate module locks", err))
+		return
+	}
+
+	var reviews []models.FlashcardReviewState
+	if err := enrolledFlashcards(db.Joins("JOIN flashcards ON flashcards.id = flashcard_review_states.flashcard_id"), userID, locked).
+		Preload("Flashcard.StudyPack.Material.Module").
+		Where("flashcard_review_states.user_id = ? AND flashcard_review_states.due_at < ?", userID, endOfDay).
+		Order("flashcard_review_states.due_at ASC").
+		Limit(reviewLimit).
+		Find(&reviews).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch due flashcards", err))
+		return
+	}
+
+	// Cards first reviewed today count against the new-card limit.
+	var introducedToday int64
+	if err := db.Model(&models.FlashcardReviewState{}).
+		Where("user_id = ? AND created_at >= ?", userID, startOfDay).
+		Count(&introducedToday).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch due flashcards", err))
+		return
+	}
+
+	var newCards []models.Flashcard
+	if remaining := newLimit - int(introducedToday); remaining > 0 {
+		if err := enrolledFlashcards(db, userID, locked).
+			Preload("StudyPack.Material.Module").
+			Where("NOT EXISTS (SELECT 1 FROM flashcard_review_states WHERE flashcard_review_states.flashcard_id = flashcards.id AND flashcard_review_states.user_id = ?)", userID).
+			Order("study_packs.created_at ASC, flashcards.position ASC, flashcards.id ASC").
+			Limit(remaining).
+			Find(&newCards).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to fetch new flashcards", err))
+			return
+		}
+	}
+
+	cards := make([]dto.DueFlashcard, 0, len(reviews)+len(newCards))
+	for _, state := range reviews {
+		cards = append(cards, dto.NewDueFlashcard(state.Flashcard, &state))
+	}
+	for _, flashcard := range newCards {
+		cards = append(cards, dto.NewDueFlashcard(flashcard, nil))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":        startOfDay.Format("2006-01-02"),
+		"reviewCount": len(reviews),
+		"newCount":    len(newCards),
+		"cards":       cards,
+	})
+}
+
+const (
+	defaultReviewLimit  = 200
+	defaultNewCardLimit = 20
+	maxReviewLimit      = 500
+)
+
+// queryLimit reads an optional positive integer query parameter.
+func queryLimit(c *gin.Context, name string, fallback, maxValue int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 0 || limit > maxValue {
+		return 0, apperror.InvalidField(name, fmt.Sprintf("%s must be a number from 0 to %d", name, maxValue))
+	}
+	return limit, nil
+}
+
+// enrolledFlashcards joins flashcards to the courses the user is enrolled in,
+// keeping only published study packs and skipping anything in the trash or
+// in a locked module.
+func enrolledFlashcards(db *gorm.DB, userID uuid.UUID, locked []uuid.UUID) *gorm.DB {
+	if len(locked) > 0 {
+		db = db.Where("modules.id NOT IN ?", locked)
+	}
+	return db.
+		Joins("JOIN study_packs ON study_packs.id = flashcards.study_pack_id").
+		Joins("JOIN materials ON materials.id = study_packs.material_id AND materials.deleted_at IS NULL").
+		Joins("JOIN modules ON modules.id = materials.module_id AND modules.deleted_at IS NULL").
+		Joins("JOIN courses ON courses.id = modules.course_id AND courses.deleted_at IS NULL").
+		Joins("JOIN enrollments ON enrollments.course_id = courses.id AND enrollments.user_id = ?", userID).
+		Where("study_packs.status = ?", "READY")
+}
+
+// reviewFlashcards applies one grade per card to the user's review state,
+// creating the state on a card's first review.
+func reviewFlashcards(tx *gorm.DB, userID uuid.UUID, grades map[uuid.UUID]srs.Grade, now time.Time) ([]models.FlashcardReviewState, error) {
+	if len(grades) == 0 {
+		return nil, nil
+	}
+	flashcardIDs := make([]uuid.UUID, 0, len(grades))
+	for flashcardID := range grades {
+		flashcardIDs = append(flashcardIDs, flashcardID)
+	}
+
+	var existing []models.FlashcardReviewState
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND flashcard_id IN ?", userID, flashcardIDs).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byCard := make(map[uuid.UUID]models.FlashcardReviewState, len(existing))
+	for _, state := range existing {
+		byCard[state.FlashcardID] = state
+	}
+
+	states := make([]models.FlashcardReviewState, 0, len(grades))
+	for _, flashcardID := range flashcardIDs {
+		grade := grades[flashcardID]
+		state, ok := byCard[flashcardID]
+		current := srs.New(now)
+		if ok {
+			current = srs.State{Ease: state.Ease, IntervalDays: state.IntervalDays, Repetitions: state.Repetitions, Lapses: state.Lapses, DueAt: state.DueAt}
+		} else {
+			state = models.FlashcardReviewState{UserID: userID, FlashcardID: flashcardID}
+		}
+
+		next := srs.Review(current, grade, now)
+		state.Ease = next.Ease
+		state.IntervalDays = next.IntervalDays
+		state.Repetitions = next.Repetitions
+		state.Lapses = next.Lapses
+		state.DueAt = next.DueAt
+		state.LastGrade = string(grade)
+		state.LastReviewedAt = now
+
+		if ok {
+			if err := tx.Save(&state).Error; err != nil {
+				return nil, err
+			}
+		} else if err := tx.Clauses(clause.OnConflict{
+			// A concurrent first review of the same card may have inserted
+			// the row after the lock above found nothing; the later wins.
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "flashcard_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"ease", "interval_days", "repetitions", "lapses", "due_at", "last_grade", "last_reviewed_at", "updated_at"}),
+		}).Create(&state).Error; err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
 }
 
 func (h *FlashcardHandler) GetSessionsByUser(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	var sessions []models.FlashcardSession
-	if err := database.GetDB().
-		Preload("StudyPack.Material").
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Limit(20).
-		Find(&sessions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+	query, err := pagination.Parse(c, flashcardSessionListSpec)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, sessions)
+	page, err := query.Find(database.GetDB().Where("user_id = ?", userID), &models.FlashcardSession{}, "StudyPack.Material")
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch sessions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Map(page, dto.NewFlashcardSession))
+}
+
+var flashcardSessionListSpec = pagination.Spec[models.FlashcardSession]{
+	Sorts: map[string]pagination.SortField[models.FlashcardSession]{
+		"createdAt":   {Column: "created_at", Kind: pagination.KindTime, Value: func(m models.FlashcardSession) interface{} { return m.CreatedAt }},
+		"durationSec": {Column: "duration_sec", Kind: pagination.KindInt, Value: func(m models.FlashcardSession) interface{} { return m.DurationSec }},
+	},
+	DefaultSort: "-createdAt",
+	ID:          func(m models.FlashcardSession) uuid.UUID { return m.ID },
+	DateColumn:  "created_at",
 }
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/gating"
+	"myway-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// moduleLock is whether a module is locked for a learner and the
+// conditions it is waiting for.
+type moduleLock struct {
+	Locked bool
+	Unmet  []gating.UnlockRule
+}
+
+// parseLockedRule parses a module's stored rule. Rules saved before rules
+// were evaluated may be free text; those are ignored rather than locking
+// the module for good.
+func parseLockedRule(module models.Module) *gating.UnlockRule {
+	if module.LockedRule == nil || *module.LockedRule == "" {
+		return nil
+	}
+	rule, err := gating.Parse(*module.LockedRule)
+	if err != nil {
+		log.Printf("Ignoring invalid lock rule on module %s: %v", module.ID, err)
+		return nil
+	}
+	return rule
+}
+
+// loadModuleLocks evaluates the unlock rules of a course loaded by
+// loadCourseTree for userID. Teachers and organizers of the course's
+// organization are never locked out; modules missing from the result are
+// unlocked.
+func loadModuleLocks(db *gorm.DB, course models.Course, userID uuid.UUID) (map[uuid.UUID]moduleLock, error) {
+	locks := map[uuid.UUID]moduleLock{}
+	if requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER") == nil {
+		return locks, nil
+	}
+
+	rules := map[uuid.UUID]*gating.UnlockRule{}
+	var refs gating.References
+	for _, module := range course.Modules {
+		if rule := parseLockedRule(module); rule != nil {
+			rules[module.ID] = rule
+			r := rule.References()
+			refs.Modules = append(refs.Modules, r.Modules...)
+			refs.Quizzes = append(refs.Quizzes, r.Quizzes...)
+			refs.Assignments = append(refs.Assignments, r.Assignments...)
+		}
+	}
+	if len(rules) == 0 {
+		return locks, nil
+	}
+
+	facts := gating.Facts{
+		Now:                  time.Now(),
+		CompletedModules:     map[uuid.UUID]bool{},
+		BestQuizScores:       map[uuid.UUID]int{},
+		SubmittedAssignments: map[uuid.UUID]bool{},
+	}
+	if len(refs.Modules) > 0 {
+		completion, err := loadCourseCompletion(db, course, []uuid.UUID{userID})
+		if err != nil {
+			return nil, err
+		}
+		modules, _ := completion.evaluate(userID)
+		for _, module := range modules {
+			facts.CompletedModules[module.ModuleID] = module.Completed
+		}
+	}
+	if len(refs.Quizzes) > 0 {
+		var scores []struct {
+			QuizID uuid.UUID
+			Best   int
+		}
+		if err := db.Model(&models.QuizAttempt{}).
+			Where("user_id = ? AND quiz_id IN ? AND status = ?", userID, refs.Quizzes, "SUBMITTED").
+			Select("quiz_id, MAX(score) AS best").
+			Group("quiz_id").
+			Scan(&scores).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range scores {
+			facts.BestQuizScores[row.QuizID] = row.Best
+		}
+	}
+	if len(refs.Assignments) > 0 {
+		var submitted []uuid.UUID
+		if err := db.Model(&models.Submission{}).
+			Where("user_id = ? AND assignment_id IN ?", userID, refs.Assignments).
+			Distinct().Pluck("assignment_id", &submitted).Error; err != nil {
+			return nil, err
+		}
+		for _, assignmentID := range submitted {
+			facts.SubmittedAssignments[assignmentID] = true
+		}
+	}
+
+	for moduleID, rule := range rules {
+		open, unmet := rule.Evaluate(facts)
+		locks[moduleID] = moduleLock{Locked: !open, Unmet: unmet}
+	}
+	return locks, nil
+}
+
+// courseModuleLocks loads a course and evaluates its unlock rules for
+// userID.
+func courseModuleLocks(db *gorm.DB, courseID, userID uuid.UUID) (map[uuid.UUID]moduleLock, error) {
+	course, err := loadCourseTree(db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return loadModuleLocks(db, course, userID)
+}
+
+// applyModuleLocks withholds the materials of modules locked for the
+// caller.
+func applyModuleLocks(modules []dto.Module, locks map[uuid.UUID]moduleLock) {
+	for i := range modules {
+		if lock := locks[modules[i].ID]; lock.Locked {
+			modules[i].Lock(lock.Unmet)
+		}
+	}
+}
+
+// requireModuleUnlocked returns FORBIDDEN if the module is locked for
+// userID.
+func requireModuleUnlocked(db *gorm.DB, userID, moduleID uuid.UUID) error {
+	var module models.Module
+	if err := db.Select("id", "course_id", "locked_rule").First(&module, "id = ?", moduleID).Error; err != nil {
+		return apperror.FromDB(err, "Module not found")
+	}
+	if parseLockedRule(module) == nil {
+		return nil
+	}
+	locks, err := courseModuleLocks(db, module.CourseID, userID)
+	if err != nil {
+		return apperror.Internal("Failed to evaluate module lock", err)
+	}
+	if locks[moduleID].Locked {
+		return apperror.Forbidden("This module is locked")
+	}
+	return nil
+}
+
+// lockedModuleIDs lists the modules locked for userID across the courses
+// they are enrolled in.
+func lockedModuleIDs(db *gorm.DB, userID uuid.UUID) ([]uuid.UUID, error) {
+	var courseIDs []uuid.UUID
+	if err := db.Model(&models.Module{}).
+		Joins("JOIN enrollments ON enrollments.course_id = modules.course_id AND enrollments.user_id = ?", userID).
+		Where("modules.locked_rule IS NOT NULL AND modules.locked_rule <> ''").
+		Distinct().Pluck("modules.course_id", &courseIDs).Error; err != nil {
+		return nil, err
+	}
+	var locked []uuid.UUID
+	for _, courseID := range courseIDs {
+		locks, err := courseModuleLocks(db, courseID, userID)
+		if err != nil {
+			return nil, err
+		}
+		for moduleID, lock := range locks {
+			if lock.Locked {
+				locked = append(locked, moduleID)
+			}
+		}
+	}
+	return locked, nil
+}
+
+// validateLockedRule checks a rule about to be saved on a module: it must
+// parse, reference only the module's own course, and not make modules wait
+// on each other in a cycle.
+func validateLockedRule(db *gorm.DB, module models.Module, raw string) error {
+	rule, err := gating.Parse(raw)
+	if err != nil {
+		return apperror.InvalidField("lockedRule", err.Error())
+	}
+	refs := rule.References()
+
+	if len(refs.Modules) > 0 {
+		var count int64
+		if err := db.Model(&models.Module{}).Where("id IN ? AND course_id = ?", refs.Modules, module.CourseID).Count(&count).Error; err != nil {
+			return apperror.Internal("Failed to validate lock rule", err)
+		}
+		if int(count) != len(distinct(refs.Modules)) {
+			return apperror.InvalidField("lockedRule", "Modules in the rule must belong to the same course")
+		}
+	}
+	if len(refs.Quizzes) > 0 {
+		var count int64
+		if err := db.Model(&models.Quiz{}).
+			Joins("JOIN study_packs ON study_packs.id = quizzes.study_pack_id").
+			Joins("JOIN materials ON materials.id = study_packs.material_id").
+			Joins("JOIN modules ON modules.id = materials.module_id").
+			Where("quizzes.id IN ? AND modules.course_id = ?", refs.Quizzes, module.CourseID).
+			Count(&count).Error; err != nil {
+			return apperror.Internal("Failed to validate lock rule", err)
+		}
+		if int(count) != len(distinct(refs.Quizzes)) {
+			return apperror.InvalidField("lockedRule", "Quizzes in the rule must belong to the same course")
+		}
+	}
+	if len(refs.Assignments) > 0 {
+		var count int64
+		if err := db.Model(&models.Assignment{}).Where("id IN ? AND course_id = ?", refs.Assignments, module.CourseID).Count(&count).Error; err != nil {
+			return apperror.Internal("Failed to validate lock rule", err)
+		}
+		if int(count) != len(distinct(refs.Assignments)) {
+			return apperror.InvalidField("lockedRule", "Assignments in the rule must belong to the same course")
+		}
+	}
+
+	// Waiting on a module in your own chain of prerequisites locks both
+	// for good.
+	var siblings []models.Module
+	if err := db.Where("course_id = ? AND id <> ?", module.CourseID, module.ID).Find(&siblings).Error; err != nil {
+		return apperror.Internal("Failed to validate lock rule", err)
+	}
+	dependsOn := map[uuid.UUID][]uuid.UUID{module.ID: refs.Modules}
+	for _, sibling := range siblings {
+		if rule := parseLockedRule(sibling); rule != nil {
+			dependsOn[sibling.ID] = rule.References().Modules
+		}
+	}
+	if _, found := gating.FindCycle(dependsOn); found {
+		return apperror.InvalidField("lockedRule", fmt.Sprintf("Module %q would wait on itself through MODULE_COMPLETED rules", module.Title))
+	}
+	return nil
+}
+
+func distinct(ids []uuid.UUID) map[uuid.UUID]bool {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return seen
+}
@@ -0,0 +1,793 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/gradebook"
+	"myway-backend/internal/models"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GradeCategoryRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	Weight     float64 `json:"weight" binding:"min=0"`
+	DropLowest int     `json:"dropLowest" binding:"min=0"`
+}
+
+type UpdateGradeCategoryRequest struct {
+	Name       *string  `json:"name" binding:"omitempty,min=1"`
+	Weight     *float64 `json:"weight" binding:"omitempty,min=0"`
+	DropLowest *int     `json:"dropLowest" binding:"omitempty,min=0"`
+	Position   *int     `json:"position" binding:"omitempty,min=0"`
+}
+
+type GradeScaleRequest struct {
+	// An empty scale restores the default.
+	Grades []gradebook.LetterGrade `json:"grades"`
+}
+
+type GradeOverrideRequest struct {
+	// ItemID names an assignment or quiz; omit it to override the final
+	// grade.
+	ItemID  *string  `json:"itemId"`
+	Percent *float64 `json:"percent" binding:"omitempty,min=0"`
+	Letter  *string  `json:"letter"`
+	Reason  *string  `json:"reason"`
+}
+
+type SetGradeCategoryRequest struct {
+	// CategoryID of null takes the quiz out of its category.
+	CategoryID *string `json:"categoryId"`
+}
+
+// GetGradeCategories lists a course's gradebook categories in order.
+func (h *CourseHandler) GetGradeCategories(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "STUDENT", "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	var categories []models.GradeCategory
+	if err := database.GetDB().Where("course_id = ?", course.ID).Scopes(byPosition).Find(&categories).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch grade categories", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGradeCategories(categories))
+}
+
+func (h *CourseHandler) CreateGradeCategory(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	var req GradeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		respondError(c, apperror.InvalidField("name", "Name is required"))
+		return
+	}
+
+	db := database.GetDB()
+	var position int
+	if err := db.Model(&models.GradeCategory{}).Where("course_id = ?", course.ID).
+		Select("COALESCE(MAX(position) + 1, 0)").Scan(&position).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to create grade category", err))
+		return
+	}
+	category := models.GradeCategory{
+		CourseID:   course.ID,
+		Name:       name,
+		Weight:     req.Weight,
+		DropLowest: req.DropLowest,
+		Position:   position,
+	}
+	if err := db.Create(&category).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to create grade category", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewGradeCategory(category))
+}
+
+// UpdateGradeCategory changes only the fields present in the request.
+func (h *CourseHandler) UpdateGradeCategory(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	category, ok := loadInstructorGradeCategory(c, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateGradeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+		if category.Name == "" {
+			respondError(c, apperror.InvalidField("name", "Name is required"))
+			return
+		}
+	}
+	if req.Weight != nil {
+		category.Weight = *req.Weight
+	}
+	if req.DropLowest != nil {
+		category.DropLowest = *req.DropLowest
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+
+	if err := database.GetDB().Model(category).Updates(map[string]interface{}{
+		"name":        category.Name,
+		"weight":      category.Weight,
+		"drop_lowest": category.DropLowest,
+		"position":    category.Position,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update grade category", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGradeCategory(*category))
+}
+
+// DeleteGradeCategory removes a category. Its assignments and quizzes stay
+// in the gradebook without a category.
+func (h *CourseHandler) DeleteGradeCategory(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	category, ok := loadInstructorGradeCategory(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Assignment{}).Where("grade_category_id = ?", category.ID).
+			Update("grade_category_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Quiz{}).Where("grade_category_id = ?", category.ID).
+			Update("grade_category_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to delete grade category", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Grade category deleted"})
+}
+
+// UpdateGradeScale replaces the letter scale of a course.
+func (h *CourseHandler) UpdateGradeScale(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	var req GradeScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	scale := gradebook.DefaultScale
+	var encoded *string
+	if len(req.Grades) > 0 {
+		scale = gradebook.Scale(req.Grades).Normalize()
+		if err := scale.Validate(); err != nil {
+			respondError(c, apperror.InvalidField("grades", err.Error()))
+			return
+		}
+		raw, err := json.Marshal(scale)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to encode grade scale", err))
+			return
+		}
+		value := string(raw)
+		encoded = &value
+	}
+
+	if err := database.GetDB().Model(course).Update("grade_scale", encoded).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update grade scale", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, scale)
+}
+
+// GetGradebook returns every enrolled student's grades in a course.
+func (h *CourseHandler) GetGradebook(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	book, err := buildGradebook(database.GetDB(), *course, nil)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to build gradebook", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
+
+// ExportGradebook returns the gradebook as CSV: one row per student with
+// the percentage on each item, each category, and the final grade.
+func (h *CourseHandler) ExportGradebook(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	book, err := buildGradebook(database.GetDB(), *course, nil)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to build gradebook", err))
+		return
+	}
+
+	header := []string{"Student", "Email"}
+	for _, item := range book.Items {
+		header = append(header, item.Title+" (%)")
+	}
+	for _, category := range book.Categories {
+		header = append(header, category.Name+" (%)")
+	}
+	header = append(header, "Final (%)", "Letter")
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-gradebook.csv"`, strings.ReplaceAll(course.Code, `"`, "")))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(csvRecord(header))
+	for _, row := range book.Rows {
+		record := []string{row.Name, row.Email}
+		for _, score := range row.Scores {
+			record = append(record, formatPercent(score.Percent))
+		}
+		for _, category := range row.Categories {
+			record = append(record, formatPercent(category.Percent))
+		}
+		letter := ""
+		if row.Letter != nil {
+			letter = *row.Letter
+		}
+		record = append(record, formatPercent(row.Percent), letter)
+		w.Write(csvRecord(record))
+	}
+	w.Flush()
+}
+
+// GetMyGrades returns the caller's own row of the gradebook.
+func (h *CourseHandler) GetMyGrades(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "STUDENT", "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	book, err := buildGradebook(database.GetDB(), *course, &userID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to build grades", err))
+		return
+	}
+	// Override reasons are notes for the teaching staff.
+	for i := range book.Rows {
+		book.Rows[i].Overrides = nil
+	}
+
+	c.JSON(http.StatusOK, book)
+}
+
+// SetGradeOverride replaces a student's grade on one item, or their final
+// grade, until the override is cleared.
+func (h *CourseHandler) SetGradeOverride(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+	studentID, ok := loadEnrolledStudent(c, course.ID)
+	if !ok {
+		return
+	}
+
+	var req GradeOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if req.Percent == nil && req.Letter == nil {
+		respondError(c, apperror.Validation("Set a percent or a letter"))
+		return
+	}
+
+	db := database.GetDB()
+	var itemID *uuid.UUID
+	if req.ItemID != nil && *req.ItemID != "" {
+		id, err := parseGradebookItem(db, course.ID, *req.ItemID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		itemID = &id
+		if req.Letter != nil {
+			respondError(c, apperror.InvalidField("letter", "Letters can only override the final grade"))
+			return
+		}
+		if req.Percent == nil {
+			respondError(c, apperror.InvalidField("percent", "Percent is required"))
+			return
+		}
+	}
+	if req.Letter != nil {
+		letter := strings.TrimSpace(*req.Letter)
+		if !slices.ContainsFunc(courseGradeScale(*course), func(g gradebook.LetterGrade) bool { return g.Letter == letter }) {
+			respondError(c, apperror.InvalidField("letter", "Letter is not part of this course's scale"))
+			return
+		}
+		req.Letter = &letter
+	}
+
+	override := models.GradeOverride{CourseID: course.ID, UserID: studentID, ItemID: itemID}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		// Serialize overrides of this student so there is one per item.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("course_id = ? AND user_id = ?", course.ID, studentID).
+			First(&models.Enrollment{}).Error; err != nil {
+			return err
+		}
+		query := tx.Where("course_id = ? AND user_id = ?", course.ID, studentID)
+		if itemID != nil {
+			query = query.Where("item_id = ?", *itemID)
+		} else {
+			query = query.Where("item_id IS NULL")
+		}
+		err := query.First(&override).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		override.Percent = req.Percent
+		override.Letter = req.Letter
+		override.Reason = emptyToNil(req.Reason)
+		override.OverriddenBy = userID
+		return tx.Save(&override).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to save grade override", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGradeOverride(override))
+}
+
+// ClearGradeOverride goes back to the computed grade. Without ?itemId it
+// clears the final grade override.
+func (h *CourseHandler) ClearGradeOverride(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	course, ok := loadGradebookCourse(c, userID, "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+	studentID, ok := loadEnrolledStudent(c, course.ID)
+	if !ok {
+		return
+	}
+
+	query := database.GetDB().Where("course_id = ? AND user_id = ?", course.ID, studentID)
+	if raw := c.Query("itemId"); raw != "" {
+		itemID, err := uuid.Parse(raw)
+		if err != nil {
+			respondError(c, apperror.InvalidField("itemId", "Invalid item ID"))
+			return
+		}
+		query = query.Where("item_id = ?", itemID)
+	} else {
+		query = query.Where("item_id IS NULL")
+	}
+	if err := query.Delete(&models.GradeOverride{}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to clear grade override", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Grade override cleared"})
+}
+
+// SetGradeCategory files a quiz under one of its course's gradebook
+// categories.
+func (h *QuizHandler) SetGradeCategory(c *gin.Context) {
+	quiz, ok := h.loadQuizForInstructor(c)
+	if !ok {
+		return
+	}
+
+	var req SetGradeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	categoryID, err := parseGradeCategory(database.GetDB(), quiz.StudyPack.Material.Module.CourseID, req.CategoryID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := database.GetDB().Model(quiz).Update("grade_category_id", categoryID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update quiz", err))
+		return
+	}
+	quiz.GradeCategoryID = categoryID
+
+	c.JSON(http.StatusOK, quizSettings(quiz))
+}
+
+// buildGradebook computes the gradebook of a course for its enrolled
+// students, or only for onlyUser when set.
+func buildGradebook(db *gorm.DB, course models.Course, onlyUser *uuid.UUID) (dto.Gradebook, error) {
+	book := dto.Gradebook{CourseID: course.ID, Scale: courseGradeScale(course)}
+
+	var categories []models.GradeCategory
+	if err := db.Where("course_id = ?", course.ID).Scopes(byPosition).Find(&categories).Error; err != nil {
+		return book, err
+	}
+	var assignments []models.Assignment
+	if err := db.Where("course_id = ?", course.ID).Order("due_at ASC, id").Find(&assignments).Error; err != nil {
+		return book, err
+	}
+	// Only published quizzes count, in course order.
+	var quizzes []models.Quiz
+	if err := db.
+		Joins("JOIN study_packs ON study_packs.id = quizzes.study_pack_id").
+		Joins("JOIN materials ON materials.id = study_packs.material_id AND materials.deleted_at IS NULL").
+		Joins("JOIN modules ON modules.id = materials.module_id AND modules.deleted_at IS NULL").
+		Where("modules.course_id = ? AND study_packs.status = ?", course.ID, "READY").
+		Preload("StudyPack.Material").
+		Order(`modules."order", materials.position, quizzes.id`).
+		Find(&quizzes).Error; err != nil {
+		return book, err
+	}
+
+	var users []models.User
+	students := db.Model(&models.Enrollment{}).Select("user_id").Where("course_id = ? AND role = ?", course.ID, "STUDENT")
+	if onlyUser != nil {
+		if err := db.Where("id = ?", *onlyUser).Find(&users).Error; err != nil {
+			return book, err
+		}
+	} else if err := db.Where("id IN (?)", students).Order("name ASC, id").Find(&users).Error; err != nil {
+		return book, err
+	}
+	userIDs := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		userIDs[i] = u.ID
+	}
+
+	assignmentIDs := make([]uuid.UUID, len(assignments))
+	for i, a := range assignments {
+		assignmentIDs[i] = a.ID
+	}
+	submissions := make(map[[2]uuid.UUID]models.Submission)
+	if len(assignmentIDs) > 0 && len(userIDs) > 0 {
+		var rows []models.Submission
+		if err := db.Where("assignment_id IN ? AND user_id IN ?", assignmentIDs, userIDs).Find(&rows).Error; err != nil {
+			return book, err
+		}
+		for _, s := range rows {
+			submissions[[2]uuid.UUID{s.AssignmentID, s.UserID}] = s
+		}
+	}
+
+	quizIDs := make([]uuid.UUID, len(quizzes))
+	for i, q := range quizzes {
+		quizIDs[i] = q.ID
+	}
+	// A quiz counts with the student's best submitted attempt.
+	bestAttempts := make(map[[2]uuid.UUID]models.QuizAttempt)
+	if len(quizIDs) > 0 && len(userIDs) > 0 {
+		var rows []models.QuizAttempt
+		if err := db.Where("quiz_id IN ? AND user_id IN ? AND status = ?", quizIDs, userIDs, "SUBMITTED").
+			Order("score DESC, created_at ASC").Find(&rows).Error; err != nil {
+			return book, err
+		}
+		for _, a := range rows {
+			key := [2]uuid.UUID{a.QuizID, a.UserID}
+			if _, ok := bestAttempts[key]; !ok {
+				bestAttempts[key] = a
+			}
+		}
+	}
+
+	overrides := make(map[uuid.UUID][]models.GradeOverride)
+	if len(userIDs) > 0 {
+		var rows []models.GradeOverride
+		if err := db.Where("course_id = ? AND user_id IN ?", course.ID, userIDs).Find(&rows).Error; err != nil {
+			return book, err
+		}
+		for _, o := range rows {
+			overrides[o.UserID] = append(overrides[o.UserID], o)
+		}
+	}
+
+	book.Categories = dto.NewGradeCategories(categories)
+	gradebookCategories := make([]gradebook.Category, len(categories))
+	for i, category := range categories {
+		gradebookCategories[i] = gradebook.Category{ID: category.ID.String(), Weight: category.Weight, DropLowest: category.DropLowest}
+	}
+	book.Items = make([]dto.GradebookItem, 0, len(assignments)+len(quizzes))
+	for _, a := range assignments {
+		points := float64(a.Points)
+		dueAt := a.DueAt
+		book.Items = append(book.Items, dto.GradebookItem{ID: a.ID, Type: "ASSIGNMENT", Title: a.Title, CategoryID: a.GradeCategoryID, Points: &points, DueAt: &dueAt})
+	}
+	for _, q := range quizzes {
+		book.Items = append(book.Items, dto.GradebookItem{ID: q.ID, Type: "QUIZ", Title: q.StudyPack.Material.Title, CategoryID: q.GradeCategoryID})
+	}
+
+	book.Rows = make([]dto.GradebookRow, 0, len(users))
+	for _, user := range users {
+		itemOverrides := make(map[uuid.UUID]models.GradeOverride)
+		var finalOverride *models.GradeOverride
+		for _, o := range overrides[user.ID] {
+			if o.ItemID == nil {
+				finalOverride = &o
+			} else {
+				itemOverrides[*o.ItemID] = o
+			}
+		}
+
+		items := make([]gradebook.Item, 0, len(book.Items))
+		for _, a := range assignments {
+			item := gradebook.Item{ID: a.ID.String(), Possible: float64(a.Points)}
+			if a.GradeCategoryID != nil {
+				item.CategoryID = a.GradeCategoryID.String()
+			}
+			// Grades given before scores were numeric are left out.
+			if s, ok := submissions[[2]uuid.UUID{a.ID, user.ID}]; ok && s.Grade != nil {
+				if score, err := strconv.Atoi(*s.Grade); err == nil {
+					item.Earned = float64(score)
+					item.Graded = true
+				}
+			}
+			items = append(items, item)
+		}
+		for _, q := range quizzes {
+			item := gradebook.Item{ID: q.ID.String(), Possible: 100}
+			if q.GradeCategoryID != nil {
+				item.CategoryID = q.GradeCategoryID.String()
+			}
+			if a, ok := bestAttempts[[2]uuid.UUID{q.ID, user.ID}]; ok {
+				item.Graded = true
+				item.Earned = float64(a.Score)
+				if a.PointsPossible > 0 {
+					item.Earned = a.PointsEarned
+					item.Possible = a.PointsPossible
+				}
+			}
+			items = append(items, item)
+		}
+
+		overridden := make(map[string]bool)
+		for i, item := range items {
+			if o, ok := itemOverrides[book.Items[i].ID]; ok && o.Percent != nil {
+				items[i].Earned = *o.Percent / 100 * item.Possible
+				items[i].Graded = true
+				overridden[item.ID] = true
+			}
+		}
+
+		result := gradebook.Compute(gradebookCategories, items, book.Scale)
+		dropped := make(map[string]bool)
+		row := dto.GradebookRow{
+			UserID:          user.ID,
+			Name:            user.Name,
+			Email:           user.Email,
+			Scores:          make([]dto.GradebookScore, len(items)),
+			Categories:      make([]dto.GradebookCategoryScore, len(categories)),
+			ComputedPercent: result.Percent,
+			Percent:         result.Percent,
+			Overrides:       dto.NewGradeOverrides(overrides[user.ID]),
+		}
+		for i, categoryResult := range result.Categories {
+			row.Categories[i] = dto.GradebookCategoryScore{CategoryID: categories[i].ID, Percent: categoryResult.Percent}
+			for _, id := range categoryResult.Dropped {
+				dropped[id] = true
+			}
+		}
+		for i, item := range items {
+			score := dto.GradebookScore{ItemID: book.Items[i].ID, Dropped: dropped[item.ID], Overridden: overridden[item.ID]}
+			if item.Graded {
+				earned, possible, percent := gradebook.Round(item.Earned), item.Possible, gradebook.Round(item.Percent())
+				score.Earned, score.Possible, score.Percent = &earned, &possible, &percent
+			}
+			row.Scores[i] = score
+		}
+		if result.Letter != "" {
+			letter := result.Letter
+			row.ComputedLetter = &letter
+			row.Letter = &letter
+		}
+		if finalOverride != nil {
+			if finalOverride.Percent != nil {
+				percent := gradebook.Round(*finalOverride.Percent)
+				letter := book.Scale.Letter(percent)
+				row.Percent = &percent
+				row.Letter = &letter
+			}
+			if finalOverride.Letter != nil {
+				row.Letter = finalOverride.Letter
+			}
+		}
+		book.Rows = append(book.Rows, row)
+	}
+	return book, nil
+}
+
+// courseGradeScale is the course's letter scale, or the default one.
+func courseGradeScale(course models.Course) gradebook.Scale {
+	if course.GradeScale != nil {
+		var scale gradebook.Scale
+		if err := json.Unmarshal([]byte(*course.GradeScale), &scale); err == nil && len(scale) > 0 {
+			return scale
+		}
+	}
+	return gradebook.DefaultScale
+}
+
+// parseGradeCategory resolves a category ID sent for an item of courseID.
+// Null or empty means no category.
+func parseGradeCategory(db *gorm.DB, courseID uuid.UUID, raw *string) (*uuid.UUID, error) {
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*raw)
+	if err != nil {
+		return nil, apperror.InvalidField("categoryId", "Invalid category ID")
+	}
+	var count int64
+	if err := db.Model(&models.GradeCategory{}).Where("id = ? AND course_id = ?", id, courseID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, apperror.InvalidField("categoryId", "Category not found in this course")
+	}
+	return &id, nil
+}
+
+// parseGradebookItem resolves an assignment or quiz ID of courseID.
+func parseGradebookItem(db *gorm.DB, courseID uuid.UUID, raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, apperror.InvalidField("itemId", "Invalid item ID")
+	}
+	var count int64
+	if err := db.Model(&models.Assignment{}).Where("id = ? AND course_id = ?", id, courseID).Count(&count).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if count == 0 {
+		if err := db.Model(&models.Quiz{}).
+			Joins("JOIN study_packs ON study_packs.id = quizzes.study_pack_id").
+			Joins("JOIN materials ON materials.id = study_packs.material_id").
+			Joins("JOIN modules ON modules.id = materials.module_id").
+			Where("quizzes.id = ? AND modules.course_id = ?", id, courseID).
+			Count(&count).Error; err != nil {
+			return uuid.Nil, err
+		}
+	}
+	if count == 0 {
+		return uuid.Nil, apperror.InvalidField("itemId", "No assignment or quiz with this ID in the course")
+	}
+	return id, nil
+}
+
+// loadGradebookCourse loads the course named by :id if the caller has one
+// of roles in its organization and can see it.
+func loadGradebookCourse(c *gin.Context, userID uuid.UUID, roles ...string) (*models.Course, bool) {
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
+		return nil, false
+	}
+	var course models.Course
+	if err := database.GetDB().First(&course, courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, course.OrgID, roles...); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	if err := requireCourseVisible(userID, course); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return &course, true
+}
+
+// loadEnrolledStudent parses :userId and checks that they are enrolled in
+// the course.
+func loadEnrolledStudent(c *gin.Context, courseID uuid.UUID) (uuid.UUID, bool) {
+	studentID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("userId", "Invalid user ID"))
+		return uuid.Nil, false
+	}
+	var count int64
+	if err := database.GetDB().Model(&models.Enrollment{}).Where("course_id = ? AND user_id = ?", courseID, studentID).Count(&count).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to check enrollment", err))
+		return uuid.Nil, false
+	}
+	if count == 0 {
+		respondError(c, apperror.NotFound("Student is not enrolled in this course"))
+		return uuid.Nil, false
+	}
+	return studentID, true
+}
+
+// loadInstructorGradeCategory loads the category named by :id if the
+// caller teaches or organizes its course's organization.
+func loadInstructorGradeCategory(c *gin.Context, userID uuid.UUID) (*models.GradeCategory, bool) {
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid category ID"))
+		return nil, false
+	}
+	var category models.GradeCategory
+	if err := database.GetDB().First(&category, categoryID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Grade category not found"))
+		return nil, false
+	}
+	var course models.Course
+	if err := database.GetDB().First(&course, category.CourseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Grade category not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return &category, true
+}
+
+func formatPercent(percent *float64) string {
+	if percent == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*percent, 'f', -1, 64)
+}
+
+// csvRecord keeps spreadsheet applications from evaluating cells that
+// start like a formula.
+func csvRecord(fields []string) []string {
+	out := make([]string, len(fields))
+	for i, field := range fields {
+		if field != "" && strings.ContainsRune("=+-@", rune(field[0])) {
+			field = "'" + field
+		}
+		out[i] = field
+	}
+	return out
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
+	"myway-backend/internal/review"
 	"net/http"
 	"regexp"
 	"strings"
@@ -15,8 +21,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kkdai/youtube/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// youtubeMetadataTimeout bounds the lookup of an imported video's length.
+const youtubeMetadataTimeout = 30 * time.Second
+
 type ImportsHandler struct{}
 
 func NewImportsHandler() *ImportsHandler {
@@ -34,58 +45,47 @@ func (h *ImportsHandler) ImportYouTube(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	var req ImportYouTubeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	courseID, err := uuid.Parse(req.CourseID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
 	// Resolve module ID
-	var moduleID uuid.UUID
-	if req.ModuleID != nil {
-		moduleID, err = uuid.Parse(*req.ModuleID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
-			return
-		}
-	} else {
-		// Find or create Resources module
-		var module models.Module
-		result := database.GetDB().Where("course_id = ? AND title = ?", courseID, "Resources").First(&module)
-		if result.Error != nil {
-			// Create Resources module
-			module = models.Module{
-				CourseID: courseID,
-				Title:    "Resources",
-				Order:    999,
-			}
-			database.GetDB().Create(&module)
-		}
-		moduleID = module.ID
+	moduleID, err := resolveImportModule(courseID, req.ModuleID)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
 	// Validate YouTube URL
 	if !isValidYouTubeURL(req.YouTubeURL) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YouTube URL"})
+		respondError(c, apperror.InvalidField("youtubeUrl", "Invalid YouTube URL"))
 		return
 	}
 
 	// Create material with QUEUED status
+	position, err := nextMaterialPosition(database.GetDB(), moduleID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to create material", err))
+		return
+	}
 	material := models.Material{
 		ModuleID:       moduleID,
 		Type:           "VIDEO",
 		Title:          "YouTube Import",
 		SourceURL:      &req.YouTubeURL,
 		TranscriptText: req.Transcript,
+		Position:       position,
 	}
 
 	if err := database.GetDB().Create(&material).Error; err != nil {
 		log.Printf("Error creating material: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create material"})
+		respondError(c, apperror.Internal("Failed to create material", err))
 		return
 	}
 
@@ -104,12 +104,16 @@ func (h *ImportsHandler) ImportYouTube(c *gin.Context) {
 
 	if err := database.GetDB().Create(&studyPack).Error; err != nil {
 		log.Printf("Error creating study pack: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study pack"})
+		respondError(c, apperror.Internal("Failed to create study pack", err))
 		return
 	}
 
 	log.Printf("Created material %s with study pack %s, status: %s", material.ID, studyPack.ID, status)
 
+	if videoID := extractVideoID(req.YouTubeURL); videoID != "" {
+		go recordYouTubeDuration(material.ID, videoID)
+	}
+
 	// If transcript provided, process immediately
 	if req.Transcript != nil && *req.Transcript != "" {
 		go h.processStudyPack(studyPack.ID, *req.Transcript)
@@ -119,7 +123,7 @@ func (h *ImportsHandler) ImportYouTube(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"material": material,
+		"material": dto.NewMaterial(material),
 		"studyPack": gin.H{
 			"id":     studyPack.ID,
 			"status": studyPack.Status,
@@ -127,14 +131,91 @@ func (h *ImportsHandler) ImportYouTube(c *gin.Context) {
 	})
 }
 
+// recordYouTubeDuration stores the length of an imported video from its
+// YouTube metadata. Until the length is known, either from here or from an
+// instructor, watch heartbeats for the video are refused.
+func recordYouTubeDuration(materialID uuid.UUID, videoID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), youtubeMetadataTimeout)
+	defer cancel()
+
+	client := youtube.Client{}
+	video, err := client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		log.Printf("Failed to fetch length of video %s for material %s: %v", videoID, materialID, err)
+		return
+	}
+	if video.Duration <= 0 {
+		log.Printf("YouTube reported no length for video %s", videoID)
+		return
+	}
+	// An instructor may have set the length in the meantime
+	if err := database.GetDB().Model(&models.Material{}).
+		Where("id = ? AND duration_sec IS NULL", materialID).
+		Update("duration_sec", video.Duration.Seconds()).Error; err != nil {
+		log.Printf("Failed to record length of material %s: %v", materialID, err)
+	}
+}
+
 func isValidYouTubeURL(url string) bool {
-	return len(url) > 0 && (url[:16] == "https://youtu.be" || url[:23] == "https://www.youtube.com" || url[:17] == "https://youtube.com")
+	return strings.HasPrefix(url, "https://youtu.be") || strings.HasPrefix(url, "https://www.youtube.com") || strings.HasPrefix(url, "https://youtube.com")
+}
+
+// resolveImportModule returns the requested module, which must belong to
+// the course, or finds or creates the course's Resources module, placed
+// last, when none is given. The course row is locked so that concurrent
+// imports agree on a single Resources module.
+func resolveImportModule(courseID uuid.UUID, rawModuleID *string) (uuid.UUID, error) {
+	if rawModuleID != nil {
+		moduleID, err := uuid.Parse(*rawModuleID)
+		if err != nil {
+			return uuid.Nil, apperror.InvalidField("moduleId", "Invalid module ID")
+		}
+		var module models.Module
+		if err := database.GetDB().Where("id = ? AND course_id = ?", moduleID, courseID).First(&module).Error; err != nil {
+			return uuid.Nil, apperror.FromDB(err, "Module not found in this course")
+		}
+		return module.ID, nil
+	}
+
+	var module models.Module
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var course models.Course
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&course, courseID).Error; err != nil {
+			return apperror.FromDB(err, "Course not found")
+		}
+
+		err := tx.Where("course_id = ? AND title = ?", courseID, "Resources").Order(`"order"`).First(&module).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.Internal("Failed to resolve module", err)
+		}
+
+		order, err := nextModuleOrder(tx, courseID)
+		if err != nil {
+			return apperror.Internal("Failed to resolve module", err)
+		}
+		module = models.Module{
+			CourseID: courseID,
+			Title:    "Resources",
+			Order:    order,
+		}
+		if err := tx.Create(&module).Error; err != nil {
+			return apperror.Internal("Failed to create Resources module", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return module.ID, nil
 }
 
 func (h *ImportsHandler) GetImportStatus(c *gin.Context) {
 	materialID, err := uuid.Parse(c.Param("materialId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		respondError(c, apperror.InvalidField("materialId", "Invalid material ID"))
 		return
 	}
 
@@ -143,7 +224,7 @@ func (h *ImportsHandler) GetImportStatus(c *gin.Context) {
 		Where("material_id = ?", materialID).
 		Order("created_at DESC").
 		First(&studyPack).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Study pack not found"})
+		respondError(c, apperror.FromDB(err, "Study pack not found"))
 		return
 	}
 
@@ -166,37 +247,21 @@ func (h *ImportsHandler) ImportDocument(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	var req ImportDocumentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	courseID, err := uuid.Parse(req.CourseID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
 	// Resolve module ID
-	var moduleID uuid.UUID
-	if req.ModuleID != nil {
-		moduleID, err = uuid.Parse(*req.ModuleID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
-			return
-		}
-	} else {
-		// Find or create Resources module
-		var module models.Module
-		result := database.GetDB().Where("course_id = ? AND title = ?", courseID, "Resources").First(&module)
-		if result.Error != nil {
-			module = models.Module{
-				CourseID: courseID,
-				Title:    "Resources",
-				Order:    999,
-			}
-			database.GetDB().Create(&module)
-		}
-		moduleID = module.ID
+	moduleID, err := resolveImportModule(courseID, req.ModuleID)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
 	// Determine file type
@@ -209,16 +274,22 @@ func (h *ImportsHandler) ImportDocument(c *gin.Context) {
 	}
 
 	// Create material
+	position, err := nextMaterialPosition(database.GetDB(), moduleID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to create material", err))
+		return
+	}
 	material := models.Material{
 		ModuleID: moduleID,
 		Type:     fileType,
 		Title:    req.Title,
 		FileURL:  &req.FileURL,
+		Position: position,
 	}
 
 	if err := database.GetDB().Create(&material).Error; err != nil {
 		log.Printf("Error creating material: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create material"})
+		respondError(c, apperror.Internal("Failed to create material", err))
 		return
 	}
 
@@ -232,7 +303,7 @@ func (h *ImportsHandler) ImportDocument(c *gin.Context) {
 
 	if err := database.GetDB().Create(&studyPack).Error; err != nil {
 		log.Printf("Error creating study pack: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study pack"})
+		respondError(c, apperror.Internal("Failed to create study pack", err))
 		return
 	}
 
@@ -242,7 +313,7 @@ func (h *ImportsHandler) ImportDocument(c *gin.Context) {
 	go h.processDocumentStudyPack(studyPack.ID, req.FileURL)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"material": material,
+		"material": dto.NewMaterial(material),
 		"studyPack": gin.H{
 			"id":     studyPack.ID,
 			"status": studyPack.Status,
@@ -254,13 +325,18 @@ func (h *ImportsHandler) processStudyPack(studyPackID uuid.UUID, transcript stri
 	log.Printf("Processing study pack %s", studyPackID)
 
 	// Update status to PROCESSING
-	database.GetDB().Model(&models.StudyPack{}).Where("id = ?", studyPackID).Update("status", "PROCESSING")
+	if err := database.GetDB().Model(&models.StudyPack{}).Where("id = ?", studyPackID).Update("status", "PROCESSING").Error; err != nil {
+		log.Printf("Error marking study pack %s as PROCESSING: %v", studyPackID, err)
+	}
 
 	// Simulate processing delay
 	time.Sleep(2 * time.Second)
 
 	// Generate study content
-	h.generateStudyContentSeed(studyPackID)
+	if err := h.generateStudyContentSeed(studyPackID); err != nil {
+		markStudyPackFailed(studyPackID, err)
+		return
+	}
 
 	log.Printf("Study pack %s processed successfully", studyPackID)
 }
@@ -275,12 +351,22 @@ func (h *ImportsHandler) processDocumentStudyPack(studyPackID uuid.UUID, fileURL
 
 	// For MVP, simulate with generated seed content
 	time.Sleep(2 * time.Second)
-	h.generateStudyContentSeed(studyPackID)
+	if err := h.generateStudyContentSeed(studyPackID); err != nil {
+		markStudyPackFailed(studyPackID, err)
+		return
+	}
 
 	log.Printf("Document study pack %s processed successfully", studyPackID)
 }
 
-func (h *ImportsHandler) generateStudyContentSeed(studyPackID uuid.UUID) {
+func markStudyPackFailed(studyPackID uuid.UUID, cause error) {
+	log.Printf("Study pack %s generation failed: %v", studyPackID, cause)
+	if err := database.GetDB().Model(&models.StudyPack{}).Where("id = ?", studyPackID).Update("status", "FAILED").Error; err != nil {
+		log.Printf("Error marking study pack %s as FAILED: %v", studyPackID, err)
+	}
+}
+
+func (h *ImportsHandler) generateStudyContentSeed(studyPackID uuid.UUID) error {
 	// Create generated summary
 	summaryContent := map[string]interface{}{
 		"summary": "This is a generated summary of the material.",
@@ -296,7 +382,9 @@ func (h *ImportsHandler) generateStudyContentSeed(studyPackID uuid.UUID) {
 		StudyPackID: studyPackID,
 		Content:     string(summaryJSON),
 	}
-	database.GetDB().Create(&summary)
+	if err := database.GetDB().Create(&summary).Error; err != nil {
+		return err
+	}
 
 	// Create generated quiz
 	quiz := models.Quiz{
@@ -304,7 +392,9 @@ func (h *ImportsHandler) generateStudyContentSeed(studyPackID uuid.UUID) {
 		Version:     1,
 		Metadata:    `{"difficulty":"Adaptive"}`,
 	}
-	database.GetDB().Create(&quiz)
+	if err := database.GetDB().Create(&quiz).Error; err != nil {
+		return err
+	}
 
 	// Add quiz questions
 	question := models.QuizQuestion{
@@ -315,7 +405,9 @@ func (h *ImportsHandler) generateStudyContentSeed(studyPackID uuid.UUID) {
 		AnswerKey:   `"To solve a specific problem"`,
 		Explanation: strPtr("Every educational topic aims to solve problems."),
 	}
-	database.GetDB().Create(&question)
+	if err := database.GetDB().Create(&question).Error; err != nil {
+		return err
+	}
 
 	// Create flashcards
 	flashcard1 := models.Flashcard{
@@ -323,16 +415,58 @@ func (h *ImportsHandler) generateStudyContentSeed(studyPackID uuid.UUID) {
 		Front:       "Define 'Abstraction'",
 		Back:        "Hiding complex reality while exposing only necessary parts.",
 	}
-	database.GetDB().Create(&flashcard1)
+	if err := database.GetDB().Create(&flashcard1).Error; err != nil {
+		return err
+	}
+
+	var studyPack models.StudyPack
+	if err := database.GetDB().Preload("Material.Module.Course").First(&studyPack, "id = ?", studyPackID).Error; err != nil {
+		return err
+	}
+	policy, err := packReviewPolicy(&studyPack)
+	if err != nil {
+		return err
+	}
+
+	// Organizations that require review get a draft; others publish
+	// straight away.
+	if policy.RequireReview {
+		if err := database.GetDB().Model(&studyPack).Updates(map[string]interface{}{
+			"status":            "GENERATED",
+			"review_status":     review.Draft,
+			"requires_approval": true,
+		}).Error; err != nil {
+			return err
+		}
+		if _, err := recordRevision(database.GetDB(), models.StudyPackRevision{
+			StudyPackID: studyPackID,
+			AuthorType:  revisionAuthorAI,
+		}); err != nil {
+			return err
+		}
+		log.Printf("Study pack %s generated and awaiting review", studyPackID)
+		return nil
+	}
 
-	// Update study pack status to READY
 	now := time.Now()
-	database.GetDB().Model(&models.StudyPack{}).Where("id = ?", studyPackID).Updates(map[string]interface{}{
-		"status":       "READY",
-		"published_at": &now,
-	})
+	if err := database.GetDB().Model(&studyPack).Updates(map[string]interface{}{
+		"status":        "READY",
+		"review_status": review.Published,
+		"published_at":  &now,
+	}).Error; err != nil {
+		return err
+	}
+
+	if _, err := recordRevision(database.GetDB(), models.StudyPackRevision{
+		StudyPackID: studyPackID,
+		AuthorType:  revisionAuthorAI,
+		Published:   true,
+	}); err != nil {
+		return err
+	}
 
 	log.Printf("Study pack %s marked as READY", studyPackID)
+	return nil
 }
 
 func strPtr(s string) *string {
@@ -343,7 +477,7 @@ func strPtr(s string) *string {
 func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 	videoURL := c.Query("url")
 	if videoURL == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'url' query parameter"})
+		respondError(c, apperror.InvalidField("url", "Missing 'url' query parameter"))
 		return
 	}
 
@@ -352,7 +486,7 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 	// Extract video ID from URL
 	videoID := extractVideoID(videoURL)
 	if videoID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YouTube URL - could not extract video ID"})
+		respondError(c, apperror.InvalidField("url", "Invalid YouTube URL - could not extract video ID"))
 		return
 	}
 
@@ -365,10 +499,7 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 	video, err := client.GetVideo(videoID)
 	if err != nil {
 		log.Printf("Error fetching video: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch video details",
-			"details": err.Error(),
-		})
+		respondError(c, apperror.Upstream("Failed to fetch video details", err))
 		return
 	}
 
@@ -377,10 +508,7 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 	// Get captions/transcripts
 	if len(video.CaptionTracks) == 0 {
 		log.Printf("No captions available for video %s", videoID)
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "No captions available for this video",
-			"message": "This video does not have captions/subtitles enabled. Please provide the transcript manually or choose a different video.",
-		})
+		respondError(c, apperror.NotFound("No captions available for this video. Please provide the transcript manually or choose a different video."))
 		return
 	}
 
@@ -417,20 +545,14 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 
 		// Fallback: Manually fetch the transcript URL
 		if selectedTrack.BaseURL == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to fetch transcript and no BaseURL available",
-				"details": err.Error(),
-			})
+			respondError(c, apperror.Upstream("Failed to fetch transcript and no BaseURL available", err))
 			return
 		}
 
 		resp, err := http.Get(selectedTrack.BaseURL)
 		if err != nil {
 			log.Printf("Manual fetch failed: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to manually fetch transcript",
-				"details": err.Error(),
-			})
+			respondError(c, apperror.Upstream("Failed to manually fetch transcript", err))
 			return
 		}
 		defer resp.Body.Close()
@@ -438,17 +560,14 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			log.Printf("Manual fetch returned status %d: %s", resp.StatusCode, string(body))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":  "Manual fetch returned error status",
-				"status": resp.StatusCode,
-			})
+			respondError(c, apperror.Upstream("Manual fetch returned error status", fmt.Errorf("status %d", resp.StatusCode)))
 			return
 		}
 
 		// Parse XML - Generic Token Parser
 		// This handles any XML structure (transcript/text, timedtext/body/p, etc)
 		// It simply extracts all character data from the XML
-		
+
 		// Create a decoder for the response body
 		decoder := xml.NewDecoder(resp.Body)
 		for {
@@ -458,9 +577,9 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 			}
 			if err != nil {
 				log.Printf("XML decode error: %v", err)
-				break 
+				break
 			}
-			
+
 			// For every CharData token (text content), append it
 			switch se := t.(type) {
 			case xml.CharData:
@@ -472,7 +591,7 @@ func (h *ImportsHandler) GetYouTubeTranscript(c *gin.Context) {
 				}
 			}
 		}
-		
+
 		log.Printf("Manual fetch successful! Extracted chars: %d", len(transcriptText))
 	}
 
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/trash"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ModuleHandler struct{}
@@ -23,15 +30,16 @@ type CreateModuleRequest struct {
 }
 
 func (h *ModuleHandler) CreateModule(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
 	var req CreateModuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	courseID, err := uuid.Parse(req.CourseID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
@@ -41,75 +49,142 @@ func (h *ModuleHandler) CreateModule(c *gin.Context) {
 		Order:      req.Order,
 		LockedRule: req.LockedRule,
 	}
+	if req.LockedRule != nil && *req.LockedRule == "" {
+		module.LockedRule = nil
+	}
+	var course models.Course
+	if err := database.GetDB().First(&course, courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireCourseOpen(database.GetDB(), courseID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if module.LockedRule != nil {
+		if err := validateLockedRule(database.GetDB(), module, *module.LockedRule); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
 
 	if err := database.GetDB().Create(&module).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create module"})
+		respondError(c, apperror.Internal("Failed to create module", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, module)
+	c.JSON(http.StatusCreated, dto.NewModule(module))
 }
 
 func (h *ModuleHandler) GetModulesByCourse(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
 	courseID, err := uuid.Parse(c.Param("courseId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
-	var modules []models.Module
-	if err := database.GetDB().
-		Preload("Materials").
-		Where("course_id = ?", courseID).
-		Order("`order` ASC").
-		Find(&modules).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch modules"})
+	query, err := pagination.Parse(c, moduleListSpec)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	db := database.GetDB()
+	var course models.Course
+	if err := db.First(&course, courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireCourseVisible(userID, course); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	page, err := query.Find(db.Where("course_id = ?", courseID), &models.Module{}, "Materials")
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch modules", err))
+		return
+	}
+	locks, err := courseModuleLocks(db, courseID, userID)
+	if err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
 		return
 	}
 
-	c.JSON(http.StatusOK, modules)
+	for i := range page.Items {
+		sortByPosition(page.Items[i].Materials)
+	}
+	result := pagination.Map(page, dto.NewModule)
+	applyModuleLocks(result.Items, locks)
+	c.JSON(http.StatusOK, result)
+}
+
+var moduleListSpec = pagination.Spec[models.Module]{
+	Sorts: map[string]pagination.SortField[models.Module]{
+		"order": {Column: `"order"`, Kind: pagination.KindInt, Value: func(m models.Module) interface{} { return m.Order }},
+		"title": {Column: "title", Value: func(m models.Module) interface{} { return m.Title }},
+	},
+	DefaultSort: "order",
+	ID:          func(m models.Module) uuid.UUID { return m.ID },
 }
 
 func (h *ModuleHandler) GetModule(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
 	moduleID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid module ID"))
 		return
 	}
 
 	var module models.Module
 	if err := database.GetDB().
+		Preload("Materials", byPosition).
 		Preload("Materials.StudyPacks").
 		Preload("Course").
 		First(&module, moduleID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+		respondError(c, apperror.FromDB(err, "Module not found"))
+		return
+	}
+	if err := requireCourseVisible(userID, module.Course); err != nil {
+		respondError(c, err)
+		return
+	}
+	locks, err := courseModuleLocks(database.GetDB(), module.CourseID, userID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to evaluate module lock", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, module)
+	result := []dto.Module{dto.NewModule(module)}
+	applyModuleLocks(result, locks)
+	c.JSON(http.StatusOK, result[0])
+}
+
+type UpdateModuleRequest struct {
+	Title      *string `json:"title"`
+	Order      *int    `json:"order"`
+	LockedRule *string `json:"lockedRule"`
 }
 
 func (h *ModuleHandler) UpdateModule(c *gin.Context) {
-	moduleID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+	userID := c.MustGet("userID").(uuid.UUID)
+	module, ok := loadInstructorModule(c, userID)
+	if !ok {
 		return
 	}
 
-	var req struct {
-		Title      *string `json:"title"`
-		Order      *int    `json:"order"`
-		LockedRule *string `json:"lockedRule"`
-	}
-
+	var req UpdateModuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
-
-	var module models.Module
-	if err := database.GetDB().First(&module, moduleID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+	if err := requireCourseOpen(database.GetDB(), module.CourseID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -121,28 +196,375 @@ func (h *ModuleHandler) UpdateModule(c *gin.Context) {
 		updates["order"] = *req.Order
 	}
 	if req.LockedRule != nil {
-		updates["locked_rule"] = *req.LockedRule
+		// An empty rule removes the lock.
+		if *req.LockedRule == "" {
+			updates["locked_rule"] = nil
+		} else {
+			if err := validateLockedRule(database.GetDB(), *module, *req.LockedRule); err != nil {
+				respondError(c, err)
+				return
+			}
+			updates["locked_rule"] = *req.LockedRule
+		}
 	}
 
-	if err := database.GetDB().Model(&module).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update module"})
+	if err := database.GetDB().Model(module).Updates(updates).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update module", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, module)
+	c.JSON(http.StatusOK, dto.NewModule(*module))
 }
 
 func (h *ModuleHandler) DeleteModule(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
 	moduleID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid module ID"))
+		return
+	}
+
+	var module models.Module
+	if err := database.GetDB().Preload("Course").First(&module, moduleID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Module not found"))
+		return
+	}
+
+	var membership models.OrgMembership
+	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, module.Course.OrgID, "Active").First(&membership).Error; err != nil {
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
+		return
+	}
+	if membership.Role != "TEACHER" && membership.Role != "ORGANIZER" {
+		respondError(c, apperror.Forbidden("Only teachers and organizers can delete modules"))
+		return
+	}
+
+	// Materials and their study packs go to the trash with the module.
+	if _, err := trash.Move(database.GetDB(), trash.EntityModule, module.ID, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Module moved to trash"})
+}
+
+// loadInstructorModule loads the module named by :id if the caller teaches
+// or organizes its course's organization, responding with an error
+// otherwise.
+func loadInstructorModule(c *gin.Context, userID uuid.UUID) (*models.Module, bool) {
+	moduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid module ID"))
+		return nil, false
+	}
+	var module models.Module
+	if err := database.GetDB().Preload("Course").First(&module, moduleID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Module not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, module.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return &module, true
+}
+
+// ReorderModules sets the order of every module in a course at once.
+func (h *ModuleHandler) ReorderModules(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
+		return
+	}
+	var course models.Course
+	if err := database.GetDB().First(&course, courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	var modules []models.Module
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Lock the course so modules added meanwhile cannot slip between
+		// the check and the renumbering.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&course, "id = ?", course.ID).Error; err != nil {
+			return err
+		}
+		if err := reorderColumn(tx, &models.Module{}, "order", "course_id", course.ID, req.IDs); err != nil {
+			return err
+		}
+		return tx.Where("course_id = ?", course.ID).Scopes(byOrder).Find(&modules).Error
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewModules(modules))
+}
+
+// ReorderMaterials sets the position of every material in a module at
+// once.
+func (h *ModuleHandler) ReorderMaterials(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	module, ok := loadInstructorModule(c, userID)
+	if !ok {
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(module, "id = ?", module.ID).Error; err != nil {
+			return err
+		}
+		if err := reorderColumn(tx, &models.Material{}, "position", "module_id", module.ID, req.IDs); err != nil {
+			return err
+		}
+		return tx.Where("module_id = ?", module.ID).Scopes(byPosition).Find(&module.Materials).Error
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewModule(*module))
+}
+
+type MoveMaterialRequest struct {
+	ModuleID uuid.UUID `json:"moduleId" binding:"required"`
+	// Position defaults to the end of the target module.
+	Position *int `json:"position" binding:"omitempty,min=0"`
+}
+
+// MoveMaterial moves a material to another module of the same course, or
+// to another position in its own module. Both modules are renumbered so
+// positions stay contiguous.
+func (h *ModuleHandler) MoveMaterial(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	materialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid material ID"))
+		return
+	}
+
+	var req MoveMaterialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	var material models.Material
+	if err := database.GetDB().Preload("Module.Course").First(&material, materialID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Material not found"))
+		return
+	}
+	if err := requireOrgRole(userID, material.Module.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+	var target models.Module
+	if err := database.GetDB().First(&target, req.ModuleID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Module not found"))
+		return
+	}
+	if target.CourseID != material.Module.CourseID {
+		respondError(c, apperror.InvalidField("moduleId", "Materials can only move between modules of the same course"))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Lock both modules in a fixed order so opposite moves cannot
+		// deadlock.
+		moduleIDs := []uuid.UUID{material.ModuleID, target.ID}
+		var locked []models.Module
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", moduleIDs).Order("id").Find(&locked).Error; err != nil {
+			return err
+		}
+
+		var siblings []models.Material
+		if err := tx.Where("module_id = ? AND id <> ?", target.ID, material.ID).Scopes(byPosition).Find(&siblings).Error; err != nil {
+			return err
+		}
+		position := len(siblings)
+		if req.Position != nil && *req.Position < position {
+			position = *req.Position
+		}
+		ids := make([]uuid.UUID, 0, len(siblings)+1)
+		for _, sibling := range siblings {
+			ids = append(ids, sibling.ID)
+		}
+		ids = slices.Insert(ids, position, material.ID)
+
+		source := material.ModuleID
+		if err := tx.Model(&material).UpdateColumn("module_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := reorderColumn(tx, &models.Material{}, "position", "module_id", target.ID, ids); err != nil {
+			return err
+		}
+		if source == target.ID {
+			return nil
+		}
+		var remaining []uuid.UUID
+		if err := tx.Model(&models.Material{}).Where("module_id = ?", source).Scopes(byPosition).Pluck("id", &remaining).Error; err != nil {
+			return err
+		}
+		return reorderColumn(tx, &models.Material{}, "position", "module_id", source, remaining)
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := database.GetDB().First(&material, material.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load material", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewMaterial(material))
+}
+
+type DuplicateModuleRequest struct {
+	// CourseID defaults to the module's own course.
+	CourseID *uuid.UUID `json:"courseId"`
+	Title    *string    `json:"title" binding:"omitempty,min=1"`
+}
+
+// DuplicateModule deep-copies a module, with its materials and generated
+// study packs, to the end of its own course or of another course.
+func (h *ModuleHandler) DuplicateModule(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	module, ok := loadInstructorModule(c, userID)
+	if !ok {
+		return
+	}
+
+	var req DuplicateModuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	db := database.GetDB()
+	target := module.Course
+	if req.CourseID != nil && *req.CourseID != module.CourseID {
+		if err := db.First(&target, *req.CourseID).Error; err != nil {
+			respondError(c, apperror.FromDB(err, "Course not found"))
+			return
+		}
+		if err := requireOrgRole(userID, target.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	if err := requireCourseOpen(db, target.ID); err != nil {
+		respondError(c, err)
+		return
+	}
+	sameCourse := target.ID == module.CourseID
+
+	opts := copyOptions{Title: module.Title, KeepLockRule: sameCourse, CopiedBy: userID}
+	if sameCourse {
+		opts.Title = module.Title + " (copy)"
+	}
+	if req.Title != nil {
+		opts.Title = *req.Title
+	}
+	policy, err := reviewPolicy(db, target.OrgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+	opts.Policy = policy
+
+	source, err := loadModuleForCopy(db, module.ID)
+	if err != nil {
+		respondError(c, apperror.FromDB(err, "Module not found"))
+		return
+	}
+
+	var copied *models.Module
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, "id = ?", target.ID).Error; err != nil {
+			return err
+		}
+		if opts.Order, err = nextModuleOrder(tx, target.ID); err != nil {
+			return err
+		}
+		copied, err = copyModule(tx, source, target.ID, opts)
+		return err
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to duplicate module", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewModule(*copied))
+}
+
+type SetVideoDurationRequest struct {
+	DurationSec float64 `json:"durationSec" binding:"required,gt=0"`
+}
+
+// SetVideoDuration records the length of a video material, for videos whose
+// length could not be read from YouTube or was read wrong. Heartbeats are
+// checked against it, and learners' watched percentages are recomputed.
+func (h *ModuleHandler) SetVideoDuration(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	materialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid material ID"))
+		return
+	}
+
+	var req SetVideoDurationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	var material models.Material
+	if err := database.GetDB().Preload("Module.Course").First(&material, materialID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Material not found"))
+		return
+	}
+	if err := requireOrgRole(userID, material.Module.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+	if material.Type != "VIDEO" {
+		respondError(c, apperror.Unprocessable("Only videos have a duration"))
 		return
 	}
 
-	if err := database.GetDB().Delete(&models.Module{}, moduleID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete module"})
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Heartbeats hold a share lock on the material while they compute
+		// a percentage, so none is left using the old duration.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Material{}, "id = ?", material.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&material).Update("duration_sec", req.DurationSec).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.MaterialActivity{}).
+			Where("material_id = ?", material.ID).
+			Update("watched_percent", gorm.Expr("LEAST(FLOOR(watched_seconds / ? * 100), 100)", req.DurationSec)).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to set video duration", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Module deleted successfully"})
+	material.DurationSec = &req.DurationSec
+	c.JSON(http.StatusOK, dto.NewMaterial(material))
 }
@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
+	"myway-backend/internal/trash"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type OrganizationHandler struct{}
@@ -26,17 +31,17 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	// Only users with ORGANIZER account role can create organizations
 	var creator models.User
 	if err := database.GetDB().Select("id", "role").First(&creator, userID).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		respondError(c, apperror.Unauthorized("User not found"))
 		return
 	}
 	if creator.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only organizer role can create organizations"})
+		respondError(c, apperror.Forbidden("Only organizer role can create organizations"))
 		return
 	}
 
 	var req CreateOrganizationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
@@ -46,7 +51,7 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	}
 
 	if err := database.GetDB().Create(&org).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		respondError(c, apperror.Internal("Failed to create organization", err))
 		return
 	}
 
@@ -59,23 +64,25 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	}
 
 	if err := database.GetDB().Create(&membership).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create membership"})
+		respondError(c, apperror.Internal("Failed to create membership", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, org)
+	c.JSON(http.StatusCreated, dto.NewOrganization(org))
 }
 
 func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	// Get user's organizations through memberships
+	// Get user's organizations through memberships, skipping trashed ones
+	db := database.GetDB()
 	var memberships []models.OrgMembership
-	if err := database.GetDB().
+	if err := db.
 		Preload("Organization").
 		Where("user_id = ?", userID).
+		Where("org_id IN (?)", db.Model(&models.Organization{}).Select("id")).
 		Find(&memberships).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		respondError(c, apperror.Internal("Failed to fetch organizations", err))
 		return
 	}
 
@@ -96,7 +103,7 @@ func (h *OrganizationHandler) SwitchOrganization(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	orgID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
 		return
 	}
 
@@ -106,7 +113,12 @@ func (h *OrganizationHandler) SwitchOrganization(c *gin.Context) {
 		Preload("Organization").
 		Where("user_id = ? AND org_id = ? AND status = ?", userID, orgID, "Active").
 		First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this organization"})
+		respondError(c, apperror.Forbidden("Not a member of this organization"))
+		return
+	}
+	// Preload skips a trashed organization
+	if membership.Organization.ID == uuid.Nil {
+		respondError(c, apperror.NotFound("Organization not found"))
 		return
 	}
 
@@ -124,27 +136,27 @@ func (h *OrganizationHandler) JoinOrganization(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	orgID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
 		return
 	}
 
 	var org models.Organization
 	if err := database.GetDB().First(&org, orgID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		respondError(c, apperror.NotFound("Organization not found"))
 		return
 	}
 
 	var existing models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ?", userID, orgID).First(&existing).Error; err == nil {
 		if existing.Status == "Active" {
-			c.JSON(http.StatusConflict, gin.H{"error": "Already a member of this organization"})
+			respondError(c, apperror.Conflict("Already a member of this organization"))
 			return
 		}
 
 		existing.Status = "Active"
 		existing.Role = "STUDENT"
 		if err := database.GetDB().Save(&existing).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to activate membership"})
+			respondError(c, apperror.Internal("Failed to activate membership", err))
 			return
 		}
 
@@ -164,7 +176,7 @@ func (h *OrganizationHandler) JoinOrganization(c *gin.Context) {
 	}
 
 	if err := database.GetDB().Create(&membership).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join organization"})
+		respondError(c, apperror.Internal("Failed to join organization", err))
 		return
 	}
 
@@ -179,212 +191,26 @@ func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	orgID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, orgID, "Active").First(&membership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
 		return
 	}
 	if membership.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only organizers can delete organizations"})
+		respondError(c, apperror.Forbidden("Only organizers can delete organizations"))
 		return
 	}
 
-	tx := database.GetDB().Begin()
-	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+	if _, err := trash.Move(database.GetDB(), trash.EntityOrganization, orgID, userID); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	var courseIDs []uuid.UUID
-	if err := tx.Model(&models.Course{}).Where("org_id = ?", orgID).Pluck("id", &courseIDs).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare course cleanup"})
-		return
-	}
-
-	if len(courseIDs) > 0 {
-		var assignmentIDs []uuid.UUID
-		if err := tx.Model(&models.Assignment{}).Where("course_id IN ?", courseIDs).Pluck("id", &assignmentIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare assignment cleanup"})
-			return
-		}
-
-		var threadIDs []uuid.UUID
-		if err := tx.Model(&models.Thread{}).Where("course_id IN ?", courseIDs).Pluck("id", &threadIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare discussion cleanup"})
-			return
-		}
-
-		var moduleIDs []uuid.UUID
-		if err := tx.Model(&models.Module{}).Where("course_id IN ?", courseIDs).Pluck("id", &moduleIDs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare module cleanup"})
-			return
-		}
-
-		var materialIDs []uuid.UUID
-		if len(moduleIDs) > 0 {
-			if err := tx.Model(&models.Material{}).Where("module_id IN ?", moduleIDs).Pluck("id", &materialIDs).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare material cleanup"})
-				return
-			}
-		}
-
-		var studyPackIDs []uuid.UUID
-		if len(materialIDs) > 0 {
-			if err := tx.Model(&models.StudyPack{}).Where("material_id IN ?", materialIDs).Pluck("id", &studyPackIDs).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare study pack cleanup"})
-				return
-			}
-		}
-
-		var quizIDs []uuid.UUID
-		if len(studyPackIDs) > 0 {
-			if err := tx.Model(&models.Quiz{}).Where("study_pack_id IN ?", studyPackIDs).Pluck("id", &quizIDs).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare quiz cleanup"})
-				return
-			}
-		}
-
-		if len(quizIDs) > 0 {
-			if err := tx.Where("quiz_id IN ?", quizIDs).Delete(&models.QuizAttempt{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quiz attempts"})
-				return
-			}
-			if err := tx.Where("quiz_id IN ?", quizIDs).Delete(&models.QuizQuestion{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quiz questions"})
-				return
-			}
-			if err := tx.Where("id IN ?", quizIDs).Delete(&models.Quiz{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quizzes"})
-				return
-			}
-		}
-
-		if len(studyPackIDs) > 0 {
-			if err := tx.Where("study_pack_id IN ?", studyPackIDs).Delete(&models.FlashcardSession{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flashcard sessions"})
-				return
-			}
-			if err := tx.Where("study_pack_id IN ?", studyPackIDs).Delete(&models.Flashcard{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flashcards"})
-				return
-			}
-			if err := tx.Where("study_pack_id IN ?", studyPackIDs).Delete(&models.Summary{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete summaries"})
-				return
-			}
-			if err := tx.Where("id IN ?", studyPackIDs).Delete(&models.StudyPack{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete study packs"})
-				return
-			}
-		}
-
-		if len(materialIDs) > 0 {
-			if err := tx.Where("id IN ?", materialIDs).Delete(&models.Material{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete materials"})
-				return
-			}
-		}
-
-		if len(moduleIDs) > 0 {
-			if err := tx.Where("id IN ?", moduleIDs).Delete(&models.Module{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete modules"})
-				return
-			}
-		}
-
-		if len(assignmentIDs) > 0 {
-			if err := tx.Where("assignment_id IN ?", assignmentIDs).Delete(&models.Submission{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete submissions"})
-				return
-			}
-			if err := tx.Where("id IN ?", assignmentIDs).Delete(&models.Assignment{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete assignments"})
-				return
-			}
-		}
-
-		if len(threadIDs) > 0 {
-			if err := tx.Where("thread_id IN ?", threadIDs).Delete(&models.Reply{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete replies"})
-				return
-			}
-			if err := tx.Where("id IN ?", threadIDs).Delete(&models.Thread{}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete threads"})
-				return
-			}
-		}
-
-		if err := tx.Where("course_id IN ?", courseIDs).Delete(&models.Enrollment{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete enrollments"})
-			return
-		}
-		if err := tx.Where("course_id IN ?", courseIDs).Delete(&models.CourseMetric{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete course metrics"})
-			return
-		}
-
-		if err := tx.Where("id IN ?", courseIDs).Delete(&models.Course{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete courses"})
-			return
-		}
-	}
-
-	if err := tx.Where("org_id = ?", orgID).Delete(&models.OrgMembership{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete memberships"})
-		return
-	}
-	if err := tx.Where("org_id = ?", orgID).Delete(&models.DailyOrgMetric{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete organization metrics"})
-		return
-	}
-
-	deleteResult := tx.Where("id = ?", orgID).Delete(&models.Organization{})
-	if deleteResult.Error != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete organization"})
-		return
-	}
-	if deleteResult.RowsAffected == 0 {
-		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
-		return
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit organization deletion"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Organization deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Organization moved to trash"})
 }
 
 type InviteToOrganizationRequest struct {
@@ -396,24 +222,24 @@ func (h *OrganizationHandler) InviteToOrganization(c *gin.Context) {
 	inviterID := c.MustGet("userID").(uuid.UUID)
 	orgID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
 		return
 	}
 
 	// Only organizer of the organization can invite
 	var inviterMembership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", inviterID, orgID, "Active").First(&inviterMembership).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+		respondError(c, apperror.Forbidden("You do not have access to this organization"))
 		return
 	}
 	if inviterMembership.Role != "ORGANIZER" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only organizers can invite users"})
+		respondError(c, apperror.Forbidden("Only organizers can invite users"))
 		return
 	}
 
 	var req InviteToOrganizationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
@@ -422,27 +248,27 @@ func (h *OrganizationHandler) InviteToOrganization(c *gin.Context) {
 		role = "STUDENT"
 	}
 	if role != "STUDENT" && role != "TEACHER" && role != "ORGANIZER" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be STUDENT, TEACHER, or ORGANIZER"})
+		respondError(c, apperror.Validation("Role must be STUDENT, TEACHER, or ORGANIZER"))
 		return
 	}
 
 	var user models.User
 	if err := database.GetDB().Where("email = ?", strings.ToLower(strings.TrimSpace(req.Email))).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found by email"})
+		respondError(c, apperror.FromDB(err, "User not found by email"))
 		return
 	}
 
 	var membership models.OrgMembership
 	if err := database.GetDB().Where("user_id = ? AND org_id = ?", user.ID, orgID).First(&membership).Error; err == nil {
 		if membership.Status == "Active" {
-			c.JSON(http.StatusConflict, gin.H{"error": "User is already an active member of this organization"})
+			respondError(c, apperror.Conflict("User is already an active member of this organization"))
 			return
 		}
 
 		membership.Status = "Active"
 		membership.Role = role
 		if err := database.GetDB().Save(&membership).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to activate membership"})
+			respondError(c, apperror.Internal("Failed to activate membership", err))
 			return
 		}
 
@@ -463,7 +289,7 @@ func (h *OrganizationHandler) InviteToOrganization(c *gin.Context) {
 		Status: "Active",
 	}
 	if err := database.GetDB().Create(&newMembership).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite user"})
+		respondError(c, apperror.Internal("Failed to invite user", err))
 		return
 	}
 
@@ -475,3 +301,97 @@ func (h *OrganizationHandler) InviteToOrganization(c *gin.Context) {
 		"status":         newMembership.Status,
 	})
 }
+
+type UpdateOrgSettingsRequest struct {
+	RequireReview     *bool `json:"requireReview"`
+	RequiredApprovals *int  `json:"requiredApprovals" binding:"omitempty,min=1,max=10"`
+	AllowSelfApproval *bool `json:"allowSelfApproval"`
+	// CourseCodePrefixes replaces the allowed prefixes; send [] to allow
+	// any code.
+	CourseCodePrefixes  *[]string `json:"courseCodePrefixes" binding:"omitempty,max=20,dive,required,max=20"`
+	DefaultCourseStatus *string   `json:"defaultCourseStatus" binding:"omitempty,oneof=DRAFT PUBLISHED"`
+}
+
+func (h *OrganizationHandler) GetSettings(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	settings, err := loadOrgSettings(database.GetDB(), orgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load organization settings", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewOrgSettings(settings))
+}
+
+// UpdateSettings changes only the fields present in the request.
+func (h *OrganizationHandler) UpdateSettings(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req UpdateOrgSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	db := database.GetDB()
+	settings, err := loadOrgSettings(db, orgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load organization settings", err))
+		return
+	}
+	if req.RequireReview != nil {
+		settings.RequireReview = *req.RequireReview
+	}
+	if req.RequiredApprovals != nil {
+		settings.RequiredApprovals = *req.RequiredApprovals
+	}
+	if req.AllowSelfApproval != nil {
+		settings.AllowSelfApproval = *req.AllowSelfApproval
+	}
+	if req.CourseCodePrefixes != nil {
+		settings.CourseCodePrefixes = nil
+		if len(*req.CourseCodePrefixes) > 0 {
+			encoded, err := json.Marshal(*req.CourseCodePrefixes)
+			if err != nil {
+				respondError(c, apperror.Internal("Failed to save organization settings", err))
+				return
+			}
+			prefixes := string(encoded)
+			settings.CourseCodePrefixes = &prefixes
+		}
+	}
+	if req.DefaultCourseStatus != nil {
+		settings.DefaultCourseStatus = *req.DefaultCourseStatus
+	}
+	if err := db.Save(&settings).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to save organization settings", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewOrgSettings(settings))
+}
+
+// loadOrgSettings returns the organization's settings, or the defaults if
+// they were never changed.
+func loadOrgSettings(db *gorm.DB, orgID uuid.UUID) (models.OrgSettings, error) {
+	settings := models.OrgSettings{OrgID: orgID, RequiredApprovals: 1, DefaultCourseStatus: "PUBLISHED"}
+	err := db.Where("org_id = ?", orgID).Limit(1).Find(&settings).Error
+	return settings, err
+}
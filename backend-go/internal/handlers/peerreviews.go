@@ -0,0 +1,690 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"myway-backend/internal/peerreview"
+	"myway-backend/internal/rubric"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PeerReviewSettingsRequest struct {
+	ReviewsPerSubmission int       `json:"reviewsPerSubmission" binding:"required,min=1,max=10"`
+	ReviewDueAt          time.Time `json:"reviewDueAt" binding:"required"`
+	// Weight is the percent of the grade taken from the peer average.
+	Weight int `json:"weight" binding:"min=0,max=100"`
+}
+
+type SubmitPeerReviewRequest struct {
+	Criteria []rubric.Selection `json:"criteria" binding:"required"`
+	Comment  string             `json:"comment"`
+}
+
+type ExcludePeerReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ConfigurePeerReview turns on peer review for an assignment, or changes
+// its settings. Once reviews are handed out only the window and weight can
+// change.
+func (h *AssignmentHandler) ConfigurePeerReview(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+
+	var req PeerReviewSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if assignment.RubricID == nil {
+		respondError(c, apperror.Unprocessable("Attach a rubric before turning on peer review"))
+		return
+	}
+	if !req.ReviewDueAt.After(assignment.DueAt) {
+		respondError(c, apperror.InvalidField("reviewDueAt", "The review window must close after the assignment is due"))
+		return
+	}
+	if assignment.PeerReviewsAssignedAt != nil && *assignment.PeerReviewCount != req.ReviewsPerSubmission {
+		respondError(c, apperror.Conflict("Reviews have been handed out; the number per submission can no longer change"))
+		return
+	}
+
+	assignment.PeerReviewCount = &req.ReviewsPerSubmission
+	assignment.PeerReviewDueAt = &req.ReviewDueAt
+	assignment.PeerReviewWeight = req.Weight
+	if err := database.GetDB().Model(assignment).Updates(map[string]interface{}{
+		"peer_review_count":  assignment.PeerReviewCount,
+		"peer_review_due_at": assignment.PeerReviewDueAt,
+		"peer_review_weight": assignment.PeerReviewWeight,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to save peer review settings", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAssignment(*assignment))
+}
+
+// DisablePeerReview turns peer review off before reviews are handed out.
+func (h *AssignmentHandler) DisablePeerReview(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+	if assignment.PeerReviewsAssignedAt != nil {
+		respondError(c, apperror.Conflict("Reviews have already been handed out"))
+		return
+	}
+
+	if err := database.GetDB().Model(assignment).Updates(map[string]interface{}{
+		"peer_review_count":  nil,
+		"peer_review_due_at": nil,
+		"peer_review_weight": 0,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to turn off peer review", err))
+		return
+	}
+	assignment.PeerReviewCount = nil
+	assignment.PeerReviewDueAt = nil
+	assignment.PeerReviewWeight = 0
+
+	c.JSON(http.StatusOK, dto.NewAssignment(*assignment))
+}
+
+// AssignPeerReviews hands out the reviews now instead of waiting for the
+// hourly run after the due date.
+func (h *AssignmentHandler) AssignPeerReviews(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+	if assignment.PeerReviewCount == nil {
+		respondError(c, apperror.Conflict("Peer review is not turned on for this assignment"))
+		return
+	}
+	if time.Now().Before(assignment.DueAt) {
+		respondError(c, apperror.Unprocessable("Reviews are handed out once the assignment is due"))
+		return
+	}
+
+	db := database.GetDB()
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(assignment, assignment.ID).Error; err != nil {
+			return err
+		}
+		if assignment.PeerReviewsAssignedAt != nil {
+			return apperror.Conflict("Reviews have already been handed out")
+		}
+		_, err := assignPeerReviews(tx, assignment, rand.New(rand.NewSource(time.Now().UnixNano())))
+		if errors.Is(err, peerreview.ErrTooFewAuthors) {
+			return apperror.Unprocessable("Peer review needs at least two submissions")
+		}
+		return err
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	overview, err := peerReviewOverview(db, *assignment)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load peer reviews", err))
+		return
+	}
+	c.JSON(http.StatusOK, overview)
+}
+
+// AssignDuePeerReviews hands out the reviews of every active assignment
+// whose due date has passed and whose review window is still open. It
+// returns how many assignments it handled.
+func AssignDuePeerReviews(db *gorm.DB) (int, error) {
+	var ids []uuid.UUID
+	now := time.Now()
+	if err := db.Model(&models.Assignment{}).
+		Where("peer_review_count IS NOT NULL AND peer_reviews_assigned_at IS NULL").
+		Where("status = ? AND due_at <= ? AND peer_review_due_at > ?", "ACTIVE", now, now).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	rng := rand.New(rand.NewSource(now.UnixNano()))
+	handled := 0
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var assignment models.Assignment
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&assignment, id).Error; err != nil {
+				return err
+			}
+			if assignment.PeerReviewsAssignedAt != nil || assignment.PeerReviewCount == nil {
+				return nil
+			}
+			_, err := assignPeerReviews(tx, &assignment, rng)
+			return err
+		})
+		// Left for the teacher, who can hand out reviews once more work is in.
+		if errors.Is(err, peerreview.ErrTooFewAuthors) {
+			continue
+		}
+		if err != nil {
+			return handled, fmt.Errorf("assignment %s: %w", id, err)
+		}
+		handled++
+	}
+	return handled, nil
+}
+
+// assignPeerReviews distributes the latest attempt of each submission to
+// other students who submitted. Only students enrolled in the course take
+// part. It must run in a transaction holding the assignment's row lock.
+func assignPeerReviews(tx *gorm.DB, assignment *models.Assignment, rng *rand.Rand) (int, error) {
+	if assignment.RubricID == nil {
+		return 0, apperror.Unprocessable("Peer review needs a rubric")
+	}
+	_, current, err := loadRubric(tx, *assignment.RubricID)
+	if err != nil {
+		return 0, err
+	}
+
+	var submissions []models.Submission
+	students := tx.Model(&models.Enrollment{}).Select("user_id").Where("course_id = ? AND role = ?", assignment.CourseID, "STUDENT")
+	if err := tx.Preload("Attempts", byNumber).Where("assignment_id = ? AND user_id IN (?)", assignment.ID, students).Find(&submissions).Error; err != nil {
+		return 0, err
+	}
+	byAuthor := make(map[string]models.Submission, len(submissions))
+	authors := make([]string, 0, len(submissions))
+	for _, submission := range submissions {
+		if len(submission.Attempts) == 0 {
+			continue
+		}
+		byAuthor[submission.UserID.String()] = submission
+		authors = append(authors, submission.UserID.String())
+	}
+	// Sorted so the shuffle alone decides the assignment.
+	sort.Strings(authors)
+
+	pairs, err := peerreview.Assign(authors, *assignment.PeerReviewCount, rng)
+	if err != nil {
+		return 0, err
+	}
+	reviews := make([]models.PeerReview, 0, len(pairs))
+	for _, pair := range pairs {
+		submission := byAuthor[pair.Author]
+		reviews = append(reviews, models.PeerReview{
+			AssignmentID:    assignment.ID,
+			SubmissionID:    submission.ID,
+			ReviewerID:      byAuthor[pair.Reviewer].UserID,
+			AttemptID:       submission.Attempts[len(submission.Attempts)-1].ID,
+			RubricVersionID: current.ID,
+			Status:          "ASSIGNED",
+		})
+	}
+	if err := tx.Create(&reviews).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	assignment.PeerReviewsAssignedAt = &now
+	if err := tx.Model(assignment).Update("peer_reviews_assigned_at", now).Error; err != nil {
+		return 0, err
+	}
+	return len(reviews), nil
+}
+
+// GetPeerReviews is the teacher's overview: every submission with its
+// reviews, peer average, teacher score and the grade they blend into.
+func (h *AssignmentHandler) GetPeerReviews(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+	if assignment.PeerReviewCount == nil {
+		respondError(c, apperror.NotFound("Peer review is not turned on for this assignment"))
+		return
+	}
+
+	overview, err := peerReviewOverview(database.GetDB(), *assignment)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load peer reviews", err))
+		return
+	}
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetPeerReviewCalibration compares each reviewer's scores with the
+// teacher's on the submissions the teacher has graded.
+func (h *AssignmentHandler) GetPeerReviewCalibration(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	var reviews []models.PeerReview
+	if err := db.Preload("Reviewer").Where("assignment_id = ?", assignment.ID).Order("created_at ASC").Find(&reviews).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load peer reviews", err))
+		return
+	}
+	teacherScores, err := reviewedTeacherScores(db, reviews)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load grades", err))
+		return
+	}
+
+	reviewers := make(map[string]models.User)
+	assigned := make(map[string]int)
+	var order []string
+	var submitted []peerreview.Review
+	for _, review := range reviews {
+		reviewer := review.ReviewerID.String()
+		if _, ok := reviewers[reviewer]; !ok {
+			reviewers[reviewer] = review.Reviewer
+			order = append(order, reviewer)
+		}
+		assigned[reviewer]++
+		if review.Status == "SUBMITTED" && review.Score != nil {
+			submitted = append(submitted, peerreview.Review{Reviewer: reviewer, Author: review.AttemptID.String(), Score: *review.Score})
+		}
+	}
+	stats := make(map[string]peerreview.Calibration)
+	for _, calibration := range peerreview.Calibrate(submitted, teacherScores) {
+		stats[calibration.Reviewer] = calibration
+	}
+
+	result := make([]dto.ReviewerCalibration, 0, len(order))
+	for _, reviewer := range order {
+		user := reviewers[reviewer]
+		calibration := stats[reviewer]
+		result = append(result, dto.ReviewerCalibration{
+			Reviewer:     dto.UserRef{ID: user.ID, Name: user.Name, Role: user.Role},
+			Assigned:     assigned[reviewer],
+			Submitted:    calibration.Reviews,
+			Compared:     calibration.Compared,
+			Bias:         calibration.Bias,
+			MeanAbsError: calibration.MeanAbsError,
+		})
+	}
+	// Least reliable reviewers first
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].MeanAbsError, result[j].MeanAbsError
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetMyPeerReviews lists the reviews the caller has been given for an
+// assignment, with the work to review but not who wrote it.
+func (h *AssignmentHandler) GetMyPeerReviews(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid assignment ID"))
+		return
+	}
+
+	db := database.GetDB()
+	var assignment models.Assignment
+	if err := db.Preload("Course").First(&assignment, assignmentID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Assignment not found"))
+		return
+	}
+	if err := requireOrgRole(userID, assignment.Course.OrgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var reviews []models.PeerReview
+	if err := db.Where("assignment_id = ? AND reviewer_id = ?", assignment.ID, userID).Order("created_at ASC, id ASC").Find(&reviews).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load peer reviews", err))
+		return
+	}
+	tasks, err := peerReviewTasks(db, assignment, reviews)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load peer reviews", err))
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// SubmitPeerReview fills out the rubric for an assigned review. It can be
+// changed until the review window closes.
+func (h *AssignmentHandler) SubmitPeerReview(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid review ID"))
+		return
+	}
+
+	var req SubmitPeerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	db := database.GetDB()
+	var review models.PeerReview
+	if err := db.Where("reviewer_id = ?", userID).First(&review, reviewID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Review not found"))
+		return
+	}
+	var assignment models.Assignment
+	if err := db.First(&assignment, review.AssignmentID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Review not found"))
+		return
+	}
+	if assignment.PeerReviewDueAt == nil || time.Now().After(*assignment.PeerReviewDueAt) {
+		respondError(c, apperror.Conflict("The review window has closed"))
+		return
+	}
+
+	var version models.RubricVersion
+	if err := db.First(&version, review.RubricVersionID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load rubric", err))
+		return
+	}
+	grade, err := rubric.Score(dto.DecodeRubricCriteria(version.Criteria), req.Criteria, assignment.Points)
+	if err != nil {
+		respondError(c, apperror.InvalidField("criteria", err.Error()))
+		return
+	}
+	encoded, err := json.Marshal(grade)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to encode review", err))
+		return
+	}
+
+	now := time.Now()
+	encodedGrade := string(encoded)
+	review.Status = "SUBMITTED"
+	review.RubricGrade = &encodedGrade
+	review.Score = &grade.Score
+	review.Comment = nil
+	if comment := strings.TrimSpace(req.Comment); comment != "" {
+		review.Comment = &comment
+	}
+	review.SubmittedAt = &now
+	if err := db.Model(&review).Updates(map[string]interface{}{
+		"status":       review.Status,
+		"rubric_grade": review.RubricGrade,
+		"score":        review.Score,
+		"comment":      review.Comment,
+		"submitted_at": review.SubmittedAt,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to save review", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAnonymousPeerReview(review))
+}
+
+// GetSubmissionPeerReviews lists the reviews of a submission. Teachers see
+// every review and its reviewer; the author sees the submitted reviews they
+// count towards their grade, without names, once the window has closed.
+func (h *AssignmentHandler) GetSubmissionPeerReviews(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	submission, ok := loadSubmission(c)
+	if !ok {
+		return
+	}
+	isTeacher := requireOrgRole(userID, submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER") == nil
+	if !isTeacher && submission.UserID != userID {
+		respondError(c, apperror.Forbidden("You can only see reviews of your own work"))
+		return
+	}
+
+	query := database.GetDB().Where("submission_id = ?", submission.ID).Order("created_at ASC, id ASC")
+	if isTeacher {
+		query = query.Preload("Reviewer")
+	} else {
+		dueAt := submission.Assignment.PeerReviewDueAt
+		if dueAt == nil || time.Now().Before(*dueAt) {
+			c.JSON(http.StatusOK, []dto.PeerReview{})
+			return
+		}
+		query = query.Where("status = ? AND excluded = ?", "SUBMITTED", false)
+	}
+	var reviews []models.PeerReview
+	if err := query.Find(&reviews).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load peer reviews", err))
+		return
+	}
+
+	if isTeacher {
+		c.JSON(http.StatusOK, mapReviews(reviews, dto.NewPeerReview))
+		return
+	}
+	c.JSON(http.StatusOK, mapReviews(reviews, dto.NewAnonymousPeerReview))
+}
+
+// ExcludePeerReview leaves a review out of the peer average, for example
+// when it is careless or unfair. It applies when the submission is next
+// graded.
+func (h *AssignmentHandler) ExcludePeerReview(c *gin.Context) {
+	var req ExcludePeerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	h.setPeerReviewExcluded(c, true, emptyToNil(&req.Reason))
+}
+
+// IncludePeerReview counts an excluded review again.
+func (h *AssignmentHandler) IncludePeerReview(c *gin.Context) {
+	h.setPeerReviewExcluded(c, false, nil)
+}
+
+func (h *AssignmentHandler) setPeerReviewExcluded(c *gin.Context, excluded bool, reason *string) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid review ID"))
+		return
+	}
+
+	db := database.GetDB()
+	var review models.PeerReview
+	if err := db.Preload("Reviewer").Preload("Submission.Assignment.Course").First(&review, reviewID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Review not found"))
+		return
+	}
+	if err := requireOrgRole(userID, review.Submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := db.Model(&review).Updates(map[string]interface{}{
+		"excluded":        excluded,
+		"excluded_reason": reason,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update review", err))
+		return
+	}
+	review.Excluded = excluded
+	review.ExcludedReason = reason
+
+	c.JSON(http.StatusOK, dto.NewPeerReview(review))
+}
+
+// peerScore averages the submitted, included reviews of an attempt.
+func peerScore(db *gorm.DB, attemptID uuid.UUID) (*int, error) {
+	var scores []int
+	if err := db.Model(&models.PeerReview{}).
+		Where("attempt_id = ? AND status = ? AND excluded = ? AND score IS NOT NULL", attemptID, "SUBMITTED", false).
+		Pluck("score", &scores).Error; err != nil {
+		return nil, err
+	}
+	return peerreview.Average(scores), nil
+}
+
+// reviewedTeacherScores returns the teacher's score on each reviewed
+// attempt that has been graded, keyed by attempt ID.
+func reviewedTeacherScores(db *gorm.DB, reviews []models.PeerReview) (map[string]int, error) {
+	attemptIDs := make([]uuid.UUID, 0, len(reviews))
+	for _, review := range reviews {
+		attemptIDs = append(attemptIDs, review.AttemptID)
+	}
+	scores := make(map[string]int)
+	if len(attemptIDs) == 0 {
+		return scores, nil
+	}
+	var attempts []models.SubmissionAttempt
+	if err := db.Where("id IN ?", attemptIDs).Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	for _, attempt := range attempts {
+		if score := teacherScore(attempt); score != nil {
+			scores[attempt.ID.String()] = *score
+		}
+	}
+	return scores, nil
+}
+
+// teacherScore is the teacher's own score on an attempt, before any peer
+// blending or late penalty.
+func teacherScore(attempt models.SubmissionAttempt) *int {
+	if attempt.TeacherScore != nil {
+		return attempt.TeacherScore
+	}
+	return attempt.RawScore
+}
+
+func peerReviewOverview(db *gorm.DB, assignment models.Assignment) (dto.PeerReviewOverview, error) {
+	overview := dto.PeerReviewOverview{
+		AssignmentID:         assignment.ID,
+		ReviewsPerSubmission: *assignment.PeerReviewCount,
+		ReviewDueAt:          *assignment.PeerReviewDueAt,
+		Weight:               assignment.PeerReviewWeight,
+		AssignedAt:           assignment.PeerReviewsAssignedAt,
+		Submissions:          []dto.PeerReviewSubmission{},
+	}
+
+	var reviews []models.PeerReview
+	if err := db.Preload("Reviewer").Preload("Submission.User").
+		Where("assignment_id = ?", assignment.ID).
+		Order("created_at ASC, id ASC").
+		Find(&reviews).Error; err != nil {
+		return overview, err
+	}
+	teacherScores, err := reviewedTeacherScores(db, reviews)
+	if err != nil {
+		return overview, err
+	}
+
+	index := make(map[uuid.UUID]int)
+	scores := make(map[uuid.UUID][]int)
+	for _, review := range reviews {
+		i, ok := index[review.SubmissionID]
+		if !ok {
+			i = len(overview.Submissions)
+			index[review.SubmissionID] = i
+			overview.Submissions = append(overview.Submissions, dto.PeerReviewSubmission{
+				SubmissionID: review.SubmissionID,
+				UserID:       review.Submission.UserID,
+				Name:         review.Submission.User.Name,
+				AttemptID:    review.AttemptID,
+				Reviews:      []dto.PeerReview{},
+			})
+		}
+		overview.Submissions[i].Reviews = append(overview.Submissions[i].Reviews, dto.NewPeerReview(review))
+		if review.Status == "SUBMITTED" && !review.Excluded && review.Score != nil {
+			scores[review.SubmissionID] = append(scores[review.SubmissionID], *review.Score)
+		}
+	}
+
+	for i := range overview.Submissions {
+		row := &overview.Submissions[i]
+		row.PeerScore = peerreview.Average(scores[row.SubmissionID])
+		if score, ok := teacherScores[row.AttemptID.String()]; ok {
+			row.TeacherScore = &score
+			blended := peerreview.Blend(score, row.PeerScore, assignment.PeerReviewWeight)
+			row.Score = &blended
+		} else if row.PeerScore != nil && assignment.PeerReviewWeight >= 100 {
+			row.Score = row.PeerScore
+		}
+	}
+	sort.SliceStable(overview.Submissions, func(i, j int) bool {
+		return overview.Submissions[i].Name < overview.Submissions[j].Name
+	})
+	return overview, nil
+}
+
+func peerReviewTasks(db *gorm.DB, assignment models.Assignment, reviews []models.PeerReview) ([]dto.PeerReviewTask, error) {
+	tasks := make([]dto.PeerReviewTask, 0, len(reviews))
+	if len(reviews) == 0 {
+		return tasks, nil
+	}
+	attemptIDs := make([]uuid.UUID, 0, len(reviews))
+	versionIDs := make([]uuid.UUID, 0, len(reviews))
+	for _, review := range reviews {
+		attemptIDs = append(attemptIDs, review.AttemptID)
+		versionIDs = append(versionIDs, review.RubricVersionID)
+	}
+	var attempts []models.SubmissionAttempt
+	if err := db.Where("id IN ?", attemptIDs).Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	var versions []models.RubricVersion
+	if err := db.Where("id IN ?", versionIDs).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	attemptByID := make(map[uuid.UUID]models.SubmissionAttempt, len(attempts))
+	for _, attempt := range attempts {
+		attemptByID[attempt.ID] = attempt
+	}
+	criteriaByVersion := make(map[uuid.UUID][]rubric.Criterion, len(versions))
+	for _, version := range versions {
+		criteriaByVersion[version.ID] = dto.DecodeRubricCriteria(version.Criteria)
+	}
+
+	for _, review := range reviews {
+		attempt := attemptByID[review.AttemptID]
+		tasks = append(tasks, dto.PeerReviewTask{
+			ID:           review.ID,
+			AssignmentID: assignment.ID,
+			Title:        assignment.Title,
+			Instructions: assignment.Instructions,
+			Points:       assignment.Points,
+			ReviewDueAt:  *assignment.PeerReviewDueAt,
+			Status:       review.Status,
+			FileURL:      attempt.FileURL,
+			Text:         attempt.Text,
+			Criteria:     criteriaByVersion[review.RubricVersionID],
+			RubricGrade:  dto.DecodeRubricGrade(review.RubricGrade),
+			Score:        review.Score,
+			Comment:      review.Comment,
+			SubmittedAt:  review.SubmittedAt,
+		})
+	}
+	return tasks, nil
+}
+
+func mapReviews(reviews []models.PeerReview, view func(models.PeerReview) dto.PeerReview) []dto.PeerReview {
+	out := make([]dto.PeerReview, 0, len(reviews))
+	for _, review := range reviews {
+		out = append(out, view(review))
+	}
+	return out
+}
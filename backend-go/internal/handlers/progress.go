@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
 	"myway-backend/internal/models"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProgressHandler struct{}
@@ -20,99 +25,69 @@ func (h *ProgressHandler) GetCourseProgress(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	courseID, err := uuid.Parse(c.Param("courseId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		respondError(c, apperror.InvalidField("courseId", "Invalid course ID"))
 		return
 	}
 
-	// Get course with modules and materials
-	var course models.Course
-	if err := database.GetDB().
-		Preload("Modules.Materials").
-		Preload("Modules.Materials.StudyPacks").
-		First(&course, courseID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+	db := database.GetDB()
+	course, err := loadCourseTree(db, courseID)
+	if err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
 		return
 	}
-
-	// Count total materials
-	totalMaterials := 0
-	for _, module := range course.Modules {
-		totalMaterials += len(module.Materials)
+	if err := requireOrgRole(userID, course.OrgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	// Get progress events for this course
-	var events []models.ProgressEvent
-	database.GetDB().
-		Where("user_id = ? AND course_id = ?", userID, courseID.String()).
-		Find(&events)
-
-	// Count completed materials (materials with study pack interactions)
-	completedMaterials := make(map[uuid.UUID]bool)
-	for _, event := range events {
-		if event.EventType == "QUIZ_ATTEMPT" || event.EventType == "FLASHCARD_SESSION" {
-			// Extract material ID from payload if possible
-			// For simplicity, we'll count unique events
-			completedMaterials[uuid.New()] = true // Simplified - would need proper parsing
-		}
+	completion, err := loadCourseCompletion(db, course, []uuid.UUID{userID})
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to calculate progress", err))
+		return
 	}
+	modules, rollup := completion.evaluate(userID)
 
-	// Get quiz attempts
-	var quizAttempts []models.QuizAttempt
-	database.GetDB().
+	var quizAttempts int64
+	if err := db.Model(&models.QuizAttempt{}).
 		Joins("JOIN quizzes ON quiz_attempts.quiz_id = quizzes.id").
 		Joins("JOIN study_packs ON quizzes.study_pack_id = study_packs.id").
 		Joins("JOIN materials ON study_packs.material_id = materials.id").
 		Joins("JOIN modules ON materials.module_id = modules.id").
 		Where("modules.course_id = ? AND quiz_attempts.user_id = ?", courseID, userID).
-		Find(&quizAttempts)
+		Where("quiz_attempts.status = ?", "SUBMITTED").
+		Count(&quizAttempts).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count quiz attempts", err))
+		return
+	}
 
-	// Get flashcard sessions
-	var flashcardSessions []models.FlashcardSession
-	database.GetDB().
+	var flashcardSessions int64
+	if err := db.Model(&models.FlashcardSession{}).
 		Joins("JOIN study_packs ON flashcard_sessions.study_pack_id = study_packs.id").
 		Joins("JOIN materials ON study_packs.material_id = materials.id").
 		Joins("JOIN modules ON materials.module_id = modules.id").
 		Where("modules.course_id = ? AND flashcard_sessions.user_id = ?", courseID, userID).
-		Find(&flashcardSessions)
-
-	// Calculate progress percentage
-	progressPercentage := 0.0
-	if totalMaterials > 0 {
-		// Count unique materials with activity
-		activeMaterials := make(map[uuid.UUID]bool)
-		for _, attempt := range quizAttempts {
-			var quiz models.Quiz
-			if err := database.GetDB().Preload("StudyPack").First(&quiz, attempt.QuizID).Error; err == nil {
-				if quiz.StudyPack.MaterialID != uuid.Nil {
-					activeMaterials[quiz.StudyPack.MaterialID] = true
-				}
-			}
-		}
-		for _, session := range flashcardSessions {
-			var studyPack models.StudyPack
-			if err := database.GetDB().First(&studyPack, session.StudyPackID).Error; err == nil {
-				if studyPack.MaterialID != uuid.Nil {
-					activeMaterials[studyPack.MaterialID] = true
-				}
-			}
-		}
-		progressPercentage = float64(len(activeMaterials)) / float64(totalMaterials) * 100
+		Count(&flashcardSessions).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to count flashcard sessions", err))
+		return
 	}
 
-	// Get last activity
-	lastActivity := time.Time{}
-	if len(events) > 0 {
-		lastActivity = events[len(events)-1].CreatedAt
+	var lastActivity *time.Time
+	if err := db.Model(&models.ProgressEvent{}).
+		Where("user_id = ? AND course_id = ?", userID, courseID.String()).
+		Select("MAX(created_at)").Scan(&lastActivity).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch progress events", err))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"courseId":          courseID,
-		"progressPercentage": progressPercentage,
-		"totalMaterials":    totalMaterials,
-		"completedMaterials": len(completedMaterials),
-		"quizAttempts":      len(quizAttempts),
-		"flashcardSessions": len(flashcardSessions),
-		"lastActivity":      lastActivity,
+		"courseId":           courseID,
+		"progressPercentage": rollup.Percent,
+		"totalMaterials":     rollup.Total,
+		"completedMaterials": rollup.Completed,
+		"modules":            modules,
+		"quizAttempts":       quizAttempts,
+		"flashcardSessions":  flashcardSessions,
+		"lastActivity":       lastActivity,
 	})
 }
 
@@ -121,34 +96,150 @@ func (h *ProgressHandler) GetProgressByOrg(c *gin.Context) {
 	orgID := c.MustGet("orgID").(uuid.UUID)
 
 	// Get all courses in org
+	db := database.GetDB()
 	var courses []models.Course
-	database.GetDB().
+	if err := db.
+		Preload("Modules").
+		Preload("Modules.Materials").
 		Where("org_id = ?", orgID).
-		Find(&courses)
+		Find(&courses).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch courses", err))
+		return
+	}
 
-	result := make([]gin.H, 0)
+	result := make([]gin.H, 0, len(courses))
 	for _, course := range courses {
-		// Get progress for this course
-		var events []models.ProgressEvent
-		database.GetDB().
-			Where("user_id = ? AND course_id = ?", userID, course.ID.String()).
-			Find(&events)
-
-		// Calculate progress (simplified)
-		progressPercentage := 0.0
-		if len(events) > 0 {
-			progressPercentage = float64(len(events)) * 10.0 // Simplified calculation
-			if progressPercentage > 100 {
-				progressPercentage = 100
-			}
+		completion, err := loadCourseCompletion(db, course, []uuid.UUID{userID})
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to calculate progress", err))
+			return
 		}
-
+		_, rollup := completion.evaluate(userID)
 		result = append(result, gin.H{
-			"courseId":          course.ID,
-			"courseTitle":       course.Title,
-			"progressPercentage": progressPercentage,
+			"courseId":           course.ID,
+			"courseTitle":        course.Title,
+			"progressPercentage": rollup.Percent,
+			"completedMaterials": rollup.Completed,
+			"totalMaterials":     rollup.Total,
 		})
 	}
 
 	c.JSON(http.StatusOK, result)
 }
+
+// RecordMaterialView marks a material as opened by the caller. Only the
+// first view is kept.
+func (h *ProgressHandler) RecordMaterialView(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	materialID, err := uuid.Parse(c.Param("materialId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("materialId", "Invalid material ID"))
+		return
+	}
+
+	db := database.GetDB()
+	var material models.Material
+	if err := db.Preload("Module.Course").First(&material, materialID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Material not found"))
+		return
+	}
+	if err := requireOrgRole(userID, material.Module.Course.OrgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireModuleUnlocked(db, userID, material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	now := time.Now()
+	activity := models.MaterialActivity{UserID: userID, MaterialID: materialID, ViewedAt: &now, UpdatedAt: now}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "material_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"viewed_at":  gorm.Expr("COALESCE(material_activities.viewed_at, EXCLUDED.viewed_at)"),
+			"updated_at": now,
+		}),
+	}).Create(&activity).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to record view", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "View recorded"})
+}
+
+type UpdateCompletionCriteriaRequest struct {
+	RequireView        bool `json:"requireView"`
+	MinWatchPercent    int  `json:"minWatchPercent" binding:"min=0,max=100"`
+	MinQuizScore       int  `json:"minQuizScore" binding:"min=0,max=100"`
+	MinMasteredPercent int  `json:"minMasteredPercent" binding:"min=0,max=100"`
+}
+
+// GetCompletionCriteria lists the organization's completion criteria for
+// every material type, including the defaults it has not changed.
+func (h *ProgressHandler) GetCompletionCriteria(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	criteria, custom, err := loadCompletionCriteria(database.GetDB(), orgID)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load completion criteria", err))
+		return
+	}
+	result := make([]dto.CompletionCriteria, 0, len(materialTypes))
+	for _, materialType := range materialTypes {
+		result = append(result, dto.NewCompletionCriteria(materialType, criteria[materialType], custom[materialType]))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateCompletionCriteria replaces the criteria for one material type.
+func (h *ProgressHandler) UpdateCompletionCriteria(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	materialType := c.Param("type")
+	if !slices.Contains(materialTypes, materialType) {
+		respondError(c, apperror.InvalidField("type", "Material type must be VIDEO, TEXT or DOC"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req UpdateCompletionCriteriaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	row := models.CompletionCriteria{
+		OrgID:              orgID,
+		MaterialType:       materialType,
+		RequireView:        req.RequireView,
+		MinWatchPercent:    req.MinWatchPercent,
+		MinQuizScore:       req.MinQuizScore,
+		MinMasteredPercent: req.MinMasteredPercent,
+		UpdatedAt:          time.Now(),
+	}
+	if err := database.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}, {Name: "material_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"require_view", "min_watch_percent", "min_quiz_score", "min_mastered_percent", "updated_at"}),
+	}).Create(&row).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to save completion criteria", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewCompletionCriteria(materialType, criteriaOf(row), true))
+}
@@ -0,0 +1,722 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/grading"
+	"myway-backend/internal/models"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// quizSubmitGrace absorbs network latency on the last save or submit of a
+// timed attempt.
+const quizSubmitGrace = 30 * time.Second
+
+type QuizHandler struct{}
+
+func NewQuizHandler() *QuizHandler {
+	return &QuizHandler{}
+}
+
+type UpdateQuizSettingsRequest struct {
+	TimeLimitSec      *int       `json:"timeLimitSec" binding:"omitempty,min=1"`
+	MaxAttempts       *int       `json:"maxAttempts" binding:"omitempty,min=1"`
+	ShuffleQuestions  bool       `json:"shuffleQuestions"`
+	ShuffleOptions    bool       `json:"shuffleOptions"`
+	RevealAfterSubmit bool       `json:"revealAfterSubmit"`
+	RevealAt          *time.Time `json:"revealAt"`
+}
+
+type SaveQuizAnswerRequest struct {
+	QuestionID string      `json:"questionId" binding:"required"`
+	Answer     interface{} `json:"answer"`
+}
+
+func (h *QuizHandler) GetSettings(c *gin.Context) {
+	quiz, ok := h.loadQuizForInstructor(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, quizSettings(quiz))
+}
+
+// UpdateSettings replaces the attempt settings of a quiz. Omitting
+// timeLimitSec or maxAttempts removes the limit, omitting revealAt the
+// reveal time. Attempts already in progress keep the order and deadline
+// they started with.
+func (h *QuizHandler) UpdateSettings(c *gin.Context) {
+	quiz, ok := h.loadQuizForInstructor(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateQuizSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	// A map so that false and nil are written rather than skipped
+	updates := map[string]interface{}{
+		"time_limit_sec":      req.TimeLimitSec,
+		"max_attempts":        req.MaxAttempts,
+		"shuffle_questions":   req.ShuffleQuestions,
+		"shuffle_options":     req.ShuffleOptions,
+		"reveal_after_submit": req.RevealAfterSubmit,
+		"reveal_at":           req.RevealAt,
+	}
+	if err := database.GetDB().Model(quiz).Updates(updates).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update quiz settings", err))
+		return
+	}
+
+	quiz.TimeLimitSec = req.TimeLimitSec
+	quiz.MaxAttempts = req.MaxAttempts
+	quiz.ShuffleQuestions = req.ShuffleQuestions
+	quiz.ShuffleOptions = req.ShuffleOptions
+	quiz.RevealAfterSubmit = req.RevealAfterSubmit
+	quiz.RevealAt = req.RevealAt
+	c.JSON(http.StatusOK, quizSettings(quiz))
+}
+
+// StartAttempt opens a new attempt, or returns the caller's attempt that is
+// still in progress so that reloading the page resumes it.
+func (h *QuizHandler) StartAttempt(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	quizID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid quiz ID"))
+		return
+	}
+
+	quiz, course, err := loadQuiz(quizID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireModuleUnlocked(database.GetDB(), userID, quiz.StudyPack.Material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireCourseVisible(userID, *course); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := requireCourseOpen(database.GetDB(), course.ID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Close a timed-out attempt first so it counts as used and is graded
+	// even if no new attempt may be started.
+	if err := expireQuizAttempts(database.GetDB(), quiz, course, userID); err != nil {
+		respondError(c, apperror.Internal("Failed to close expired attempts", err))
+		return
+	}
+
+	var attempt models.QuizAttempt
+	created := false
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Serialize starts on this quiz so a double click cannot open two attempts
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Quiz{}, "id = ?", quiz.ID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("quiz_id = ? AND user_id = ? AND status = ?", quiz.ID, userID, "IN_PROGRESS").First(&attempt).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := requireAnswersHidden(tx, quiz.ID, userID); err != nil {
+			return err
+		}
+		if quiz.MaxAttempts != nil {
+			var used int64
+			if err := tx.Model(&models.QuizAttempt{}).Where("quiz_id = ? AND user_id = ?", quiz.ID, userID).Count(&used).Error; err != nil {
+				return err
+			}
+			if used >= int64(*quiz.MaxAttempts) {
+				return apperror.Conflict("You have used all attempts for this quiz")
+			}
+		}
+
+		attempt = newQuizAttempt(quiz, userID, time.Now())
+		created = true
+		return tx.Create(&attempt).Error
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	view, err := quizAttemptView(quiz, &attempt, false)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(status, view)
+}
+
+func (h *QuizHandler) GetAttempt(c *gin.Context) {
+	quiz, attempt, ok := h.loadOwnAttempt(c)
+	if !ok {
+		return
+	}
+	h.respondAttempt(c, quiz, attempt)
+}
+
+// SaveAnswer stores one answer on an attempt in progress. Sending a null
+// answer clears it.
+func (h *QuizHandler) SaveAnswer(c *gin.Context) {
+	quiz, attempt, ok := h.loadOwnAttempt(c)
+	if !ok {
+		return
+	}
+
+	var req SaveQuizAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	if attempt.Status != "IN_PROGRESS" {
+		respondError(c, apperror.Conflict("This attempt has already been submitted"))
+		return
+	}
+
+	if !attemptHasQuestion(quiz, attempt, req.QuestionID) {
+		respondError(c, apperror.InvalidField("questionId", "Question is not part of this attempt"))
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{req.QuestionID: req.Answer})
+	if err != nil {
+		respondError(c, apperror.InvalidField("answer", "Answer must be valid JSON"))
+		return
+	}
+
+	// Merge in SQL so concurrent saves of different questions don't overwrite
+	// each other. The deadline is checked in the same statement.
+	query := database.GetDB().Model(&models.QuizAttempt{}).Where("id = ? AND status = ?", attempt.ID, "IN_PROGRESS")
+	if attempt.ExpiresAt != nil {
+		query = query.Where("expires_at > ?", time.Now().Add(-quizSubmitGrace))
+	}
+	result := query.Update("answers", gorm.Expr("answers || ?::jsonb", string(patch)))
+	if result.Error != nil {
+		respondError(c, apperror.Internal("Failed to save answer", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		if attempt.ExpiresAt != nil && time.Now().After(attempt.ExpiresAt.Add(quizSubmitGrace)) {
+			respondError(c, apperror.Conflict("Time is up for this attempt"))
+			return
+		}
+		respondError(c, apperror.Conflict("This attempt has already been submitted"))
+		return
+	}
+
+	if err := database.GetDB().First(attempt, attempt.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to reload attempt", err))
+		return
+	}
+	view, err := quizAttemptView(quiz, attempt, false)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// SubmitAttempt grades the saved answers and closes the attempt. Submitting
+// an attempt that is already closed returns its result.
+func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
+	quiz, attempt, ok := h.loadOwnAttempt(c)
+	if !ok {
+		return
+	}
+
+	if attempt.Status == "IN_PROGRESS" {
+		course := quiz.StudyPack.Material.Module.Course
+		if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+			return finalizeQuizAttempt(tx, quiz, &course, attempt, submissionTime(attempt, time.Now()))
+		}); err != nil {
+			respondError(c, apperror.Internal("Failed to submit attempt", err))
+			return
+		}
+		if err := database.GetDB().First(attempt, attempt.ID).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to reload attempt", err))
+			return
+		}
+	}
+
+	h.respondAttempt(c, quiz, attempt)
+}
+
+// respondAttempt renders a student's attempt, with the answers once the
+// attempt is submitted and they may be revealed.
+func (h *QuizHandler) respondAttempt(c *gin.Context, quiz *models.Quiz, attempt *models.QuizAttempt) {
+	revealed := false
+	if attempt.Status == "SUBMITTED" {
+		var err error
+		revealed, err = revealQuizAnswers(database.GetDB(), quiz, quiz.StudyPack.Material.Module.CourseID, attempt.UserID)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to check answer reveal", err))
+			return
+		}
+	}
+	view, err := quizAttemptView(quiz, attempt, revealed)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// loadOwnAttempt loads the attempt in the :id parameter for its owner,
+// closing it first if its time ran out.
+func (h *QuizHandler) loadOwnAttempt(c *gin.Context) (*models.Quiz, *models.QuizAttempt, bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	attemptID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid attempt ID"))
+		return nil, nil, false
+	}
+
+	var attempt models.QuizAttempt
+	if err := database.GetDB().Where("id = ? AND user_id = ?", attemptID, userID).First(&attempt).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Attempt not found"))
+		return nil, nil, false
+	}
+
+	quiz, course, err := loadQuiz(attempt.QuizID)
+	if err != nil {
+		respondError(c, err)
+		return nil, nil, false
+	}
+	quiz, err = quizForAttempt(database.GetDB(), quiz, &attempt)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load attempt questions", err))
+		return nil, nil, false
+	}
+
+	if attempt.Status == "IN_PROGRESS" && attemptTimedOut(&attempt, time.Now()) {
+		if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+			return finalizeQuizAttempt(tx, quiz, course, &attempt, *attempt.ExpiresAt)
+		}); err != nil {
+			respondError(c, apperror.Internal("Failed to close expired attempt", err))
+			return nil, nil, false
+		}
+		if err := database.GetDB().First(&attempt, attempt.ID).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to reload attempt", err))
+			return nil, nil, false
+		}
+	}
+
+	return quiz, &attempt, true
+}
+
+func (h *QuizHandler) loadQuizForInstructor(c *gin.Context) (*models.Quiz, bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	quizID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid quiz ID"))
+		return nil, false
+	}
+
+	quiz, course, err := loadQuiz(quizID)
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return quiz, true
+}
+
+// loadQuiz loads a quiz with its questions and the course it belongs to.
+// Quizzes under trashed materials, modules or courses are not found.
+func loadQuiz(quizID uuid.UUID) (*models.Quiz, *models.Course, error) {
+	var quiz models.Quiz
+	if err := database.GetDB().
+		Preload("Questions", byPosition).
+		Preload("StudyPack.Material.Module.Course").
+		First(&quiz, quizID).Error; err != nil {
+		return nil, nil, apperror.FromDB(err, "Quiz not found")
+	}
+
+	course := quiz.StudyPack.Material.Module.Course
+	if course.ID == uuid.Nil {
+		return nil, nil, apperror.NotFound("Quiz not found")
+	}
+	return &quiz, &course, nil
+}
+
+// quizForAttempt returns the quiz as the attempt saw it. When the quiz was
+// edited after the attempt started, the attempt keeps the questions it was
+// given, including ones since replaced or deleted.
+func quizForAttempt(db *gorm.DB, quiz *models.Quiz, attempt *models.QuizAttempt) (*models.Quiz, error) {
+	if attempt.QuizVersion == quiz.Version || attempt.QuestionOrder == nil {
+		return quiz, nil
+	}
+	var ids []string
+	json.Unmarshal([]byte(*attempt.QuestionOrder), &ids)
+
+	var questions []models.QuizQuestion
+	if err := db.Unscoped().Where("quiz_id = ? AND id IN ?", quiz.ID, ids).Scopes(byPosition).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	versioned := *quiz
+	versioned.Questions = questions
+	return &versioned, nil
+}
+
+// byPosition orders authored questions, flashcards and materials.
+func byPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC").Order("id ASC")
+}
+
+// byOrder orders the modules of a course.
+func byOrder(db *gorm.DB) *gorm.DB {
+	return db.Order(`"order" ASC`).Order("id ASC")
+}
+
+// newQuizAttempt fixes the question and option order for the attempt, so
+// every reload shows the same order.
+func newQuizAttempt(quiz *models.Quiz, userID uuid.UUID, now time.Time) models.QuizAttempt {
+	questionOrder := make([]string, len(quiz.Questions))
+	optionOrder := make(map[string][]string, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		questionOrder[i] = question.ID.String()
+		options := questionOptions(question)
+		if quiz.ShuffleOptions {
+			rand.Shuffle(len(options), func(a, b int) { options[a], options[b] = options[b], options[a] })
+		}
+		optionOrder[question.ID.String()] = options
+	}
+	if quiz.ShuffleQuestions {
+		rand.Shuffle(len(questionOrder), func(a, b int) {
+			questionOrder[a], questionOrder[b] = questionOrder[b], questionOrder[a]
+		})
+	}
+
+	questionJSON, _ := json.Marshal(questionOrder)
+	optionJSON, _ := json.Marshal(optionOrder)
+	questionStr, optionStr := string(questionJSON), string(optionJSON)
+
+	attempt := models.QuizAttempt{
+		ID:            uuid.New(),
+		QuizID:        quiz.ID,
+		UserID:        userID,
+		Answers:       "{}",
+		Status:        "IN_PROGRESS",
+		QuizVersion:   quiz.Version,
+		QuestionOrder: &questionStr,
+		OptionOrder:   &optionStr,
+		StartedAt:     &now,
+	}
+	if quiz.TimeLimitSec != nil {
+		expiresAt := now.Add(time.Duration(*quiz.TimeLimitSec) * time.Second)
+		attempt.ExpiresAt = &expiresAt
+	}
+	return attempt
+}
+
+// finalizeQuizAttempt grades the saved answers and marks the attempt
+// submitted at the given time. It is a no-op if another request already
+// closed the attempt.
+func finalizeQuizAttempt(tx *gorm.DB, quiz *models.Quiz, course *models.Course, attempt *models.QuizAttempt, at time.Time) error {
+	var answers map[string]interface{}
+	if err := json.Unmarshal([]byte(attempt.Answers), &answers); err != nil {
+		return apperror.Internal("Failed to read saved answers", err)
+	}
+	report := grading.GradeQuiz(quiz.Questions, answers)
+	resultsJSON, _ := json.Marshal(report.Questions)
+
+	result := tx.Model(&models.QuizAttempt{}).
+		Where("id = ? AND status = ?", attempt.ID, "IN_PROGRESS").
+		Updates(map[string]interface{}{
+			"status":           "SUBMITTED",
+			"score":            report.Percentage(),
+			"points_earned":    report.Earned,
+			"points_possible":  report.Possible,
+			"question_results": string(resultsJSON),
+			"submitted_at":     at,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	return tx.Create(&models.ProgressEvent{
+		UserID:    attempt.UserID,
+		CourseID:  course.ID.String(),
+		EventType: "QUIZ_ATTEMPT",
+		Payload:   attempt.Answers,
+	}).Error
+}
+
+// expireQuizAttempts closes the user's attempts on the quiz whose time ran out.
+func expireQuizAttempts(db *gorm.DB, quiz *models.Quiz, course *models.Course, userID uuid.UUID) error {
+	var expired []models.QuizAttempt
+	if err := db.Where("quiz_id = ? AND user_id = ? AND status = ? AND expires_at < ?",
+		quiz.ID, userID, "IN_PROGRESS", time.Now().Add(-quizSubmitGrace)).
+		Find(&expired).Error; err != nil {
+		return err
+	}
+	for i := range expired {
+		attemptQuiz, err := quizForAttempt(db, quiz, &expired[i])
+		if err != nil {
+			return err
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return finalizeQuizAttempt(tx, attemptQuiz, course, &expired[i], *expired[i].ExpiresAt)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func attemptTimedOut(attempt *models.QuizAttempt, now time.Time) bool {
+	return attempt.ExpiresAt != nil && now.After(attempt.ExpiresAt.Add(quizSubmitGrace))
+}
+
+// submissionTime records a submit that arrives after the deadline as made
+// at the deadline.
+func submissionTime(attempt *models.QuizAttempt, now time.Time) time.Time {
+	if attempt.ExpiresAt != nil && now.After(*attempt.ExpiresAt) {
+		return *attempt.ExpiresAt
+	}
+	return now
+}
+
+func attemptHasQuestion(quiz *models.Quiz, attempt *models.QuizAttempt, questionID string) bool {
+	for _, id := range attemptQuestionOrder(quiz, attempt) {
+		if id == questionID {
+			return true
+		}
+	}
+	return false
+}
+
+// attemptQuestionOrder falls back to the quiz order for attempts recorded
+// without a session.
+func attemptQuestionOrder(quiz *models.Quiz, attempt *models.QuizAttempt) []string {
+	var order []string
+	if attempt.QuestionOrder != nil {
+		json.Unmarshal([]byte(*attempt.QuestionOrder), &order)
+	}
+	if len(order) == 0 {
+		for _, question := range quiz.Questions {
+			order = append(order, question.ID.String())
+		}
+	}
+	return order
+}
+
+// attemptQuestionResults returns the stored per-question results, grading
+// on the fly for attempts recorded before results were stored.
+func attemptQuestionResults(questions []models.QuizQuestion, attempt *models.QuizAttempt, answers map[string]interface{}) map[uuid.UUID]grading.QuestionResult {
+	var stored []grading.QuestionResult
+	if attempt.QuestionResults != nil {
+		json.Unmarshal([]byte(*attempt.QuestionResults), &stored)
+	}
+	if len(stored) == 0 {
+		stored = grading.GradeQuiz(questions, answers).Questions
+	}
+
+	byID := make(map[uuid.UUID]grading.QuestionResult, len(stored))
+	for _, result := range stored {
+		byID[result.QuestionID] = result
+	}
+	return byID
+}
+
+func questionOptions(question models.QuizQuestion) []string {
+	var options []string
+	json.Unmarshal([]byte(question.Options), &options)
+	return options
+}
+
+func quizSettings(quiz *models.Quiz) gin.H {
+	return gin.H{
+		"quizId":            quiz.ID,
+		"timeLimitSec":      quiz.TimeLimitSec,
+		"maxAttempts":       quiz.MaxAttempts,
+		"shuffleQuestions":  quiz.ShuffleQuestions,
+		"shuffleOptions":    quiz.ShuffleOptions,
+		"revealAfterSubmit": quiz.RevealAfterSubmit,
+		"revealAt":          quiz.RevealAt,
+		"gradeCategoryId":   quiz.GradeCategoryID,
+	}
+}
+
+// quizAttemptView is what the student sees: questions in attempt order
+// without answer keys, their saved answers, and once submitted the result.
+// Correct answers and explanations are only included when revealed, see
+// revealQuizAnswers.
+func quizAttemptView(quiz *models.Quiz, attempt *models.QuizAttempt, revealed bool) (gin.H, error) {
+	byID := make(map[string]models.QuizQuestion, len(quiz.Questions))
+	for _, question := range quiz.Questions {
+		byID[question.ID.String()] = question
+	}
+
+	var optionOrder map[string][]string
+	if attempt.OptionOrder != nil {
+		json.Unmarshal([]byte(*attempt.OptionOrder), &optionOrder)
+	}
+	answers := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(attempt.Answers), &answers); err != nil {
+		return nil, apperror.Internal("Failed to read saved answers", err)
+	}
+
+	questions := []gin.H{}
+	var ordered []models.QuizQuestion
+	for _, id := range attemptQuestionOrder(quiz, attempt) {
+		question, ok := byID[id]
+		if !ok {
+			continue
+		}
+		ordered = append(ordered, question)
+		options, ok := optionOrder[id]
+		if !ok {
+			options = questionOptions(question)
+		}
+		questions = append(questions, gin.H{
+			"id":      question.ID,
+			"type":    question.Type,
+			"prompt":  question.Prompt,
+			"options": options,
+		})
+	}
+
+	view := gin.H{
+		"id":           attempt.ID,
+		"quizId":       attempt.QuizID,
+		"quizVersion":  attempt.QuizVersion,
+		"status":       attempt.Status,
+		"startedAt":    attempt.StartedAt,
+		"expiresAt":    attempt.ExpiresAt,
+		"submittedAt":  attempt.SubmittedAt,
+		"timeLimitSec": quiz.TimeLimitSec,
+		"questions":    questions,
+		"answers":      answers,
+	}
+
+	if attempt.Status == "SUBMITTED" {
+		result := gin.H{
+			"score":          attempt.Score,
+			"pointsEarned":   attempt.PointsEarned,
+			"pointsPossible": attempt.PointsPossible,
+			"revealed":       revealed,
+		}
+		if revealed {
+			graded := attemptQuestionResults(ordered, attempt, answers)
+			review := make([]gin.H, len(ordered))
+			for i, question := range ordered {
+				var answerKey interface{}
+				json.Unmarshal([]byte(question.AnswerKey), &answerKey)
+				outcome := graded[question.ID]
+				review[i] = gin.H{
+					"questionId":  question.ID,
+					"correct":     outcome.Correct,
+					"points":      outcome.Points,
+					"earned":      outcome.Earned,
+					"answerKey":   answerKey,
+					"explanation": question.Explanation,
+				}
+			}
+			result["questions"] = review
+		}
+		view["result"] = result
+	}
+
+	return view, nil
+}
+
+// revealQuizAnswers reports whether a student may see the answer keys and
+// explanations of a quiz. Beyond the quiz revealing them, the student needs
+// a submitted attempt, none in progress, and no way to start another: all
+// attempts used, the course closed, or the quiz's reveal time passed. A
+// reveal is recorded so that requireAnswersHidden refuses later attempts.
+func revealQuizAnswers(db *gorm.DB, quiz *models.Quiz, courseID, userID uuid.UUID) (bool, error) {
+	if !quiz.RevealAfterSubmit {
+		return false, nil
+	}
+
+	revealed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// StartAttempt holds this lock while it checks for a reveal
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id").First(&models.Quiz{}, "id = ?", quiz.ID).Error; err != nil {
+			return err
+		}
+		var shown int64
+		if err := tx.Model(&models.QuizReveal{}).Where("quiz_id = ? AND user_id = ?", quiz.ID, userID).Count(&shown).Error; err != nil {
+			return err
+		}
+		if shown > 0 {
+			revealed = true
+			return nil
+		}
+
+		var statuses []string
+		if err := tx.Model(&models.QuizAttempt{}).Where("quiz_id = ? AND user_id = ?", quiz.ID, userID).Pluck("status", &statuses).Error; err != nil {
+			return err
+		}
+		if !slices.Contains(statuses, "SUBMITTED") || slices.Contains(statuses, "IN_PROGRESS") {
+			return nil
+		}
+
+		final := (quiz.RevealAt != nil && !time.Now().Before(*quiz.RevealAt)) ||
+			(quiz.MaxAttempts != nil && len(statuses) >= *quiz.MaxAttempts)
+		if !final {
+			open, err := courseOpen(tx, courseID)
+			if err != nil {
+				return err
+			}
+			final = !open
+		}
+		if !final {
+			return nil
+		}
+
+		revealed = true
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.QuizReveal{QuizID: quiz.ID, UserID: userID}).Error
+	})
+	return revealed, err
+}
+
+// requireAnswersHidden refuses a new attempt from a student who has seen
+// the quiz's answers.
+func requireAnswersHidden(tx *gorm.DB, quizID, userID uuid.UUID) error {
+	var shown int64
+	if err := tx.Model(&models.QuizReveal{}).Where("quiz_id = ? AND user_id = ?", quizID, userID).Count(&shown).Error; err != nil {
+		return err
+	}
+	if shown > 0 {
+		return apperror.Conflict("The answers to this quiz have been shown to you, so no more attempts can be made")
+	}
+	return nil
+}
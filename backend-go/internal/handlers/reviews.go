@@ -0,0 +1,668 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/review"
+	"myway-backend/internal/studypack"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AssignReviewerRequest struct {
+	UserID uuid.UUID `json:"userId" binding:"required"`
+}
+
+type ReviewActionRequest struct {
+	Notes string `json:"notes"`
+}
+
+type ReviewDecisionRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=APPROVED CHANGES_REQUESTED"`
+	Comment  string `json:"comment"`
+}
+
+type ReviewCommentRequest struct {
+	Target        string     `json:"target" binding:"required,oneof=GENERAL SUMMARY KEY_POINT QUESTION"`
+	KeyPointIndex *int       `json:"keyPointIndex"`
+	QuestionID    *uuid.UUID `json:"questionId"`
+	Body          string     `json:"body" binding:"required"`
+}
+
+func (h *StudyPackHandler) GetReview(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+func (h *StudyPackHandler) AssignReviewer(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req AssignReviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if requireOrgRole(req.UserID, studyPack.Material.Module.Course.OrgID, "TEACHER", "ORGANIZER") != nil {
+		respondError(c, apperror.InvalidField("userId", "Reviewers must be teachers or organizers in this organization"))
+		return
+	}
+
+	result := database.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&models.StudyPackReviewer{
+		StudyPackID: studyPack.ID,
+		ReviewerID:  req.UserID,
+		AssignedBy:  userID,
+		Decision:    review.Pending,
+	})
+	if result.Error != nil {
+		respondError(c, apperror.Internal("Failed to assign reviewer", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondError(c, apperror.Conflict("This user is already a reviewer"))
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+// RemoveReviewer unassigns a reviewer. A pack in review is re-settled, as
+// the remaining decisions may now approve it.
+func (h *StudyPackHandler) RemoveReviewer(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	reviewerID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("userId", "Invalid user ID"))
+		return
+	}
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		result := tx.Where("study_pack_id = ? AND reviewer_id = ?", studyPack.ID, reviewerID).Delete(&models.StudyPackReviewer{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.NotFound("Reviewer not found")
+		}
+		if current.ReviewStatus != review.InReview {
+			return nil
+		}
+		return settleReview(tx, studyPack.ID, policy)
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+// SubmitForReview freezes the pack's content and asks its reviewers for a
+// fresh decision.
+func (h *StudyPackHandler) SubmitForReview(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if studyPack.Status != "GENERATED" && studyPack.Status != "READY" {
+		respondError(c, apperror.Conflict("Study pack content has not been generated yet"))
+		return
+	}
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		next, err := nextReviewStatus(current, review.Submit, policy)
+		if err != nil {
+			return err
+		}
+
+		reviewers := tx.Model(&models.StudyPackReviewer{}).Where("study_pack_id = ?", studyPack.ID)
+		if !policy.AllowSelfApproval {
+			reviewers = reviewers.Where("reviewer_id <> ?", userID)
+		}
+		var count int64
+		if err := reviewers.Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) < policy.Approvals() {
+			return apperror.Unprocessable(fmt.Sprintf("Assign at least %d reviewer(s) other than yourself before submitting", policy.Approvals()))
+		}
+
+		if err := tx.Model(&models.StudyPackReviewer{}).Where("study_pack_id = ?", studyPack.ID).Updates(map[string]interface{}{
+			"decision":   review.Pending,
+			"decided_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		return tx.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Updates(map[string]interface{}{
+			"review_status": next,
+			"submitted_by":  userID,
+			"submitted_at":  &now,
+		}).Error
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+// WithdrawReview returns a pack in review or approved to DRAFT so its
+// author can keep editing.
+func (h *StudyPackHandler) WithdrawReview(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		next, err := nextReviewStatus(current, review.Withdraw, policy)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Update("review_status", next).Error
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+// DecideReview records the caller's decision as an assigned reviewer.
+// Requesting changes needs a comment, which is kept as a GENERAL review
+// comment.
+func (h *StudyPackHandler) DecideReview(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ReviewDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if req.Decision == review.ChangesRequested && comment == "" {
+		respondError(c, apperror.InvalidField("comment", "Say what needs to change"))
+		return
+	}
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		if current.ReviewStatus != review.InReview {
+			return apperror.Conflict("Study pack is not in review")
+		}
+		if req.Decision == review.Approved && !policy.AllowSelfApproval &&
+			current.SubmittedBy != nil && *current.SubmittedBy == userID {
+			return apperror.Forbidden("You cannot approve a study pack you submitted")
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.StudyPackReviewer{}).
+			Where("study_pack_id = ? AND reviewer_id = ?", studyPack.ID, userID).
+			Updates(map[string]interface{}{"decision": req.Decision, "decided_at": &now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.Forbidden("You are not a reviewer of this study pack")
+		}
+
+		if comment != "" {
+			if err := tx.Create(&models.ReviewComment{
+				StudyPackID: studyPack.ID,
+				AuthorID:    userID,
+				Target:      "GENERAL",
+				Body:        comment,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return settleReview(tx, studyPack.ID, policy)
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+// PublishStudyPack makes the pack visible to students. Organizations that
+// require review only publish approved packs.
+func (h *StudyPackHandler) PublishStudyPack(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if studyPack.Status != "GENERATED" && studyPack.Status != "READY" {
+		respondError(c, apperror.Conflict("Study pack content has not been generated yet"))
+		return
+	}
+
+	var req ReviewActionRequest
+	_ = c.ShouldBindJSON(&req)
+
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := nextReviewStatus(current, review.Publish, policy); err != nil {
+			return err
+		}
+		_, err = publishStudyPack(tx, models.StudyPackRevision{
+			StudyPackID: studyPack.ID,
+			AuthorType:  revisionAuthorInstructor,
+			AuthorID:    &userID,
+			Notes:       &req.Notes,
+		})
+		return err
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+// UnpublishStudyPack hides the pack from students and reopens it for
+// editing.
+func (h *StudyPackHandler) UnpublishStudyPack(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		next, err := nextReviewStatus(current, review.Unpublish, policy)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Updates(map[string]interface{}{
+			"status":            "GENERATED",
+			"review_status":     next,
+			"requires_approval": true,
+		}).Error
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondReview(c, studyPack.ID)
+}
+
+func (h *StudyPackHandler) ListReviewComments(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var comments []models.ReviewComment
+	if err := database.GetDB().Preload("Author").
+		Where("study_pack_id = ?", studyPack.ID).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch review comments", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewReviewComments(comments))
+}
+
+// CreateReviewComment adds a comment anchored to the summary, a key point
+// by index or a quiz question by ID.
+func (h *StudyPackHandler) CreateReviewComment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ReviewCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		respondError(c, apperror.InvalidField("body", "Comment cannot be blank"))
+		return
+	}
+
+	comment := models.ReviewComment{
+		StudyPackID: studyPack.ID,
+		AuthorID:    userID,
+		Target:      req.Target,
+		Body:        body,
+	}
+	switch req.Target {
+	case "KEY_POINT":
+		if req.KeyPointIndex == nil {
+			respondError(c, apperror.InvalidField("keyPointIndex", "keyPointIndex is required for KEY_POINT comments"))
+			return
+		}
+		var summary models.Summary
+		if err := database.GetDB().Where("study_pack_id = ?", studyPack.ID).Limit(1).Find(&summary).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to load summary", err))
+			return
+		}
+		_, keyPoints := studypack.ParseSummary(summary.Content)
+		if *req.KeyPointIndex < 0 || *req.KeyPointIndex >= len(keyPoints) {
+			respondError(c, apperror.InvalidField("keyPointIndex", "keyPointIndex does not match a key point"))
+			return
+		}
+		comment.KeyPointIndex = req.KeyPointIndex
+	case "QUESTION":
+		if req.QuestionID == nil {
+			respondError(c, apperror.InvalidField("questionId", "questionId is required for QUESTION comments"))
+			return
+		}
+		var count int64
+		if err := database.GetDB().Model(&models.QuizQuestion{}).
+			Joins("JOIN quizzes ON quizzes.id = quiz_questions.quiz_id").
+			Where("quiz_questions.id = ? AND quizzes.study_pack_id = ?", *req.QuestionID, studyPack.ID).
+			Count(&count).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to load question", err))
+			return
+		}
+		if count == 0 {
+			respondError(c, apperror.InvalidField("questionId", "questionId is not a question in this study pack"))
+			return
+		}
+		comment.QuestionID = req.QuestionID
+	}
+
+	if err := database.GetDB().Create(&comment).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to create review comment", err))
+		return
+	}
+	if err := database.GetDB().Preload("Author").First(&comment, "id = ?", comment.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to reload review comment", err))
+		return
+	}
+	c.JSON(http.StatusCreated, dto.NewReviewComment(comment))
+}
+
+func (h *StudyPackHandler) ResolveReviewComment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid comment ID"))
+		return
+	}
+
+	var comment models.ReviewComment
+	if err := database.GetDB().First(&comment, "id = ?", commentID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Review comment not found"))
+		return
+	}
+	if _, ok := h.loadForInstructor(c, comment.StudyPackID.String()); !ok {
+		return
+	}
+
+	if comment.ResolvedAt == nil {
+		now := time.Now()
+		if err := database.GetDB().Model(&comment).Updates(map[string]interface{}{
+			"resolved_at": &now,
+			"resolved_by": userID,
+		}).Error; err != nil {
+			respondError(c, apperror.Internal("Failed to resolve review comment", err))
+			return
+		}
+	}
+	if err := database.GetDB().Preload("Author").First(&comment, "id = ?", comment.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to reload review comment", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewReviewComment(comment))
+}
+
+// GetReviewQueue lists a course's study packs by review status, by default
+// those still waiting on a reviewer or their author.
+func (h *StudyPackHandler) GetReviewQueue(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid course ID"))
+		return
+	}
+
+	var course models.Course
+	if err := database.GetDB().Select("id", "org_id").First(&course, "id = ?", courseID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Course not found"))
+		return
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	query, err := pagination.Parse(c, reviewQueueSpec)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(query.Status) == 0 {
+		query.Status = []string{review.InReview, review.ChangesRequested, review.Approved}
+	}
+
+	base := database.GetDB().
+		Joins("JOIN materials ON materials.id = study_packs.material_id AND materials.deleted_at IS NULL").
+		Joins("JOIN modules ON modules.id = materials.module_id AND modules.deleted_at IS NULL").
+		Where("modules.course_id = ?", courseID)
+	if raw := c.Query("reviewer"); raw != "" {
+		reviewerID := userID
+		if raw != "me" {
+			if reviewerID, err = uuid.Parse(raw); err != nil {
+				respondError(c, apperror.InvalidField("reviewer", "reviewer must be a user ID or \"me\""))
+				return
+			}
+		}
+		base = base.Where("EXISTS (SELECT 1 FROM study_pack_reviewers WHERE study_pack_reviewers.study_pack_id = study_packs.id AND study_pack_reviewers.reviewer_id = ?)", reviewerID)
+	}
+
+	page, err := query.Find(base, &models.StudyPack{}, "Material", "Reviewers.Reviewer")
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch review queue", err))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(page.Items))
+	for i, item := range page.Items {
+		ids[i] = item.ID
+	}
+	openComments, err := countOpenComments(ids)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to count review comments", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Map(page, func(s models.StudyPack) dto.StudyPackReview {
+		return dto.NewStudyPackReview(s, openComments[s.ID])
+	}))
+}
+
+var reviewQueueSpec = pagination.Spec[models.StudyPack]{
+	Sorts: map[string]pagination.SortField[models.StudyPack]{
+		"createdAt": {Column: "study_packs.created_at", Kind: pagination.KindTime, Value: func(m models.StudyPack) interface{} { return m.CreatedAt }},
+	},
+	DefaultSort:  "createdAt",
+	IDColumn:     "study_packs.id",
+	ID:           func(m models.StudyPack) uuid.UUID { return m.ID },
+	StatusColumn: "study_packs.review_status",
+	DateColumn:   "study_packs.created_at",
+}
+
+func respondReview(c *gin.Context, studyPackID uuid.UUID) {
+	var studyPack models.StudyPack
+	if err := database.GetDB().
+		Preload("Material").
+		Preload("Reviewers", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Reviewers.Reviewer").
+		First(&studyPack, "id = ?", studyPackID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Study pack not found"))
+		return
+	}
+	openComments, err := countOpenComments([]uuid.UUID{studyPackID})
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to count review comments", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewStudyPackReview(studyPack, openComments[studyPackID]))
+}
+
+func countOpenComments(studyPackIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(studyPackIDs))
+	if len(studyPackIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		StudyPackID uuid.UUID
+		Count       int64
+	}
+	if err := database.GetDB().Model(&models.ReviewComment{}).
+		Select("study_pack_id, COUNT(*) AS count").
+		Where("study_pack_id IN ? AND resolved_at IS NULL", studyPackIDs).
+		Group("study_pack_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.StudyPackID] = row.Count
+	}
+	return counts, nil
+}
+
+// packReviewPolicy needs the study pack's Material.Module.Course loaded.
+func packReviewPolicy(studyPack *models.StudyPack) (review.Policy, error) {
+	return reviewPolicy(database.GetDB(), studyPack.Material.Module.Course.OrgID)
+}
+
+func reviewPolicy(db *gorm.DB, orgID uuid.UUID) (review.Policy, error) {
+	settings, err := loadOrgSettings(db, orgID)
+	if err != nil {
+		return review.Policy{}, err
+	}
+	return review.Policy{
+		RequireReview:     settings.RequireReview,
+		RequiredApprovals: settings.RequiredApprovals,
+		AllowSelfApproval: settings.AllowSelfApproval,
+	}, nil
+}
+
+func lockStudyPack(tx *gorm.DB, studyPackID uuid.UUID) (*models.StudyPack, error) {
+	var studyPack models.StudyPack
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&studyPack, "id = ?", studyPackID).Error; err != nil {
+		return nil, err
+	}
+	return &studyPack, nil
+}
+
+// nextReviewStatus reports a disallowed transition as CONFLICT.
+func nextReviewStatus(studyPack *models.StudyPack, action review.Action, policy review.Policy) (string, error) {
+	next, err := review.Next(studyPack.ReviewStatus, action, policy)
+	var transition *review.TransitionError
+	if errors.As(err, &transition) {
+		message := transition.Error()
+		if action == review.Publish && policy.RequireReview {
+			message += "; this organization requires review approval before publishing"
+		}
+		return "", apperror.Conflict(strings.ToUpper(message[:1]) + message[1:])
+	}
+	return next, err
+}
+
+// settleReview moves a pack in review to the outcome of its reviewers'
+// decisions.
+func settleReview(tx *gorm.DB, studyPackID uuid.UUID, policy review.Policy) error {
+	var decisions []string
+	if err := tx.Model(&models.StudyPackReviewer{}).Where("study_pack_id = ?", studyPackID).Pluck("decision", &decisions).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.StudyPack{}).Where("id = ?", studyPackID).
+		Update("review_status", review.Outcome(decisions, policy)).Error
+}
+
+// publishStudyPack makes the study pack visible to students and records
+// the published content as revision, which must have an AuthorID.
+func publishStudyPack(tx *gorm.DB, revision models.StudyPackRevision) (*models.StudyPackRevision, error) {
+	now := time.Now()
+	approvedBy := revision.AuthorID.String()
+	if err := tx.Model(&models.StudyPack{}).Where("id = ?", revision.StudyPackID).Updates(map[string]interface{}{
+		"status":            "READY",
+		"review_status":     review.Published,
+		"published_at":      &now,
+		"requires_approval": false,
+		"approved_by":       &approvedBy,
+	}).Error; err != nil {
+		return nil, err
+	}
+	revision.Published = true
+	return recordRevision(tx, revision)
+}
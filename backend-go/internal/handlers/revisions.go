@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"encoding/json"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/review"
+	"myway-backend/internal/studypack"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	revisionAuthorAI         = "AI"
+	revisionAuthorInstructor = "INSTRUCTOR"
+)
+
+type RollbackStudyPackRequest struct {
+	Notes string `json:"notes"`
+}
+
+func (h *StudyPackHandler) ListRevisions(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	query, err := pagination.Parse(c, revisionListSpec)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	page, err := query.Find(database.GetDB().Where("study_pack_id = ?", studyPack.ID), &models.StudyPackRevision{}, "Author")
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch revisions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Map(page, func(r models.StudyPackRevision) dto.StudyPackRevision {
+		return dto.NewStudyPackRevision(r, false)
+	}))
+}
+
+func (h *StudyPackHandler) GetRevision(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	revision, err := loadRevision(studyPack.ID, c.Param("number"), "number")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewStudyPackRevision(*revision, true))
+}
+
+// DiffRevisions compares the revisions in the base and head query
+// parameters field by field.
+func (h *StudyPackHandler) DiffRevisions(c *gin.Context) {
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	base, err := loadRevision(studyPack.ID, c.Query("base"), "base")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	head, err := loadRevision(studyPack.ID, c.Query("head"), "head")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var baseSnapshot, headSnapshot studypack.Snapshot
+	json.Unmarshal([]byte(base.Snapshot), &baseSnapshot)
+	json.Unmarshal([]byte(head.Snapshot), &headSnapshot)
+
+	c.JSON(http.StatusOK, gin.H{
+		"base":    base.Number,
+		"head":    head.Number,
+		"changes": studypack.Diff(baseSnapshot, headSnapshot),
+	})
+}
+
+// RollbackRevision restores the content of a revision and publishes it,
+// or leaves it as a draft where the organization requires review. The
+// rollback is recorded as a new revision, so history is never lost.
+func (h *StudyPackHandler) RollbackRevision(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPack, ok := h.loadForInstructor(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	target, err := loadRevision(studyPack.ID, c.Param("number"), "number")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req RollbackStudyPackRequest
+	_ = c.ShouldBindJSON(&req)
+
+	var snapshot studypack.Snapshot
+	if err := json.Unmarshal([]byte(target.Snapshot), &snapshot); err != nil {
+		respondError(c, apperror.Internal("Failed to read revision", err))
+		return
+	}
+
+	notes := "Rolled back to revision " + strconv.Itoa(target.Number)
+	if strings.TrimSpace(req.Notes) != "" {
+		notes += ": " + strings.TrimSpace(req.Notes)
+	}
+
+	policy, err := packReviewPolicy(studyPack)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to load review policy", err))
+		return
+	}
+
+	var revision *models.StudyPackRevision
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		current, err := lockStudyPack(tx, studyPack.ID)
+		if err != nil {
+			return err
+		}
+		if current.ReviewStatus == review.InReview || current.ReviewStatus == review.Approved {
+			return apperror.Conflict("Withdraw the study pack from review before rolling it back")
+		}
+		if err := restoreSnapshot(tx, studyPack.ID, snapshot); err != nil {
+			return err
+		}
+
+		restored := models.StudyPackRevision{
+			StudyPackID:  studyPack.ID,
+			AuthorType:   revisionAuthorInstructor,
+			AuthorID:     &userID,
+			Notes:        &notes,
+			RestoredFrom: &target.Number,
+		}
+		if !policy.RequireReview {
+			revision, err = publishStudyPack(tx, restored)
+			return err
+		}
+		// Restored content goes through review like any other edit.
+		if err := tx.Model(&models.StudyPack{}).Where("id = ?", studyPack.ID).Updates(map[string]interface{}{
+			"status":            "GENERATED",
+			"review_status":     review.Draft,
+			"requires_approval": true,
+		}).Error; err != nil {
+			return err
+		}
+		revision, err = recordRevision(tx, restored)
+		return err
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := database.GetDB().Preload("Author").First(revision, revision.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to reload revision", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewStudyPackRevision(*revision, true))
+}
+
+var revisionListSpec = pagination.Spec[models.StudyPackRevision]{
+	Sorts: map[string]pagination.SortField[models.StudyPackRevision]{
+		"number": {Column: "number", Kind: pagination.KindInt, Value: func(m models.StudyPackRevision) interface{} { return m.Number }},
+	},
+	DefaultSort: "-number",
+	ID:          func(m models.StudyPackRevision) uuid.UUID { return m.ID },
+	DateColumn:  "created_at",
+}
+
+func loadRevision(studyPackID uuid.UUID, rawNumber, field string) (*models.StudyPackRevision, error) {
+	number, err := strconv.Atoi(rawNumber)
+	if err != nil || number < 1 {
+		return nil, apperror.InvalidField(field, "Revision number must be a positive integer")
+	}
+	var revision models.StudyPackRevision
+	if err := database.GetDB().Preload("Author").
+		Where("study_pack_id = ? AND number = ?", studyPackID, number).
+		First(&revision).Error; err != nil {
+		return nil, apperror.FromDB(err, "Revision not found")
+	}
+	return &revision, nil
+}
+
+// recordRevision snapshots the study pack's current content as its next
+// revision. The caller fills in StudyPackID, the author and the flags;
+// blank notes are dropped.
+func recordRevision(db *gorm.DB, revision models.StudyPackRevision) (*models.StudyPackRevision, error) {
+	if revision.Notes != nil {
+		notes := strings.TrimSpace(*revision.Notes)
+		revision.Notes = &notes
+		if notes == "" {
+			revision.Notes = nil
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Serialize numbering per study pack
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.StudyPack{}, "id = ?", revision.StudyPackID).Error; err != nil {
+			return err
+		}
+
+		var studyPack models.StudyPack
+		if err := tx.
+			Preload("Summary").
+			Preload("Quizzes", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+			Preload("Quizzes.Questions", byPosition).
+			Preload("Flashcards", byPosition).
+			First(&studyPack, "id = ?", revision.StudyPackID).Error; err != nil {
+			return err
+		}
+		snapshot, err := json.Marshal(studypack.Capture(studyPack))
+		if err != nil {
+			return err
+		}
+		revision.Snapshot = string(snapshot)
+
+		if err := tx.Model(&models.StudyPackRevision{}).Where("study_pack_id = ?", revision.StudyPackID).
+			Select("COALESCE(MAX(number), 0) + 1").Scan(&revision.Number).Error; err != nil {
+			return err
+		}
+		return tx.Create(&revision).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// restoreSnapshot makes the study pack's summary, quiz questions and
+// flashcards match a snapshot. Questions follow the same rules as
+// authoring edits, so attempts keep the questions they answered.
+func restoreSnapshot(tx *gorm.DB, studyPackID uuid.UUID, snapshot studypack.Snapshot) error {
+	if err := saveSummary(tx, studyPackID, studypack.SummaryContent(snapshot.Summary, snapshot.KeyPoints)); err != nil {
+		return err
+	}
+
+	var quizIDs []uuid.UUID
+	if err := tx.Model(&models.Quiz{}).Where("study_pack_id = ?", studyPackID).Pluck("id", &quizIDs).Error; err != nil {
+		return err
+	}
+	restored := make(map[uuid.UUID]bool, len(snapshot.Quizzes))
+	for _, quiz := range snapshot.Quizzes {
+		restored[quiz.ID] = true
+		var count int64
+		if err := tx.Model(&models.Quiz{}).Where("id = ? AND study_pack_id = ?", quiz.ID, studyPackID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Create(&models.Quiz{ID: quiz.ID, StudyPackID: studyPackID, Version: 1, Metadata: "{}", RevealAfterSubmit: true}).Error; err != nil {
+				return err
+			}
+		}
+		if err := syncQuizQuestions(tx, quiz.ID, quiz.Questions); err != nil {
+			return err
+		}
+	}
+	// Quizzes added after the revision are emptied rather than deleted so
+	// their attempts keep a quiz to point at.
+	for _, quizID := range quizIDs {
+		if !restored[quizID] {
+			if err := syncQuizQuestions(tx, quizID, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return syncFlashcards(tx, studyPackID, snapshot.Flashcards)
+}
+
+// saveSummary creates or replaces the study pack's summary content.
+// recordBaselineRevision snapshots a study pack that has no revisions yet,
+// such as one generated before revisions were kept, so content about to be
+// replaced can still be rolled back to.
+func recordBaselineRevision(tx *gorm.DB, studyPack *models.StudyPack) error {
+	var count int64
+	if err := tx.Model(&models.StudyPackRevision{}).Where("study_pack_id = ?", studyPack.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	notes := "Content from before revision history"
+	_, err := recordRevision(tx, models.StudyPackRevision{
+		StudyPackID: studyPack.ID,
+		AuthorType:  revisionAuthorAI,
+		Notes:       &notes,
+		Published:   studyPack.ReviewStatus == review.Published,
+	})
+	return err
+}
+
+func saveSummary(tx *gorm.DB, studyPackID uuid.UUID, content string) error {
+	summary := models.Summary{StudyPackID: studyPackID, Content: content}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "study_pack_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content"}),
+	}).Create(&summary).Error
+}
+
+// syncQuizQuestions makes a quiz's live questions match want, keeping
+// rows that are unchanged and bumping the quiz version if anything moved.
+func syncQuizQuestions(tx *gorm.DB, quizID uuid.UUID, want []studypack.SnapshotQuestion) error {
+	attempted, err := lockAttemptedQuiz(tx, quizID)
+	if err != nil {
+		return err
+	}
+
+	var current []models.QuizQuestion
+	if err := tx.Where("quiz_id = ?", quizID).Find(&current).Error; err != nil {
+		return err
+	}
+	live := make(map[uuid.UUID]models.QuizQuestion, len(current))
+	for _, question := range current {
+		live[question.ID] = question
+	}
+
+	changed := false
+	for position, question := range want {
+		existing, ok := live[question.ID]
+		delete(live, question.ID)
+		switch {
+		case ok && sameQuestion(existing, question):
+			if existing.Position != position {
+				if err := tx.Model(&existing).UpdateColumn("position", position).Error; err != nil {
+					return err
+				}
+				changed = true
+			}
+			continue
+		case ok && !attempted:
+			if err := tx.Model(&existing).Updates(map[string]interface{}{
+				"type":        question.Type,
+				"prompt":      question.Prompt,
+				"options":     string(question.Options),
+				"answer_key":  string(question.AnswerKey),
+				"explanation": question.Explanation,
+				"points":      question.Points,
+				"position":    position,
+			}).Error; err != nil {
+				return err
+			}
+		case ok:
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(snapshotQuestion(uuid.New(), quizID, question, position)).Error; err != nil {
+				return err
+			}
+		default:
+			var previous models.QuizQuestion
+			err := tx.Unscoped().Where("id = ?", question.ID).Limit(1).Find(&previous).Error
+			if err != nil {
+				return err
+			}
+			switch {
+			case previous.ID == uuid.Nil:
+				err = tx.Create(snapshotQuestion(question.ID, quizID, question, position)).Error
+			case previous.QuizID == quizID && sameQuestion(previous, question):
+				// Deleted since the revision but unchanged, so attempts
+				// that saw it are unaffected by bringing it back.
+				err = tx.Unscoped().Model(&previous).Updates(map[string]interface{}{"deleted_at": nil, "position": position}).Error
+			default:
+				err = tx.Create(snapshotQuestion(uuid.New(), quizID, question, position)).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		changed = true
+	}
+
+	for _, leftover := range live {
+		db := tx
+		if !attempted {
+			db = tx.Unscoped()
+		}
+		if err := db.Delete(&leftover).Error; err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return bumpQuizVersion(tx, quizID)
+}
+
+func syncFlashcards(tx *gorm.DB, studyPackID uuid.UUID, want []studypack.SnapshotFlashcard) error {
+	var current []models.Flashcard
+	if err := tx.Where("study_pack_id = ?", studyPackID).Find(&current).Error; err != nil {
+		return err
+	}
+	live := make(map[uuid.UUID]models.Flashcard, len(current))
+	for _, flashcard := range current {
+		live[flashcard.ID] = flashcard
+	}
+
+	for position, flashcard := range want {
+		var tags *string
+		if raw := string(flashcard.Tags); raw != "" && raw != "null" {
+			tags = &raw
+		}
+		if existing, ok := live[flashcard.ID]; ok {
+			delete(live, flashcard.ID)
+			if err := tx.Model(&existing).Updates(map[string]interface{}{
+				"front":    flashcard.Front,
+				"back":     flashcard.Back,
+				"tags":     tags,
+				"position": position,
+			}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Create(&models.Flashcard{
+			ID:          flashcard.ID,
+			StudyPackID: studyPackID,
+			Front:       flashcard.Front,
+			Back:        flashcard.Back,
+			Tags:        tags,
+			Position:    position,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, leftover := range live {
+		if err := tx.Where("flashcard_id = ?", leftover.ID).Delete(&models.FlashcardReviewState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&leftover).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameQuestion(row models.QuizQuestion, question studypack.SnapshotQuestion) bool {
+	explanation := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return row.Type == question.Type &&
+		row.Prompt == question.Prompt &&
+		studypack.SameJSON(row.Options, string(question.Options)) &&
+		studypack.SameJSON(row.AnswerKey, string(question.AnswerKey)) &&
+		explanation(row.Explanation) == explanation(question.Explanation) &&
+		row.Points == question.Points
+}
+
+func snapshotQuestion(id, quizID uuid.UUID, question studypack.SnapshotQuestion, position int) *models.QuizQuestion {
+	return &models.QuizQuestion{
+		ID:          id,
+		QuizID:      quizID,
+		Type:        question.Type,
+		Prompt:      question.Prompt,
+		Options:     string(question.Options),
+		AnswerKey:   string(question.AnswerKey),
+		Explanation: question.Explanation,
+		Points:      question.Points,
+		Position:    position,
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"myway-backend/internal/rubric"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RubricRequest struct {
+	Title       string  `json:"title" binding:"required"`
+	Description *string `json:"description"`
+	// Criteria and levels keep their identity by ID; omit the ID for new
+	// ones.
+	Criteria []rubric.Criterion `json:"criteria" binding:"required,min=1"`
+}
+
+type AttachRubricRequest struct {
+	RubricID string `json:"rubricId" binding:"required"`
+}
+
+// CreateRubric adds a rubric to an organization for its teachers to attach
+// to assignments.
+func (h *AssignmentHandler) CreateRubric(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req RubricRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	title, criteria, err := parseRubricRequest(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	rubricRow := models.Rubric{OrgID: orgID, Title: title, Description: emptyToNil(req.Description), Version: 1, CreatedBy: userID}
+	var current models.RubricVersion
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rubricRow).Error; err != nil {
+			return err
+		}
+		current = models.RubricVersion{RubricID: rubricRow.ID, Number: 1, Criteria: criteria, CreatedBy: userID}
+		return tx.Create(&current).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to create rubric", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewRubric(rubricRow, current))
+}
+
+// GetRubricsByOrg lists an organization's rubrics by title.
+func (h *AssignmentHandler) GetRubricsByOrg(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var rubrics []models.Rubric
+	if err := database.GetDB().
+		Preload("Versions", currentRubricVersion).
+		Where("org_id = ?", orgID).
+		Order("title ASC, id").
+		Find(&rubrics).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch rubrics", err))
+		return
+	}
+
+	response := make([]dto.Rubric, 0, len(rubrics))
+	for _, r := range rubrics {
+		var current models.RubricVersion
+		if len(r.Versions) > 0 {
+			current = r.Versions[0]
+		}
+		response = append(response, dto.NewRubric(r, current))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AssignmentHandler) GetRubric(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	rubricRow, current, ok := loadInstructorRubric(c, userID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewRubric(*rubricRow, *current))
+}
+
+// UpdateRubric replaces a rubric's title, description and criteria. While
+// no submission has been graded with the current version it is edited in
+// place; after that the criteria become a new version, and earlier grades
+// keep the version they were given with.
+func (h *AssignmentHandler) UpdateRubric(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	rubricRow, _, ok := loadInstructorRubric(c, userID)
+	if !ok {
+		return
+	}
+
+	var req RubricRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	title, criteria, err := parseRubricRequest(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var current models.RubricVersion
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Graders read the current version under a share lock, so no grade
+		// can land on it between the check and the edit.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(rubricRow, "id = ?", rubricRow.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("rubric_id = ? AND number = ?", rubricRow.ID, rubricRow.Version).First(&current).Error; err != nil {
+			return err
+		}
+		var graded, reviewed int64
+		if err := tx.Model(&models.SubmissionAttempt{}).Where("rubric_version_id = ?", current.ID).Count(&graded).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PeerReview{}).Where("rubric_version_id = ?", current.ID).Count(&reviewed).Error; err != nil {
+			return err
+		}
+		graded += reviewed
+
+		if graded > 0 {
+			current = models.RubricVersion{RubricID: rubricRow.ID, Number: rubricRow.Version + 1, Criteria: criteria, CreatedBy: userID}
+			if err := tx.Create(&current).Error; err != nil {
+				return err
+			}
+			rubricRow.Version = current.Number
+		} else {
+			current.Criteria = criteria
+			if err := tx.Model(&current).Update("criteria", criteria).Error; err != nil {
+				return err
+			}
+		}
+
+		rubricRow.Title = title
+		rubricRow.Description = emptyToNil(req.Description)
+		return tx.Model(rubricRow).Updates(map[string]interface{}{
+			"title":       rubricRow.Title,
+			"description": rubricRow.Description,
+			"version":     rubricRow.Version,
+		}).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to update rubric", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewRubric(*rubricRow, current))
+}
+
+// DeleteRubric removes a rubric that no assignment uses. Grades given with
+// it keep their breakdown.
+func (h *AssignmentHandler) DeleteRubric(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	rubricRow, _, ok := loadInstructorRubric(c, userID)
+	if !ok {
+		return
+	}
+
+	// Trashed assignments count too, so that restoring them finds the rubric.
+	var attached int64
+	if err := database.GetDB().Unscoped().Model(&models.Assignment{}).Where("rubric_id = ?", rubricRow.ID).Count(&attached).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to check rubric usage", err))
+		return
+	}
+	if attached > 0 {
+		respondError(c, apperror.Conflict("This rubric is attached to assignments; detach it first"))
+		return
+	}
+	if err := database.GetDB().Delete(rubricRow).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to delete rubric", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rubric deleted"})
+}
+
+// GetRubricVersions lists every version of a rubric, oldest first.
+func (h *AssignmentHandler) GetRubricVersions(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	rubricRow, _, ok := loadInstructorRubric(c, userID)
+	if !ok {
+		return
+	}
+
+	var versions []models.RubricVersion
+	if err := database.GetDB().Where("rubric_id = ?", rubricRow.ID).Scopes(byNumber).Find(&versions).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch rubric versions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewRubricVersions(versions))
+}
+
+// AttachRubric makes an assignment graded with a rubric of its course's
+// organization, replacing any rubric it had.
+func (h *AssignmentHandler) AttachRubric(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+
+	var req AttachRubricRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	rubricID, err := uuid.Parse(req.RubricID)
+	if err != nil {
+		respondError(c, apperror.InvalidField("rubricId", "Invalid rubric ID"))
+		return
+	}
+	var rubricRow models.Rubric
+	if err := database.GetDB().Where("org_id = ?", assignment.Course.OrgID).First(&rubricRow, rubricID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Rubric not found"))
+		return
+	}
+
+	if err := database.GetDB().Model(assignment).Update("rubric_id", rubricRow.ID).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to attach rubric", err))
+		return
+	}
+	assignment.RubricID = &rubricRow.ID
+
+	c.JSON(http.StatusOK, dto.NewAssignment(*assignment))
+}
+
+// DetachRubric goes back to grading an assignment with a single score.
+func (h *AssignmentHandler) DetachRubric(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	assignment, ok := loadInstructorAssignment(c, userID)
+	if !ok {
+		return
+	}
+	if assignment.PeerReviewCount != nil {
+		respondError(c, apperror.Conflict("Peer review needs the rubric; turn it off first"))
+		return
+	}
+
+	if err := database.GetDB().Model(assignment).Update("rubric_id", nil).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to detach rubric", err))
+		return
+	}
+	assignment.RubricID = nil
+
+	c.JSON(http.StatusOK, dto.NewAssignment(*assignment))
+}
+
+// parseRubricRequest trims and validates a rubric, returning its title and
+// criteria encoded for RubricVersion.Criteria.
+func parseRubricRequest(req RubricRequest) (string, string, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return "", "", apperror.InvalidField("title", "Title is required")
+	}
+	criteria := rubric.Normalize(req.Criteria)
+	if err := rubric.Validate(criteria); err != nil {
+		return "", "", apperror.InvalidField("criteria", err.Error())
+	}
+	encoded, err := json.Marshal(criteria)
+	if err != nil {
+		return "", "", apperror.Internal("Failed to encode rubric", err)
+	}
+	return title, string(encoded), nil
+}
+
+// loadInstructorRubric loads the rubric named by :id with its current
+// version if the caller teaches or organizes its organization, responding
+// with an error otherwise.
+func loadInstructorRubric(c *gin.Context, userID uuid.UUID) (*models.Rubric, *models.RubricVersion, bool) {
+	rubricID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid rubric ID"))
+		return nil, nil, false
+	}
+	rubricRow, current, err := loadRubric(database.GetDB(), rubricID)
+	if err != nil {
+		respondError(c, err)
+		return nil, nil, false
+	}
+	if err := requireOrgRole(userID, rubricRow.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, nil, false
+	}
+	return rubricRow, current, true
+}
+
+// loadRubric loads a rubric and its current version.
+func loadRubric(db *gorm.DB, rubricID uuid.UUID) (*models.Rubric, *models.RubricVersion, error) {
+	var rubricRow models.Rubric
+	if err := db.First(&rubricRow, rubricID).Error; err != nil {
+		return nil, nil, apperror.FromDB(err, "Rubric not found")
+	}
+	var current models.RubricVersion
+	if err := db.Where("rubric_id = ? AND number = ?", rubricRow.ID, rubricRow.Version).First(&current).Error; err != nil {
+		return nil, nil, apperror.FromDB(err, "Rubric not found")
+	}
+	return &rubricRow, &current, nil
+}
+
+// currentRubricVersion preloads only the current version of each rubric.
+func currentRubricVersion(db *gorm.DB) *gorm.DB {
+	return db.Where("number = (SELECT version FROM rubrics WHERE rubrics.id = rubric_versions.rubric_id)")
+}
@@ -0,0 +1,537 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/grading"
+	"myway-backend/internal/models"
+	"myway-backend/internal/review"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StudyPackHandler lets instructors author the quizzes and flashcards of a
+// study pack while it is a draft. Every change to a quiz's questions bumps
+// Quiz.Version; attempts record the version they started on.
+type StudyPackHandler struct{}
+
+func NewStudyPackHandler() *StudyPackHandler {
+	return &StudyPackHandler{}
+}
+
+type QuizQuestionRequest struct {
+	Type        string      `json:"type" binding:"required"`
+	Prompt      string      `json:"prompt" binding:"required"`
+	Options     []string    `json:"options"`
+	AnswerKey   interface{} `json:"answerKey"` // shape depends on type, see README
+	Explanation *string     `json:"explanation"`
+	Points      *int        `json:"points" binding:"omitempty,min=1,max=1000"`
+}
+
+type FlashcardRequest struct {
+	Front string   `json:"front" binding:"required"`
+	Back  string   `json:"back" binding:"required"`
+	Tags  []string `json:"tags"`
+}
+
+// ReorderRequest lists every item of a collection in its new order.
+type ReorderRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1"`
+}
+
+// CreateQuiz adds an empty quiz to a draft study pack.
+func (h *StudyPackHandler) CreateQuiz(c *gin.Context) {
+	studyPack, ok := h.loadDraft(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	quiz := models.Quiz{StudyPackID: studyPack.ID, Version: 1, Metadata: "{}", RevealAfterSubmit: true}
+	if err := database.GetDB().Create(&quiz).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to create quiz", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewQuiz(quiz, dto.Instructor))
+}
+
+func (h *StudyPackHandler) CreateQuestion(c *gin.Context) {
+	quiz, ok := h.loadDraftQuiz(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req QuizQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	question, err := newQuizQuestion(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	question.QuizID = quiz.ID
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := lockQuiz(tx, quiz.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.QuizQuestion{}).Where("quiz_id = ?", quiz.ID).
+			Select("COALESCE(MAX(position) + 1, 0)").Scan(&question.Position).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&question).Error; err != nil {
+			return err
+		}
+		return bumpQuizVersion(tx, quiz.ID)
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to create question", err))
+		return
+	}
+
+	h.respondQuiz(c, http.StatusCreated, quiz.ID)
+}
+
+// UpdateQuestion replaces a question. Once the quiz has attempts the old
+// row is kept for them and the edit is saved as a new question, so the
+// response carries the question's new ID.
+func (h *StudyPackHandler) UpdateQuestion(c *gin.Context) {
+	existing, ok := h.loadDraftQuestion(c)
+	if !ok {
+		return
+	}
+
+	var req QuizQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	question, err := newQuizQuestion(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		attempted, err := lockAttemptedQuiz(tx, existing.QuizID)
+		if err != nil {
+			return err
+		}
+		if attempted {
+			question.QuizID = existing.QuizID
+			question.Position = existing.Position
+			if err := tx.Delete(existing).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&question).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(existing).Updates(map[string]interface{}{
+			"type":        question.Type,
+			"prompt":      question.Prompt,
+			"options":     question.Options,
+			"answer_key":  question.AnswerKey,
+			"explanation": question.Explanation,
+			"points":      question.Points,
+		}).Error; err != nil {
+			return err
+		}
+		return bumpQuizVersion(tx, existing.QuizID)
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to update question", err))
+		return
+	}
+
+	h.respondQuiz(c, http.StatusOK, existing.QuizID)
+}
+
+// DeleteQuestion removes a question from the quiz. Attempts that were
+// given the question keep it.
+func (h *StudyPackHandler) DeleteQuestion(c *gin.Context) {
+	existing, ok := h.loadDraftQuestion(c)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		attempted, err := lockAttemptedQuiz(tx, existing.QuizID)
+		if err != nil {
+			return err
+		}
+		if !attempted {
+			tx = tx.Unscoped()
+		}
+		if err := tx.Delete(existing).Error; err != nil {
+			return err
+		}
+		return bumpQuizVersion(tx, existing.QuizID)
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to delete question", err))
+		return
+	}
+
+	h.respondQuiz(c, http.StatusOK, existing.QuizID)
+}
+
+func (h *StudyPackHandler) ReorderQuestions(c *gin.Context) {
+	quiz, ok := h.loadDraftQuiz(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := lockQuiz(tx, quiz.ID); err != nil {
+			return err
+		}
+		if err := reorder(tx, &models.QuizQuestion{}, "quiz_id", quiz.ID, req.IDs); err != nil {
+			return err
+		}
+		return bumpQuizVersion(tx, quiz.ID)
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.respondQuiz(c, http.StatusOK, quiz.ID)
+}
+
+func (h *StudyPackHandler) CreateFlashcard(c *gin.Context) {
+	studyPack, ok := h.loadDraft(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req FlashcardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	flashcard, err := newFlashcard(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	flashcard.StudyPackID = studyPack.ID
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Flashcard{}).Where("study_pack_id = ?", studyPack.ID).
+			Select("COALESCE(MAX(position) + 1, 0)").Scan(&flashcard.Position).Error; err != nil {
+			return err
+		}
+		return tx.Create(&flashcard).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to create flashcard", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewFlashcard(flashcard))
+}
+
+// UpdateFlashcard edits a card in place; learners keep their review
+// schedule for it.
+func (h *StudyPackHandler) UpdateFlashcard(c *gin.Context) {
+	existing, ok := h.loadDraftFlashcard(c)
+	if !ok {
+		return
+	}
+
+	var req FlashcardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	flashcard, err := newFlashcard(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := database.GetDB().Model(existing).Updates(map[string]interface{}{
+		"front": flashcard.Front,
+		"back":  flashcard.Back,
+		"tags":  flashcard.Tags,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update flashcard", err))
+		return
+	}
+	existing.Front, existing.Back, existing.Tags = flashcard.Front, flashcard.Back, flashcard.Tags
+
+	c.JSON(http.StatusOK, dto.NewFlashcard(*existing))
+}
+
+func (h *StudyPackHandler) DeleteFlashcard(c *gin.Context) {
+	existing, ok := h.loadDraftFlashcard(c)
+	if !ok {
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("flashcard_id = ?", existing.ID).Delete(&models.FlashcardReviewState{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(existing).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to delete flashcard", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Flashcard deleted"})
+}
+
+func (h *StudyPackHandler) ReorderFlashcards(c *gin.Context) {
+	studyPack, ok := h.loadDraft(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		return reorder(tx, &models.Flashcard{}, "study_pack_id", studyPack.ID, req.IDs)
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var flashcards []models.Flashcard
+	if err := database.GetDB().Where("study_pack_id = ?", studyPack.ID).Scopes(byPosition).Find(&flashcards).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch flashcards", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewFlashcards(flashcards))
+}
+
+// loadDraft loads a study pack the caller may edit: they teach in its
+// organization and it is not published, in review or approved.
+func (h *StudyPackHandler) loadDraft(c *gin.Context, rawID string) (*models.StudyPack, bool) {
+	studyPack, ok := h.loadForInstructor(c, rawID)
+	if !ok {
+		return nil, false
+	}
+	if !review.Editable(studyPack.ReviewStatus) {
+		respondError(c, apperror.Conflict("Study packs can only be edited as drafts, after changes are requested or once unpublished"))
+		return nil, false
+	}
+	return studyPack, true
+}
+
+// loadForInstructor loads a study pack whose organization the caller
+// teaches in.
+func (h *StudyPackHandler) loadForInstructor(c *gin.Context, rawID string) (*models.StudyPack, bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	studyPackID, err := uuid.Parse(rawID)
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid study pack ID"))
+		return nil, false
+	}
+
+	var studyPack models.StudyPack
+	if err := database.GetDB().Preload("Material.Module.Course").First(&studyPack, "id = ?", studyPackID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Study pack not found"))
+		return nil, false
+	}
+	course := studyPack.Material.Module.Course
+	if course.ID == uuid.Nil {
+		respondError(c, apperror.NotFound("Study pack not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return &studyPack, true
+}
+
+func (h *StudyPackHandler) loadDraftQuiz(c *gin.Context, rawID string) (*models.Quiz, bool) {
+	quizID, err := uuid.Parse(rawID)
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid quiz ID"))
+		return nil, false
+	}
+	var quiz models.Quiz
+	if err := database.GetDB().Select("id", "study_pack_id").First(&quiz, "id = ?", quizID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Quiz not found"))
+		return nil, false
+	}
+	if _, ok := h.loadDraft(c, quiz.StudyPackID.String()); !ok {
+		return nil, false
+	}
+	return &quiz, true
+}
+
+func (h *StudyPackHandler) loadDraftQuestion(c *gin.Context) (*models.QuizQuestion, bool) {
+	questionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid question ID"))
+		return nil, false
+	}
+	var question models.QuizQuestion
+	if err := database.GetDB().First(&question, "id = ?", questionID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Question not found"))
+		return nil, false
+	}
+	if _, ok := h.loadDraftQuiz(c, question.QuizID.String()); !ok {
+		return nil, false
+	}
+	return &question, true
+}
+
+func (h *StudyPackHandler) loadDraftFlashcard(c *gin.Context) (*models.Flashcard, bool) {
+	flashcardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid flashcard ID"))
+		return nil, false
+	}
+	var flashcard models.Flashcard
+	if err := database.GetDB().First(&flashcard, "id = ?", flashcardID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Flashcard not found"))
+		return nil, false
+	}
+	if _, ok := h.loadDraft(c, flashcard.StudyPackID.String()); !ok {
+		return nil, false
+	}
+	return &flashcard, true
+}
+
+// respondQuiz returns the quiz as instructors see it after an edit.
+func (h *StudyPackHandler) respondQuiz(c *gin.Context, status int, quizID uuid.UUID) {
+	quiz, _, err := loadQuiz(quizID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(status, dto.NewQuiz(*quiz, dto.Instructor))
+}
+
+// newQuizQuestion validates a question for its type and encodes it for
+// storage.
+func newQuizQuestion(req QuizQuestionRequest) (models.QuizQuestion, error) {
+	questionType := strings.ToUpper(strings.TrimSpace(req.Type))
+	if strings.TrimSpace(req.Prompt) == "" {
+		return models.QuizQuestion{}, apperror.InvalidField("prompt", "Prompt is required")
+	}
+	options := req.Options
+	if options == nil {
+		options = []string{}
+	}
+	if err := grading.Validate(questionType, options, req.AnswerKey); err != nil {
+		var fieldErr *grading.FieldError
+		if errors.As(err, &fieldErr) {
+			return models.QuizQuestion{}, apperror.InvalidField(fieldErr.Field, fieldErr.Message)
+		}
+		return models.QuizQuestion{}, err
+	}
+
+	optionsJSON, _ := json.Marshal(options)
+	keyJSON, _ := json.Marshal(req.AnswerKey)
+	points := 1
+	if req.Points != nil {
+		points = *req.Points
+	}
+	return models.QuizQuestion{
+		Type:        questionType,
+		Prompt:      strings.TrimSpace(req.Prompt),
+		Options:     string(optionsJSON),
+		AnswerKey:   string(keyJSON),
+		Explanation: req.Explanation,
+		Points:      points,
+	}, nil
+}
+
+func newFlashcard(req FlashcardRequest) (models.Flashcard, error) {
+	front, back := strings.TrimSpace(req.Front), strings.TrimSpace(req.Back)
+	if front == "" {
+		return models.Flashcard{}, apperror.InvalidField("front", "Front is required")
+	}
+	if back == "" {
+		return models.Flashcard{}, apperror.InvalidField("back", "Back is required")
+	}
+	flashcard := models.Flashcard{Front: front, Back: back}
+	if len(req.Tags) > 0 {
+		tagsJSON, _ := json.Marshal(req.Tags)
+		tags := string(tagsJSON)
+		flashcard.Tags = &tags
+	}
+	return flashcard, nil
+}
+
+// lockQuiz serializes edits with each other and with attempt starts.
+func lockQuiz(tx *gorm.DB, quizID uuid.UUID) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Quiz{}, "id = ?", quizID).Error
+}
+
+// lockAttemptedQuiz locks the quiz and reports whether anyone has started
+// an attempt on it.
+func lockAttemptedQuiz(tx *gorm.DB, quizID uuid.UUID) (bool, error) {
+	if err := lockQuiz(tx, quizID); err != nil {
+		return false, err
+	}
+	var attempts int64
+	if err := tx.Model(&models.QuizAttempt{}).Where("quiz_id = ?", quizID).Count(&attempts).Error; err != nil {
+		return false, err
+	}
+	return attempts > 0, nil
+}
+
+func bumpQuizVersion(tx *gorm.DB, quizID uuid.UUID) error {
+	return tx.Model(&models.Quiz{}).Where("id = ?", quizID).UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
+// reorder sets the position of every row of model under parentColumn to
+// its index in ids. ids must list each of those rows exactly once.
+func reorder(tx *gorm.DB, model interface{}, parentColumn string, parentID uuid.UUID, ids []uuid.UUID) error {
+	return reorderColumn(tx, model, "position", parentColumn, parentID, ids)
+}
+
+// reorderColumn is reorder for models whose position is kept in column.
+func reorderColumn(tx *gorm.DB, model interface{}, column, parentColumn string, parentID uuid.UUID, ids []uuid.UUID) error {
+	var current []uuid.UUID
+	if err := tx.Model(model).Where(parentColumn+" = ?", parentID).Pluck("id", &current).Error; err != nil {
+		return err
+	}
+	if !sameIDs(current, ids) {
+		return apperror.InvalidField("ids", "ids must list every item exactly once")
+	}
+	for position, id := range ids {
+		if err := tx.Model(model).Where("id = ?", id).UpdateColumn(column, position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameIDs(current, proposed []uuid.UUID) bool {
+	if len(current) != len(proposed) {
+		return false
+	}
+	remaining := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range proposed {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RequestResubmissionRequest struct {
+	Comment string `json:"comment" binding:"required"`
+}
+
+// RequestResubmission asks the student to hand in again. The comment is
+// shown to the student until they do, and the request allows one attempt
+// past the assignment's limit.
+func (h *AssignmentHandler) RequestResubmission(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	submission, ok := loadSubmission(c)
+	if !ok {
+		return
+	}
+	if err := requireOrgRole(userID, submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req RequestResubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if comment == "" {
+		respondError(c, apperror.InvalidField("comment", "A comment is required"))
+		return
+	}
+	if submission.Status != "SUBMITTED" && submission.Status != "GRADED" {
+		respondError(c, apperror.Conflict("A resubmission has already been requested"))
+		return
+	}
+
+	now := time.Now()
+	if err := database.GetDB().Model(submission).Updates(map[string]interface{}{
+		"status":                "RE_SUBMIT_REQUESTED",
+		"resubmit_comment":      comment,
+		"resubmit_requested_at": now,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to request resubmission", err))
+		return
+	}
+	submission.Status = "RE_SUBMIT_REQUESTED"
+	submission.ResubmitComment = &comment
+	submission.ResubmitRequestedAt = &now
+
+	c.JSON(http.StatusOK, dto.NewSubmission(*submission))
+}
+
+// GetSubmissionAttempts lists every attempt of a submission, oldest first.
+// Students see only their own.
+func (h *AssignmentHandler) GetSubmissionAttempts(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	submission, ok := loadSubmission(c)
+	if !ok {
+		return
+	}
+	if submission.UserID != userID {
+		if err := requireOrgRole(userID, submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	var attempts []models.SubmissionAttempt
+	if err := database.GetDB().Where("submission_id = ?", submission.ID).Scopes(byNumber).Find(&attempts).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch attempts", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSubmissionAttempts(attempts))
+}
+
+// loadSubmission loads the submission named by :id with its assignment and
+// course, responding with an error if it does not exist.
+func loadSubmission(c *gin.Context) (*models.Submission, bool) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid submission ID"))
+		return nil, false
+	}
+	var submission models.Submission
+	if err := database.GetDB().Preload("Assignment.Course").First(&submission, submissionID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Submission not found"))
+		return nil, false
+	}
+	return &submission, true
+}
+
+// byNumber orders a submission's attempts, oldest first.
+func byNumber(db *gorm.DB) *gorm.DB {
+	return db.Order("number ASC")
+}
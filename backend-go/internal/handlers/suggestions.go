@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/gradeassist"
+	"myway-backend/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// suggestionTimeout bounds one call to the AI provider.
+const suggestionTimeout = 90 * time.Second
+
+type SuggestGradeRequest struct {
+	// AttemptID defaults to the latest attempt.
+	AttemptID *uuid.UUID `json:"attemptId"`
+}
+
+// SuggestGrade asks the AI provider to draft a grade for an attempt from
+// the assignment's instructions, its rubric and the attempt's text. Only
+// the text the student submitted is read, not the uploaded file. The draft
+// is stored as a suggestion with that text; the attempt's grade is left
+// alone.
+func (h *AIHandler) SuggestGrade(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	submission, ok := loadSubmission(c)
+	if !ok {
+		return
+	}
+	if err := requireOrgRole(userID, submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req SuggestGradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if h.LLM == nil {
+		respondError(c, apperror.Unavailable("No AI provider is configured"))
+		return
+	}
+
+	db := database.GetDB()
+	query := db.Where("submission_id = ?", submission.ID)
+	if req.AttemptID != nil {
+		query = query.Where("id = ?", *req.AttemptID)
+	} else {
+		query = query.Order("number DESC")
+	}
+	var attempt models.SubmissionAttempt
+	if err := query.First(&attempt).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Attempt not found"))
+		return
+	}
+	if attempt.Text == nil || strings.TrimSpace(*attempt.Text) == "" {
+		respondError(c, apperror.Unprocessable("This attempt has no submitted text; suggestions do not read uploaded files"))
+		return
+	}
+
+	assignment := submission.Assignment
+	in := gradeassist.Input{
+		Title:        assignment.Title,
+		Instructions: assignment.Instructions,
+		Points:       assignment.Points,
+		Text:         *attempt.Text,
+	}
+	suggestion := models.GradingSuggestion{
+		AttemptID:   attempt.ID,
+		Status:      "PENDING",
+		Provider:    h.LLM.Name(),
+		Model:       h.LLM.Model(),
+		RequestedBy: userID,
+	}
+	if assignment.RubricID != nil {
+		_, current, err := loadRubric(db, *assignment.RubricID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		in.Criteria = dto.DecodeRubricCriteria(current.Criteria)
+		suggestion.RubricVersionID = &current.ID
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), suggestionTimeout)
+	defer cancel()
+	draft, err := gradeassist.Suggest(ctx, h.LLM, in)
+	if err != nil {
+		respondError(c, apperror.Upstream("The AI provider could not draft a grade", err))
+		return
+	}
+
+	suggestion.Score = draft.Suggestion.Score
+	suggestion.Justification = emptyToNil(&draft.Suggestion.Justification)
+	suggestion.Feedback = draft.Suggestion.Feedback
+	suggestion.Text = draft.Text
+	suggestion.TextSource = "STUDENT_PROVIDED"
+	suggestion.Prompt = draft.Prompt
+	suggestion.Output = draft.Output
+	if draft.Suggestion.Grade != nil {
+		criteria, err := json.Marshal(draft.Suggestion.Criteria)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to encode suggestion", err))
+			return
+		}
+		grade, err := json.Marshal(draft.Suggestion.Grade)
+		if err != nil {
+			respondError(c, apperror.Internal("Failed to encode suggestion", err))
+			return
+		}
+		encodedCriteria, encodedGrade := string(criteria), string(grade)
+		suggestion.Criteria = &encodedCriteria
+		suggestion.RubricGrade = &encodedGrade
+	}
+	if err := db.Create(&suggestion).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to save grading suggestion", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewGradingSuggestion(suggestion))
+}
+
+// GetGradingSuggestions lists the suggestions for every attempt of a
+// submission, newest first.
+func (h *AIHandler) GetGradingSuggestions(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	submission, ok := loadSubmission(c)
+	if !ok {
+		return
+	}
+	if err := requireOrgRole(userID, submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	db := database.GetDB()
+	attempts := db.Model(&models.SubmissionAttempt{}).Select("id").Where("submission_id = ?", submission.ID)
+	var suggestions []models.GradingSuggestion
+	if err := db.Where("attempt_id IN (?)", attempts).Order("created_at DESC").Find(&suggestions).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch grading suggestions", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGradingSuggestions(suggestions))
+}
+
+// DismissGradingSuggestion marks a pending suggestion as not used.
+func (h *AIHandler) DismissGradingSuggestion(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	suggestionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid suggestion ID"))
+		return
+	}
+
+	db := database.GetDB()
+	var suggestion models.GradingSuggestion
+	if err := db.First(&suggestion, suggestionID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Grading suggestion not found"))
+		return
+	}
+	var attempt models.SubmissionAttempt
+	if err := db.Preload("Submission.Assignment.Course").First(&attempt, suggestion.AttemptID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Grading suggestion not found"))
+		return
+	}
+	if err := requireOrgRole(userID, attempt.Submission.Assignment.Course.OrgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&suggestion, suggestion.ID).Error; err != nil {
+			return err
+		}
+		if suggestion.Status != "PENDING" {
+			return apperror.Conflict("This suggestion has already been reviewed")
+		}
+		now := time.Now()
+		suggestion.Status = "DISMISSED"
+		suggestion.ReviewedBy = &userID
+		suggestion.ReviewedAt = &now
+		return tx.Model(&suggestion).Updates(map[string]interface{}{
+			"status":      suggestion.Status,
+			"reviewed_by": suggestion.ReviewedBy,
+			"reviewed_at": suggestion.ReviewedAt,
+		}).Error
+	}); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGradingSuggestion(suggestion))
+}
+
+// reviewGradingSuggestion records that an attempt was graded from a
+// suggestion: ACCEPTED if the grade is the suggestion unchanged, EDITED
+// otherwise. It runs inside the grading transaction.
+func reviewGradingSuggestion(tx *gorm.DB, suggestionID uuid.UUID, attempt models.SubmissionAttempt, req GradeSubmissionRequest, graderID uuid.UUID) error {
+	var suggestion models.GradingSuggestion
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND attempt_id = ?", suggestionID, attempt.ID).
+		First(&suggestion).Error; err != nil {
+		return apperror.FromDB(err, "Grading suggestion not found for this attempt")
+	}
+	if suggestion.Status == "DISMISSED" {
+		return apperror.Conflict("This suggestion was dismissed")
+	}
+
+	view := dto.NewGradingSuggestion(suggestion)
+	draft := gradeassist.Suggestion{
+		Criteria: view.Criteria,
+		Grade:    view.RubricGrade,
+		Score:    view.Score,
+		Feedback: view.Feedback,
+	}
+	status := "EDITED"
+	sameRubric := (suggestion.RubricVersionID == nil && attempt.RubricVersionID == nil) ||
+		(suggestion.RubricVersionID != nil && attempt.RubricVersionID != nil && *suggestion.RubricVersionID == *attempt.RubricVersionID)
+	if sameRubric && draft.Matches(req.Criteria, req.Score, req.Feedback) {
+		status = "ACCEPTED"
+	}
+	return tx.Model(&suggestion).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": graderID,
+		"reviewed_at": time.Now(),
+	}).Error
+}
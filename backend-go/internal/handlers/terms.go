@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TermRequest struct {
+	Name     string    `json:"name" binding:"required"`
+	StartsOn time.Time `json:"startsOn" binding:"required"`
+	EndsOn   time.Time `json:"endsOn" binding:"required"`
+}
+
+type UpdateTermRequest struct {
+	Name     *string    `json:"name" binding:"omitempty,min=1"`
+	StartsOn *time.Time `json:"startsOn"`
+	EndsOn   *time.Time `json:"endsOn"`
+}
+
+// CreateTerm adds a term to an organization. Only organizers manage terms.
+func (h *CourseHandler) CreateTerm(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req TermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if !req.EndsOn.After(req.StartsOn) {
+		respondError(c, apperror.InvalidField("endsOn", "A term must end after it starts"))
+		return
+	}
+
+	term := models.Term{OrgID: orgID, Name: req.Name, StartsOn: req.StartsOn, EndsOn: req.EndsOn}
+	if err := database.GetDB().Create(&term).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to create term", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewTerm(term))
+}
+
+// GetTermsByOrg lists an organization's terms, latest first, archived ones
+// included.
+func (h *CourseHandler) GetTermsByOrg(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+	if err := requireOrgRole(userID, orgID, "STUDENT", "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var terms []models.Term
+	if err := database.GetDB().Where("org_id = ?", orgID).Order("starts_on DESC, id").Find(&terms).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to fetch terms", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewTerms(terms))
+}
+
+func (h *CourseHandler) UpdateTerm(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	term, ok := loadOrganizerTerm(c, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	if req.Name != nil {
+		term.Name = *req.Name
+	}
+	if req.StartsOn != nil {
+		term.StartsOn = *req.StartsOn
+	}
+	if req.EndsOn != nil {
+		term.EndsOn = *req.EndsOn
+	}
+	if !term.EndsOn.After(term.StartsOn) {
+		respondError(c, apperror.InvalidField("endsOn", "A term must end after it starts"))
+		return
+	}
+
+	if err := database.GetDB().Model(term).Updates(map[string]interface{}{
+		"name":      term.Name,
+		"starts_on": term.StartsOn,
+		"ends_on":   term.EndsOn,
+	}).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update term", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewTerm(*term))
+}
+
+// ArchiveTerm closes a term. Its courses stay readable, but no longer
+// accept submissions, quiz attempts or new content.
+func (h *CourseHandler) ArchiveTerm(c *gin.Context) {
+	h.setTermArchived(c, true)
+}
+
+// UnarchiveTerm reopens an archived term.
+func (h *CourseHandler) UnarchiveTerm(c *gin.Context) {
+	h.setTermArchived(c, false)
+}
+
+func (h *CourseHandler) setTermArchived(c *gin.Context, archived bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	term, ok := loadOrganizerTerm(c, userID)
+	if !ok {
+		return
+	}
+
+	// Archiving twice keeps the original timestamp.
+	if archived == (term.ArchivedAt != nil) {
+		c.JSON(http.StatusOK, dto.NewTerm(*term))
+		return
+	}
+	var archivedAt *time.Time
+	if archived {
+		now := time.Now()
+		archivedAt = &now
+	}
+	if err := database.GetDB().Model(term).Update("archived_at", archivedAt).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to update term", err))
+		return
+	}
+	term.ArchivedAt = archivedAt
+
+	c.JSON(http.StatusOK, dto.NewTerm(*term))
+}
+
+// loadOrganizerTerm loads the term named by :id if the caller organizes
+// its organization, responding with an error otherwise.
+func loadOrganizerTerm(c *gin.Context, userID uuid.UUID) (*models.Term, bool) {
+	termID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid term ID"))
+		return nil, false
+	}
+	var term models.Term
+	if err := database.GetDB().First(&term, termID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Term not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, term.OrgID, "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return &term, true
+}
+
+// loadOpenTerm loads a term of orgID that courses can still be added to.
+func loadOpenTerm(db *gorm.DB, orgID, termID uuid.UUID) (*models.Term, error) {
+	var term models.Term
+	if err := db.Where("org_id = ?", orgID).First(&term, termID).Error; err != nil {
+		return nil, apperror.FromDB(err, "Term not found")
+	}
+	if term.ArchivedAt != nil {
+		return nil, apperror.InvalidField("termId", "Courses cannot be added to an archived term")
+	}
+	return &term, nil
+}
+
+// requireCourseOpen returns CONFLICT if the course, or the term it belongs
+// to, is archived.
+func requireCourseOpen(db *gorm.DB, courseID uuid.UUID) error {
+	var course models.Course
+	if err := db.Preload("Term").First(&course, courseID).Error; err != nil {
+		return apperror.FromDB(err, "Course not found")
+	}
+	if course.Status == "ARCHIVED" {
+		return apperror.Conflict("This course is archived")
+	}
+	if course.Term != nil && course.Term.ArchivedAt != nil {
+		return apperror.Conflict("This course's term is archived")
+	}
+	return nil
+}
+
+// courseOpen reports whether neither the course nor its term is archived.
+func courseOpen(db *gorm.DB, courseID uuid.UUID) (bool, error) {
+	var course models.Course
+	if err := db.Preload("Term").First(&course, courseID).Error; err != nil {
+		return false, err
+	}
+	return course.Status != "ARCHIVED" && (course.Term == nil || course.Term.ArchivedAt == nil), nil
+}
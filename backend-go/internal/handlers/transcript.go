@@ -5,6 +5,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"myway-backend/internal/apperror"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -26,20 +27,19 @@ type TranscriptResponse struct {
 func FetchTranscriptHandler(c *gin.Context) {
 	var req TranscriptRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, apperror.FromBinding(err))
 		return
 	}
 
 	videoID, err := extractVideoIDForTranscript(req.VideoURL)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YouTube URL"})
+		respondError(c, apperror.InvalidField("videoUrl", "Invalid YouTube URL"))
 		return
 	}
 
 	transcript, err := getTranscript(videoID)
 	if err != nil {
-		// Fallback to detailed error message for debugging
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch transcript: %v", err)})
+		respondError(c, apperror.Upstream("Failed to fetch transcript", err))
 		return
 	}
 
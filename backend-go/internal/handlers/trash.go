@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/models"
+	"myway-backend/internal/pagination"
+	"myway-backend/internal/trash"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TrashHandler struct {
+	retention time.Duration
+}
+
+func NewTrashHandler(retention time.Duration) *TrashHandler {
+	return &TrashHandler{retention: retention}
+}
+
+var trashListSpec = pagination.Spec[models.TrashItem]{
+	Sorts: map[string]pagination.SortField[models.TrashItem]{
+		"trashedAt": {Column: "trashed_at", Kind: pagination.KindTime, Value: func(m models.TrashItem) interface{} { return m.TrashedAt }},
+		"title":     {Column: "title", Value: func(m models.TrashItem) interface{} { return m.Title }},
+	},
+	DefaultSort:   "-trashedAt",
+	ID:            func(m models.TrashItem) uuid.UUID { return m.ID },
+	CreatorColumn: "deleted_by",
+	DateColumn:    "trashed_at",
+}
+
+// ListTrash lists what was deleted in an organization, including the
+// organization itself, with the date each item will be purged.
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid organization ID"))
+		return
+	}
+
+	if err := requireOrgRole(userID, orgID, "TEACHER", "ORGANIZER"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	query, err := pagination.Parse(c, trashListSpec)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	page, err := query.Find(database.GetDB().Where("org_id = ?", orgID), &models.TrashItem{}, "Deleter")
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to fetch trash", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Map(page, func(item models.TrashItem) gin.H {
+		return gin.H{
+			"id":         item.ID,
+			"entityType": item.EntityType,
+			"entityId":   item.EntityID,
+			"title":      item.Title,
+			"deletedBy": gin.H{
+				"id":   item.Deleter.ID,
+				"name": item.Deleter.Name,
+			},
+			"trashedAt":  item.TrashedAt,
+			"purgeAfter": item.TrashedAt.Add(h.retention),
+		}
+	}))
+}
+
+func (h *TrashHandler) RestoreOrganization(c *gin.Context) {
+	h.restore(c, trash.EntityOrganization, "Organization", "ORGANIZER")
+}
+
+func (h *TrashHandler) RestoreCourse(c *gin.Context) {
+	h.restore(c, trash.EntityCourse, "Course", "ORGANIZER")
+}
+
+func (h *TrashHandler) RestoreModule(c *gin.Context) {
+	h.restore(c, trash.EntityModule, "Module", "TEACHER", "ORGANIZER")
+}
+
+// restore takes the entity ID from the :id path parameter. The roles match
+// the ones allowed to delete that kind of entity.
+func (h *TrashHandler) restore(c *gin.Context, entityType, label string, roles ...string) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("id", "Invalid "+label+" ID"))
+		return
+	}
+
+	var item models.TrashItem
+	if err := database.GetDB().Where("entity_type = ? AND entity_id = ?", entityType, entityID).First(&item).Error; err != nil {
+		respondError(c, apperror.FromDB(err, label+" is not in the trash"))
+		return
+	}
+
+	if err := requireOrgRole(userID, item.OrgID, roles...); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := trash.Restore(database.GetDB(), &item); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": label + " restored"})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/models"
+	"myway-backend/internal/watch"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VideoHeartbeatRequest struct {
+	FromSec      float64  `json:"fromSec" binding:"min=0"`
+	ToSec        float64  `json:"toSec" binding:"min=0"`
+	DurationSec  float64  `json:"durationSec" binding:"required,gt=0"`
+	PlaybackRate *float64 `json:"playbackRate" binding:"omitempty,gt=0"`
+}
+
+// RecordHeartbeat adds a played segment of a video to the caller's watched
+// intervals. Heartbeats that fail the anti-cheat checks are counted and
+// logged, then rejected as UNPROCESSABLE. The video's length must be on
+// record; it comes from YouTube or an instructor, never from a heartbeat.
+func (h *ProgressHandler) RecordHeartbeat(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	material, ok := loadVideo(c, userID, "STUDENT", "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+	if err := requireModuleUnlocked(database.GetDB(), userID, material.ModuleID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if material.DurationSec == nil {
+		respondError(c, apperror.Conflict("This video's length is not known yet, so watch progress cannot be recorded"))
+		return
+	}
+
+	var req VideoHeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, apperror.FromBinding(err))
+		return
+	}
+	heartbeat := watch.Heartbeat{
+		From:         req.FromSec,
+		To:           req.ToSec,
+		Duration:     req.DurationSec,
+		PlaybackRate: 1,
+		At:           time.Now(),
+	}
+	if req.PlaybackRate != nil {
+		heartbeat.PlaybackRate = *req.PlaybackRate
+	}
+
+	var activity *models.MaterialActivity
+	var rejected *watch.RejectError
+	var intervals []watch.Interval
+	if err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Share the lock SetVideoDuration takes, and use the duration as of now
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(material, "id = ?", material.ID).Error; err != nil {
+			return err
+		}
+		var err error
+		if activity, err = lockMaterialActivity(tx, userID, material.ID); err != nil {
+			return err
+		}
+
+		entry := models.VideoHeartbeat{
+			UserID:       userID,
+			MaterialID:   material.ID,
+			FromSec:      heartbeat.From,
+			ToSec:        heartbeat.To,
+			PlaybackRate: heartbeat.PlaybackRate,
+			Accepted:     true,
+		}
+		if err := watch.Check(heartbeat, activity.LastHeartbeatAt, material.DurationSec, watch.DefaultLimits); err != nil {
+			if !errors.As(err, &rejected) {
+				return err
+			}
+			entry.Accepted = false
+			entry.RejectReason = &rejected.Reason
+			activity.RejectedHeartbeats++
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			return tx.Model(activity).Update("rejected_heartbeats", activity.RejectedHeartbeats).Error
+		}
+
+		if intervals, err = decodeIntervals(activity.WatchedIntervals); err != nil {
+			return err
+		}
+		intervals = watch.Merge(append(intervals, watch.Interval{Start: heartbeat.From, End: heartbeat.To}))
+		encoded, err := json.Marshal(intervals)
+		if err != nil {
+			return err
+		}
+		watched := string(encoded)
+		activity.WatchedIntervals = &watched
+		activity.WatchedSeconds = watch.Covered(intervals)
+		activity.FurthestSeconds = math.Max(activity.FurthestSeconds, heartbeat.To)
+		activity.WatchedPercent = watch.Percent(activity.WatchedSeconds, *material.DurationSec)
+		activity.LastHeartbeatAt = &heartbeat.At
+		activity.UpdatedAt = heartbeat.At
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		return tx.Save(activity).Error
+	}); err != nil {
+		respondError(c, apperror.Internal("Failed to record heartbeat", err))
+		return
+	}
+	if rejected != nil {
+		respondError(c, apperror.Unprocessable(rejected.Reason))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewVideoWatch(*material, *activity, intervals))
+}
+
+// GetVideoWatch returns how much of a video the caller has watched.
+func (h *ProgressHandler) GetVideoWatch(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	material, ok := loadVideo(c, userID, "STUDENT", "TEACHER", "ORGANIZER")
+	if !ok {
+		return
+	}
+
+	activity := models.MaterialActivity{UserID: userID, MaterialID: material.ID}
+	if err := database.GetDB().Where("user_id = ? AND material_id = ?", userID, material.ID).Limit(1).Find(&activity).Error; err != nil {
+		respondError(c, apperror.Internal("Failed to load watch progress", err))
+		return
+	}
+	intervals, err := decodeIntervals(activity.WatchedIntervals)
+	if err != nil {
+		respondError(c, apperror.Internal("Failed to read watched intervals", err))
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewVideoWatch(*material, activity, intervals))
+}
+
+// loadVideo loads the VIDEO material named by :materialId if the caller
+// has one of roles in its organization, responding with an error otherwise.
+func loadVideo(c *gin.Context, userID uuid.UUID, roles ...string) (*models.Material, bool) {
+	materialID, err := uuid.Parse(c.Param("materialId"))
+	if err != nil {
+		respondError(c, apperror.InvalidField("materialId", "Invalid material ID"))
+		return nil, false
+	}
+	var material models.Material
+	if err := database.GetDB().Preload("Module.Course").First(&material, materialID).Error; err != nil {
+		respondError(c, apperror.FromDB(err, "Material not found"))
+		return nil, false
+	}
+	if err := requireOrgRole(userID, material.Module.Course.OrgID, roles...); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	if material.Type != "VIDEO" {
+		respondError(c, apperror.Unprocessable("Watch progress is only tracked for videos"))
+		return nil, false
+	}
+	return &material, true
+}
+
+// lockMaterialActivity returns the learner's activity row for a material,
+// creating it if needed, locked for update.
+func lockMaterialActivity(tx *gorm.DB, userID, materialID uuid.UUID) (*models.MaterialActivity, error) {
+	activity := models.MaterialActivity{UserID: userID, MaterialID: materialID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&activity).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND material_id = ?", userID, materialID).
+		First(&activity).Error; err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+func decodeIntervals(raw *string) ([]watch.Interval, error) {
+	var intervals []watch.Interval
+	if raw == nil {
+		return intervals, nil
+	}
+	err := json.Unmarshal([]byte(*raw), &intervals)
+	return intervals, err
+}
@@ -0,0 +1,58 @@
+// Package lateness decides how an assignment treats work handed in after
+// its due date.
+package lateness
+
+import (
+	"math"
+	"time"
+)
+
+// Late policies.
+const (
+	// Reject refuses submissions after the due date.
+	Reject = "REJECT"
+	// Accept takes late submissions and flags them.
+	Accept = "ACCEPT"
+	// Penalty takes late submissions and deducts PenaltyPerDay percent of
+	// the score for each started day.
+	Penalty = "PENALTY"
+)
+
+// Policy is an assignment's late policy.
+type Policy struct {
+	Type          string
+	PenaltyPerDay int
+}
+
+// Result is how a submission stands against its due date.
+type Result struct {
+	Late           bool
+	DaysLate       int
+	PenaltyPercent int
+	// Rejected is set when the policy refuses the submission.
+	Rejected bool
+}
+
+// Evaluate checks a submission made at submittedAt against due. A partial
+// day counts as a whole one, and the penalty stops at 100%.
+func Evaluate(policy Policy, due, submittedAt time.Time) Result {
+	if !submittedAt.After(due) {
+		return Result{}
+	}
+	result := Result{
+		Late:     true,
+		DaysLate: int(math.Ceil(submittedAt.Sub(due).Hours() / 24)),
+	}
+	switch policy.Type {
+	case Reject:
+		result.Rejected = true
+	case Penalty:
+		result.PenaltyPercent = min(100, result.DaysLate*policy.PenaltyPerDay)
+	}
+	return result
+}
+
+// Apply deducts a penalty from a score, rounding to the nearest point.
+func Apply(score, penaltyPercent int) int {
+	return int(math.Round(float64(score) * float64(100-penaltyPercent) / 100))
+}
@@ -0,0 +1,36 @@
+package lateness
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	due := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		policy Policy
+		at     time.Time
+		want   Result
+	}{
+		{"on time", Policy{Type: Reject}, due, Result{}},
+		{"rejected", Policy{Type: Reject}, due.Add(time.Minute), Result{Late: true, DaysLate: 1, Rejected: true}},
+		{"flagged", Policy{Type: Accept}, due.Add(30 * time.Hour), Result{Late: true, DaysLate: 2}},
+		{"penalty per started day", Policy{Type: Penalty, PenaltyPerDay: 10}, due.Add(49 * time.Hour), Result{Late: true, DaysLate: 3, PenaltyPercent: 30}},
+		{"penalty capped", Policy{Type: Penalty, PenaltyPerDay: 40}, due.Add(72 * time.Hour), Result{Late: true, DaysLate: 3, PenaltyPercent: 100}},
+	}
+	for _, tc := range tests {
+		if got := Evaluate(tc.policy, due, tc.at); got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	if got := Apply(85, 30); got != 60 {
+		t.Errorf("Apply(85, 30) = %d, want 60", got)
+	}
+	if got := Apply(85, 100); got != 0 {
+		t.Errorf("Apply(85, 100) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake is a Provider for tests and local development. It answers with
+// Responses in turn, repeating the last one, and records every request.
+type Fake struct {
+	Responses []string
+	// Err, if set, is returned instead of a response.
+	Err error
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+func (f *Fake) Name() string  { return "fake" }
+func (f *Fake) Model() string { return "fake" }
+
+func (f *Fake) Generate(ctx context.Context, req Request) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	if f.Err != nil {
+		return "", f.Err
+	}
+	if len(f.Responses) == 0 {
+		return "{}", nil
+	}
+	n := min(len(f.requests), len(f.Responses))
+	return f.Responses[n-1], nil
+}
+
+// Requests returns the requests received so far.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultGeminiModel is the model Gemini requests go to.
+const DefaultGeminiModel = "gemini-3-flash-preview"
+
+// Gemini calls Google's Generative Language API.
+type Gemini struct {
+	APIKey    string
+	ModelName string
+	Client    *http.Client
+}
+
+func NewGemini(apiKey string) *Gemini {
+	return &Gemini{
+		APIKey:    apiKey,
+		ModelName: DefaultGeminiModel,
+		Client:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (g *Gemini) Name() string  { return "gemini" }
+func (g *Gemini) Model() string { return g.ModelName }
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      float64 `json:"temperature,omitempty"`
+	MaxOutputTokens  int     `json:"maxOutputTokens,omitempty"`
+	ResponseMIMEType string  `json:"responseMimeType,omitempty"`
+}
+
+type geminiRequest struct {
+	Contents         []geminiContent        `json:"contents"`
+	GenerationConfig geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+}
+
+func (g *Gemini) Generate(ctx context.Context, req Request) (string, error) {
+	body := geminiRequest{
+		Contents: []geminiContent{
+			{Role: "user", Parts: []geminiPart{{Text: req.Prompt}}},
+		},
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     req.Temperature,
+			MaxOutputTokens: req.MaxOutputTokens,
+		},
+	}
+	if req.JSON {
+		body.GenerationConfig.ResponseMIMEType = "application/json"
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	url := "https://generativelanguage.googleapis.com/v1beta/models/" + g.ModelName + ":generateContent?key=" + g.APIKey
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.Client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("gemini returned status %d", resp.StatusCode)
+	}
+
+	var parsed geminiResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", err
+	}
+	for _, candidate := range parsed.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if text := strings.TrimSpace(part.Text); text != "" {
+				return text, nil
+			}
+		}
+	}
+	return "", errors.New("empty response from gemini")
+}
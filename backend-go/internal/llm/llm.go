@@ -0,0 +1,45 @@
+// Package llm is the backend's interface to text-generation models. Features
+// ask a Provider for completions and record its Name and Model alongside
+// whatever they generate.
+package llm
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// Request is one prompt to a model.
+type Request struct {
+	Prompt          string
+	Temperature     float64
+	MaxOutputTokens int
+	// JSON asks the model to answer with a JSON document only.
+	JSON bool
+}
+
+// Provider generates text. Implementations must be safe for concurrent use.
+type Provider interface {
+	// Name identifies the provider, such as "gemini".
+	Name() string
+	// Model is the model requests are sent to.
+	Model() string
+	Generate(ctx context.Context, req Request) (string, error)
+}
+
+// New returns the provider called name, or nil if it cannot be used, such
+// as Gemini without an API key. An empty name means Gemini.
+func New(name, geminiAPIKey string) Provider {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "gemini":
+		if strings.TrimSpace(geminiAPIKey) == "" {
+			return nil
+		}
+		return NewGemini(geminiAPIKey)
+	case "fake":
+		return &Fake{}
+	default:
+		log.Printf("Unknown LLM_PROVIDER %q, AI features are disabled", name)
+		return nil
+	}
+}
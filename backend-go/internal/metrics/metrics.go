@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Counter is a monotonically increasing count split by label values.
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []*Counter
+)
+
+// NewCounter registers a counter that is exposed by Handler.
+func NewCounter(name, help string, labels ...string) *Counter {
+	counter := &Counter{name: name, help: help, labels: labels, values: map[string]uint64{}}
+	registryMu.Lock()
+	registry = append(registry, counter)
+	registryMu.Unlock()
+	return counter
+}
+
+// Inc adds one for the given label values, which must match the labels the
+// counter was created with.
+func (c *Counter) Inc(labelValues ...string) {
+	key := strings.Join(labelValues, "\x00")
+	c.mu.Lock()
+	c.values[key]++
+	c.mu.Unlock()
+}
+
+// Value returns the current count for the given label values.
+func (c *Counter) Value(labelValues ...string) uint64 {
+	key := strings.Join(labelValues, "\x00")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := strings.Split(key, "\x00")
+		pairs := make([]string, len(c.labels))
+		for i, label := range c.labels {
+			pairs[i] = fmt.Sprintf("%s=%q", label, values[i])
+		}
+		fmt.Fprintf(b, "%s{%s} %d\n", c.name, strings.Join(pairs, ","), c.values[key])
+	}
+}
+
+// Handler serves all registered counters in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var b strings.Builder
+		registryMu.Lock()
+		for _, counter := range registry {
+			counter.write(&b)
+		}
+		registryMu.Unlock()
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+	}
+}
+
+// LegacyRouteRequests counts requests served through deprecated
+// unversioned aliases, so they can be retired once traffic drops to zero.
+var LegacyRouteRequests = NewCounter(
+	"myway_legacy_route_requests_total",
+	"Requests served through deprecated unversioned route aliases.",
+	"method", "route",
+)
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"myway-backend/internal/metrics"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegacyAliasMiddleware marks responses from unversioned legacy routes as
+// deprecated (RFC 8594 Sunset plus a Deprecation header), links to the
+// versioned successor and counts the hit so the alias can be retired once
+// nobody uses it. successors overrides the successor for routes whose
+// versioned replacement has a different shape, keyed by "METHOD /path".
+func LegacyAliasMiddleware(prefix string, sunset time.Time, successors map[string]string) gin.HandlerFunc {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		metrics.LegacyRouteRequests.Inc(c.Request.Method, route)
+
+		header := c.Writer.Header()
+		header.Set("Deprecation", "true")
+		header.Set("Sunset", sunsetHeader)
+
+		successor := prefix + c.Request.URL.Path
+		if override, ok := successors[c.Request.Method+" "+route]; ok {
+			successor = fillParams(c, override[strings.Index(override, " ")+1:])
+		}
+		if successor != "" {
+			header.Set("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+
+		c.Next()
+	}
+}
+
+// fillParams substitutes :params in a route template from the current
+// request. It returns "" when the template needs a parameter the request
+// does not carry in its path.
+func fillParams(c *gin.Context, template string) string {
+	segments := strings.Split(template, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		value := c.Param(seg[1:])
+		if value == "" {
+			return ""
+		}
+		segments[i] = value
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log"
+	"myway-backend/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorMiddleware renders the last error attached with c.Error as the JSON
+// error envelope. Handlers attach an *apperror.Error and abort instead of
+// writing error responses themselves.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		appErr := apperror.As(c.Errors.Last().Err)
+		if appErr.Code == apperror.CodeInternal || appErr.Code == apperror.CodeUpstream {
+			log.Printf("%s %s: %v", c.Request.Method, c.FullPath(), appErr)
+		}
+
+		c.JSON(appErr.Status(), appErr.Body())
+	}
+}
+
+// AbortWithError attaches err for ErrorMiddleware to render and stops the
+// handler chain.
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyProcessingTime = 2 * time.Minute
+)
+
+// IdempotencyMiddleware makes a mutating route safe to retry. When the
+// request carries an Idempotency-Key header, the first successful (2xx)
+// response is stored per user and key for ttl and replayed verbatim for
+// retries with the same body. Reusing a key for a different request is
+// rejected, as is a retry that arrives while the original is still running.
+// Failed requests release the key so the client can retry them.
+//
+// Must run after AuthMiddleware.
+func IdempotencyMiddleware(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			AbortWithError(c, apperror.InvalidField(IdempotencyKeyHeader, "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		userID := c.MustGet("userID").(uuid.UUID)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			AbortWithError(c, apperror.Wrap(apperror.CodeValidation, "Failed to read request body", err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(c, body)
+
+		record, claimed, err := claimIdempotencyKey(userID, key, hash)
+		if err != nil {
+			AbortWithError(c, apperror.Internal("Failed to check idempotency key", err))
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.RequestHash != hash:
+				AbortWithError(c, apperror.Unprocessable("Idempotency-Key was already used for a different request"))
+			case record.Status != "COMPLETED":
+				AbortWithError(c, apperror.Conflict("A request with this Idempotency-Key is still being processed"))
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(record.StatusCode, record.ContentType, []byte(record.ResponseBody))
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if len(c.Errors) > 0 || status < 200 || status >= 300 {
+			if err := database.GetDB().Delete(record).Error; err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", record.ID, err)
+			}
+			return
+		}
+
+		if err := database.GetDB().Model(record).Updates(map[string]interface{}{
+			"status":        "COMPLETED",
+			"status_code":   status,
+			"content_type":  recorder.Header().Get("Content-Type"),
+			"response_body": recorder.body.String(),
+			"expires_at":    time.Now().Add(ttl),
+		}).Error; err != nil {
+			log.Printf("Failed to store idempotent response %s: %v", record.ID, err)
+		}
+	}
+}
+
+// claimIdempotencyKey inserts a PROCESSING record for the key. It reports
+// claimed=false with the existing record when the key is already in use.
+// Expired records, including PROCESSING ones left behind by a crash, are
+// cleared first.
+func claimIdempotencyKey(userID uuid.UUID, key, hash string) (*models.IdempotencyKey, bool, error) {
+	db := database.GetDB()
+
+	if err := db.Where("user_id = ? AND key = ? AND expires_at < ?", userID, key, time.Now()).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := &models.IdempotencyKey{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Key:         key,
+		RequestHash: hash,
+		Status:      "PROCESSING",
+		ExpiresAt:   time.Now().Add(idempotencyProcessingTime),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released between our insert and lookup; let the client retry.
+			return &models.IdempotencyKey{RequestHash: hash}, false, nil
+		}
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// PurgeExpiredIdempotencyKeys deletes stored responses past their TTL.
+func PurgeExpiredIdempotencyKeys() (int64, error) {
+	result := database.GetDB().Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// requestHash fingerprints the route and body so a key cannot be replayed
+// against a different request.
+func requestHash(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -1,10 +1,10 @@
 package middleware
 
 import (
+	"myway-backend/internal/apperror"
 	"myway-backend/internal/database"
 	"myway-backend/internal/models"
 	jwtutil "myway-backend/pkg/jwt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -15,22 +15,19 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			AbortWithError(c, apperror.Unauthorized("Authorization header required"))
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
-			c.Abort()
+			AbortWithError(c, apperror.Unauthorized("Invalid authorization format"))
 			return
 		}
 
 		claims, err := jwtutil.ValidateToken(tokenString, jwtSecret)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			AbortWithError(c, apperror.Unauthorized("Invalid token"))
 			return
 		}
 
@@ -44,8 +41,9 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Org-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Org-ID, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Deprecation, Sunset, Link, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -71,23 +69,24 @@ func OrgMembershipMiddleware() gin.HandlerFunc {
 			orgIDStr = c.Param("orgId")
 		}
 		if orgIDStr == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Organization ID required"})
-			c.Abort()
+			AbortWithError(c, apperror.InvalidField("orgId", "Organization ID required"))
 			return
 		}
 
 		orgID, err := uuid.Parse(orgIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
-			c.Abort()
+			AbortWithError(c, apperror.InvalidField("orgId", "Invalid organization ID"))
 			return
 		}
 
 		// Check membership
 		var membership models.OrgMembership
 		if err := database.GetDB().Where("user_id = ? AND org_id = ? AND status = ?", userID, orgID, "Active").First(&membership).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this organization"})
-			c.Abort()
+			AbortWithError(c, apperror.Forbidden("Not a member of this organization"))
+			return
+		}
+		if err := database.GetDB().Select("id").First(&models.Organization{}, "id = ?", orgID).Error; err != nil {
+			AbortWithError(c, apperror.FromDB(err, "Organization not found"))
 			return
 		}
 
@@ -106,16 +105,14 @@ func RBACMiddleware(allowedRoles ...string) gin.HandlerFunc {
 			userID := c.MustGet("userID").(uuid.UUID)
 			orgID := c.GetString("orgID")
 			if orgID == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Organization context required for RBAC"})
-				c.Abort()
+				AbortWithError(c, apperror.Validation("Organization context required for RBAC"))
 				return
 			}
 
 			orgUUID, _ := uuid.Parse(orgID)
 			var membership models.OrgMembership
 			if err := database.GetDB().Where("user_id = ? AND org_id = ?", userID, orgUUID).First(&membership).Error; err != nil {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this organization"})
-				c.Abort()
+				AbortWithError(c, apperror.Forbidden("Not a member of this organization"))
 				return
 			}
 			role = membership.Role
@@ -130,8 +127,7 @@ func RBACMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		}
 
 		if !allowed {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-			c.Abort()
+			AbortWithError(c, apperror.Forbidden("Insufficient permissions"))
 			return
 		}
 
@@ -46,12 +46,30 @@ type Organization struct {
 	Name      string    `gorm:"not null"`
 	Plan      string    `gorm:"default:'Free'"`
 	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	Memberships  []OrgMembership  `gorm:"foreignKey:OrgID"`
 	Courses      []Course         `gorm:"foreignKey:OrgID"`
 	DailyMetrics []DailyOrgMetric `gorm:"foreignKey:OrgID"`
 }
 
+// OrgSettings model: per-organization policy. Organizations without a row
+// use the column defaults.
+type OrgSettings struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrgID             uuid.UUID `gorm:"type:uuid;uniqueIndex;not null"`
+	RequireReview     bool      `gorm:"not null;default:false"` // study packs must be approved by reviewers before publishing
+	RequiredApprovals int       `gorm:"not null;default:1"`
+	AllowSelfApproval bool      `gorm:"not null;default:false"` // whoever submitted a pack may approve it
+	// Course codes must start with one of these prefixes, a JSON array;
+	// none means any code is allowed.
+	CourseCodePrefixes  *string `gorm:"type:jsonb"`
+	DefaultCourseStatus string  `gorm:"not null;default:'PUBLISHED'"` // status of courses created without one
+	UpdatedAt           time.Time
+
+	Organization Organization `gorm:"foreignKey:OrgID;references:ID"`
+}
+
 // OrgMembership model
 type OrgMembership struct {
 	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -66,15 +84,23 @@ type OrgMembership struct {
 
 // Course model
 type Course struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	OrgID       uuid.UUID `gorm:"type:uuid;not null"`
-	Code        string    `gorm:"not null"`
-	Title       string    `gorm:"not null"`
-	Description string    `gorm:"not null"`
-	CreatedBy   uuid.UUID `gorm:"type:uuid;not null"`
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrgID         uuid.UUID `gorm:"type:uuid;not null"`
+	Code          string    `gorm:"not null"`
+	Title         string    `gorm:"not null"`
+	Description   string    `gorm:"not null"`
+	CreatedBy     uuid.UUID `gorm:"type:uuid;not null"`
+	Status        string    `gorm:"not null;default:'PUBLISHED'"` // DRAFT, PUBLISHED, ARCHIVED
+	CoverImageURL *string
+	Metadata      *string        `gorm:"type:jsonb"`
+	TermID        *uuid.UUID     `gorm:"type:uuid;index"`
+	ClonedFrom    *uuid.UUID     `gorm:"type:uuid"`  // course this offering was cloned from
+	GradeScale    *string        `gorm:"type:jsonb"` // a gradebook.Scale; nil uses gradebook.DefaultScale
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
 
 	Organization Organization   `gorm:"foreignKey:OrgID;references:ID"`
 	Creator      User           `gorm:"foreignKey:CreatedBy;references:ID"`
+	Term         *Term          `gorm:"foreignKey:TermID;references:ID"`
 	Enrollments  []Enrollment   `gorm:"foreignKey:CourseID"`
 	Modules      []Module       `gorm:"foreignKey:CourseID"`
 	Assignments  []Assignment   `gorm:"foreignKey:CourseID"`
@@ -82,6 +108,20 @@ type Course struct {
 	Metrics      []CourseMetric `gorm:"foreignKey:CourseID"`
 }
 
+// Term model: a teaching period, such as a semester, that course offerings
+// run in. Courses in an archived term stay readable but take no new work.
+type Term struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrgID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name       string    `gorm:"not null"`
+	StartsOn   time.Time `gorm:"not null"`
+	EndsOn     time.Time `gorm:"not null"`
+	ArchivedAt *time.Time
+	CreatedAt  time.Time
+
+	Organization Organization `gorm:"foreignKey:OrgID;references:ID"`
+}
+
 // Enrollment model
 type Enrollment struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -101,6 +141,7 @@ type Module struct {
 	Title      string    `gorm:"not null"`
 	Order      int       `gorm:"not null"`
 	LockedRule *string
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
 
 	Course    Course     `gorm:"foreignKey:CourseID;references:ID"`
 	Materials []Material `gorm:"foreignKey:ModuleID"`
@@ -114,7 +155,10 @@ type Material struct {
 	Title          string    `gorm:"not null"`
 	SourceURL      *string
 	FileURL        *string
-	TranscriptText *string `gorm:"type:text"`
+	TranscriptText *string        `gorm:"type:text"`
+	DurationSec    *float64       // video length, from YouTube at import or set by an instructor
+	Position       int            `gorm:"not null;default:0"`
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
 
 	Module     Module      `gorm:"foreignKey:ModuleID;references:ID"`
 	StudyPacks []StudyPack `gorm:"foreignKey:MaterialID"`
@@ -125,17 +169,56 @@ type StudyPack struct {
 	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	MaterialID       uuid.UUID `gorm:"type:uuid;not null"`
 	CreatedBy        string    `gorm:"not null"`
-	Status           string    `gorm:"not null"` // PENDING, GENERATED, FAILED
+	Status           string    `gorm:"not null"` // PENDING, PROCESSING, GENERATED, READY, FAILED
 	CreatedAt        time.Time
 	PublishedAt      *time.Time
 	RequiresApproval bool `gorm:"default:false"`
 	ApprovedBy       *string
 
-	Material   Material           `gorm:"foreignKey:MaterialID;references:ID"`
-	Summary    *Summary           `gorm:"foreignKey:StudyPackID"`
-	Quizzes    []Quiz             `gorm:"foreignKey:StudyPackID"`
-	Flashcards []Flashcard        `gorm:"foreignKey:StudyPackID"`
-	Sessions   []FlashcardSession `gorm:"foreignKey:StudyPackID"`
+	// Review workflow; Status stays READY exactly while this is PUBLISHED.
+	ReviewStatus string     `gorm:"not null;default:'DRAFT'"` // DRAFT, IN_REVIEW, CHANGES_REQUESTED, APPROVED, PUBLISHED, UNPUBLISHED
+	SubmittedBy  *uuid.UUID `gorm:"type:uuid"`
+	SubmittedAt  *time.Time
+
+	Material   Material            `gorm:"foreignKey:MaterialID;references:ID"`
+	Summary    *Summary            `gorm:"foreignKey:StudyPackID"`
+	Quizzes    []Quiz              `gorm:"foreignKey:StudyPackID"`
+	Flashcards []Flashcard         `gorm:"foreignKey:StudyPackID"`
+	Sessions   []FlashcardSession  `gorm:"foreignKey:StudyPackID"`
+	Reviewers  []StudyPackReviewer `gorm:"foreignKey:StudyPackID"`
+}
+
+// StudyPackReviewer model: an instructor assigned to review a study pack.
+// Decisions reset to PENDING each time the pack is submitted for review.
+type StudyPackReviewer struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	StudyPackID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_study_pack_reviewer"`
+	ReviewerID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_study_pack_reviewer"`
+	AssignedBy  uuid.UUID `gorm:"type:uuid;not null"`
+	Decision    string    `gorm:"not null;default:'PENDING'"` // PENDING, APPROVED, CHANGES_REQUESTED
+	DecidedAt   *time.Time
+	CreatedAt   time.Time
+
+	StudyPack StudyPack `gorm:"foreignKey:StudyPackID;references:ID"`
+	Reviewer  User      `gorm:"foreignKey:ReviewerID;references:ID"`
+}
+
+// ReviewComment model: a review note on a study pack, either general or
+// anchored to the summary, one key point or one quiz question.
+type ReviewComment struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	StudyPackID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	AuthorID      uuid.UUID  `gorm:"type:uuid;not null"`
+	Target        string     `gorm:"not null"` // GENERAL, SUMMARY, KEY_POINT, QUESTION
+	KeyPointIndex *int       // for KEY_POINT
+	QuestionID    *uuid.UUID `gorm:"type:uuid"` // for QUESTION
+	Body          string     `gorm:"type:text;not null"`
+	ResolvedAt    *time.Time
+	ResolvedBy    *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt     time.Time
+
+	StudyPack StudyPack `gorm:"foreignKey:StudyPackID;references:ID"`
+	Author    User      `gorm:"foreignKey:AuthorID;references:ID"`
 }
 
 // Summary model
@@ -147,6 +230,25 @@ type Summary struct {
 	StudyPack StudyPack `gorm:"foreignKey:StudyPackID;references:ID"`
 }
 
+// StudyPackRevision model: an immutable snapshot of a study pack's content
+// (a studypack.Snapshot) taken whenever it is generated, approved or
+// rolled back. Numbers count up from 1 per study pack.
+type StudyPackRevision struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	StudyPackID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_study_pack_revision_number"`
+	Number       int        `gorm:"not null;uniqueIndex:idx_study_pack_revision_number"`
+	AuthorType   string     `gorm:"not null"` // AI, INSTRUCTOR
+	AuthorID     *uuid.UUID `gorm:"type:uuid"`
+	Notes        *string
+	Published    bool   `gorm:"not null;default:false"` // the revision went live when it was recorded
+	RestoredFrom *int   // revision number a rollback restored
+	Snapshot     string `gorm:"type:jsonb;not null"`
+	CreatedAt    time.Time
+
+	StudyPack StudyPack `gorm:"foreignKey:StudyPackID;references:ID"`
+	Author    User      `gorm:"foreignKey:AuthorID;references:ID"`
+}
+
 // Quiz model
 type Quiz struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -154,20 +256,48 @@ type Quiz struct {
 	Version     int       `gorm:"default:1"`
 	Metadata    string    `gorm:"type:jsonb"`
 
+	// Attempt settings. A nil TimeLimitSec or MaxAttempts means unlimited.
+	TimeLimitSec      *int
+	MaxAttempts       *int
+	ShuffleQuestions  bool `gorm:"not null;default:false"`
+	ShuffleOptions    bool `gorm:"not null;default:false"`
+	RevealAfterSubmit bool `gorm:"not null;default:true"`
+	// RevealAt opens the answers to every student with a submitted attempt,
+	// even those who have attempts left.
+	RevealAt *time.Time
+
+	// Gradebook category of the course; uncategorized quizzes only count
+	// in courses without categories.
+	GradeCategoryID *uuid.UUID `gorm:"type:uuid"`
+
 	StudyPack StudyPack      `gorm:"foreignKey:StudyPackID;references:ID"`
 	Questions []QuizQuestion `gorm:"foreignKey:QuizID"`
 	Attempts  []QuizAttempt  `gorm:"foreignKey:QuizID"`
 }
 
+// QuizReveal model: a student was shown a quiz's answer keys. They cannot
+// start another attempt on it afterwards.
+type QuizReveal struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	QuizID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_quiz_reveal_user"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_quiz_reveal_user"`
+	CreatedAt time.Time
+}
+
 // QuizQuestion model
 type QuizQuestion struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	QuizID      uuid.UUID `gorm:"type:uuid;not null"`
-	Type        string    `gorm:"not null"` // MCQ, SHORT_ANSWER
+	Type        string    `gorm:"not null"` // MCQ, MULTI_SELECT, TRUE_FALSE, NUMERIC, ORDERING, MATCHING, FILL_BLANK, SHORT_ANSWER
 	Prompt      string    `gorm:"not null"`
 	Options     string    `gorm:"type:jsonb;not null"`
 	AnswerKey   string    `gorm:"type:jsonb;not null"`
 	Explanation *string
+	Points      int `gorm:"not null;default:1"`
+	Position    int `gorm:"not null;default:0"`
+	// Questions are never removed from attempts that answered them: deleting
+	// or editing a question of an attempted quiz soft-deletes the old row.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	Quiz Quiz `gorm:"foreignKey:QuizID;references:ID"`
 }
@@ -179,6 +309,7 @@ type Flashcard struct {
 	Front       string    `gorm:"not null"`
 	Back        string    `gorm:"not null"`
 	Tags        *string   `gorm:"type:jsonb"`
+	Position    int       `gorm:"not null;default:0"`
 
 	StudyPack StudyPack `gorm:"foreignKey:StudyPackID;references:ID"`
 }
@@ -188,10 +319,29 @@ type QuizAttempt struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	QuizID    uuid.UUID `gorm:"type:uuid;not null"`
 	UserID    uuid.UUID `gorm:"type:uuid;not null"`
-	Score     int       `gorm:"not null"`
+	Score     int       `gorm:"not null"` // percentage of possible points
 	Answers   string    `gorm:"type:jsonb;not null"`
+	Status    string    `gorm:"not null;default:SUBMITTED;index"` // IN_PROGRESS, SUBMITTED
 	CreatedAt time.Time
 
+	// QuizVersion is the Quiz.Version the attempt was started on.
+	QuizVersion int `gorm:"not null;default:1"`
+
+	// Set when the attempt is graded. QuestionResults holds the per-question
+	// grading.QuestionResult list in question order.
+	PointsEarned    float64 `gorm:"not null;default:0"`
+	PointsPossible  float64 `gorm:"not null;default:0"`
+	QuestionResults *string `gorm:"type:jsonb"`
+
+	// Set for attempts taken through an attempt session. QuestionOrder is
+	// the question IDs as presented; OptionOrder maps a question ID to its
+	// options as presented.
+	QuestionOrder *string `gorm:"type:jsonb"`
+	OptionOrder   *string `gorm:"type:jsonb"`
+	StartedAt     *time.Time
+	ExpiresAt     *time.Time
+	SubmittedAt   *time.Time
+
 	Quiz Quiz `gorm:"foreignKey:QuizID;references:ID"`
 	User User `gorm:"foreignKey:UserID;references:ID"`
 }
@@ -210,6 +360,76 @@ type FlashcardSession struct {
 	User      User      `gorm:"foreignKey:UserID;references:ID"`
 }
 
+// FlashcardReviewState model: one learner's spaced repetition schedule for
+// one card. A card without a row has never been reviewed.
+type FlashcardReviewState struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_flashcard_review_user_card"`
+	FlashcardID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_flashcard_review_user_card"`
+	Ease           float64   `gorm:"not null;default:2.5"`
+	IntervalDays   int       `gorm:"not null;default:0"`
+	Repetitions    int       `gorm:"not null;default:0"`
+	Lapses         int       `gorm:"not null;default:0"`
+	DueAt          time.Time `gorm:"not null;index"`
+	LastGrade      string    `gorm:"not null"` // again, hard, good, easy
+	LastReviewedAt time.Time `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	Flashcard Flashcard `gorm:"foreignKey:FlashcardID;references:ID"`
+}
+
+// MaterialActivity model: what a learner has done with a material that
+// no other table records. Quiz scores and flashcard mastery are read from
+// attempts and review states when progress is calculated.
+type MaterialActivity struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_material_activity_user"`
+	MaterialID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_material_activity_user"`
+	ViewedAt       *time.Time
+	WatchedPercent int `gorm:"not null;default:0"`
+	UpdatedAt      time.Time
+
+	// Video playback from accepted heartbeats. WatchedIntervals holds the
+	// merged watch.Interval list; WatchedPercent is WatchedSeconds over the
+	// material's DurationSec.
+	WatchedIntervals   *string `gorm:"type:jsonb"`
+	WatchedSeconds     float64 `gorm:"not null;default:0"`
+	FurthestSeconds    float64 `gorm:"not null;default:0"`
+	LastHeartbeatAt    *time.Time
+	RejectedHeartbeats int `gorm:"not null;default:0"`
+
+	User     User     `gorm:"foreignKey:UserID;references:ID"`
+	Material Material `gorm:"foreignKey:MaterialID;references:ID"`
+}
+
+// VideoHeartbeat model: one playback segment reported by a learner's
+// player, kept for engagement analytics and to audit rejected heartbeats.
+type VideoHeartbeat struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index:idx_video_heartbeat_user"`
+	MaterialID   uuid.UUID `gorm:"type:uuid;not null;index:idx_video_heartbeat_user"`
+	FromSec      float64   `gorm:"not null"`
+	ToSec        float64   `gorm:"not null"`
+	PlaybackRate float64   `gorm:"not null;default:1"`
+	Accepted     bool      `gorm:"not null"`
+	RejectReason *string
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+// CompletionCriteria model: an organization's conditions for completing
+// one type of material. Types without a row use progress.DefaultCriteria.
+type CompletionCriteria struct {
+	ID                 uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrgID              uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_completion_criteria_type"`
+	MaterialType       string    `gorm:"not null;uniqueIndex:idx_completion_criteria_type"` // VIDEO, TEXT, DOC
+	RequireView        bool      `gorm:"not null;default:false"`
+	MinWatchPercent    int       `gorm:"not null;default:0"`
+	MinQuizScore       int       `gorm:"not null;default:0"`
+	MinMasteredPercent int       `gorm:"not null;default:0"`
+	UpdatedAt          time.Time
+}
+
 // ProgressEvent model
 type ProgressEvent struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -230,10 +450,42 @@ type Assignment struct {
 	DueAt        time.Time `gorm:"not null"`
 	Points       int       `gorm:"not null"`
 	Instructions string    `gorm:"not null"`
-	Status       string    `gorm:"not null"` // ACTIVE, ARCHIVED
+	Status       string    `gorm:"not null"`                  // ACTIVE, ARCHIVED
+	LatePolicy   string    `gorm:"not null;default:'ACCEPT'"` // REJECT, ACCEPT, PENALTY
+	// Percent of the score deducted per started day late under PENALTY
+	LatePenaltyPerDay int        `gorm:"not null;default:0"`
+	MaxAttempts       *int       // nil means unlimited
+	RubricID          *uuid.UUID `gorm:"type:uuid"`
+	GradeCategoryID   *uuid.UUID `gorm:"type:uuid"`
+	// Peer review is on when PeerReviewCount is set: after the due date
+	// each submission goes to that many other students, who grade it with
+	// the rubric until PeerReviewDueAt.
+	PeerReviewCount *int
+	PeerReviewDueAt *time.Time
+	// Percent of the grade taken from the peer average
+	PeerReviewWeight      int `gorm:"not null;default:0"`
+	PeerReviewsAssignedAt *time.Time
+	DeletedAt             gorm.DeletedAt `gorm:"index"`
+
+	Course      Course                `gorm:"foreignKey:CourseID;references:ID"`
+	Rubric      *Rubric               `gorm:"foreignKey:RubricID;references:ID"`
+	Submissions []Submission          `gorm:"foreignKey:AssignmentID"`
+	Extensions  []AssignmentExtension `gorm:"foreignKey:AssignmentID"`
+}
 
-	Course      Course       `gorm:"foreignKey:CourseID;references:ID"`
-	Submissions []Submission `gorm:"foreignKey:AssignmentID"`
+// AssignmentExtension model: a later due date granted to one student
+type AssignmentExtension struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	AssignmentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_assignment_extension"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_assignment_extension"`
+	DueAt        time.Time `gorm:"not null"`
+	Reason       *string
+	GrantedBy    uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	Assignment Assignment `gorm:"foreignKey:AssignmentID;references:ID"`
+	User       User       `gorm:"foreignKey:UserID;references:ID"`
 }
 
 // Submission model
@@ -242,13 +494,168 @@ type Submission struct {
 	AssignmentID uuid.UUID `gorm:"type:uuid;not null"`
 	UserID       uuid.UUID `gorm:"type:uuid;not null"`
 	Status       string    `gorm:"not null"` // SUBMITTED, GRADED, RE_SUBMIT_REQUESTED
+	// FileURL, SubmittedAt and the lateness fields mirror the latest
+	// attempt; Grade and Feedback mirror the attempt graded last.
+	FileURL     *string
+	SubmittedAt time.Time
+	Grade       *string
+	Feedback    *string
+	IsLate      bool `gorm:"not null;default:false"`
+	// Percent deducted from the score when graded, fixed at submission
+	LatePenaltyPercent int `gorm:"not null;default:0"`
+	// Set while Status is RE_SUBMIT_REQUESTED
+	ResubmitComment     *string
+	ResubmitRequestedAt *time.Time
+
+	Assignment Assignment          `gorm:"foreignKey:AssignmentID;references:ID"`
+	User       User                `gorm:"foreignKey:UserID;references:ID"`
+	Attempts   []SubmissionAttempt `gorm:"foreignKey:SubmissionID"`
+}
+
+// SubmissionAttempt model: one hand-in of an assignment. The work is kept
+// as submitted; grading only fills in the score fields.
+type SubmissionAttempt struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	SubmissionID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_submission_attempt_number"`
+	Number       int       `gorm:"not null;uniqueIndex:idx_submission_attempt_number"`
 	FileURL      *string
-	SubmittedAt  time.Time
-	Grade        *string
+	// Text is the written answer, or the text extracted from the file.
+	Text               *string   `gorm:"type:text"`
+	SubmittedAt        time.Time `gorm:"not null"`
+	IsLate             bool      `gorm:"not null;default:false"`
+	LatePenaltyPercent int       `gorm:"not null;default:0"`
+	RawScore           *int      // before the late penalty
+	// For peer-reviewed assignments RawScore blends the teacher's score
+	// with the average of the peer reviews.
+	TeacherScore *int
+	PeerScore    *int
+	Score        *int
 	Feedback     *string
+	GradedBy     *uuid.UUID `gorm:"type:uuid"`
+	GradedAt     *time.Time
+	// Set when graded with a rubric. RubricGrade is the rubric.Grade with
+	// the level picked for each criterion.
+	RubricVersionID *uuid.UUID `gorm:"type:uuid;index"`
+	RubricGrade     *string    `gorm:"type:jsonb"`
+	// Set when the grade came from an AI grading suggestion.
+	GradingSuggestionID *uuid.UUID `gorm:"type:uuid"`
+
+	Submission Submission `gorm:"foreignKey:SubmissionID;references:ID"`
+}
 
-	Assignment Assignment `gorm:"foreignKey:AssignmentID;references:ID"`
-	User       User       `gorm:"foreignKey:UserID;references:ID"`
+// GradingSuggestion model: an AI-drafted grade for a submission attempt.
+// It never changes the attempt's grade itself; it keeps the prompt and the
+// model's answer so the draft can be traced.
+type GradingSuggestion struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	AttemptID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	RubricVersionID *uuid.UUID `gorm:"type:uuid"`
+	// Criteria is the []gradeassist.CriterionSuggestion and RubricGrade the
+	// rubric.Grade they add up to, for assignments with a rubric.
+	Criteria    *string `gorm:"type:jsonb"`
+	RubricGrade *string `gorm:"type:jsonb"`
+	Score       int     `gorm:"not null"` // out of the assignment's points, before any late penalty
+	// Justification explains a score suggested without a rubric.
+	Justification *string
+	Feedback      string     `gorm:"type:text;not null"`
+	Status        string     `gorm:"not null;default:'PENDING'"` // PENDING, ACCEPTED, EDITED, DISMISSED
+	Provider      string     `gorm:"not null"`
+	Model         string     `gorm:"not null"`
+	Prompt        string     `gorm:"type:text;not null"`
+	Output        string     `gorm:"type:text;not null"`
+	RequestedBy   uuid.UUID  `gorm:"type:uuid;not null"`
+	ReviewedBy    *uuid.UUID `gorm:"type:uuid"`
+	ReviewedAt    *time.Time
+	CreatedAt     time.Time
+
+	// Text is the submission text exactly as the model read it. Its
+	// TextSource is STUDENT_PROVIDED: what the student typed or pasted,
+	// never checked against the attempt's file.
+	Text       string `gorm:"type:text;not null;default:''"`
+	TextSource string `gorm:"not null;default:'STUDENT_PROVIDED'"`
+}
+
+// PeerReview model: one student's review of another's submission. The
+// reviewer grades the attempt that was current when reviews were handed
+// out, with the rubric version of that time.
+type PeerReview struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	AssignmentID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	SubmissionID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_peer_review_reviewer"`
+	ReviewerID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_peer_review_reviewer"`
+	AttemptID       uuid.UUID `gorm:"type:uuid;not null"`
+	RubricVersionID uuid.UUID `gorm:"type:uuid;not null"`
+	Status          string    `gorm:"not null;default:'ASSIGNED'"` // ASSIGNED, SUBMITTED
+	RubricGrade     *string   `gorm:"type:jsonb"`
+	Score           *int      // scaled to the assignment's points
+	Comment         *string
+	SubmittedAt     *time.Time
+	// Excluded reviews are left out of the peer average by a teacher.
+	Excluded       bool `gorm:"not null;default:false"`
+	ExcludedReason *string
+	CreatedAt      time.Time
+
+	Submission Submission `gorm:"foreignKey:SubmissionID;references:ID"`
+	Reviewer   User       `gorm:"foreignKey:ReviewerID;references:ID"`
+}
+
+// Rubric model: a reusable set of grading criteria owned by an
+// organization. The criteria live in RubricVersion rows; Version is the
+// number of the current one.
+type Rubric struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrgID       uuid.UUID `gorm:"type:uuid;not null;index"`
+	Title       string    `gorm:"not null"`
+	Description *string
+	Version     int       `gorm:"not null;default:1"`
+	CreatedBy   uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+
+	Versions []RubricVersion `gorm:"foreignKey:RubricID"`
+}
+
+// RubricVersion model: a rubric's criteria (a []rubric.Criterion) as of
+// one version. Once a submission has been graded with a version it is never
+// changed; editing the rubric starts the next version instead.
+type RubricVersion struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RubricID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_rubric_version_number"`
+	Number    int       `gorm:"not null;uniqueIndex:idx_rubric_version_number"`
+	Criteria  string    `gorm:"type:jsonb;not null"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+}
+
+// GradeCategory model: a weighted group of a course's assignments and
+// quizzes in the gradebook, such as homework or exams.
+type GradeCategory struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CourseID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name       string    `gorm:"not null"`
+	Weight     float64   `gorm:"not null;default:0"`
+	DropLowest int       `gorm:"not null;default:0"`
+	Position   int       `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+}
+
+// GradeOverride model: a teacher's replacement for one student's grade on
+// one assignment or quiz, or, without an ItemID, for their final grade.
+type GradeOverride struct {
+	ID       uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CourseID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	UserID   uuid.UUID  `gorm:"type:uuid;not null"`
+	ItemID   *uuid.UUID `gorm:"type:uuid"` // assignment or quiz ID
+	// Percent replaces the computed percentage; Letter, final grades only,
+	// replaces the letter the scale would give.
+	Percent      *float64
+	Letter       *string
+	Reason       *string
+	OverriddenBy uuid.UUID `gorm:"type:uuid;not null"`
+	UpdatedAt    time.Time
+
+	User User `gorm:"foreignKey:UserID;references:ID"`
 }
 
 // Thread model
@@ -259,6 +666,7 @@ type Thread struct {
 	Title     string    `gorm:"not null"`
 	Body      string    `gorm:"not null"`
 	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	Course  Course  `gorm:"foreignKey:CourseID;references:ID"`
 	Creator User    `gorm:"foreignKey:CreatedBy;references:ID"`
@@ -304,6 +712,37 @@ type CourseMetric struct {
 	Course Course `gorm:"foreignKey:CourseID;references:ID"`
 }
 
+// IdempotencyKey stores the outcome of a mutating request so that a retry
+// with the same Idempotency-Key header replays it instead of repeating it.
+type IdempotencyKey struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	RequestHash  string    `gorm:"not null"`
+	Status       string    `gorm:"not null;default:'PROCESSING'"` // PROCESSING, COMPLETED
+	StatusCode   int
+	ContentType  string
+	ResponseBody string `gorm:"type:text"`
+	CreatedAt    time.Time
+	ExpiresAt    time.Time `gorm:"not null;index"`
+}
+
+// TrashItem records a soft-deleted organization, course or module so it can
+// be listed, restored, or purged once the retention period has passed.
+// Everything the entity owns is stamped with the same DeletedAt as
+// TrashedAt, which is how restore finds exactly what was removed with it.
+type TrashItem struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrgID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	EntityType string    `gorm:"not null"` // ORGANIZATION, COURSE, MODULE
+	EntityID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	Title      string    `gorm:"not null"`
+	DeletedBy  uuid.UUID `gorm:"type:uuid;not null"`
+	TrashedAt  time.Time `gorm:"not null;index"`
+
+	Deleter User `gorm:"foreignKey:DeletedBy;references:ID"`
+}
+
 // BeforeCreate hooks to ensure UUID generation
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
@@ -0,0 +1,247 @@
+package openapi
+
+import (
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/dto"
+	"myway-backend/internal/studypack"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The types below describe handler responses that are built with gin.H.
+// They exist for documentation only; handlers do not use them.
+
+type ErrorResponse struct {
+	Error  string                `json:"error" binding:"required"`
+	Code   apperror.Code         `json:"code" binding:"required"`
+	Fields []apperror.FieldError `json:"fields"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message" binding:"required"`
+}
+
+type AuthUser struct {
+	ID    uuid.UUID `json:"id" binding:"required"`
+	Email string    `json:"email" binding:"required"`
+	Name  string    `json:"name" binding:"required"`
+	Role  string    `json:"role" binding:"required"`
+}
+
+type AuthResponse struct {
+	AccessToken  string   `json:"accessToken" binding:"required"`
+	RefreshToken string   `json:"refreshToken" binding:"required"`
+	User         AuthUser `json:"user" binding:"required"`
+}
+
+type RefreshTokenResponse struct {
+	AccessToken string `json:"accessToken" binding:"required"`
+}
+
+type OrganizationSummary struct {
+	ID   uuid.UUID `json:"id" binding:"required"`
+	Name string    `json:"name" binding:"required"`
+	Plan string    `json:"plan" binding:"required"`
+	Role string    `json:"role"`
+}
+
+type SwitchOrganizationResponse struct {
+	Organization OrganizationSummary `json:"organization" binding:"required"`
+	Role         string              `json:"role" binding:"required"`
+}
+
+type MembershipResponse struct {
+	OrganizationID uuid.UUID  `json:"organizationId" binding:"required"`
+	UserID         *uuid.UUID `json:"userId"`
+	Email          *string    `json:"email"`
+	Role           string     `json:"role" binding:"required"`
+	Status         string     `json:"status" binding:"required"`
+}
+
+type StudyPackRef struct {
+	ID     uuid.UUID `json:"id" binding:"required"`
+	Status string    `json:"status" binding:"required"`
+}
+
+type ImportResponse struct {
+	Material  dto.Material `json:"material" binding:"required"`
+	StudyPack StudyPackRef `json:"studyPack" binding:"required"`
+}
+
+type ImportStatusResponse struct {
+	Status      string     `json:"status" binding:"required"`
+	StudyPackID uuid.UUID  `json:"studyPackId" binding:"required"`
+	CreatedAt   time.Time  `json:"createdAt" binding:"required"`
+	PublishedAt *time.Time `json:"publishedAt"`
+}
+
+type YouTubeTranscriptResponse struct {
+	VideoID    string  `json:"videoId" binding:"required"`
+	Title      string  `json:"title" binding:"required"`
+	Transcript string  `json:"transcript" binding:"required"`
+	Language   string  `json:"language" binding:"required"`
+	Duration   float64 `json:"duration" binding:"required"`
+}
+
+type CourseProgressSummary struct {
+	CourseID           uuid.UUID `json:"courseId" binding:"required"`
+	CourseTitle        string    `json:"courseTitle" binding:"required"`
+	ProgressPercentage float64   `json:"progressPercentage" binding:"required"`
+	CompletedMaterials int       `json:"completedMaterials" binding:"required"`
+	TotalMaterials     int       `json:"totalMaterials" binding:"required"`
+}
+
+type CourseProgressResponse struct {
+	CourseID           uuid.UUID            `json:"courseId" binding:"required"`
+	ProgressPercentage float64              `json:"progressPercentage" binding:"required"`
+	TotalMaterials     int                  `json:"totalMaterials" binding:"required"`
+	CompletedMaterials int                  `json:"completedMaterials" binding:"required"`
+	Modules            []dto.ModuleProgress `json:"modules" binding:"required"`
+	QuizAttempts       int64                `json:"quizAttempts" binding:"required"`
+	FlashcardSessions  int64                `json:"flashcardSessions" binding:"required"`
+	LastActivity       *time.Time           `json:"lastActivity"`
+}
+
+type OrganizerDashboardResponse struct {
+	ActiveUsers         int               `json:"activeUsers" binding:"required"`
+	TotalUsers          int64             `json:"totalUsers" binding:"required"`
+	StudyPacksGenerated int64             `json:"studyPacksGenerated" binding:"required"`
+	QuizzesTaken        int64             `json:"quizzesTaken" binding:"required"`
+	RetentionRate       float64           `json:"retentionRate" binding:"required"`
+	DailyMetrics        []dto.DailyMetric `json:"dailyMetrics" binding:"required"`
+}
+
+type VideoEngagementResponse struct {
+	MaterialID            uuid.UUID `json:"materialId" binding:"required"`
+	DurationSec           *float64  `json:"durationSec"`
+	Viewers               int       `json:"viewers" binding:"required"`
+	CompletedViewers      int       `json:"completedViewers" binding:"required"`
+	AverageWatchedPercent float64   `json:"averageWatchedPercent" binding:"required"`
+	TotalWatchSeconds     float64   `json:"totalWatchSeconds" binding:"required"`
+	RejectedHeartbeats    int64     `json:"rejectedHeartbeats" binding:"required"`
+	Retention             []float64 `json:"retention" binding:"required"`
+}
+
+type MeResponse struct {
+	ID          uuid.UUID        `json:"id" binding:"required"`
+	Email       string           `json:"email" binding:"required"`
+	Name        string           `json:"name" binding:"required"`
+	Role        string           `json:"role" binding:"required"`
+	Memberships []dto.Membership `json:"memberships" binding:"required"`
+}
+
+type ThreadSummary struct {
+	ID         uuid.UUID    `json:"id" binding:"required"`
+	CourseID   uuid.UUID    `json:"courseId" binding:"required"`
+	Title      string       `json:"title" binding:"required"`
+	Body       string       `json:"body" binding:"required"`
+	CreatedAt  time.Time    `json:"createdAt" binding:"required"`
+	Creator    *dto.UserRef `json:"creator"`
+	ReplyCount int64        `json:"replyCount" binding:"required"`
+}
+
+type AssignmentSummary struct {
+	ID                uuid.UUID       `json:"id" binding:"required"`
+	Title             string          `json:"title" binding:"required"`
+	DueAt             time.Time       `json:"dueAt" binding:"required"`
+	ExtendedDueAt     *time.Time      `json:"extendedDueAt"`
+	Points            int             `json:"points" binding:"required"`
+	Instructions      string          `json:"instructions" binding:"required"`
+	Status            string          `json:"status" binding:"required"`
+	LatePolicy        string          `json:"latePolicy" binding:"required"`
+	LatePenaltyPerDay int             `json:"latePenaltyPerDay" binding:"required"`
+	MaxAttempts       *int            `json:"maxAttempts"`
+	RubricID          *uuid.UUID      `json:"rubricId"`
+	GradeCategoryID   *uuid.UUID      `json:"gradeCategoryId"`
+	PeerReviewCount   *int            `json:"peerReviewCount"`
+	PeerReviewDueAt   *time.Time      `json:"peerReviewDueAt"`
+	PeerReviewWeight  int             `json:"peerReviewWeight" binding:"required"`
+	Submission        *dto.Submission `json:"submission"`
+	SubmissionCount   *int64          `json:"submissionCount"`
+}
+
+type TutorChatResponse struct {
+	Answer                 string   `json:"answer" binding:"required"`
+	SourceReferences       []string `json:"sourceReferences" binding:"required"`
+	AnalyzedMaterialsCount int      `json:"analyzedMaterialsCount" binding:"required"`
+	Provider               string   `json:"provider" binding:"required"`
+	Model                  string   `json:"model" binding:"required"`
+}
+
+type TrashDeleter struct {
+	ID   uuid.UUID `json:"id" binding:"required"`
+	Name string    `json:"name" binding:"required"`
+}
+
+type TrashItemSummary struct {
+	ID         uuid.UUID    `json:"id" binding:"required"`
+	EntityType string       `json:"entityType" binding:"required"`
+	EntityID   uuid.UUID    `json:"entityId" binding:"required"`
+	Title      string       `json:"title" binding:"required"`
+	DeletedBy  TrashDeleter `json:"deletedBy" binding:"required"`
+	TrashedAt  time.Time    `json:"trashedAt" binding:"required"`
+	PurgeAfter time.Time    `json:"purgeAfter" binding:"required"`
+}
+
+type QuizSettings struct {
+	QuizID            uuid.UUID  `json:"quizId" binding:"required"`
+	TimeLimitSec      *int       `json:"timeLimitSec"`
+	MaxAttempts       *int       `json:"maxAttempts"`
+	ShuffleQuestions  bool       `json:"shuffleQuestions" binding:"required"`
+	ShuffleOptions    bool       `json:"shuffleOptions" binding:"required"`
+	RevealAfterSubmit bool       `json:"revealAfterSubmit" binding:"required"`
+	RevealAt          *time.Time `json:"revealAt"`
+	GradeCategoryID   *uuid.UUID `json:"gradeCategoryId"`
+}
+
+type QuizAttemptQuestion struct {
+	ID      uuid.UUID `json:"id" binding:"required"`
+	Type    string    `json:"type" binding:"required"`
+	Prompt  string    `json:"prompt" binding:"required"`
+	Options []string  `json:"options" binding:"required"`
+}
+
+type QuizQuestionReview struct {
+	QuestionID  uuid.UUID   `json:"questionId" binding:"required"`
+	Correct     bool        `json:"correct" binding:"required"`
+	Points      float64     `json:"points" binding:"required"`
+	Earned      float64     `json:"earned" binding:"required"`
+	AnswerKey   interface{} `json:"answerKey" binding:"required"`
+	Explanation *string     `json:"explanation"`
+}
+
+type QuizAttemptResult struct {
+	Score          int                  `json:"score" binding:"required"`
+	PointsEarned   float64              `json:"pointsEarned" binding:"required"`
+	PointsPossible float64              `json:"pointsPossible" binding:"required"`
+	Revealed       bool                 `json:"revealed" binding:"required"`
+	Questions      []QuizQuestionReview `json:"questions"`
+}
+
+type QuizAttemptView struct {
+	ID           uuid.UUID              `json:"id" binding:"required"`
+	QuizID       uuid.UUID              `json:"quizId" binding:"required"`
+	QuizVersion  int                    `json:"quizVersion" binding:"required"`
+	Status       string                 `json:"status" binding:"required"`
+	StartedAt    *time.Time             `json:"startedAt"`
+	ExpiresAt    *time.Time             `json:"expiresAt"`
+	SubmittedAt  *time.Time             `json:"submittedAt"`
+	TimeLimitSec *int                   `json:"timeLimitSec"`
+	Questions    []QuizAttemptQuestion  `json:"questions" binding:"required"`
+	Answers      map[string]interface{} `json:"answers" binding:"required"`
+	Result       *QuizAttemptResult     `json:"result"`
+}
+
+type DueFlashcardsResponse struct {
+	Date        string             `json:"date" binding:"required"`
+	ReviewCount int                `json:"reviewCount" binding:"required"`
+	NewCount    int                `json:"newCount" binding:"required"`
+	Cards       []dto.DueFlashcard `json:"cards" binding:"required"`
+}
+
+type RevisionDiffResponse struct {
+	Base    int                `json:"base" binding:"required"`
+	Head    int                `json:"head" binding:"required"`
+	Changes []studypack.Change `json:"changes" binding:"required"`
+}
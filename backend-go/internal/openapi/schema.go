@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	// gorm.DeletedAt marshals as a nullable timestamp, not as its struct.
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	// json.RawMessage passes stored JSON through, so it can be any value.
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas collects named struct schemas under components/schemas so that
+// recursive models (Course -> Modules -> Course) resolve through $ref.
+type schemas map[string]interface{}
+
+// of returns the schema for a Go value's type, registering named structs
+// as components on the way.
+func (s schemas) of(v interface{}) map[string]interface{} {
+	return s.forType(reflect.TypeOf(v))
+}
+
+func (s schemas) forType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case deletedAtType:
+		return map[string]interface{}{"type": "string", "format": "date-time", "nullable": true}
+	case rawJSONType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.forType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.forType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s[t.Name()]; !ok {
+			// Reserve the name before walking fields to stop recursion.
+			s[t.Name()] = nil
+			s[t.Name()] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (s schemas) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		// Untagged embedded structs are flattened by encoding/json.
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			embedded := s.structSchema(field.Type)
+			for k, v := range embedded["properties"].(map[string]interface{}) {
+				properties[k] = v
+			}
+			if req, ok := embedded["required"].([]string); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+
+		prop := s.forType(field.Type)
+		if field.Type.Kind() == reflect.Ptr {
+			prop = nullable(prop)
+		}
+		properties[name] = prop
+
+		// Untagged model fields are always serialized; request fields are
+		// required only when binding says so.
+		if isRequired(field) || (field.Tag.Get("json") == "" && field.Type.Kind() != reflect.Ptr) {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonName mirrors encoding/json: the tag name if present, else the field name.
+func jsonName(field reflect.StructField) (name string, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name = strings.SplitN(tag, ",", 2)[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name, false
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if _, isRef := schema["$ref"]; isRef {
+		return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+	}
+	out := map[string]interface{}{"nullable": true}
+	for k, v := range schema {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,419 @@
+package openapi
+
+import (
+	"myway-backend/internal/dto"
+	"myway-backend/internal/gradebook"
+	"myway-backend/internal/handlers"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BasePath is where the documented API version is mounted.
+const BasePath = "/api/v1"
+
+// Operation documents one route. Paths use gin syntax (/courses/:id) and are
+// relative to BasePath unless Unversioned is set.
+type Operation struct {
+	Method      string
+	Path        string
+	ID          string
+	Tag         string
+	Summary     string
+	Public      bool
+	Unversioned bool
+	Idempotent  bool
+	Request     interface{}
+	Response    interface{}
+	Status      int
+	List        bool
+	Query       []string
+	HTML        bool
+	Text        bool
+	CSV         bool
+}
+
+// listQuery returns the paging parameters plus the filters an endpoint
+// supports.
+func listQuery(filters ...string) []string {
+	return append([]string{"limit", "cursor", "sort"}, filters...)
+}
+
+var queryDescriptions = map[string]string{
+	"limit":       "Page size, 1-100 (default 20)",
+	"cursor":      "Opaque cursor from the previous page's nextCursor",
+	"sort":        "Sort key; prefix with - for descending order",
+	"status":      "Comma-separated list of statuses",
+	"createdBy":   "Creator user ID or \"me\"",
+	"from":        "Lower bound, RFC3339 timestamp or YYYY-MM-DD",
+	"to":          "Upper bound, RFC3339 timestamp or YYYY-MM-DD",
+	"url":         "YouTube video URL",
+	"reviewLimit": "Most due cards to return, 0-500 (default 200)",
+	"newLimit":    "New cards per day, 0-500 (default 20)",
+	"base":        "Revision number to compare from",
+	"head":        "Revision number to compare to",
+	"reviewer":    "Reviewer user ID or \"me\"",
+	"termId":      "Only courses in this term",
+	"itemId":      "Assignment or quiz ID; omit for the final grade",
+}
+
+// Operations is the API surface. The router test fails when a registered
+// route is missing here, so add new routes to this table as well.
+var Operations = []Operation{
+	// Meta
+	{Method: http.MethodGet, Path: "/", ID: "getServiceInfo", Tag: "Meta", Summary: "Service banner", Public: true, Unversioned: true},
+	{Method: http.MethodGet, Path: "/health", ID: "getHealth", Tag: "Meta", Summary: "Health check", Public: true, Unversioned: true},
+	{Method: http.MethodGet, Path: "/openapi.json", ID: "getOpenAPI", Tag: "Meta", Summary: "This OpenAPI document", Public: true, Unversioned: true},
+	{Method: http.MethodGet, Path: "/docs", ID: "getDocs", Tag: "Meta", Summary: "Swagger UI", Public: true, Unversioned: true, HTML: true},
+	{Method: http.MethodGet, Path: "/metrics", ID: "getMetrics", Tag: "Meta", Summary: "Prometheus metrics, including legacy route usage", Public: true, Unversioned: true, Text: true},
+
+	// Auth
+	{Method: http.MethodPost, Path: "/auth/signup", ID: "signUp", Tag: "Auth", Summary: "Create an account", Public: true, Request: handlers.SignUpRequest{}, Response: AuthResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/auth/signin", ID: "signIn", Tag: "Auth", Summary: "Sign in with email and password", Public: true, Request: handlers.SignInRequest{}, Response: AuthResponse{}},
+	{Method: http.MethodPost, Path: "/auth/refresh", ID: "refreshToken", Tag: "Auth", Summary: "Exchange a refresh token for an access token", Public: true, Request: handlers.RefreshTokenRequest{}, Response: RefreshTokenResponse{}},
+	{Method: http.MethodGet, Path: "/auth/me", ID: "getMe", Tag: "Auth", Summary: "Current user and memberships", Response: MeResponse{}},
+	{Method: http.MethodPost, Path: "/auth/logout", ID: "logout", Tag: "Auth", Summary: "Revoke a refresh token", Request: handlers.LogoutRequest{}, Response: MessageResponse{}},
+
+	// Organizations
+	{Method: http.MethodPost, Path: "/organizations", ID: "createOrganization", Idempotent: true, Tag: "Organizations", Summary: "Create an organization", Request: handlers.CreateOrganizationRequest{}, Response: dto.Organization{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/organizations", ID: "listOrganizations", Tag: "Organizations", Summary: "Organizations the current user belongs to", Response: []OrganizationSummary{}},
+	{Method: http.MethodDelete, Path: "/organizations/:id", ID: "deleteOrganization", Tag: "Organizations", Summary: "Move an organization and its courses to the trash", Response: MessageResponse{}},
+	{Method: http.MethodGet, Path: "/organizations/:id/trash", ID: "listOrganizationTrash", Tag: "Organizations", Summary: "Deleted items in an organization awaiting purge", Response: TrashItemSummary{}, List: true, Query: listQuery("createdBy", "from", "to")},
+	{Method: http.MethodPost, Path: "/organizations/:id/restore", ID: "restoreOrganization", Tag: "Organizations", Summary: "Restore an organization from the trash", Response: MessageResponse{}},
+	{Method: http.MethodPost, Path: "/organizations/:id/join", ID: "joinOrganization", Tag: "Organizations", Summary: "Join an organization as a student", Response: MembershipResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/organizations/:id/invite", ID: "inviteToOrganization", Tag: "Organizations", Summary: "Add a user to an organization", Request: handlers.InviteToOrganizationRequest{}, Response: MembershipResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/organizations/:id/switch", ID: "switchOrganization", Tag: "Organizations", Summary: "Switch the active organization", Response: SwitchOrganizationResponse{}},
+	{Method: http.MethodGet, Path: "/organizations/:id/settings", ID: "getOrganizationSettings", Tag: "Organizations", Summary: "An organization's policy settings", Response: dto.OrgSettings{}},
+	{Method: http.MethodPatch, Path: "/organizations/:id/settings", ID: "updateOrganizationSettings", Tag: "Organizations", Summary: "Change an organization's policy settings", Request: handlers.UpdateOrgSettingsRequest{}, Response: dto.OrgSettings{}},
+
+	// Courses
+	{Method: http.MethodPost, Path: "/courses", ID: "createCourse", Idempotent: true, Tag: "Courses", Summary: "Create a course", Request: handlers.CreateCourseRequest{}, Response: dto.Course{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/courses/:id", ID: "deleteCourse", Tag: "Courses", Summary: "Move a course to the trash", Response: MessageResponse{}},
+	{Method: http.MethodPost, Path: "/courses/:id/restore", ID: "restoreCourse", Tag: "Courses", Summary: "Restore a course from the trash", Response: MessageResponse{}},
+	{Method: http.MethodGet, Path: "/courses/:id", ID: "getCourse", Tag: "Courses", Summary: "Course with modules and materials", Response: dto.Course{}},
+	{Method: http.MethodPut, Path: "/courses/:id", ID: "updateCourse", Tag: "Courses", Summary: "Update a course's details and lifecycle status", Request: handlers.UpdateCourseRequest{}, Response: dto.Course{}},
+	{Method: http.MethodPatch, Path: "/courses/:id", ID: "patchCourse", Tag: "Courses", Summary: "Update a course's details and lifecycle status (same as PUT)", Request: handlers.UpdateCourseRequest{}, Response: dto.Course{}},
+	{Method: http.MethodGet, Path: "/courses/org/:orgId", ID: "listCoursesByOrg", Tag: "Courses", Summary: "Courses in an organization", Response: dto.Course{}, List: true, Query: listQuery("status", "createdBy", "termId")},
+	{Method: http.MethodGet, Path: "/courses/:id/review-queue", ID: "getReviewQueue", Tag: "Study Pack Review", Summary: "Study packs in a course by review status, by default those awaiting review", Response: dto.StudyPackReview{}, List: true, Query: listQuery("status", "from", "to", "reviewer")},
+	{Method: http.MethodPost, Path: "/courses/:id/clone", ID: "cloneCourse", Tag: "Courses", Summary: "Start a new offering of a course with its content and shifted due dates", Request: handlers.CloneCourseRequest{}, Response: dto.Course{}, Status: http.StatusCreated, Idempotent: true},
+
+	// Gradebook
+	{Method: http.MethodGet, Path: "/courses/:id/grade-categories", ID: "listGradeCategories", Tag: "Gradebook", Summary: "A course's gradebook categories in order", Response: []dto.GradeCategory{}},
+	{Method: http.MethodPost, Path: "/courses/:id/grade-categories", ID: "createGradeCategory", Tag: "Gradebook", Summary: "Add a weighted gradebook category", Request: handlers.GradeCategoryRequest{}, Response: dto.GradeCategory{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodPut, Path: "/grade-categories/:id", ID: "updateGradeCategory", Tag: "Gradebook", Summary: "Rename, reweight or reorder a gradebook category", Request: handlers.UpdateGradeCategoryRequest{}, Response: dto.GradeCategory{}},
+	{Method: http.MethodDelete, Path: "/grade-categories/:id", ID: "deleteGradeCategory", Tag: "Gradebook", Summary: "Delete a gradebook category, leaving its items uncategorized", Response: MessageResponse{}},
+	{Method: http.MethodPut, Path: "/courses/:id/grade-scale", ID: "updateGradeScale", Tag: "Gradebook", Summary: "Replace a course's letter scale", Request: handlers.GradeScaleRequest{}, Response: gradebook.Scale{}},
+	{Method: http.MethodGet, Path: "/courses/:id/gradebook", ID: "getGradebook", Tag: "Gradebook", Summary: "Every enrolled student's grades", Response: dto.Gradebook{}},
+	{Method: http.MethodGet, Path: "/courses/:id/gradebook/export", ID: "exportGradebook", Tag: "Gradebook", Summary: "The gradebook as CSV", CSV: true},
+	{Method: http.MethodPut, Path: "/courses/:id/gradebook/overrides/:userId", ID: "setGradeOverride", Tag: "Gradebook", Summary: "Override a student's grade on an item or their final grade", Request: handlers.GradeOverrideRequest{}, Response: dto.GradeOverride{}},
+	{Method: http.MethodDelete, Path: "/courses/:id/gradebook/overrides/:userId", ID: "clearGradeOverride", Tag: "Gradebook", Summary: "Clear a grade override", Query: []string{"itemId"}, Response: MessageResponse{}},
+	{Method: http.MethodGet, Path: "/courses/:id/my-grades", ID: "getMyGrades", Tag: "Gradebook", Summary: "The caller's own grades in a course", Response: dto.Gradebook{}},
+
+	// Terms
+	{Method: http.MethodPost, Path: "/organizations/:id/terms", ID: "createTerm", Tag: "Terms", Summary: "Add a term to an organization", Request: handlers.TermRequest{}, Response: dto.Term{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodGet, Path: "/organizations/:id/terms", ID: "listTerms", Tag: "Terms", Summary: "An organization's terms, latest first", Response: []dto.Term{}},
+	{Method: http.MethodPut, Path: "/terms/:id", ID: "updateTerm", Tag: "Terms", Summary: "Rename or reschedule a term", Request: handlers.UpdateTermRequest{}, Response: dto.Term{}},
+	{Method: http.MethodPost, Path: "/terms/:id/archive", ID: "archiveTerm", Tag: "Terms", Summary: "Make a term's courses read-only", Response: dto.Term{}},
+	{Method: http.MethodPost, Path: "/terms/:id/unarchive", ID: "unarchiveTerm", Tag: "Terms", Summary: "Reopen an archived term", Response: dto.Term{}},
+
+	// Modules
+	{Method: http.MethodPost, Path: "/modules", ID: "createModule", Tag: "Modules", Summary: "Create a module", Request: handlers.CreateModuleRequest{}, Response: dto.Module{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/modules/course/:courseId", ID: "listModulesByCourse", Tag: "Modules", Summary: "Modules in a course", Response: dto.Module{}, List: true, Query: listQuery()},
+	{Method: http.MethodGet, Path: "/modules/:id", ID: "getModule", Tag: "Modules", Summary: "Module with materials", Response: dto.Module{}},
+	{Method: http.MethodPut, Path: "/modules/:id", ID: "updateModule", Tag: "Modules", Summary: "Update a module", Request: handlers.UpdateModuleRequest{}, Response: dto.Module{}},
+	{Method: http.MethodDelete, Path: "/modules/:id", ID: "deleteModule", Tag: "Modules", Summary: "Move a module to the trash", Response: MessageResponse{}},
+	{Method: http.MethodPost, Path: "/modules/:id/restore", ID: "restoreModule", Tag: "Modules", Summary: "Restore a module from the trash", Response: MessageResponse{}},
+	{Method: http.MethodPut, Path: "/courses/:id/modules/order", ID: "reorderModules", Tag: "Modules", Summary: "Reorder a course's modules", Request: handlers.ReorderRequest{}, Response: []dto.Module{}},
+	{Method: http.MethodPut, Path: "/modules/:id/materials/order", ID: "reorderMaterials", Tag: "Modules", Summary: "Reorder a module's materials", Request: handlers.ReorderRequest{}, Response: dto.Module{}},
+	{Method: http.MethodPost, Path: "/modules/:id/duplicate", ID: "duplicateModule", Tag: "Modules", Summary: "Deep-copy a module into this or another course", Request: handlers.DuplicateModuleRequest{}, Response: dto.Module{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodPost, Path: "/materials/:id/move", ID: "moveMaterial", Tag: "Modules", Summary: "Move a material to another module or position", Request: handlers.MoveMaterialRequest{}, Response: dto.Material{}},
+	{Method: http.MethodPut, Path: "/materials/:id/duration", ID: "setVideoDuration", Tag: "Modules", Summary: "Set the length of a video material", Request: handlers.SetVideoDurationRequest{}, Response: dto.Material{}},
+
+	// Assignments
+	{Method: http.MethodPost, Path: "/assignments", ID: "createAssignment", Tag: "Assignments", Summary: "Create an assignment", Request: handlers.CreateAssignmentRequest{}, Response: dto.Assignment{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/assignments/course/:courseId", ID: "listAssignmentsByCourse", Tag: "Assignments", Summary: "Assignments in a course with the caller's submission state", Response: AssignmentSummary{}, List: true, Query: listQuery("status", "from", "to")},
+	{Method: http.MethodGet, Path: "/assignments/:id", ID: "getAssignment", Tag: "Assignments", Summary: "Assignment detail"},
+	{Method: http.MethodPut, Path: "/assignments/:id", ID: "updateAssignment", Tag: "Assignments", Summary: "Update an assignment and its late policy", Request: handlers.UpdateAssignmentRequest{}, Response: dto.Assignment{}},
+	{Method: http.MethodPost, Path: "/assignments/:id/archive", ID: "archiveAssignment", Tag: "Assignments", Summary: "Archive an assignment so it takes no more submissions", Response: dto.Assignment{}},
+	{Method: http.MethodPost, Path: "/assignments/:id/unarchive", ID: "unarchiveAssignment", Tag: "Assignments", Summary: "Make an archived assignment active again", Response: dto.Assignment{}},
+	{Method: http.MethodGet, Path: "/assignments/:id/extensions", ID: "listAssignmentExtensions", Tag: "Assignments", Summary: "Due-date extensions granted on an assignment", Response: []dto.AssignmentExtension{}},
+	{Method: http.MethodPut, Path: "/assignments/:id/extensions/:userId", ID: "grantAssignmentExtension", Tag: "Assignments", Summary: "Give a student a later due date", Request: handlers.ExtensionRequest{}, Response: dto.AssignmentExtension{}},
+	{Method: http.MethodDelete, Path: "/assignments/:id/extensions/:userId", ID: "revokeAssignmentExtension", Tag: "Assignments", Summary: "Remove a student's extension", Response: MessageResponse{}},
+	{Method: http.MethodPut, Path: "/assignments/:id/rubric", ID: "attachRubric", Tag: "Assignments", Summary: "Grade an assignment with a rubric", Request: handlers.AttachRubricRequest{}, Response: dto.Assignment{}},
+	{Method: http.MethodDelete, Path: "/assignments/:id/rubric", ID: "detachRubric", Tag: "Assignments", Summary: "Stop grading an assignment with a rubric", Response: dto.Assignment{}},
+
+	// Peer review
+	{Method: http.MethodPut, Path: "/assignments/:id/peer-review", ID: "configurePeerReview", Tag: "Peer Review", Summary: "Turn on peer review or change its settings", Request: handlers.PeerReviewSettingsRequest{}, Response: dto.Assignment{}},
+	{Method: http.MethodDelete, Path: "/assignments/:id/peer-review", ID: "disablePeerReview", Tag: "Peer Review", Summary: "Turn off peer review before reviews are handed out", Response: dto.Assignment{}},
+	{Method: http.MethodPost, Path: "/assignments/:id/peer-reviews/assign", ID: "assignPeerReviews", Tag: "Peer Review", Summary: "Hand out the reviews now that the assignment is due", Response: dto.PeerReviewOverview{}},
+	{Method: http.MethodGet, Path: "/assignments/:id/peer-reviews", ID: "getPeerReviews", Tag: "Peer Review", Summary: "Every submission with its reviews and blended score", Response: dto.PeerReviewOverview{}},
+	{Method: http.MethodGet, Path: "/assignments/:id/peer-reviews/calibration", ID: "getPeerReviewCalibration", Tag: "Peer Review", Summary: "How each reviewer's scores compare with the teacher's", Response: []dto.ReviewerCalibration{}},
+	{Method: http.MethodGet, Path: "/assignments/:id/peer-reviews/mine", ID: "getMyPeerReviews", Tag: "Peer Review", Summary: "The reviews the caller has to do", Response: []dto.PeerReviewTask{}},
+	{Method: http.MethodPut, Path: "/peer-reviews/:id", ID: "submitPeerReview", Tag: "Peer Review", Summary: "Fill out the rubric for an assigned review", Request: handlers.SubmitPeerReviewRequest{}, Response: dto.PeerReview{}},
+	{Method: http.MethodPost, Path: "/peer-reviews/:id/exclude", ID: "excludePeerReview", Tag: "Peer Review", Summary: "Leave a review out of the peer average", Request: handlers.ExcludePeerReviewRequest{}, Response: dto.PeerReview{}},
+	{Method: http.MethodPost, Path: "/peer-reviews/:id/include", ID: "includePeerReview", Tag: "Peer Review", Summary: "Count an excluded review again", Response: dto.PeerReview{}},
+	{Method: http.MethodGet, Path: "/submissions/:id/peer-reviews", ID: "getSubmissionPeerReviews", Tag: "Peer Review", Summary: "The reviews of a submission", Response: []dto.PeerReview{}},
+	{Method: http.MethodPost, Path: "/assignments/:id/submit", ID: "submitAssignment", Idempotent: true, Tag: "Assignments", Summary: "Submit an assignment", Request: handlers.SubmitAssignmentRequest{}, Response: dto.Submission{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/submissions/:id/grade", ID: "gradeSubmission", Tag: "Assignments", Summary: "Grade an attempt of a submission, the latest by default", Request: handlers.GradeSubmissionRequest{}},
+	{Method: http.MethodPost, Path: "/submissions/:id/request-resubmission", ID: "requestResubmission", Tag: "Assignments", Summary: "Ask the student to hand in again", Request: handlers.RequestResubmissionRequest{}, Response: dto.Submission{}},
+	{Method: http.MethodGet, Path: "/submissions/:id/attempts", ID: "getSubmissionAttempts", Tag: "Assignments", Summary: "List every attempt of a submission", Response: []dto.SubmissionAttempt{}},
+	{Method: http.MethodPost, Path: "/submissions/:id/grading-suggestions", ID: "suggestGrade", Tag: "AI", Summary: "Draft a grade for an attempt with the AI provider", Request: handlers.SuggestGradeRequest{}, Response: dto.GradingSuggestion{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodGet, Path: "/submissions/:id/grading-suggestions", ID: "listGradingSuggestions", Tag: "AI", Summary: "List the AI grading suggestions for a submission", Response: []dto.GradingSuggestion{}},
+	{Method: http.MethodPost, Path: "/grading-suggestions/:id/dismiss", ID: "dismissGradingSuggestion", Tag: "AI", Summary: "Dismiss a pending grading suggestion", Response: dto.GradingSuggestion{}},
+	{Method: http.MethodPost, Path: "/organizations/:id/rubrics", ID: "createRubric", Tag: "Rubrics", Summary: "Add a reusable rubric to an organization", Request: handlers.RubricRequest{}, Response: dto.Rubric{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodGet, Path: "/organizations/:id/rubrics", ID: "listRubrics", Tag: "Rubrics", Summary: "An organization's rubrics by title", Response: []dto.Rubric{}},
+	{Method: http.MethodGet, Path: "/rubrics/:id", ID: "getRubric", Tag: "Rubrics", Summary: "A rubric with its current criteria", Response: dto.Rubric{}},
+	{Method: http.MethodPut, Path: "/rubrics/:id", ID: "updateRubric", Tag: "Rubrics", Summary: "Edit a rubric, starting a new version once it has been graded with", Request: handlers.RubricRequest{}, Response: dto.Rubric{}},
+	{Method: http.MethodDelete, Path: "/rubrics/:id", ID: "deleteRubric", Tag: "Rubrics", Summary: "Delete a rubric no assignment uses", Response: MessageResponse{}},
+	{Method: http.MethodGet, Path: "/rubrics/:id/versions", ID: "listRubricVersions", Tag: "Rubrics", Summary: "Every version of a rubric, oldest first", Response: []dto.RubricVersion{}},
+
+	// Discussions
+	{Method: http.MethodPost, Path: "/discussions/threads", ID: "createThread", Idempotent: true, Tag: "Discussions", Summary: "Start a thread", Request: handlers.CreateThreadRequest{}, Response: dto.Thread{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/discussions/threads/course/:courseId", ID: "listThreadsByCourse", Tag: "Discussions", Summary: "Threads in a course", Response: ThreadSummary{}, List: true, Query: listQuery("createdBy", "from", "to")},
+	{Method: http.MethodGet, Path: "/discussions/threads/:id", ID: "getThread", Tag: "Discussions", Summary: "Thread with replies", Response: dto.Thread{}},
+	{Method: http.MethodPost, Path: "/discussions/threads/:threadId/replies", ID: "createReply", Idempotent: true, Tag: "Discussions", Summary: "Reply to a thread", Request: handlers.CreateReplyRequest{}, Response: dto.Reply{}, Status: http.StatusCreated},
+
+	// Flashcards
+	{Method: http.MethodGet, Path: "/flashcards/studypack/:studyPackId", ID: "listFlashcardsByStudyPack", Tag: "Flashcards", Summary: "Flashcards in a study pack", Response: []dto.Flashcard{}},
+	{Method: http.MethodPost, Path: "/flashcards/sessions", ID: "recordFlashcardSession", Idempotent: true, Tag: "Flashcards", Summary: "Record a flashcard session", Request: handlers.FlashcardSessionRequest{}, Response: dto.FlashcardSession{}},
+	{Method: http.MethodGet, Path: "/flashcards/sessions", ID: "listFlashcardSessions", Tag: "Flashcards", Summary: "The caller's flashcard sessions", Response: dto.FlashcardSession{}, List: true, Query: listQuery("from", "to")},
+	{Method: http.MethodGet, Path: "/flashcards/due", ID: "getDueFlashcards", Tag: "Flashcards", Summary: "Today's review queue across enrolled courses", Response: DueFlashcardsResponse{}, Query: []string{"reviewLimit", "newLimit"}},
+	{Method: http.MethodPost, Path: "/flashcards/:id/review", ID: "reviewFlashcard", Idempotent: true, Tag: "Flashcards", Summary: "Grade a flashcard and reschedule it", Request: handlers.ReviewFlashcardRequest{}, Response: dto.FlashcardSchedule{}},
+
+	// Progress
+	{Method: http.MethodGet, Path: "/progress/course/:courseId", ID: "getCourseProgress", Tag: "Progress", Summary: "The caller's completion of a course, by module and material", Response: CourseProgressResponse{}},
+	{Method: http.MethodGet, Path: "/progress/org", ID: "getProgressByOrg", Tag: "Progress", Summary: "The caller's progress across the active organization", Response: []CourseProgressSummary{}},
+	{Method: http.MethodPost, Path: "/progress/materials/:materialId/view", ID: "recordMaterialView", Tag: "Progress", Summary: "Mark a material as opened by the caller", Response: MessageResponse{}},
+	{Method: http.MethodPost, Path: "/progress/materials/:materialId/heartbeat", ID: "recordVideoHeartbeat", Tag: "Progress", Summary: "Report a played segment of a video", Request: handlers.VideoHeartbeatRequest{}, Response: dto.VideoWatch{}},
+	{Method: http.MethodGet, Path: "/progress/materials/:materialId/watch", ID: "getVideoWatch", Tag: "Progress", Summary: "How much of a video the caller has watched", Response: dto.VideoWatch{}},
+	{Method: http.MethodGet, Path: "/organizations/:id/completion-criteria", ID: "getCompletionCriteria", Tag: "Progress", Summary: "Completion criteria for each material type", Response: []dto.CompletionCriteria{}},
+	{Method: http.MethodPut, Path: "/organizations/:id/completion-criteria/:type", ID: "updateCompletionCriteria", Tag: "Progress", Summary: "Replace the completion criteria for a material type", Request: handlers.UpdateCompletionCriteriaRequest{}, Response: dto.CompletionCriteria{}},
+
+	// Quizzes
+	{Method: http.MethodGet, Path: "/quizzes/:id/settings", ID: "getQuizSettings", Tag: "Quizzes", Summary: "Attempt settings of a quiz", Response: QuizSettings{}},
+	{Method: http.MethodPut, Path: "/quizzes/:id/settings", ID: "updateQuizSettings", Tag: "Quizzes", Summary: "Replace the attempt settings of a quiz", Request: handlers.UpdateQuizSettingsRequest{}, Response: QuizSettings{}},
+	{Method: http.MethodPut, Path: "/quizzes/:id/grade-category", ID: "setQuizGradeCategory", Tag: "Gradebook", Summary: "File a quiz under a gradebook category", Request: handlers.SetGradeCategoryRequest{}, Response: QuizSettings{}},
+	{Method: http.MethodPost, Path: "/quizzes/:id/attempts", ID: "startQuizAttempt", Tag: "Quizzes", Summary: "Start an attempt, or resume the one in progress", Response: QuizAttemptView{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/quiz-attempts/:id", ID: "getQuizAttempt", Tag: "Quizzes", Summary: "An attempt with its saved answers and result", Response: QuizAttemptView{}},
+	{Method: http.MethodPut, Path: "/quiz-attempts/:id/answers", ID: "saveQuizAnswer", Tag: "Quizzes", Summary: "Save one answer on an attempt in progress", Request: handlers.SaveQuizAnswerRequest{}, Response: QuizAttemptView{}},
+	{Method: http.MethodPost, Path: "/quiz-attempts/:id/submit", ID: "submitQuizAttempt", Tag: "Quizzes", Summary: "Submit an attempt for grading", Response: QuizAttemptView{}},
+
+	// Study pack authoring
+	{Method: http.MethodPost, Path: "/studypacks/:id/quizzes", ID: "createQuiz", Idempotent: true, Tag: "Study Pack Authoring", Summary: "Add an empty quiz to a draft study pack", Response: dto.Quiz{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/quizzes/:id/questions", ID: "createQuizQuestion", Idempotent: true, Tag: "Study Pack Authoring", Summary: "Add a question and return the quiz", Request: handlers.QuizQuestionRequest{}, Response: dto.Quiz{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/quizzes/:id/questions/order", ID: "reorderQuizQuestions", Tag: "Study Pack Authoring", Summary: "Reorder a quiz's questions", Request: handlers.ReorderRequest{}, Response: dto.Quiz{}},
+	{Method: http.MethodPut, Path: "/quiz-questions/:id", ID: "updateQuizQuestion", Tag: "Study Pack Authoring", Summary: "Replace a question and return the quiz", Request: handlers.QuizQuestionRequest{}, Response: dto.Quiz{}},
+	{Method: http.MethodDelete, Path: "/quiz-questions/:id", ID: "deleteQuizQuestion", Tag: "Study Pack Authoring", Summary: "Remove a question and return the quiz", Response: dto.Quiz{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/flashcards", ID: "createFlashcard", Idempotent: true, Tag: "Study Pack Authoring", Summary: "Add a flashcard to a draft study pack", Request: handlers.FlashcardRequest{}, Response: dto.Flashcard{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/studypacks/:id/flashcards/order", ID: "reorderFlashcards", Tag: "Study Pack Authoring", Summary: "Reorder a study pack's flashcards", Request: handlers.ReorderRequest{}, Response: []dto.Flashcard{}},
+	{Method: http.MethodPut, Path: "/flashcards/:id", ID: "updateFlashcard", Tag: "Study Pack Authoring", Summary: "Edit a flashcard", Request: handlers.FlashcardRequest{}, Response: dto.Flashcard{}},
+	{Method: http.MethodDelete, Path: "/flashcards/:id", ID: "deleteFlashcard", Tag: "Study Pack Authoring", Summary: "Delete a flashcard", Response: MessageResponse{}},
+	{Method: http.MethodGet, Path: "/studypacks/:id/revisions", ID: "listStudyPackRevisions", Tag: "Study Pack Authoring", Summary: "A study pack's revision history, without snapshots", Response: dto.StudyPackRevision{}, List: true, Query: listQuery("from", "to")},
+	{Method: http.MethodGet, Path: "/studypacks/:id/revisions/diff", ID: "diffStudyPackRevisions", Tag: "Study Pack Authoring", Summary: "Field-level changes between two revisions", Response: RevisionDiffResponse{}, Query: []string{"base", "head"}},
+	{Method: http.MethodGet, Path: "/studypacks/:id/revisions/:number", ID: "getStudyPackRevision", Tag: "Study Pack Authoring", Summary: "A revision with its snapshot", Response: dto.StudyPackRevision{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/revisions/:number/rollback", ID: "rollbackStudyPack", Idempotent: true, Tag: "Study Pack Authoring", Summary: "Restore a revision's content and publish it as a new revision", Request: handlers.RollbackStudyPackRequest{}, Response: dto.StudyPackRevision{}},
+
+	// Study pack review
+	{Method: http.MethodGet, Path: "/studypacks/:id/review", ID: "getStudyPackReview", Tag: "Study Pack Review", Summary: "Review status, reviewers and open comment count", Response: dto.StudyPackReview{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/reviewers", ID: "assignStudyPackReviewer", Idempotent: true, Tag: "Study Pack Review", Summary: "Assign a reviewer", Request: handlers.AssignReviewerRequest{}, Response: dto.StudyPackReview{}},
+	{Method: http.MethodDelete, Path: "/studypacks/:id/reviewers/:userId", ID: "removeStudyPackReviewer", Tag: "Study Pack Review", Summary: "Unassign a reviewer", Response: dto.StudyPackReview{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/review/submit", ID: "submitStudyPackForReview", Tag: "Study Pack Review", Summary: "Submit a draft for review", Response: dto.StudyPackReview{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/review/withdraw", ID: "withdrawStudyPackReview", Tag: "Study Pack Review", Summary: "Return a pack in review to draft", Response: dto.StudyPackReview{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/review/decision", ID: "decideStudyPackReview", Tag: "Study Pack Review", Summary: "Approve or request changes as an assigned reviewer", Request: handlers.ReviewDecisionRequest{}, Response: dto.StudyPackReview{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/publish", ID: "publishStudyPack", Tag: "Study Pack Review", Summary: "Publish a study pack to students", Request: handlers.ReviewActionRequest{}, Response: dto.StudyPackReview{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/unpublish", ID: "unpublishStudyPack", Tag: "Study Pack Review", Summary: "Hide a published study pack and reopen it for editing", Response: dto.StudyPackReview{}},
+	{Method: http.MethodGet, Path: "/studypacks/:id/review/comments", ID: "listReviewComments", Tag: "Study Pack Review", Summary: "Review comments, oldest first", Response: []dto.ReviewComment{}},
+	{Method: http.MethodPost, Path: "/studypacks/:id/review/comments", ID: "createReviewComment", Idempotent: true, Tag: "Study Pack Review", Summary: "Comment on the summary, a key point or a question", Request: handlers.ReviewCommentRequest{}, Response: dto.ReviewComment{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/review-comments/:id/resolve", ID: "resolveReviewComment", Tag: "Study Pack Review", Summary: "Mark a review comment resolved", Response: dto.ReviewComment{}},
+
+	// Analytics
+	{Method: http.MethodGet, Path: "/analytics/student", ID: "getStudentDashboard", Tag: "Analytics", Summary: "Student dashboard"},
+	{Method: http.MethodGet, Path: "/analytics/teacher", ID: "getTeacherDashboard", Tag: "Analytics", Summary: "Teacher dashboard"},
+	{Method: http.MethodGet, Path: "/analytics/organizer", ID: "getOrganizerDashboard", Tag: "Analytics", Summary: "Organizer dashboard for the active organization", Response: OrganizerDashboardResponse{}},
+	{Method: http.MethodGet, Path: "/analytics/materials/:materialId/video", ID: "getVideoEngagement", Tag: "Analytics", Summary: "How learners watched a video", Response: VideoEngagementResponse{}},
+	{Method: http.MethodPost, Path: "/analytics/quiz/attempt", ID: "recordQuizAttempt", Idempotent: true, Tag: "Analytics", Summary: "Record a finished attempt on an untimed quiz", Request: handlers.RecordQuizAttemptRequest{}, Response: dto.QuizAttempt{}},
+
+	// AI
+	{Method: http.MethodPost, Path: "/ai/transcript", ID: "fetchTranscript", Tag: "AI", Summary: "Fetch a video transcript", Public: true, Request: handlers.TranscriptRequest{}, Response: handlers.TranscriptResponse{}},
+	{Method: http.MethodGet, Path: "/ai/studypack/:materialId", ID: "getStudyPack", Tag: "AI", Summary: "Published study pack for a material", Response: dto.StudyPackContent{}},
+	{Method: http.MethodGet, Path: "/ai/review/:materialId", ID: "getReviewDraft", Tag: "AI", Summary: "Study pack draft for review"},
+	{Method: http.MethodPost, Path: "/ai/review/:materialId/approve", ID: "approveStudyPack", Tag: "AI", Summary: "Approve and publish a study pack draft", Request: handlers.ApproveStudyPackRequest{}},
+	{Method: http.MethodPost, Path: "/ai/review/:materialId/regenerate", ID: "regenerateStudyPack", Tag: "AI", Summary: "Regenerate a study pack draft", Request: handlers.RegenerateStudyPackRequest{}},
+	{Method: http.MethodPost, Path: "/ai/tutor", ID: "tutorChat", Tag: "AI", Summary: "Ask the AI tutor", Request: handlers.TutorChatRequest{}, Response: TutorChatResponse{}},
+
+	// Imports
+	{Method: http.MethodGet, Path: "/youtube/transcript", ID: "getYouTubeTranscript", Tag: "Imports", Summary: "Fetch a YouTube transcript", Public: true, Response: YouTubeTranscriptResponse{}, Query: []string{"url"}},
+	{Method: http.MethodPost, Path: "/imports/youtube", ID: "importYouTube", Idempotent: true, Tag: "Imports", Summary: "Import a YouTube video as a material", Request: handlers.ImportYouTubeRequest{}, Response: ImportResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/imports/document", ID: "importDocument", Idempotent: true, Tag: "Imports", Summary: "Import a document as a material", Request: handlers.ImportDocumentRequest{}, Response: ImportResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/imports/status/:materialId", ID: "getImportStatus", Tag: "Imports", Summary: "Study pack generation status", Response: ImportStatusResponse{}},
+}
+
+// Document builds the OpenAPI 3 document from Operations.
+func Document() map[string]interface{} {
+	components := schemas{}
+	errorRef := components.of(ErrorResponse{})
+	paths := map[string]interface{}{}
+
+	for _, op := range Operations {
+		path, params := convertPath(op.Path)
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			if op.Unversioned {
+				item["servers"] = []interface{}{map[string]interface{}{"url": "/"}}
+			}
+			paths[path] = item
+		}
+
+		operation := map[string]interface{}{
+			"operationId": op.ID,
+			"tags":        []string{op.Tag},
+			"summary":     op.Summary,
+		}
+
+		var parameters []interface{}
+		for _, name := range params {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string", "format": "uuid"},
+			})
+		}
+		for _, name := range op.Query {
+			param := map[string]interface{}{
+				"name": name, "in": "query",
+				"schema": map[string]interface{}{"type": "string"},
+			}
+			if name == "limit" {
+				param["schema"] = map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 100}
+			}
+			if desc, ok := queryDescriptions[name]; ok {
+				param["description"] = desc
+			}
+			parameters = append(parameters, param)
+		}
+		if op.Idempotent {
+			parameters = append(parameters, map[string]interface{}{
+				"name": "Idempotency-Key", "in": "header",
+				"description": "Retries with the same key and body replay the first successful response",
+				"schema":      map[string]interface{}{"type": "string", "maxLength": 255},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": components.of(op.Request)},
+				},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		switch {
+		case op.HTML:
+			success["content"] = map[string]interface{}{
+				"text/html": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		case op.Text:
+			success["content"] = map[string]interface{}{
+				"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		case op.CSV:
+			success["content"] = map[string]interface{}{
+				"text/csv": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		default:
+			success["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": responseSchema(components, op)},
+			}
+		}
+
+		errorResponse := map[string]interface{}{
+			"description": "Error envelope",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": errorRef},
+			},
+		}
+		operation["responses"] = map[string]interface{}{
+			strconv.Itoa(status): success,
+			"default":            errorResponse,
+		}
+
+		if !op.Public {
+			operation["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		}
+
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "MyWay LMS API",
+			"version": "1.0.0",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": BasePath}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}(components),
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+func responseSchema(components schemas, op Operation) map[string]interface{} {
+	if op.Response == nil {
+		return map[string]interface{}{"type": "object"}
+	}
+	item := components.of(op.Response)
+	if !op.List {
+		return item
+	}
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"items", "total", "nextCursor"},
+		"properties": map[string]interface{}{
+			"items":      map[string]interface{}{"type": "array", "items": item},
+			"total":      map[string]interface{}{"type": "integer"},
+			"nextCursor": map[string]interface{}{"type": "string", "nullable": true},
+		},
+	}
+}
+
+// convertPath turns /courses/:id into /courses/{id} and returns the
+// parameter names in order.
+func convertPath(ginPath string) (string, []string) {
+	segments := strings.Split(ginPath, "/")
+	var params []string
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// Routes returns the absolute "METHOD /path" keys in gin syntax, sorted.
+func Routes() []string {
+	keys := make([]string, len(Operations))
+	for i, op := range Operations {
+		path := op.Path
+		if !op.Unversioned {
+			path = BasePath + path
+		}
+		keys[i] = op.Method + " " + path
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,313 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"myway-backend/internal/apperror"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Kind tells the cursor decoder how to restore a sort value.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindTime
+)
+
+// SortField maps an API sort key onto a column and a way to read the value
+// back from a row when building the next cursor.
+type SortField[T any] struct {
+	Column string
+	Kind   Kind
+	Value  func(T) interface{}
+}
+
+// Spec declares what a list endpoint supports. Empty filter columns mean the
+// filter is not available and requesting it is a validation error.
+type Spec[T any] struct {
+	Sorts         map[string]SortField[T]
+	DefaultSort   string
+	IDColumn      string
+	ID            func(T) uuid.UUID
+	StatusColumn  string
+	CreatorColumn string
+	DateColumn    string
+}
+
+// Query is a parsed list request.
+type Query[T any] struct {
+	spec      Spec[T]
+	Limit     int
+	SortKey   string
+	Desc      bool
+	Status    []string
+	CreatedBy *uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	after     *cursor
+}
+
+// Page is the list response envelope.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	Total      int64   `json:"total"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+type cursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v"`
+	ID    uuid.UUID   `json:"id"`
+}
+
+// Parse reads limit, cursor, sort, status, createdBy, from and to from the
+// query string.
+//
+//	?limit=20&cursor=<opaque>&sort=-createdAt&status=ACTIVE,ARCHIVED&createdBy=me&from=2024-01-01&to=2024-02-01
+func Parse[T any](c *gin.Context, spec Spec[T]) (*Query[T], error) {
+	q := &Query[T]{spec: spec, Limit: DefaultLimit}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return nil, apperror.InvalidField("limit", "limit must be a positive integer")
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		q.Limit = limit
+	}
+
+	sortParam := c.DefaultQuery("sort", spec.DefaultSort)
+	q.Desc = strings.HasPrefix(sortParam, "-")
+	q.SortKey = strings.TrimPrefix(sortParam, "-")
+	if _, ok := spec.Sorts[q.SortKey]; !ok {
+		return nil, apperror.InvalidField("sort", "sort must be one of: "+strings.Join(spec.sortKeys(), ", "))
+	}
+
+	if raw := c.Query("status"); raw != "" {
+		if spec.StatusColumn == "" {
+			return nil, apperror.InvalidField("status", "status filter is not supported here")
+		}
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				q.Status = append(q.Status, strings.ToUpper(s))
+			}
+		}
+	}
+
+	if raw := c.Query("createdBy"); raw != "" {
+		if spec.CreatorColumn == "" {
+			return nil, apperror.InvalidField("createdBy", "createdBy filter is not supported here")
+		}
+		var creator uuid.UUID
+		if raw == "me" {
+			creator = c.MustGet("userID").(uuid.UUID)
+		} else {
+			parsed, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, apperror.InvalidField("createdBy", "createdBy must be a user ID or \"me\"")
+			}
+			creator = parsed
+		}
+		q.CreatedBy = &creator
+	}
+
+	for _, param := range []string{"from", "to"} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		if spec.DateColumn == "" {
+			return nil, apperror.InvalidField(param, "date range filter is not supported here")
+		}
+		t, dateOnly, err := parseDate(raw)
+		if err != nil {
+			return nil, apperror.InvalidField(param, param+" must be an RFC3339 timestamp or YYYY-MM-DD date")
+		}
+		if param == "from" {
+			q.From = &t
+		} else {
+			// A bare date includes the whole day.
+			if dateOnly {
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			q.To = &t
+		}
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := decodeCursor(raw, spec.Sorts[q.SortKey].Kind)
+		if err != nil || cur.Sort != sortParam {
+			return nil, apperror.InvalidField("cursor", "cursor is invalid or does not match the requested sort")
+		}
+		q.after = cur
+	}
+
+	return q, nil
+}
+
+// Filter applies the filters only; use it for counts.
+func (q *Query[T]) Filter(db *gorm.DB) *gorm.DB {
+	if len(q.Status) > 0 {
+		db = db.Where(q.spec.StatusColumn+" IN ?", q.Status)
+	}
+	if q.CreatedBy != nil {
+		db = db.Where(q.spec.CreatorColumn+" = ?", *q.CreatedBy)
+	}
+	if q.From != nil {
+		db = db.Where(q.spec.DateColumn+" >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where(q.spec.DateColumn+" <= ?", *q.To)
+	}
+	return db
+}
+
+// Find loads one page. base must already be scoped to the parent resource;
+// preloads are applied to the row query only, not to the total count.
+func (q *Query[T]) Find(base *gorm.DB, model interface{}, preloads ...string) (Page[T], error) {
+	page := Page[T]{Items: []T{}}
+
+	if err := q.Filter(base.Session(&gorm.Session{})).Model(model).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	field := q.spec.Sorts[q.SortKey]
+	idColumn := q.spec.idColumn()
+	direction, cmp := "ASC", ">"
+	if q.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	db := q.Filter(base.Session(&gorm.Session{}))
+	for _, preload := range preloads {
+		db = db.Preload(preload)
+	}
+	if q.after != nil {
+		db = db.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", field.Column, cmp, field.Column, idColumn, cmp),
+			q.after.Value, q.after.Value, q.after.ID,
+		)
+	}
+
+	var rows []T
+	if err := db.
+		Order(field.Column + " " + direction).
+		Order(idColumn + " " + direction).
+		Limit(q.Limit + 1).
+		Find(&rows).Error; err != nil {
+		return page, err
+	}
+
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		last := rows[len(rows)-1]
+		next := encodeCursor(cursor{
+			Sort:  q.sortParam(),
+			Value: field.Value(last),
+			ID:    q.spec.ID(last),
+		})
+		page.NextCursor = &next
+	}
+	page.Items = rows
+
+	return page, nil
+}
+
+// Map converts page items while keeping the paging metadata.
+func Map[T, U any](page Page[T], f func(T) U) Page[U] {
+	out := Page[U]{Items: make([]U, len(page.Items)), Total: page.Total, NextCursor: page.NextCursor}
+	for i, item := range page.Items {
+		out.Items[i] = f(item)
+	}
+	return out
+}
+
+func (q *Query[T]) sortParam() string {
+	if q.Desc {
+		return "-" + q.SortKey
+	}
+	return q.SortKey
+}
+
+func (s Spec[T]) idColumn() string {
+	if s.IDColumn != "" {
+		return s.IDColumn
+	}
+	return "id"
+}
+
+func (s Spec[T]) sortKeys() []string {
+	keys := make([]string, 0, len(s.Sorts))
+	for k := range s.Sorts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parseDate(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	return t, true, err
+}
+
+func encodeCursor(cur cursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string, kind Kind) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var cur struct {
+		Sort  string          `json:"s"`
+		Value json.RawMessage `json:"v"`
+		ID    uuid.UUID       `json:"id"`
+	}
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch kind {
+	case KindTime:
+		var t time.Time
+		err = json.Unmarshal(cur.Value, &t)
+		value = t
+	case KindInt:
+		var n int64
+		err = json.Unmarshal(cur.Value, &n)
+		value = n
+	default:
+		var s string
+		err = json.Unmarshal(cur.Value, &s)
+		value = s
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor{Sort: cur.Sort, Value: value, ID: cur.ID}, nil
+}
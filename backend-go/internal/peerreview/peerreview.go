@@ -0,0 +1,137 @@
+// Package peerreview distributes submissions to student reviewers and
+// combines the scores they give with the teacher's.
+package peerreview
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// MaxReviews is the most reviews a submission can be sent out for.
+const MaxReviews = 10
+
+// ErrTooFewAuthors is returned by Assign when there is nobody to swap
+// submissions with.
+var ErrTooFewAuthors = errors.New("peer review needs at least two submissions")
+
+// Pair is one review to be done: Reviewer grades Author's submission.
+type Pair struct {
+	Reviewer string
+	Author   string
+}
+
+// Assign gives each author's submission to n other authors. Authors are
+// put in a random circle and each reviews the n that follow, so nobody
+// reviews themselves, every submission gets the same number of reviews
+// and every reviewer does the same number. n is lowered when there are
+// not enough other authors.
+func Assign(authors []string, n int, rng *rand.Rand) ([]Pair, error) {
+	if len(authors) < 2 {
+		return nil, ErrTooFewAuthors
+	}
+	if n < 1 {
+		return nil, errors.New("each submission needs at least one review")
+	}
+	n = min(n, len(authors)-1)
+
+	circle := slices.Clone(authors)
+	rng.Shuffle(len(circle), func(i, j int) { circle[i], circle[j] = circle[j], circle[i] })
+
+	pairs := make([]Pair, 0, len(circle)*n)
+	for i, reviewer := range circle {
+		for k := 1; k <= n; k++ {
+			pairs = append(pairs, Pair{Reviewer: reviewer, Author: circle[(i+k)%len(circle)]})
+		}
+	}
+	return pairs, nil
+}
+
+// Average is the mean of scores rounded to the nearest point, or nil if
+// there are none.
+func Average(scores []int) *int {
+	if len(scores) == 0 {
+		return nil
+	}
+	total := 0
+	for _, score := range scores {
+		total += score
+	}
+	average := int(math.Round(float64(total) / float64(len(scores))))
+	return &average
+}
+
+// Blend combines the teacher's score with the peer average, weight being
+// the percent taken from peers. Without a peer score the teacher's stands.
+func Blend(teacher int, peer *int, weight int) int {
+	if peer == nil || weight <= 0 {
+		return teacher
+	}
+	weight = min(weight, 100)
+	return int(math.Round(float64(*peer*weight+teacher*(100-weight)) / 100))
+}
+
+// Review is a finished review, for calibration.
+type Review struct {
+	Reviewer string
+	Author   string
+	Score    int
+}
+
+// Calibration compares one reviewer's scores with the teacher's.
+type Calibration struct {
+	Reviewer string
+	Reviews  int
+	// Compared counts the reviews of submissions the teacher has graded;
+	// Bias and MeanAbsError are over those, nil when there are none.
+	Compared int
+	// Bias is the mean of reviewer minus teacher: positive for a generous
+	// reviewer, negative for a harsh one.
+	Bias         *float64
+	MeanAbsError *float64
+}
+
+// Calibrate compares reviewers with the teacher's scores, keyed by author.
+// Reviewers are returned in the order they first appear in reviews.
+func Calibrate(reviews []Review, teacher map[string]int) []Calibration {
+	var order []string
+	type tally struct {
+		reviews, compared int
+		diff, absDiff     float64
+	}
+	byReviewer := make(map[string]*tally)
+	for _, review := range reviews {
+		stats, ok := byReviewer[review.Reviewer]
+		if !ok {
+			stats = &tally{}
+			byReviewer[review.Reviewer] = stats
+			order = append(order, review.Reviewer)
+		}
+		stats.reviews++
+		if score, graded := teacher[review.Author]; graded {
+			diff := float64(review.Score - score)
+			stats.compared++
+			stats.diff += diff
+			stats.absDiff += math.Abs(diff)
+		}
+	}
+
+	result := make([]Calibration, 0, len(order))
+	for _, reviewer := range order {
+		stats := byReviewer[reviewer]
+		calibration := Calibration{Reviewer: reviewer, Reviews: stats.reviews, Compared: stats.compared}
+		if stats.compared > 0 {
+			bias := round(stats.diff / float64(stats.compared))
+			meanAbs := round(stats.absDiff / float64(stats.compared))
+			calibration.Bias = &bias
+			calibration.MeanAbsError = &meanAbs
+		}
+		result = append(result, calibration)
+	}
+	return result
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
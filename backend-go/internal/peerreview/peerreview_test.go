@@ -0,0 +1,91 @@
+package peerreview
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAssignIsBalancedWithoutSelfReview(t *testing.T) {
+	authors := []string{"a", "b", "c", "d", "e", "f", "g"}
+	for seed := int64(0); seed < 20; seed++ {
+		pairs, err := Assign(authors, 3, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pairs) != 21 {
+			t.Fatalf("got %d pairs, want 21", len(pairs))
+		}
+		given := map[string]int{}
+		received := map[string]int{}
+		seen := map[Pair]bool{}
+		for _, pair := range pairs {
+			if pair.Reviewer == pair.Author {
+				t.Fatalf("seed %d: %s reviews themselves", seed, pair.Reviewer)
+			}
+			if seen[pair] {
+				t.Fatalf("seed %d: %v assigned twice", seed, pair)
+			}
+			seen[pair] = true
+			given[pair.Reviewer]++
+			received[pair.Author]++
+		}
+		for _, author := range authors {
+			if given[author] != 3 || received[author] != 3 {
+				t.Fatalf("seed %d: %s gives %d and receives %d reviews, want 3 and 3", seed, author, given[author], received[author])
+			}
+		}
+	}
+}
+
+func TestAssignCapsReviewsAtOtherAuthors(t *testing.T) {
+	pairs, err := Assign([]string{"a", "b"}, 5, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || pairs[0].Reviewer == pairs[0].Author {
+		t.Errorf("unexpected pairs %v", pairs)
+	}
+	if _, err := Assign([]string{"a"}, 1, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("a single submission cannot be peer reviewed")
+	}
+}
+
+func TestBlend(t *testing.T) {
+	peer := Average([]int{8, 9, 6})
+	if peer == nil || *peer != 8 {
+		t.Fatalf("average = %v, want 8", peer)
+	}
+	if got := Blend(10, peer, 30); got != 9 { // 0.3*8 + 0.7*10 = 9.4
+		t.Errorf("blend = %d, want 9", got)
+	}
+	if got := Blend(10, peer, 100); got != 8 {
+		t.Errorf("peer-only blend = %d, want 8", got)
+	}
+	if got := Blend(10, nil, 50); got != 10 {
+		t.Errorf("blend without reviews = %d, want the teacher's 10", got)
+	}
+	if Average(nil) != nil {
+		t.Error("no scores should have no average")
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	reviews := []Review{
+		{Reviewer: "r1", Author: "a", Score: 9},
+		{Reviewer: "r1", Author: "b", Score: 5},
+		{Reviewer: "r2", Author: "a", Score: 6},
+		{Reviewer: "r2", Author: "c", Score: 7},
+	}
+	result := Calibrate(reviews, map[string]int{"a": 8, "b": 8})
+	if len(result) != 2 || result[0].Reviewer != "r1" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	// r1: +1 and -3
+	if r1 := result[0]; r1.Compared != 2 || *r1.Bias != -1 || *r1.MeanAbsError != 2 {
+		t.Errorf("unexpected r1 %+v", r1)
+	}
+	// r2: -2 on a; c is ungraded
+	if r2 := result[1]; r2.Reviews != 2 || r2.Compared != 1 || *r2.Bias != -2 {
+		t.Errorf("unexpected r2 %+v", r2)
+	}
+}
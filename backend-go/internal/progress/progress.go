@@ -0,0 +1,139 @@
+// Package progress decides when a learner has completed a material and
+// rolls material completion up to modules and courses. Every progress
+// figure the API reports comes from here.
+package progress
+
+import "math"
+
+// MasteredIntervalDays is the review interval at which a flashcard counts
+// as mastered: it has been recalled correctly after at least a day and a
+// six-day gap.
+const MasteredIntervalDays = 6
+
+// Criteria are the conditions for completing a material. A zero threshold
+// disables that condition; quiz and flashcard conditions also only apply
+// when the material's published study pack has a quiz or flashcards.
+type Criteria struct {
+	RequireView        bool
+	MinWatchPercent    int
+	MinQuizScore       int
+	MinMasteredPercent int
+}
+
+// DefaultCriteria applies when an organization has not configured a
+// material type: watch most of a video or open anything else, and pass
+// the quiz if there is one.
+func DefaultCriteria(materialType string) Criteria {
+	if materialType == "VIDEO" {
+		return Criteria{MinWatchPercent: 90, MinQuizScore: 70}
+	}
+	return Criteria{RequireView: true, MinQuizScore: 70}
+}
+
+// State is what a learner has done with one material.
+type State struct {
+	Viewed             bool
+	WatchedPercent     int
+	HasQuiz            bool
+	BestQuizScore      *int
+	Flashcards         int
+	MasteredFlashcards int
+}
+
+// MasteredPercent is the share of the material's flashcards mastered.
+func (s State) MasteredPercent() int {
+	if s.Flashcards == 0 {
+		return 0
+	}
+	return s.MasteredFlashcards * 100 / s.Flashcards
+}
+
+// Condition names for Result.Unmet.
+const (
+	View       = "view"
+	Watch      = "watch"
+	Quiz       = "quiz"
+	Flashcards = "flashcards"
+)
+
+// Result is one material's completion. Percent gives partial credit
+// across the conditions that apply; Unmet lists those still outstanding.
+type Result struct {
+	Completed bool
+	Percent   float64
+	Unmet     []string
+}
+
+// Evaluate applies criteria to a learner's state. Criteria with no
+// applicable condition fall back to requiring a view.
+func Evaluate(c Criteria, s State) Result {
+	var parts []float64
+	unmet := []string{}
+	check := func(name string, done float64) {
+		done = math.Min(math.Max(done, 0), 1)
+		parts = append(parts, done)
+		if done < 1 {
+			unmet = append(unmet, name)
+		}
+	}
+
+	if c.RequireView {
+		check(View, boolFraction(s.Viewed))
+	}
+	if c.MinWatchPercent > 0 {
+		check(Watch, float64(s.WatchedPercent)/float64(c.MinWatchPercent))
+	}
+	if c.MinQuizScore > 0 && s.HasQuiz {
+		passed := s.BestQuizScore != nil && *s.BestQuizScore >= c.MinQuizScore
+		check(Quiz, boolFraction(passed))
+	}
+	if c.MinMasteredPercent > 0 && s.Flashcards > 0 {
+		check(Flashcards, float64(s.MasteredPercent())/float64(c.MinMasteredPercent))
+	}
+	if len(parts) == 0 {
+		check(View, boolFraction(s.Viewed || s.WatchedPercent > 0))
+	}
+
+	total := 0.0
+	for _, part := range parts {
+		total += part
+	}
+	return Result{
+		Completed: len(unmet) == 0,
+		Percent:   round(total / float64(len(parts)) * 100),
+		Unmet:     unmet,
+	}
+}
+
+// Rollup summarizes the materials of a module or course. Percent is the
+// share of materials completed; an empty rollup is 0%.
+type Rollup struct {
+	Completed int
+	Total     int
+	Percent   float64
+}
+
+func Summarize(results []Result) Rollup {
+	r := Rollup{Total: len(results)}
+	for _, result := range results {
+		if result.Completed {
+			r.Completed++
+		}
+	}
+	if r.Total > 0 {
+		r.Percent = round(float64(r.Completed) / float64(r.Total) * 100)
+	}
+	return r
+}
+
+func boolFraction(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// round keeps one decimal place.
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,41 @@
+package progress
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	score := func(v int) *int { return &v }
+	video := DefaultCriteria("VIDEO")
+
+	cases := []struct {
+		name     string
+		criteria Criteria
+		state    State
+		want     Result
+	}{
+		{"untouched video", video, State{HasQuiz: true}, Result{Percent: 0, Unmet: []string{Watch, Quiz}}},
+		{"half watched", video, State{WatchedPercent: 45}, Result{Percent: 50, Unmet: []string{Watch}}},
+		{"watched, quiz failed", video, State{WatchedPercent: 95, HasQuiz: true, BestQuizScore: score(60)}, Result{Percent: 50, Unmet: []string{Quiz}}},
+		{"watched and passed", video, State{WatchedPercent: 90, HasQuiz: true, BestQuizScore: score(70)}, Result{Completed: true, Percent: 100, Unmet: []string{}}},
+		{"document opened", DefaultCriteria("DOC"), State{Viewed: true}, Result{Completed: true, Percent: 100, Unmet: []string{}}},
+		{"flashcards", Criteria{MinMasteredPercent: 80}, State{Flashcards: 10, MasteredFlashcards: 4}, Result{Percent: 50, Unmet: []string{Flashcards}}},
+		{"nothing applies", Criteria{MinQuizScore: 70}, State{Viewed: true}, Result{Completed: true, Percent: 100, Unmet: []string{}}},
+	}
+	for _, tc := range cases {
+		if got := Evaluate(tc.criteria, tc.state); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	got := Summarize([]Result{{Completed: true}, {}, {Completed: true}})
+	if got.Completed != 2 || got.Total != 3 || got.Percent != 66.7 {
+		t.Errorf("got %+v, want 2 of 3 at 66.7%%", got)
+	}
+	if empty := Summarize(nil); empty.Percent != 0 {
+		t.Errorf("empty rollup = %v%%, want 0", empty.Percent)
+	}
+}
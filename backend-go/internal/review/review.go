@@ -0,0 +1,102 @@
+// Package review is the state machine study packs move through between
+// generation and being visible to students:
+//
+//	DRAFT → IN_REVIEW → CHANGES_REQUESTED → IN_REVIEW → APPROVED → PUBLISHED ⇄ UNPUBLISHED
+//
+// Organizations that do not require review may publish straight from any
+// editable status.
+package review
+
+import "fmt"
+
+const (
+	Draft            = "DRAFT"
+	InReview         = "IN_REVIEW"
+	ChangesRequested = "CHANGES_REQUESTED"
+	Approved         = "APPROVED"
+	Published        = "PUBLISHED"
+	Unpublished      = "UNPUBLISHED"
+)
+
+// Pending is a reviewer decision that has not been made yet. Reviewers
+// otherwise decide Approved or ChangesRequested.
+const Pending = "PENDING"
+
+type Action string
+
+const (
+	Submit    Action = "submit"
+	Withdraw  Action = "withdraw"
+	Publish   Action = "publish"
+	Unpublish Action = "unpublish"
+)
+
+// Policy is an organization's review configuration.
+type Policy struct {
+	RequireReview     bool
+	RequiredApprovals int
+	AllowSelfApproval bool
+}
+
+// Approvals is the number of approving reviewers a pack needs.
+func (p Policy) Approvals() int {
+	return max(p.RequiredApprovals, 1)
+}
+
+// Editable reports whether content may change in status. Packs in review
+// or approved are frozen so reviewers approve what gets published.
+func Editable(status string) bool {
+	return status == Draft || status == ChangesRequested || status == Unpublished
+}
+
+// TransitionError reports an action that is not allowed from a status.
+type TransitionError struct {
+	From   string
+	Action Action
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot %s a study pack that is %s", e.Action, e.From)
+}
+
+// Next returns the status after action, or a *TransitionError.
+func Next(status string, action Action, policy Policy) (string, error) {
+	switch action {
+	case Submit:
+		if Editable(status) {
+			return InReview, nil
+		}
+	case Withdraw:
+		if status == InReview || status == Approved {
+			return Draft, nil
+		}
+	case Publish:
+		if status == Approved || (!policy.RequireReview && Editable(status)) {
+			return Published, nil
+		}
+	case Unpublish:
+		if status == Published {
+			return Unpublished, nil
+		}
+	}
+	return "", &TransitionError{From: status, Action: action}
+}
+
+// Outcome is the status of a pack in review given its reviewers'
+// decisions. A single request for changes sends it back to the author;
+// otherwise it is approved once enough reviewers approve.
+func Outcome(decisions []string, policy Policy) string {
+	approvals := 0
+	for _, decision := range decisions {
+		switch decision {
+		case ChangesRequested:
+			return ChangesRequested
+		case Approved:
+			approvals++
+		}
+	}
+	if approvals >= policy.Approvals() {
+		return Approved
+	}
+	return InReview
+}
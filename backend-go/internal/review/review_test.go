@@ -0,0 +1,60 @@
+package review
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNext(t *testing.T) {
+	strict := Policy{RequireReview: true, RequiredApprovals: 2}
+	open := Policy{}
+
+	cases := []struct {
+		from   string
+		action Action
+		policy Policy
+		want   string
+	}{
+		{Draft, Submit, strict, InReview},
+		{ChangesRequested, Submit, strict, InReview},
+		{Unpublished, Submit, strict, InReview},
+		{InReview, Withdraw, strict, Draft},
+		{Approved, Publish, strict, Published},
+		{Draft, Publish, open, Published},
+		{Unpublished, Publish, open, Published},
+		{Published, Unpublish, strict, Unpublished},
+		{Draft, Publish, strict, ""},
+		{InReview, Publish, open, ""},
+		{InReview, Submit, strict, ""},
+		{Draft, Unpublish, open, ""},
+	}
+	for _, tc := range cases {
+		got, err := Next(tc.from, tc.action, tc.policy)
+		if tc.want == "" {
+			var transition *TransitionError
+			if !errors.As(err, &transition) {
+				t.Errorf("%s from %s: got %q, want a TransitionError", tc.action, tc.from, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s from %s = %q, %v; want %q", tc.action, tc.from, got, err, tc.want)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	policy := Policy{RequireReview: true, RequiredApprovals: 2}
+	if got := Outcome([]string{Approved, Pending}, policy); got != InReview {
+		t.Errorf("one of two approvals = %s, want %s", got, InReview)
+	}
+	if got := Outcome([]string{Approved, Approved, Pending}, policy); got != Approved {
+		t.Errorf("two approvals = %s, want %s", got, Approved)
+	}
+	if got := Outcome([]string{Approved, Approved, ChangesRequested}, policy); got != ChangesRequested {
+		t.Errorf("changes requested = %s, want %s", got, ChangesRequested)
+	}
+	if got := Outcome([]string{Approved}, Policy{}); got != Approved {
+		t.Errorf("zero required approvals = %s, want %s", got, Approved)
+	}
+}
@@ -0,0 +1,189 @@
+// Package rubric scores assignment submissions against a rubric: a list of
+// criteria, each with levels worth a number of points.
+package rubric
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Level is one descriptor of a criterion, such as "Proficient".
+type Level struct {
+	ID          string `json:"id"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description,omitempty"`
+	Points      int    `json:"points"`
+}
+
+// Criterion is one aspect of the work being graded.
+type Criterion struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title" binding:"required"`
+	Description string  `json:"description,omitempty"`
+	Levels      []Level `json:"levels" binding:"required"`
+}
+
+// MaxPoints is the points of the criterion's best level.
+func (c Criterion) MaxPoints() int {
+	best := 0
+	for _, level := range c.Levels {
+		best = max(best, level.Points)
+	}
+	return best
+}
+
+// MaxPoints is the most a submission can earn on the rubric.
+func MaxPoints(criteria []Criterion) int {
+	total := 0
+	for _, criterion := range criteria {
+		total += criterion.MaxPoints()
+	}
+	return total
+}
+
+// Normalize trims titles and gives criteria and levels without an ID a new
+// one, so that edits keep the IDs of the criteria and levels they keep.
+func Normalize(criteria []Criterion) []Criterion {
+	out := make([]Criterion, len(criteria))
+	for i, criterion := range criteria {
+		criterion.Title = strings.TrimSpace(criterion.Title)
+		if criterion.ID == "" {
+			criterion.ID = uuid.NewString()
+		}
+		levels := make([]Level, len(criterion.Levels))
+		for j, level := range criterion.Levels {
+			level.Title = strings.TrimSpace(level.Title)
+			if level.ID == "" {
+				level.ID = uuid.NewString()
+			}
+			levels[j] = level
+		}
+		criterion.Levels = levels
+		out[i] = criterion
+	}
+	return out
+}
+
+// Validate checks that a rubric can be graded with: at least one criterion,
+// each titled and with at least one level, IDs unique, points non-negative
+// and some points to earn.
+func Validate(criteria []Criterion) error {
+	if len(criteria) == 0 {
+		return errors.New("a rubric needs at least one criterion")
+	}
+	criterionIDs := make(map[string]bool)
+	for i, criterion := range criteria {
+		if criterion.Title == "" {
+			return fmt.Errorf("criterion %d needs a title", i+1)
+		}
+		if criterionIDs[criterion.ID] {
+			return fmt.Errorf("criterion %q appears twice", criterion.ID)
+		}
+		criterionIDs[criterion.ID] = true
+		if len(criterion.Levels) == 0 {
+			return fmt.Errorf("criterion %q needs at least one level", criterion.Title)
+		}
+		levelIDs := make(map[string]bool)
+		for j, level := range criterion.Levels {
+			if level.Title == "" {
+				return fmt.Errorf("level %d of criterion %q needs a title", j+1, criterion.Title)
+			}
+			if levelIDs[level.ID] {
+				return fmt.Errorf("level %q of criterion %q appears twice", level.ID, criterion.Title)
+			}
+			levelIDs[level.ID] = true
+			if level.Points < 0 {
+				return fmt.Errorf("level %q of criterion %q has negative points", level.Title, criterion.Title)
+			}
+		}
+	}
+	if MaxPoints(criteria) == 0 {
+		return errors.New("a rubric needs at least one level worth points")
+	}
+	return nil
+}
+
+// Selection is the level a grader picked for one criterion.
+type Selection struct {
+	CriterionID string `json:"criterionId" binding:"required"`
+	LevelID     string `json:"levelId" binding:"required"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// CriterionGrade is one line of a graded rubric.
+type CriterionGrade struct {
+	CriterionID string `json:"criterionId" binding:"required"`
+	Criterion   string `json:"criterion" binding:"required"`
+	LevelID     string `json:"levelId" binding:"required"`
+	Level       string `json:"level" binding:"required"`
+	Points      int    `json:"points"`
+	MaxPoints   int    `json:"maxPoints"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// Grade is a graded rubric.
+type Grade struct {
+	// Earned and Possible are rubric points; Score is Earned scaled to the
+	// assignment's points and rounded.
+	Earned    int              `json:"earned"`
+	Possible  int              `json:"possible"`
+	Score     int              `json:"score"`
+	Breakdown []CriterionGrade `json:"breakdown" binding:"required"`
+}
+
+// Score grades selections against criteria, scaling the total to points.
+// Every criterion needs exactly one selection naming one of its levels.
+func Score(criteria []Criterion, selections []Selection, points int) (Grade, error) {
+	byCriterion := make(map[string]Selection, len(selections))
+	for _, selection := range selections {
+		if _, ok := byCriterion[selection.CriterionID]; ok {
+			return Grade{}, fmt.Errorf("criterion %q is graded twice", selection.CriterionID)
+		}
+		byCriterion[selection.CriterionID] = selection
+	}
+
+	grade := Grade{Breakdown: make([]CriterionGrade, 0, len(criteria))}
+	for _, criterion := range criteria {
+		selection, ok := byCriterion[criterion.ID]
+		if !ok {
+			return Grade{}, fmt.Errorf("criterion %q has no level selected", criterion.Title)
+		}
+		delete(byCriterion, criterion.ID)
+
+		var level *Level
+		for i := range criterion.Levels {
+			if criterion.Levels[i].ID == selection.LevelID {
+				level = &criterion.Levels[i]
+				break
+			}
+		}
+		if level == nil {
+			return Grade{}, fmt.Errorf("level %q is not part of criterion %q", selection.LevelID, criterion.Title)
+		}
+
+		line := CriterionGrade{
+			CriterionID: criterion.ID,
+			Criterion:   criterion.Title,
+			LevelID:     level.ID,
+			Level:       level.Title,
+			Points:      level.Points,
+			MaxPoints:   criterion.MaxPoints(),
+			Comment:     strings.TrimSpace(selection.Comment),
+		}
+		grade.Earned += line.Points
+		grade.Possible += line.MaxPoints
+		grade.Breakdown = append(grade.Breakdown, line)
+	}
+	for id := range byCriterion {
+		return Grade{}, fmt.Errorf("criterion %q is not part of the rubric", id)
+	}
+
+	if grade.Possible > 0 {
+		grade.Score = int(math.Round(float64(grade.Earned) * float64(points) / float64(grade.Possible)))
+	}
+	return grade, nil
+}
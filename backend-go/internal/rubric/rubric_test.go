@@ -0,0 +1,84 @@
+package rubric
+
+import "testing"
+
+func essayRubric() []Criterion {
+	return []Criterion{
+		{ID: "thesis", Title: "Thesis", Levels: []Level{
+			{ID: "weak", Title: "Weak", Points: 0},
+			{ID: "clear", Title: "Clear", Points: 2},
+			{ID: "strong", Title: "Strong", Points: 4},
+		}},
+		{ID: "evidence", Title: "Evidence", Levels: []Level{
+			{ID: "none", Title: "None", Points: 0},
+			{ID: "some", Title: "Some", Points: 3},
+			{ID: "ample", Title: "Ample", Points: 6},
+		}},
+	}
+}
+
+func TestScore(t *testing.T) {
+	grade, err := Score(essayRubric(), []Selection{
+		{CriterionID: "evidence", LevelID: "some", Comment: " Cite more sources "},
+		{CriterionID: "thesis", LevelID: "strong"},
+	}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grade.Earned != 7 || grade.Possible != 10 || grade.Score != 35 {
+		t.Errorf("got %d/%d scaled to %d, want 7/10 scaled to 35", grade.Earned, grade.Possible, grade.Score)
+	}
+	if len(grade.Breakdown) != 2 || grade.Breakdown[0].CriterionID != "thesis" {
+		t.Fatalf("breakdown should follow rubric order, got %+v", grade.Breakdown)
+	}
+	if got := grade.Breakdown[1]; got.Level != "Some" || got.MaxPoints != 6 || got.Comment != "Cite more sources" {
+		t.Errorf("unexpected evidence line %+v", got)
+	}
+}
+
+func TestScoreRejectsIncompleteGrading(t *testing.T) {
+	tests := []struct {
+		name       string
+		selections []Selection
+	}{
+		{"missing criterion", []Selection{{CriterionID: "thesis", LevelID: "clear"}}},
+		{"unknown level", []Selection{{CriterionID: "thesis", LevelID: "ample"}, {CriterionID: "evidence", LevelID: "some"}}},
+		{"unknown criterion", []Selection{{CriterionID: "thesis", LevelID: "clear"}, {CriterionID: "evidence", LevelID: "some"}, {CriterionID: "style", LevelID: "good"}}},
+		{"graded twice", []Selection{{CriterionID: "thesis", LevelID: "clear"}, {CriterionID: "thesis", LevelID: "weak"}}},
+	}
+	for _, tc := range tests {
+		if _, err := Score(essayRubric(), tc.selections, 10); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(essayRubric()); err != nil {
+		t.Errorf("valid rubric rejected: %v", err)
+	}
+	if err := Validate(nil); err == nil {
+		t.Error("empty rubric accepted")
+	}
+	noPoints := []Criterion{{ID: "a", Title: "A", Levels: []Level{{ID: "x", Title: "X"}}}}
+	if err := Validate(noPoints); err == nil {
+		t.Error("rubric without points accepted")
+	}
+	duplicate := []Criterion{essayRubric()[0], essayRubric()[0]}
+	if err := Validate(duplicate); err == nil {
+		t.Error("duplicate criterion accepted")
+	}
+}
+
+func TestNormalizeKeepsIDs(t *testing.T) {
+	criteria := Normalize([]Criterion{
+		{ID: "thesis", Title: " Thesis ", Levels: []Level{{Title: "Clear", Points: 2}}},
+		{Title: "Style", Levels: []Level{{ID: "good", Title: "Good", Points: 1}}},
+	})
+	if criteria[0].ID != "thesis" || criteria[0].Title != "Thesis" || criteria[0].Levels[0].ID == "" {
+		t.Errorf("unexpected first criterion %+v", criteria[0])
+	}
+	if criteria[1].ID == "" || criteria[1].Levels[0].ID != "good" {
+		t.Errorf("unexpected second criterion %+v", criteria[1])
+	}
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"log"
+	"myway-backend/internal/config"
+	"myway-backend/internal/handlers"
+	"myway-backend/internal/llm"
+	"myway-backend/internal/metrics"
+	"myway-backend/internal/middleware"
+	"myway-backend/internal/openapi"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIPrefix is where the current API version is mounted.
+const APIPrefix = openapi.BasePath
+
+// LegacySuccessors maps legacy-only duplicate routes to the canonical route
+// that replaces them under APIPrefix. Every other legacy alias is succeeded
+// by the same path under APIPrefix.
+var LegacySuccessors = map[string]string{
+	"POST /organizations/:id/delete": "DELETE " + APIPrefix + "/organizations/:id",
+	"POST /discussions/replies":      "POST " + APIPrefix + "/discussions/threads/:threadId/replies",
+}
+
+// LegacyRoutes are the routes that were served without a prefix before
+// the API was versioned. Only these get legacy aliases; routes added since
+// exist under APIPrefix alone. The list is frozen.
+var LegacyRoutes = []string{
+	"GET /youtube/transcript",
+	"POST /ai/transcript",
+	"POST /auth/signup",
+	"POST /auth/signin",
+	"POST /auth/refresh",
+	"GET /auth/me",
+	"POST /auth/logout",
+	"POST /organizations",
+	"GET /organizations",
+	"DELETE /organizations/:id",
+	"POST /organizations/:id/delete",
+	"POST /organizations/:id/join",
+	"POST /organizations/:id/invite",
+	"POST /organizations/:id/switch",
+	"POST /courses",
+	"DELETE /courses/:id",
+	"GET /courses/:id",
+	"GET /courses/org/:orgId",
+	"POST /modules",
+	"GET /modules/course/:courseId",
+	"GET /modules/:id",
+	"PUT /modules/:id",
+	"DELETE /modules/:id",
+	"POST /assignments",
+	"GET /assignments/course/:courseId",
+	"GET /assignments/:id",
+	"POST /assignments/:id/submit",
+	"PUT /submissions/:id/grade",
+	"POST /discussions/threads",
+	"GET /discussions/threads/course/:courseId",
+	"GET /discussions/threads/:id",
+	"POST /discussions/threads/:threadId/replies",
+	"POST /discussions/replies",
+	"GET /flashcards/studypack/:studyPackId",
+	"POST /flashcards/sessions",
+	"GET /flashcards/sessions",
+	"GET /progress/course/:courseId",
+	"GET /progress/org",
+	"GET /analytics/student",
+	"GET /analytics/teacher",
+	"GET /analytics/organizer",
+	"POST /analytics/quiz/attempt",
+	"GET /ai/studypack/:materialId",
+	"GET /ai/review/:materialId",
+	"POST /ai/review/:materialId/approve",
+	"POST /ai/review/:materialId/regenerate",
+	"POST /ai/tutor",
+	"POST /imports/youtube",
+	"POST /imports/document",
+	"GET /imports/status/:materialId",
+}
+
+type routes struct {
+	cfg *config.Config
+
+	auth       *handlers.AuthHandler
+	org        *handlers.OrganizationHandler
+	course     *handlers.CourseHandler
+	module     *handlers.ModuleHandler
+	assignment *handlers.AssignmentHandler
+	discussion *handlers.DiscussionHandler
+	flashcard  *handlers.FlashcardHandler
+	progress   *handlers.ProgressHandler
+	analytics  *handlers.AnalyticsHandler
+	ai         *handlers.AIHandler
+	imports    *handlers.ImportsHandler
+	trash      *handlers.TrashHandler
+	quiz       *handlers.QuizHandler
+	studyPack  *handlers.StudyPackHandler
+}
+
+// NewRouter builds the HTTP router with all middleware and routes. Every
+// route registered here must also be documented in openapi.Operations.
+func NewRouter(cfg *config.Config) *gin.Engine {
+	// Initialize Gin router
+	router := gin.Default()
+
+	// Apply middleware
+	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.ErrorMiddleware())
+
+	// Initialize handlers
+	r := &routes{
+		cfg:        cfg,
+		auth:       handlers.NewAuthHandler(cfg.JWTSecret),
+		org:        handlers.NewOrganizationHandler(),
+		course:     handlers.NewCourseHandler(),
+		module:     handlers.NewModuleHandler(),
+		assignment: handlers.NewAssignmentHandler(),
+		discussion: handlers.NewDiscussionHandler(),
+		flashcard:  handlers.NewFlashcardHandler(),
+		progress:   handlers.NewProgressHandler(),
+		analytics:  handlers.NewAnalyticsHandler(),
+		ai:         handlers.NewAIHandler(llm.New(cfg.LLMProvider, cfg.GeminiAPIKey)),
+		imports:    handlers.NewImportsHandler(),
+		trash:      handlers.NewTrashHandler(cfg.TrashRetention),
+		quiz:       handlers.NewQuizHandler(),
+		studyPack:  handlers.NewStudyPackHandler(),
+	}
+
+	// API documentation and operational endpoints stay unversioned
+	router.GET("/openapi.json", openapi.SpecHandler())
+	router.GET("/docs", openapi.UIHandler())
+	router.GET("/metrics", metrics.Handler())
+
+	// Root route
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "MyWay LMS - Go Backend API",
+			"version": "1.0.0",
+			"prefix":  APIPrefix,
+			"endpoints": gin.H{
+				"health":        "GET /health",
+				"auth":          "POST " + APIPrefix + "/auth/signup, POST " + APIPrefix + "/auth/signin, GET " + APIPrefix + "/auth/me",
+				"organizations": "GET/POST " + APIPrefix + "/organizations",
+				"courses":       "GET/POST " + APIPrefix + "/courses",
+				"analytics":     "GET " + APIPrefix + "/analytics/student, GET " + APIPrefix + "/analytics/teacher",
+				"ai":            "GET " + APIPrefix + "/ai/studypack/:id, POST " + APIPrefix + "/ai/tutor",
+				"imports":       "POST " + APIPrefix + "/imports/youtube",
+				"docs":          "GET /docs, GET /openapi.json",
+			},
+		})
+	})
+
+	// Health check
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "healthy"})
+	})
+
+	r.register(routeGroup{group: router.Group(APIPrefix)}, false)
+
+	// Legacy unversioned aliases, kept until the sunset date
+	sunset, err := time.Parse("2006-01-02", cfg.LegacyAPISunset)
+	if err != nil {
+		log.Printf("Invalid LEGACY_API_SUNSET %q, defaulting to %s", cfg.LegacyAPISunset, config.DefaultLegacyAPISunset)
+		sunset, _ = time.Parse("2006-01-02", config.DefaultLegacyAPISunset)
+	}
+	frozen := make(map[string]bool, len(LegacyRoutes))
+	for _, route := range LegacyRoutes {
+		frozen[route] = true
+	}
+	r.register(routeGroup{group: router.Group("", middleware.LegacyAliasMiddleware(APIPrefix, sunset, LegacySuccessors)), only: frozen}, true)
+
+	return router
+}
+
+// register mounts the API on base. Duplicate routes that only exist for old
+// clients are registered on the legacy mount only.
+func (r *routes) register(base routeGroup, legacy bool) {
+	// Public YouTube transcript endpoint
+	base.GET("/youtube/transcript", r.imports.GetYouTubeTranscript)
+	base.POST("/ai/transcript", handlers.FetchTranscriptHandler)
+	// Keep existing GET for backward compatibility if needed, or replace.
+	// User asked for "backend service", usually POST for actions, but user code might expect GET.
+	// The previous implementation was GET, but my new handler expects JSON body (POST).
+	// I will use POST for robustness and update frontend to POST.
+
+	// Auth routes (no auth required)
+	auth := base.Group("/auth")
+	{
+		auth.POST("/signup", r.auth.SignUp)
+		auth.POST("/signin", r.auth.SignIn)
+		auth.POST("/refresh", r.auth.RefreshToken)
+		auth.GET("/me", middleware.AuthMiddleware(r.cfg.JWTSecret), r.auth.GetMe)
+	}
+
+	// Protected routes
+	api := base.Group("")
+	api.Use(middleware.AuthMiddleware(r.cfg.JWTSecret))
+	idempotent := middleware.IdempotencyMiddleware(r.cfg.IdempotencyTTL)
+	{
+		// Auth
+		api.POST("/auth/logout", r.auth.Logout)
+
+		// Organizations
+		api.POST("/organizations", idempotent, r.org.CreateOrganization)
+		api.GET("/organizations", r.org.GetOrganizations)
+		api.DELETE("/organizations/:id", r.org.DeleteOrganization)
+		if legacy {
+			api.POST("/organizations/:id/delete", r.org.DeleteOrganization)
+		}
+		api.GET("/organizations/:id/trash", r.trash.ListTrash)
+		api.POST("/organizations/:id/restore", r.trash.RestoreOrganization)
+		api.POST("/organizations/:id/join", r.org.JoinOrganization)
+		api.POST("/organizations/:id/invite", r.org.InviteToOrganization)
+		api.POST("/organizations/:id/switch", r.org.SwitchOrganization)
+		api.GET("/organizations/:id/settings", r.org.GetSettings)
+		api.PATCH("/organizations/:id/settings", r.org.UpdateSettings)
+
+		// Courses
+		api.POST("/courses", idempotent, r.course.CreateCourse)
+		api.DELETE("/courses/:id", r.course.DeleteCourse)
+		api.POST("/courses/:id/restore", r.trash.RestoreCourse)
+		api.GET("/courses/:id", r.course.GetCourse)
+		api.PUT("/courses/:id", r.course.UpdateCourse)
+		api.PATCH("/courses/:id", r.course.UpdateCourse)
+		api.GET("/courses/org/:orgId", r.course.GetCoursesByOrg)
+		api.GET("/courses/:id/review-queue", r.studyPack.GetReviewQueue)
+		api.POST("/courses/:id/clone", idempotent, r.course.CloneCourse)
+
+		// Terms
+		api.POST("/organizations/:id/terms", idempotent, r.course.CreateTerm)
+		api.GET("/organizations/:id/terms", r.course.GetTermsByOrg)
+		api.PUT("/terms/:id", r.course.UpdateTerm)
+		api.POST("/terms/:id/archive", r.course.ArchiveTerm)
+		api.POST("/terms/:id/unarchive", r.course.UnarchiveTerm)
+
+		// Gradebook
+		api.GET("/courses/:id/grade-categories", r.course.GetGradeCategories)
+		api.POST("/courses/:id/grade-categories", idempotent, r.course.CreateGradeCategory)
+		api.PUT("/grade-categories/:id", r.course.UpdateGradeCategory)
+		api.DELETE("/grade-categories/:id", r.course.DeleteGradeCategory)
+		api.PUT("/courses/:id/grade-scale", r.course.UpdateGradeScale)
+		api.GET("/courses/:id/gradebook", r.course.GetGradebook)
+		api.GET("/courses/:id/gradebook/export", r.course.ExportGradebook)
+		api.PUT("/courses/:id/gradebook/overrides/:userId", r.course.SetGradeOverride)
+		api.DELETE("/courses/:id/gradebook/overrides/:userId", r.course.ClearGradeOverride)
+		api.GET("/courses/:id/my-grades", r.course.GetMyGrades)
+
+		// Modules
+		api.POST("/modules", r.module.CreateModule)
+		api.GET("/modules/course/:courseId", r.module.GetModulesByCourse)
+		api.GET("/modules/:id", r.module.GetModule)
+		api.PUT("/modules/:id", r.module.UpdateModule)
+		api.DELETE("/modules/:id", r.module.DeleteModule)
+		api.POST("/modules/:id/restore", r.trash.RestoreModule)
+		api.PUT("/courses/:id/modules/order", r.module.ReorderModules)
+		api.PUT("/modules/:id/materials/order", r.module.ReorderMaterials)
+		api.POST("/modules/:id/duplicate", idempotent, r.module.DuplicateModule)
+		api.POST("/materials/:id/move", r.module.MoveMaterial)
+		api.PUT("/materials/:id/duration", r.module.SetVideoDuration)
+
+		// Assignments
+		api.POST("/assignments", r.assignment.CreateAssignment)
+		api.GET("/assignments/course/:courseId", r.assignment.GetAssignmentsByCourse)
+		api.GET("/assignments/:id", r.assignment.GetAssignment)
+		api.PUT("/assignments/:id", r.assignment.UpdateAssignment)
+		api.POST("/assignments/:id/archive", r.assignment.ArchiveAssignment)
+		api.POST("/assignments/:id/unarchive", r.assignment.UnarchiveAssignment)
+		api.GET("/assignments/:id/extensions", r.assignment.GetExtensions)
+		api.PUT("/assignments/:id/extensions/:userId", r.assignment.GrantExtension)
+		api.DELETE("/assignments/:id/extensions/:userId", r.assignment.RevokeExtension)
+		api.PUT("/assignments/:id/rubric", r.assignment.AttachRubric)
+		api.DELETE("/assignments/:id/rubric", r.assignment.DetachRubric)
+		api.PUT("/assignments/:id/peer-review", r.assignment.ConfigurePeerReview)
+		api.DELETE("/assignments/:id/peer-review", r.assignment.DisablePeerReview)
+		api.POST("/assignments/:id/peer-reviews/assign", r.assignment.AssignPeerReviews)
+		api.GET("/assignments/:id/peer-reviews", r.assignment.GetPeerReviews)
+		api.GET("/assignments/:id/peer-reviews/calibration", r.assignment.GetPeerReviewCalibration)
+		api.GET("/assignments/:id/peer-reviews/mine", r.assignment.GetMyPeerReviews)
+		api.PUT("/peer-reviews/:id", r.assignment.SubmitPeerReview)
+		api.POST("/peer-reviews/:id/exclude", r.assignment.ExcludePeerReview)
+		api.POST("/peer-reviews/:id/include", r.assignment.IncludePeerReview)
+		api.GET("/submissions/:id/peer-reviews", r.assignment.GetSubmissionPeerReviews)
+		api.POST("/assignments/:id/submit", idempotent, r.assignment.SubmitAssignment)
+		api.PUT("/submissions/:id/grade", r.assignment.GradeSubmission)
+		api.POST("/submissions/:id/request-resubmission", r.assignment.RequestResubmission)
+		api.GET("/submissions/:id/attempts", r.assignment.GetSubmissionAttempts)
+		api.POST("/submissions/:id/grading-suggestions", idempotent, r.ai.SuggestGrade)
+		api.GET("/submissions/:id/grading-suggestions", r.ai.GetGradingSuggestions)
+		api.POST("/grading-suggestions/:id/dismiss", r.ai.DismissGradingSuggestion)
+
+		// Rubrics
+		api.POST("/organizations/:id/rubrics", idempotent, r.assignment.CreateRubric)
+		api.GET("/organizations/:id/rubrics", r.assignment.GetRubricsByOrg)
+		api.GET("/rubrics/:id", r.assignment.GetRubric)
+		api.PUT("/rubrics/:id", r.assignment.UpdateRubric)
+		api.DELETE("/rubrics/:id", r.assignment.DeleteRubric)
+		api.GET("/rubrics/:id/versions", r.assignment.GetRubricVersions)
+
+		// Discussions
+		api.POST("/discussions/threads", idempotent, r.discussion.CreateThread)
+		api.GET("/discussions/threads/course/:courseId", r.discussion.GetThreadsByCourse)
+		api.GET("/discussions/threads/:id", r.discussion.GetThread)
+		api.POST("/discussions/threads/:threadId/replies", idempotent, r.discussion.CreateReply)
+		if legacy {
+			api.POST("/discussions/replies", idempotent, r.discussion.CreateReplyByBody)
+		}
+
+		// Flashcards
+		api.GET("/flashcards/studypack/:studyPackId", r.flashcard.GetFlashcardsByStudyPack)
+		api.POST("/flashcards/sessions", idempotent, r.flashcard.RecordSession)
+		api.GET("/flashcards/sessions", r.flashcard.GetSessionsByUser)
+		api.GET("/flashcards/due", r.flashcard.GetDueFlashcards)
+		api.POST("/flashcards/:id/review", idempotent, r.flashcard.ReviewFlashcard)
+
+		// Quizzes
+		api.GET("/quizzes/:id/settings", r.quiz.GetSettings)
+		api.PUT("/quizzes/:id/settings", r.quiz.UpdateSettings)
+		api.PUT("/quizzes/:id/grade-category", r.quiz.SetGradeCategory)
+		api.POST("/quizzes/:id/attempts", r.quiz.StartAttempt)
+		api.GET("/quiz-attempts/:id", r.quiz.GetAttempt)
+		api.PUT("/quiz-attempts/:id/answers", r.quiz.SaveAnswer)
+		api.POST("/quiz-attempts/:id/submit", r.quiz.SubmitAttempt)
+
+		// Study pack authoring
+		api.POST("/studypacks/:id/quizzes", idempotent, r.studyPack.CreateQuiz)
+		api.POST("/quizzes/:id/questions", idempotent, r.studyPack.CreateQuestion)
+		api.PUT("/quizzes/:id/questions/order", r.studyPack.ReorderQuestions)
+		api.PUT("/quiz-questions/:id", r.studyPack.UpdateQuestion)
+		api.DELETE("/quiz-questions/:id", r.studyPack.DeleteQuestion)
+		api.POST("/studypacks/:id/flashcards", idempotent, r.studyPack.CreateFlashcard)
+		api.PUT("/studypacks/:id/flashcards/order", r.studyPack.ReorderFlashcards)
+		api.PUT("/flashcards/:id", r.studyPack.UpdateFlashcard)
+		api.DELETE("/flashcards/:id", r.studyPack.DeleteFlashcard)
+		api.GET("/studypacks/:id/revisions", r.studyPack.ListRevisions)
+		api.GET("/studypacks/:id/revisions/diff", r.studyPack.DiffRevisions)
+		api.GET("/studypacks/:id/revisions/:number", r.studyPack.GetRevision)
+		api.POST("/studypacks/:id/revisions/:number/rollback", idempotent, r.studyPack.RollbackRevision)
+
+		// Study pack review
+		api.GET("/studypacks/:id/review", r.studyPack.GetReview)
+		api.POST("/studypacks/:id/reviewers", idempotent, r.studyPack.AssignReviewer)
+		api.DELETE("/studypacks/:id/reviewers/:userId", r.studyPack.RemoveReviewer)
+		api.POST("/studypacks/:id/review/submit", r.studyPack.SubmitForReview)
+		api.POST("/studypacks/:id/review/withdraw", r.studyPack.WithdrawReview)
+		api.POST("/studypacks/:id/review/decision", r.studyPack.DecideReview)
+		api.POST("/studypacks/:id/publish", r.studyPack.PublishStudyPack)
+		api.POST("/studypacks/:id/unpublish", r.studyPack.UnpublishStudyPack)
+		api.GET("/studypacks/:id/review/comments", r.studyPack.ListReviewComments)
+		api.POST("/studypacks/:id/review/comments", idempotent, r.studyPack.CreateReviewComment)
+		api.POST("/review-comments/:id/resolve", r.studyPack.ResolveReviewComment)
+
+		// Progress
+		api.GET("/progress/course/:courseId", r.progress.GetCourseProgress)
+		api.GET("/progress/org", middleware.OrgMembershipMiddleware(), r.progress.GetProgressByOrg)
+		api.POST("/progress/materials/:materialId/view", r.progress.RecordMaterialView)
+		api.POST("/progress/materials/:materialId/heartbeat", r.progress.RecordHeartbeat)
+		api.GET("/progress/materials/:materialId/watch", r.progress.GetVideoWatch)
+		api.GET("/organizations/:id/completion-criteria", r.progress.GetCompletionCriteria)
+		api.PUT("/organizations/:id/completion-criteria/:type", r.progress.UpdateCompletionCriteria)
+
+		// Analytics
+		api.GET("/analytics/student", r.analytics.GetStudentDashboard)
+		api.GET("/analytics/teacher", r.analytics.GetTeacherDashboard)
+		api.GET("/analytics/organizer", middleware.OrgMembershipMiddleware(), middleware.RBACMiddleware("ORGANIZER"), r.analytics.GetOrganizerDashboard)
+		api.GET("/analytics/materials/:materialId/video", r.analytics.GetVideoEngagement)
+		api.POST("/analytics/quiz/attempt", idempotent, r.analytics.RecordQuizAttempt)
+
+		// AI
+		api.GET("/ai/studypack/:materialId", r.ai.GetStudyPack)
+		api.GET("/ai/review/:materialId", r.ai.GetReviewDraft)
+		api.POST("/ai/review/:materialId/approve", r.ai.ApproveStudyPack)
+		api.POST("/ai/review/:materialId/regenerate", r.ai.RegenerateStudyPack)
+		api.POST("/ai/tutor", r.ai.TutorChat)
+
+		// Imports
+		api.POST("/imports/youtube", idempotent, r.imports.ImportYouTube)
+		api.POST("/imports/document", idempotent, r.imports.ImportDocument)
+		api.GET("/imports/status/:materialId", r.imports.GetImportStatus)
+	}
+}
+
+// routeGroup is a gin.RouterGroup that, when only is set, skips every
+// route not in it. The legacy mount uses it to serve LegacyRoutes alone.
+type routeGroup struct {
+	group *gin.RouterGroup
+	only  map[string]bool
+}
+
+func (g routeGroup) Group(relativePath string, handlers ...gin.HandlerFunc) routeGroup {
+	return routeGroup{group: g.group.Group(relativePath, handlers...), only: g.only}
+}
+
+func (g routeGroup) Use(middleware ...gin.HandlerFunc) {
+	g.group.Use(middleware...)
+}
+
+func (g routeGroup) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodGet, relativePath, handlers)
+}
+
+func (g routeGroup) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPost, relativePath, handlers)
+}
+
+func (g routeGroup) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPut, relativePath, handlers)
+}
+
+func (g routeGroup) PATCH(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPatch, relativePath, handlers)
+}
+
+func (g routeGroup) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodDelete, relativePath, handlers)
+}
+
+func (g routeGroup) handle(method, relativePath string, handlers []gin.HandlerFunc) {
+	if g.only != nil && !g.only[method+" "+path.Join(g.group.BasePath(), relativePath)] {
+		return
+	}
+	g.group.Handle(method, relativePath, handlers...)
+}
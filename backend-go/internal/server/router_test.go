@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"myway-backend/internal/config"
+	"myway-backend/internal/metrics"
+	"myway-backend/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return NewRouter(&config.Config{JWTSecret: "test-secret", LegacyAPISunset: config.DefaultLegacyAPISunset})
+}
+
+// TestRoutesAreDocumented fails when a route is registered without a matching
+// entry in openapi.Operations, or when the spec documents a route that no
+// longer exists. Legacy aliases are not documented themselves but must
+// point at a documented successor.
+func TestRoutesAreDocumented(t *testing.T) {
+	registered := map[string]bool{}
+	legacy := map[string]bool{}
+	for _, route := range newTestRouter().Routes() {
+		key := route.Method + " " + route.Path
+		if isLegacy(route.Path) {
+			legacy[key] = true
+			continue
+		}
+		registered[key] = true
+	}
+
+	documented := map[string]bool{}
+	for _, key := range openapi.Routes() {
+		if documented[key] {
+			t.Errorf("route %s is documented more than once", key)
+		}
+		documented[key] = true
+	}
+
+	for key := range registered {
+		if !documented[key] {
+			t.Errorf("route %s is missing from the OpenAPI spec", key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("route %s is documented but not registered", key)
+		}
+	}
+	for key := range legacy {
+		if !documented[legacySuccessor(key)] {
+			t.Errorf("legacy route %s has no documented successor", key)
+		}
+	}
+}
+
+// TestLegacyRoutesAreFrozen fails when the legacy mount serves anything but
+// LegacyRoutes, so routes added under APIPrefix get no unversioned alias.
+func TestLegacyRoutesAreFrozen(t *testing.T) {
+	legacy := map[string]bool{}
+	for _, route := range newTestRouter().Routes() {
+		if isLegacy(route.Path) {
+			legacy[route.Method+" "+route.Path] = true
+		}
+	}
+	for _, key := range LegacyRoutes {
+		if !legacy[key] {
+			t.Errorf("legacy route %s is not registered", key)
+		}
+		delete(legacy, key)
+	}
+	for key := range legacy {
+		t.Errorf("route %s has a legacy alias but is not in LegacyRoutes", key)
+	}
+}
+
+func isLegacy(path string) bool {
+	if strings.HasPrefix(path, APIPrefix+"/") {
+		return false
+	}
+	for _, op := range openapi.Operations {
+		if op.Unversioned && op.Path == path {
+			return false
+		}
+	}
+	return true
+}
+
+func legacySuccessor(key string) string {
+	if successor, ok := LegacySuccessors[key]; ok {
+		return successor
+	}
+	method, path, _ := strings.Cut(key, " ")
+	return method + " " + APIPrefix + path
+}
+
+func TestLegacyAliasesAreDeprecated(t *testing.T) {
+	router := newTestRouter()
+	before := metrics.LegacyRouteRequests.Value(http.MethodGet, "/courses/:id")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/courses/abc", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /courses/abc returned %d, want 401", rec.Code)
+	}
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Errorf("missing Deprecation header")
+	}
+	if rec.Header().Get("Sunset") == "" {
+		t.Errorf("missing Sunset header")
+	}
+	if link := rec.Header().Get("Link"); link != `</api/v1/courses/abc>; rel="successor-version"` {
+		t.Errorf("Link = %q", link)
+	}
+	if after := metrics.LegacyRouteRequests.Value(http.MethodGet, "/courses/:id"); after != before+1 {
+		t.Errorf("legacy counter = %d, want %d", after, before+1)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/organizations/abc/delete", nil))
+	if link := rec.Header().Get("Link"); link != `</api/v1/organizations/abc>; rel="successor-version"` {
+		t.Errorf("Link = %q", link)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/courses/abc", nil))
+	if rec.Header().Get("Deprecation") != "" {
+		t.Errorf("versioned route should not be deprecated")
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/courses/abc/gradebook", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Deprecation") != "" {
+		t.Errorf("GET /courses/abc/gradebook returned %d; routes added after versioning have no legacy alias", rec.Code)
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	newTestRouter().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json returned %d", rec.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi version = %q, want 3.x", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/courses/{id}"]["get"]; !ok {
+		t.Errorf("GET /courses/{id} missing from paths")
+	}
+
+	// Every $ref must resolve to a component schema.
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := node["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if doc.Components.Schemas[name] == nil {
+					t.Errorf("unresolved $ref %s", ref)
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	var raw interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &raw)
+	walk(raw)
+}
+
+func TestRequestSchemasFollowBindingTags(t *testing.T) {
+	doc := openapi.Document()
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	course, ok := schemas["CreateCourseRequest"].(map[string]interface{})
+	if !ok {
+		t.Fatal("CreateCourseRequest schema missing")
+	}
+	required := map[string]bool{}
+	for _, name := range course["required"].([]string) {
+		required[name] = true
+	}
+	for _, name := range []string{"orgId", "code", "title", "description"} {
+		if !required[name] {
+			t.Errorf("CreateCourseRequest.%s should be required", name)
+		}
+	}
+
+	youtube := schemas["ImportYouTubeRequest"].(map[string]interface{})
+	props := youtube["properties"].(map[string]interface{})
+	if _, ok := props["youtubeUrl"]; !ok {
+		t.Error("ImportYouTubeRequest.youtubeUrl missing")
+	}
+	if moduleID := props["moduleId"].(map[string]interface{}); moduleID["nullable"] != true {
+		t.Error("ImportYouTubeRequest.moduleId should be nullable")
+	}
+}
@@ -0,0 +1,135 @@
+// Package srs schedules flashcard reviews with SM-2, using the four-grade
+// variant popularised by Anki: "again" resets the card, "hard", "good" and
+// "easy" grow the interval by progressively larger factors and nudge the
+// card's ease up or down.
+package srs
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type Grade string
+
+const (
+	Again Grade = "again"
+	Hard  Grade = "hard"
+	Good  Grade = "good"
+	Easy  Grade = "easy"
+)
+
+const (
+	// InitialEase is the ease factor of a card that has never been reviewed.
+	InitialEase = 2.5
+	// MinEase keeps repeatedly failed cards from collapsing to daily reviews
+	// forever.
+	MinEase = 1.3
+
+	// RelearnDelay is how soon a card graded "again" comes back, so it is
+	// seen again in the same day's queue.
+	RelearnDelay = 10 * time.Minute
+
+	hardFactor = 1.2
+	easyBonus  = 1.3
+	day        = 24 * time.Hour
+)
+
+// Grades lists the accepted grades, worst first.
+func Grades() []Grade {
+	return []Grade{Again, Hard, Good, Easy}
+}
+
+// ParseGrade accepts the four grades case-insensitively, plus the legacy
+// "known" and "unknown" responses of flashcard sessions.
+func ParseGrade(value string) (Grade, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "again", "unknown":
+		return Again, nil
+	case "hard":
+		return Hard, nil
+	case "good", "known":
+		return Good, nil
+	case "easy":
+		return Easy, nil
+	}
+	return "", fmt.Errorf("unknown grade %q", value)
+}
+
+// Passed reports whether the learner recalled the card.
+func (g Grade) Passed() bool {
+	return g != Again
+}
+
+// State is one learner's schedule for one card.
+type State struct {
+	Ease         float64
+	IntervalDays int
+	Repetitions  int
+	Lapses       int
+	DueAt        time.Time
+}
+
+// New is the state of a card that has never been reviewed. It is due now.
+func New(now time.Time) State {
+	return State{Ease: InitialEase, DueAt: now}
+}
+
+// Review applies a grade given at now and returns the next state.
+func Review(s State, grade Grade, now time.Time) State {
+	if s.Ease == 0 {
+		s.Ease = InitialEase
+	}
+
+	if grade == Again {
+		if s.Repetitions > 0 {
+			s.Lapses++
+		}
+		s.Repetitions = 0
+		s.IntervalDays = 0
+		s.Ease = math.Max(MinEase, s.Ease-0.2)
+		s.DueAt = now.Add(RelearnDelay)
+		return s
+	}
+
+	s.IntervalDays = nextInterval(s, grade)
+	switch grade {
+	case Hard:
+		s.Ease = math.Max(MinEase, s.Ease-0.15)
+	case Easy:
+		s.Ease += 0.15
+	}
+	s.Repetitions++
+	s.DueAt = now.Add(time.Duration(s.IntervalDays) * day)
+	return s
+}
+
+// nextInterval follows SM-2's fixed first steps of one and six days, then
+// multiplies the interval by the ease. "hard" grows it by a fixed small
+// factor and "easy" by a bonus on top of the ease. A passed review always
+// adds at least a day.
+func nextInterval(s State, grade Grade) int {
+	switch {
+	case s.Repetitions == 0:
+		if grade == Easy {
+			return 4
+		}
+		return 1
+	case s.Repetitions == 1 && grade == Good:
+		return 6
+	}
+
+	factor := s.Ease
+	switch grade {
+	case Hard:
+		factor = hardFactor
+	case Easy:
+		factor = s.Ease * easyBonus
+	}
+	next := int(math.Round(float64(s.IntervalDays) * factor))
+	if grade == Easy && s.Repetitions == 1 {
+		next = max(next, 6)
+	}
+	return max(next, s.IntervalDays+1)
+}
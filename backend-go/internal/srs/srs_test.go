@@ -0,0 +1,54 @@
+package srs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReviewSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := New(now)
+
+	steps := []struct {
+		grade    Grade
+		interval int
+		lapses   int
+	}{
+		{Good, 1, 0},
+		{Good, 6, 0},
+		{Good, 15, 0},
+		{Hard, 18, 0},
+		{Again, 0, 1},
+		{Good, 1, 1},
+		{Easy, 6, 1},
+	}
+	for i, step := range steps {
+		s = Review(s, step.grade, now)
+		if s.IntervalDays != step.interval || s.Lapses != step.lapses {
+			t.Fatalf("step %d (%s): interval %d lapses %d, want %d and %d", i, step.grade, s.IntervalDays, s.Lapses, step.interval, step.lapses)
+		}
+	}
+
+	if want := 2.5 - 0.15 - 0.2 + 0.15; s.Ease != want {
+		t.Errorf("ease = %v, want %v", s.Ease, want)
+	}
+}
+
+func TestAgainComesBackToday(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := Review(Review(New(now), Easy, now), Again, now)
+	if !s.DueAt.Equal(now.Add(RelearnDelay)) || s.Repetitions != 0 {
+		t.Errorf("got due %v after %d repetitions", s.DueAt, s.Repetitions)
+	}
+}
+
+func TestEaseHasFloor(t *testing.T) {
+	now := time.Now()
+	s := New(now)
+	for i := 0; i < 20; i++ {
+		s = Review(s, Again, now)
+	}
+	if s.Ease != MinEase {
+		t.Errorf("ease = %v, want %v", s.Ease, MinEase)
+	}
+}
@@ -0,0 +1,120 @@
+package studypack
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one field that differs between two snapshots. Before is null
+// for added entries and After is null for removed ones.
+type Change struct {
+	Field  string      `json:"field" binding:"required"`
+	Kind   string      `json:"kind" binding:"required"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff lists what changed from base to head. Quizzes, questions, key
+// points and flashcards are compared by position, so field paths read like
+// "quizzes[0].questions[2].prompt".
+func Diff(base, head Snapshot) []Change {
+	d := differ{changes: []Change{}}
+	d.value("summary", base.Summary, head.Summary)
+
+	for i := 0; i < max(len(base.KeyPoints), len(head.KeyPoints)); i++ {
+		field := fmt.Sprintf("keyPoints[%d]", i)
+		switch {
+		case i >= len(head.KeyPoints):
+			d.removed(field, base.KeyPoints[i])
+		case i >= len(base.KeyPoints):
+			d.added(field, head.KeyPoints[i])
+		default:
+			d.value(field, base.KeyPoints[i], head.KeyPoints[i])
+		}
+	}
+
+	for i := 0; i < max(len(base.Quizzes), len(head.Quizzes)); i++ {
+		field := fmt.Sprintf("quizzes[%d]", i)
+		switch {
+		case i >= len(head.Quizzes):
+			d.removed(field, base.Quizzes[i])
+		case i >= len(base.Quizzes):
+			d.added(field, head.Quizzes[i])
+		default:
+			d.questions(field, base.Quizzes[i].Questions, head.Quizzes[i].Questions)
+		}
+	}
+
+	for i := 0; i < max(len(base.Flashcards), len(head.Flashcards)); i++ {
+		field := fmt.Sprintf("flashcards[%d]", i)
+		switch {
+		case i >= len(head.Flashcards):
+			d.removed(field, base.Flashcards[i])
+		case i >= len(base.Flashcards):
+			d.added(field, head.Flashcards[i])
+		default:
+			b, h := base.Flashcards[i], head.Flashcards[i]
+			d.value(field+".front", b.Front, h.Front)
+			d.value(field+".back", b.Back, h.Back)
+			d.raw(field+".tags", b.Tags, h.Tags)
+		}
+	}
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) questions(prefix string, base, head []SnapshotQuestion) {
+	for i := 0; i < max(len(base), len(head)); i++ {
+		field := fmt.Sprintf("%s.questions[%d]", prefix, i)
+		switch {
+		case i >= len(head):
+			d.removed(field, base[i])
+		case i >= len(base):
+			d.added(field, head[i])
+		default:
+			b, h := base[i], head[i]
+			d.value(field+".type", b.Type, h.Type)
+			d.value(field+".prompt", b.Prompt, h.Prompt)
+			d.raw(field+".options", b.Options, h.Options)
+			d.raw(field+".answerKey", b.AnswerKey, h.AnswerKey)
+			d.value(field+".explanation", deref(b.Explanation), deref(h.Explanation))
+			d.value(field+".points", b.Points, h.Points)
+		}
+	}
+}
+
+func (d *differ) value(field string, before, after interface{}) {
+	if before != after {
+		d.changes = append(d.changes, Change{Field: field, Kind: Changed, Before: before, After: after})
+	}
+}
+
+func (d *differ) raw(field string, before, after json.RawMessage) {
+	if !SameJSON(string(before), string(after)) {
+		d.changes = append(d.changes, Change{Field: field, Kind: Changed, Before: before, After: after})
+	}
+}
+
+func (d *differ) added(field string, after interface{}) {
+	d.changes = append(d.changes, Change{Field: field, Kind: Added, After: after})
+}
+
+func (d *differ) removed(field string, before interface{}) {
+	d.changes = append(d.changes, Change{Field: field, Kind: Removed, Before: before})
+}
+
+func deref(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -0,0 +1,46 @@
+package studypack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDiff(t *testing.T) {
+	quizID, questionID := uuid.New(), uuid.New()
+	base := Snapshot{
+		Summary:   "Cells",
+		KeyPoints: []string{"Nucleus", "Membrane"},
+		Quizzes: []SnapshotQuiz{{ID: quizID, Questions: []SnapshotQuestion{
+			{ID: questionID, Type: "MCQ", Prompt: "Powerhouse?", Options: json.RawMessage(`["Mitochondria","Ribosome"]`), AnswerKey: json.RawMessage(`"Mitochondria"`), Points: 1},
+		}}},
+		Flashcards: []SnapshotFlashcard{{Front: "ATP", Back: "Energy", Tags: json.RawMessage("null")}},
+	}
+	head := base
+	head.KeyPoints = []string{"Nucleus"}
+	head.Quizzes = []SnapshotQuiz{{ID: quizID, Questions: []SnapshotQuestion{base.Quizzes[0].Questions[0]}}}
+	head.Quizzes[0].Questions[0].Points = 2
+	// Same JSON, different formatting
+	head.Quizzes[0].Questions[0].Options = json.RawMessage(`[ "Mitochondria", "Ribosome" ]`)
+	head.Flashcards = append(head.Flashcards, SnapshotFlashcard{Front: "DNA", Back: "Genes"})
+
+	changes := Diff(base, head)
+
+	want := []struct{ field, kind string }{
+		{"keyPoints[1]", Removed},
+		{"quizzes[0].questions[0].points", Changed},
+		{"flashcards[1]", Added},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Field != w.field || changes[i].Kind != w.kind {
+			t.Errorf("change %d = %s %s, want %s %s", i, changes[i].Kind, changes[i].Field, w.kind, w.field)
+		}
+	}
+	if changes[1].Before != 1 || changes[1].After != 2 {
+		t.Errorf("points change = %v -> %v", changes[1].Before, changes[1].After)
+	}
+}
@@ -0,0 +1,130 @@
+// Package studypack captures the content of a study pack as a snapshot, so
+// that revisions can be stored, compared and restored independently of the
+// rows they were taken from.
+package studypack
+
+import (
+	"bytes"
+	"encoding/json"
+	"myway-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Snapshot is the content of a study pack at one point in time. Quizzes,
+// questions and flashcards are in position order.
+type Snapshot struct {
+	Summary    string              `json:"summary" binding:"required"`
+	KeyPoints  []string            `json:"keyPoints" binding:"required"`
+	Quizzes    []SnapshotQuiz      `json:"quizzes" binding:"required"`
+	Flashcards []SnapshotFlashcard `json:"flashcards" binding:"required"`
+}
+
+type SnapshotQuiz struct {
+	ID        uuid.UUID          `json:"id" binding:"required"`
+	Questions []SnapshotQuestion `json:"questions" binding:"required"`
+}
+
+type SnapshotQuestion struct {
+	ID          uuid.UUID       `json:"id" binding:"required"`
+	Type        string          `json:"type" binding:"required"`
+	Prompt      string          `json:"prompt" binding:"required"`
+	Options     json.RawMessage `json:"options"`
+	AnswerKey   json.RawMessage `json:"answerKey"`
+	Explanation *string         `json:"explanation"`
+	Points      int             `json:"points" binding:"required"`
+}
+
+type SnapshotFlashcard struct {
+	ID    uuid.UUID       `json:"id" binding:"required"`
+	Front string          `json:"front" binding:"required"`
+	Back  string          `json:"back" binding:"required"`
+	Tags  json.RawMessage `json:"tags"`
+}
+
+// Capture builds a snapshot from a study pack with its Summary, Quizzes,
+// Quizzes.Questions and Flashcards preloaded in position order.
+func Capture(pack models.StudyPack) Snapshot {
+	snapshot := Snapshot{
+		KeyPoints:  []string{},
+		Quizzes:    make([]SnapshotQuiz, len(pack.Quizzes)),
+		Flashcards: make([]SnapshotFlashcard, len(pack.Flashcards)),
+	}
+	if pack.Summary != nil {
+		snapshot.Summary, snapshot.KeyPoints = ParseSummary(pack.Summary.Content)
+	}
+
+	for i, quiz := range pack.Quizzes {
+		questions := make([]SnapshotQuestion, len(quiz.Questions))
+		for j, q := range quiz.Questions {
+			questions[j] = SnapshotQuestion{
+				ID:          q.ID,
+				Type:        q.Type,
+				Prompt:      q.Prompt,
+				Options:     compact(q.Options),
+				AnswerKey:   compact(q.AnswerKey),
+				Explanation: q.Explanation,
+				Points:      q.Points,
+			}
+		}
+		snapshot.Quizzes[i] = SnapshotQuiz{ID: quiz.ID, Questions: questions}
+	}
+
+	for i, f := range pack.Flashcards {
+		tags := json.RawMessage("null")
+		if f.Tags != nil {
+			tags = compact(*f.Tags)
+		}
+		snapshot.Flashcards[i] = SnapshotFlashcard{ID: f.ID, Front: f.Front, Back: f.Back, Tags: tags}
+	}
+	return snapshot
+}
+
+// ParseSummary reads the summary text and key points from Summary.Content.
+// Older content stores the key points under "keyPoints" instead of
+// "bullets".
+func ParseSummary(content string) (string, []string) {
+	var parsed struct {
+		Summary   string   `json:"summary" binding:"required"`
+		Bullets   []string `json:"bullets" binding:"required"`
+		KeyPoints []string `json:"keyPoints" binding:"required"`
+	}
+	json.Unmarshal([]byte(content), &parsed)
+	points := parsed.Bullets
+	if len(points) == 0 {
+		points = parsed.KeyPoints
+	}
+	if points == nil {
+		points = []string{}
+	}
+	return parsed.Summary, points
+}
+
+// SummaryContent is the Summary.Content for a summary and its key points.
+func SummaryContent(summary string, keyPoints []string) string {
+	content, _ := json.Marshal(map[string]interface{}{
+		"summary": summary,
+		"bullets": keyPoints,
+	})
+	return string(content)
+}
+
+// SameJSON reports whether two JSON documents are equal ignoring
+// formatting, as jsonb columns do not round-trip whitespace.
+func SameJSON(a, b string) bool {
+	return bytes.Equal(compact(a), compact(b))
+}
+
+// compact strips insignificant whitespace, falling back to null for
+// invalid input.
+func compact(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return json.RawMessage("null")
+	}
+	out, _ := json.Marshal(decoded)
+	return out
+}
@@ -0,0 +1,247 @@
+package trash
+
+import (
+	"fmt"
+	"myway-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scope is everything owned by a deleted entity, collected regardless of
+// soft-delete state. It is the single cascade used for soft delete, restore
+// and purge.
+type Scope struct {
+	OrgIDs        []uuid.UUID
+	CourseIDs     []uuid.UUID
+	ModuleIDs     []uuid.UUID
+	MaterialIDs   []uuid.UUID
+	StudyPackIDs  []uuid.UUID
+	QuizIDs       []uuid.UUID
+	AssignmentIDs []uuid.UUID
+	ThreadIDs     []uuid.UUID
+}
+
+// ForOrganization collects an organization and all of its courses.
+func ForOrganization(tx *gorm.DB, orgID uuid.UUID) (*Scope, error) {
+	var courseIDs []uuid.UUID
+	if err := tx.Unscoped().Model(&models.Course{}).Where("org_id = ?", orgID).Pluck("id", &courseIDs).Error; err != nil {
+		return nil, err
+	}
+	scope, err := ForCourses(tx, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	scope.OrgIDs = []uuid.UUID{orgID}
+	return scope, nil
+}
+
+// ForCourses collects courses with their modules, assignments and threads.
+func ForCourses(tx *gorm.DB, courseIDs []uuid.UUID) (*Scope, error) {
+	scope := &Scope{CourseIDs: courseIDs}
+	if len(courseIDs) == 0 {
+		return scope, nil
+	}
+
+	db := tx.Unscoped()
+	if err := db.Model(&models.Assignment{}).Where("course_id IN ?", courseIDs).Pluck("id", &scope.AssignmentIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Thread{}).Where("course_id IN ?", courseIDs).Pluck("id", &scope.ThreadIDs).Error; err != nil {
+		return nil, err
+	}
+	var moduleIDs []uuid.UUID
+	if err := db.Model(&models.Module{}).Where("course_id IN ?", courseIDs).Pluck("id", &moduleIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := scope.addModules(tx, moduleIDs); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+// ForModules collects modules with their materials and generated content.
+func ForModules(tx *gorm.DB, moduleIDs []uuid.UUID) (*Scope, error) {
+	scope := &Scope{}
+	if err := scope.addModules(tx, moduleIDs); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+func (s *Scope) addModules(tx *gorm.DB, moduleIDs []uuid.UUID) error {
+	s.ModuleIDs = moduleIDs
+	if len(moduleIDs) == 0 {
+		return nil
+	}
+
+	db := tx.Unscoped()
+	if err := db.Model(&models.Material{}).Where("module_id IN ?", moduleIDs).Pluck("id", &s.MaterialIDs).Error; err != nil {
+		return err
+	}
+	if len(s.MaterialIDs) == 0 {
+		return nil
+	}
+	if err := db.Model(&models.StudyPack{}).Where("material_id IN ?", s.MaterialIDs).Pluck("id", &s.StudyPackIDs).Error; err != nil {
+		return err
+	}
+	if len(s.StudyPackIDs) == 0 {
+		return nil
+	}
+	return db.Model(&models.Quiz{}).Where("study_pack_id IN ?", s.StudyPackIDs).Pluck("id", &s.QuizIDs).Error
+}
+
+type table struct {
+	model interface{}
+	ids   []uuid.UUID
+}
+
+// softDeletable lists the soft-delete tables in the scope.
+func (s *Scope) softDeletable() []table {
+	return []table{
+		{&models.Organization{}, s.OrgIDs},
+		{&models.Course{}, s.CourseIDs},
+		{&models.Module{}, s.ModuleIDs},
+		{&models.Material{}, s.MaterialIDs},
+		{&models.Assignment{}, s.AssignmentIDs},
+		{&models.Thread{}, s.ThreadIDs},
+	}
+}
+
+// SoftDelete stamps every live row in the scope with at. Rows that were
+// already deleted keep their own timestamp so they are not restored with
+// this batch.
+func (s *Scope) SoftDelete(tx *gorm.DB, at time.Time) error {
+	for _, t := range s.softDeletable() {
+		if len(t.ids) == 0 {
+			continue
+		}
+		if err := tx.Unscoped().Model(t.model).
+			Where("id IN ? AND deleted_at IS NULL", t.ids).
+			Update("deleted_at", at).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore clears deleted_at on the rows that were deleted at exactly at.
+func (s *Scope) Restore(tx *gorm.DB, at time.Time) error {
+	for _, t := range s.softDeletable() {
+		if len(t.ids) == 0 {
+			continue
+		}
+		if err := tx.Unscoped().Model(t.model).
+			Where("id IN ? AND deleted_at = ?", t.ids, at).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge hard-deletes everything in the scope, children first.
+func (s *Scope) Purge(tx *gorm.DB) error {
+	db := tx.Unscoped()
+
+	steps := []struct {
+		what   string
+		ids    []uuid.UUID
+		column string
+		model  interface{}
+	}{
+		{"quiz attempts", s.QuizIDs, "quiz_id", &models.QuizAttempt{}},
+		{"quiz reveals", s.QuizIDs, "quiz_id", &models.QuizReveal{}},
+		{"quiz questions", s.QuizIDs, "quiz_id", &models.QuizQuestion{}},
+		{"quizzes", s.QuizIDs, "id", &models.Quiz{}},
+		{"flashcard sessions", s.StudyPackIDs, "study_pack_id", &models.FlashcardSession{}},
+		{"flashcards", s.StudyPackIDs, "study_pack_id", &models.Flashcard{}},
+		{"summaries", s.StudyPackIDs, "study_pack_id", &models.Summary{}},
+		{"study pack revisions", s.StudyPackIDs, "study_pack_id", &models.StudyPackRevision{}},
+		{"study pack reviewers", s.StudyPackIDs, "study_pack_id", &models.StudyPackReviewer{}},
+		{"review comments", s.StudyPackIDs, "study_pack_id", &models.ReviewComment{}},
+		{"study packs", s.StudyPackIDs, "id", &models.StudyPack{}},
+		{"material activity", s.MaterialIDs, "material_id", &models.MaterialActivity{}},
+		{"video heartbeats", s.MaterialIDs, "material_id", &models.VideoHeartbeat{}},
+		{"materials", s.MaterialIDs, "id", &models.Material{}},
+		{"modules", s.ModuleIDs, "id", &models.Module{}},
+		{"peer reviews", s.AssignmentIDs, "assignment_id", &models.PeerReview{}},
+		{"submissions", s.AssignmentIDs, "assignment_id", &models.Submission{}},
+		{"assignment extensions", s.AssignmentIDs, "assignment_id", &models.AssignmentExtension{}},
+		{"assignments", s.AssignmentIDs, "id", &models.Assignment{}},
+		{"replies", s.ThreadIDs, "thread_id", &models.Reply{}},
+		{"threads", s.ThreadIDs, "id", &models.Thread{}},
+		{"enrollments", s.CourseIDs, "course_id", &models.Enrollment{}},
+		{"grade overrides", s.CourseIDs, "course_id", &models.GradeOverride{}},
+		{"grade categories", s.CourseIDs, "course_id", &models.GradeCategory{}},
+		{"course metrics", s.CourseIDs, "course_id", &models.CourseMetric{}},
+		{"courses", s.CourseIDs, "id", &models.Course{}},
+		{"terms", s.OrgIDs, "org_id", &models.Term{}},
+		{"rubrics", s.OrgIDs, "org_id", &models.Rubric{}},
+		{"memberships", s.OrgIDs, "org_id", &models.OrgMembership{}},
+		{"organization metrics", s.OrgIDs, "org_id", &models.DailyOrgMetric{}},
+		{"organization settings", s.OrgIDs, "org_id", &models.OrgSettings{}},
+		{"completion criteria", s.OrgIDs, "org_id", &models.CompletionCriteria{}},
+		{"organizations", s.OrgIDs, "id", &models.Organization{}},
+	}
+
+	// Attempts, and the suggestions for them, hang off submissions rather
+	// than the assignment.
+	if len(s.AssignmentIDs) > 0 {
+		submissions := db.Model(&models.Submission{}).Select("id").Where("assignment_id IN ?", s.AssignmentIDs)
+		attempts := db.Model(&models.SubmissionAttempt{}).Select("id").Where("submission_id IN (?)", submissions)
+		if err := db.Where("attempt_id IN (?)", attempts).Delete(&models.GradingSuggestion{}).Error; err != nil {
+			return fmt.Errorf("delete grading suggestions: %w", err)
+		}
+		if err := db.Where("submission_id IN (?)", submissions).Delete(&models.SubmissionAttempt{}).Error; err != nil {
+			return fmt.Errorf("delete submission attempts: %w", err)
+		}
+	}
+
+	// Rubric versions hang off the organization's rubrics.
+	if len(s.OrgIDs) > 0 {
+		rubrics := db.Model(&models.Rubric{}).Select("id").Where("org_id IN ?", s.OrgIDs)
+		if err := db.Where("rubric_id IN (?)", rubrics).Delete(&models.RubricVersion{}).Error; err != nil {
+			return fmt.Errorf("delete rubric versions: %w", err)
+		}
+	}
+
+	// Review schedules hang off flashcards rather than the study pack.
+	if len(s.StudyPackIDs) > 0 {
+		flashcards := db.Model(&models.Flashcard{}).Select("id").Where("study_pack_id IN ?", s.StudyPackIDs)
+		if err := db.Where("flashcard_id IN (?)", flashcards).Delete(&models.FlashcardReviewState{}).Error; err != nil {
+			return fmt.Errorf("delete flashcard review states: %w", err)
+		}
+	}
+
+	for _, step := range steps {
+		if len(step.ids) == 0 {
+			continue
+		}
+		if err := db.Where(step.column+" IN ?", step.ids).Delete(step.model).Error; err != nil {
+			return fmt.Errorf("delete %s: %w", step.what, err)
+		}
+	}
+
+	// Progress events store the course ID as text.
+	if len(s.CourseIDs) > 0 {
+		courseIDs := make([]string, len(s.CourseIDs))
+		for i, id := range s.CourseIDs {
+			courseIDs[i] = id.String()
+		}
+		if err := db.Where("course_id IN ?", courseIDs).Delete(&models.ProgressEvent{}).Error; err != nil {
+			return fmt.Errorf("delete progress events: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// entityIDs returns every ID that may have its own trash entry.
+func (s *Scope) entityIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(s.OrgIDs)+len(s.CourseIDs)+len(s.ModuleIDs))
+	ids = append(ids, s.OrgIDs...)
+	ids = append(ids, s.CourseIDs...)
+	return append(ids, s.ModuleIDs...)
+}
@@ -0,0 +1,237 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"myway-backend/internal/apperror"
+	"myway-backend/internal/database"
+	"myway-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	EntityOrganization = "ORGANIZATION"
+	EntityCourse       = "COURSE"
+	EntityModule       = "MODULE"
+)
+
+// Move soft-deletes an entity with everything it owns and records a trash
+// item for it. It returns NOT_FOUND when the entity does not exist or is
+// already in the trash.
+func Move(db *gorm.DB, entityType string, entityID, deletedBy uuid.UUID) (*models.TrashItem, error) {
+	// Postgres keeps microseconds; truncate so restore can match exactly.
+	at := time.Now().UTC().Truncate(time.Microsecond)
+
+	var item *models.TrashItem
+	err := db.Transaction(func(tx *gorm.DB) error {
+		orgID, title, err := describe(tx, entityType, entityID)
+		if err != nil {
+			return err
+		}
+
+		scope, err := scopeFor(tx, entityType, entityID)
+		if err != nil {
+			return apperror.Internal("Failed to collect "+entityLabel(entityType)+" contents", err)
+		}
+		if err := scope.SoftDelete(tx, at); err != nil {
+			return apperror.Internal("Failed to delete "+entityLabel(entityType), err)
+		}
+
+		item = &models.TrashItem{
+			ID:         uuid.New(),
+			OrgID:      orgID,
+			EntityType: entityType,
+			EntityID:   entityID,
+			Title:      title,
+			DeletedBy:  deletedBy,
+			TrashedAt:  at,
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return apperror.Internal("Failed to record deletion", err)
+		}
+		return nil
+	})
+	return item, err
+}
+
+// Restore brings back an entity and everything deleted with it. The parent
+// must be live: a module cannot be restored into a deleted course. A course
+// cannot come back while another live course of its organization has its
+// code.
+func Restore(db *gorm.DB, item *models.TrashItem) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := requireLiveParent(tx, item); err != nil {
+			return err
+		}
+		if item.EntityType == EntityCourse {
+			if err := requireFreeCourseCode(tx, item.EntityID); err != nil {
+				return err
+			}
+		}
+
+		scope, err := scopeFor(tx, item.EntityType, item.EntityID)
+		if err != nil {
+			return apperror.Internal("Failed to collect "+entityLabel(item.EntityType)+" contents", err)
+		}
+		if err := scope.Restore(tx, item.TrashedAt); err != nil {
+			if database.IsUniqueViolation(err, database.CourseCodeIndex) {
+				return apperror.Conflict("A course being restored has a code another course of the organization now uses")
+			}
+			return apperror.Internal("Failed to restore "+entityLabel(item.EntityType), err)
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			return apperror.Internal("Failed to clear trash entry", err)
+		}
+		return nil
+	})
+}
+
+// Purge hard-deletes a trashed entity and everything it owns, including
+// anything that was trashed separately beforehand.
+func Purge(db *gorm.DB, item *models.TrashItem) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		scope, err := scopeFor(tx, item.EntityType, item.EntityID)
+		if err != nil {
+			return err
+		}
+		if err := scope.Purge(tx); err != nil {
+			return err
+		}
+		return tx.Where("entity_id IN ?", scope.entityIDs()).Delete(&models.TrashItem{}).Error
+	})
+}
+
+// PurgeExpired purges every trash item older than retention and returns how
+// many were purged. A failing item is skipped and retried on the next run.
+func PurgeExpired(db *gorm.DB, retention time.Duration) (int, error) {
+	var items []models.TrashItem
+	if err := db.Where("trashed_at < ?", time.Now().Add(-retention)).
+		Order("trashed_at").
+		Find(&items).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for i := range items {
+		// An earlier item in this run may have purged this one as a child.
+		var count int64
+		if err := db.Model(&models.TrashItem{}).Where("id = ?", items[i].ID).Count(&count).Error; err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if count == 0 {
+			continue
+		}
+
+		if err := Purge(db, &items[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", items[i].EntityType, items[i].EntityID, err))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+func scopeFor(tx *gorm.DB, entityType string, entityID uuid.UUID) (*Scope, error) {
+	switch entityType {
+	case EntityOrganization:
+		return ForOrganization(tx, entityID)
+	case EntityCourse:
+		return ForCourses(tx, []uuid.UUID{entityID})
+	case EntityModule:
+		return ForModules(tx, []uuid.UUID{entityID})
+	default:
+		return nil, fmt.Errorf("unknown trash entity type %q", entityType)
+	}
+}
+
+// describe loads the live entity's organization and title for the trash
+// listing.
+func describe(tx *gorm.DB, entityType string, entityID uuid.UUID) (uuid.UUID, string, error) {
+	switch entityType {
+	case EntityOrganization:
+		var org models.Organization
+		if err := tx.First(&org, entityID).Error; err != nil {
+			return uuid.Nil, "", apperror.FromDB(err, "Organization not found")
+		}
+		return org.ID, org.Name, nil
+	case EntityCourse:
+		var course models.Course
+		if err := tx.First(&course, entityID).Error; err != nil {
+			return uuid.Nil, "", apperror.FromDB(err, "Course not found")
+		}
+		return course.OrgID, course.Title, nil
+	case EntityModule:
+		var module models.Module
+		if err := tx.Preload("Course").First(&module, entityID).Error; err != nil {
+			return uuid.Nil, "", apperror.FromDB(err, "Module not found")
+		}
+		return module.Course.OrgID, module.Title, nil
+	default:
+		return uuid.Nil, "", apperror.Internal("Unknown trash entity type", fmt.Errorf("%q", entityType))
+	}
+}
+
+func requireLiveParent(tx *gorm.DB, item *models.TrashItem) error {
+	var parent interface{}
+	var parentID interface{}
+	var label string
+
+	switch item.EntityType {
+	case EntityCourse:
+		parent, parentID, label = &models.Organization{}, item.OrgID, "organization"
+	case EntityModule:
+		var module models.Module
+		if err := tx.Unscoped().Select("course_id").First(&module, item.EntityID).Error; err != nil {
+			return apperror.FromDB(err, "Module not found")
+		}
+		parent, parentID, label = &models.Course{}, module.CourseID, "course"
+	default:
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(parent).Where("id = ?", parentID).Count(&count).Error; err != nil {
+		return apperror.Internal("Failed to check parent "+label, err)
+	}
+	if count == 0 {
+		return apperror.Conflict("Restore the parent " + label + " first")
+	}
+	return nil
+}
+
+// requireFreeCourseCode refuses to restore a course whose code another
+// course of the organization took while it was in the trash.
+func requireFreeCourseCode(tx *gorm.DB, courseID uuid.UUID) error {
+	var course models.Course
+	if err := tx.Unscoped().Select("id", "org_id", "code").First(&course, courseID).Error; err != nil {
+		return apperror.FromDB(err, "Course not found")
+	}
+	var count int64
+	if err := tx.Model(&models.Course{}).
+		Where("org_id = ? AND LOWER(code) = LOWER(?) AND id <> ?", course.OrgID, course.Code, course.ID).
+		Count(&count).Error; err != nil {
+		return apperror.Internal("Failed to check course code", err)
+	}
+	if count > 0 {
+		return apperror.Conflict("Another course in this organization now uses code " + course.Code + "; change its code before restoring this course")
+	}
+	return nil
+}
+
+func entityLabel(entityType string) string {
+	switch entityType {
+	case EntityOrganization:
+		return "organization"
+	case EntityCourse:
+		return "course"
+	case EntityModule:
+		return "module"
+	default:
+		return "item"
+	}
+}
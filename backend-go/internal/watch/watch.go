@@ -0,0 +1,154 @@
+// Package watch turns video player heartbeats into watched intervals and
+// rejects heartbeats that could not come from real playback.
+package watch
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Interval is a span of the video in seconds.
+type Interval struct {
+	Start float64 `json:"start" binding:"required"`
+	End   float64 `json:"end" binding:"required"`
+}
+
+// Merge sorts intervals and joins those that overlap or touch.
+func Merge(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		if interval.End > interval.Start {
+			sorted = append(sorted, interval)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := make([]Interval, 0, len(sorted))
+	for _, interval := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && interval.Start <= merged[last].End {
+			merged[last].End = math.Max(merged[last].End, interval.End)
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Covered is the total length of merged intervals.
+func Covered(merged []Interval) float64 {
+	total := 0.0
+	for _, interval := range merged {
+		total += interval.End - interval.Start
+	}
+	return total
+}
+
+// Percent is the whole percentage of duration covered, at most 100.
+func Percent(covered, duration float64) int {
+	if duration <= 0 {
+		return 0
+	}
+	return int(math.Min(math.Floor(covered/duration*100), 100))
+}
+
+// Limits are the anti-cheat thresholds for heartbeats.
+type Limits struct {
+	// MaxPlaybackRate is the fastest speed the player offers.
+	MaxPlaybackRate float64
+	// MaxSegmentSec caps the playback one heartbeat can report, however
+	// long ago the previous one was.
+	MaxSegmentSec float64
+	// SlackSec absorbs network jitter between heartbeats.
+	SlackSec float64
+	// DurationTolerance is how far, as a fraction, a reported duration may
+	// differ from the one recorded for the video.
+	DurationTolerance float64
+}
+
+var DefaultLimits = Limits{
+	MaxPlaybackRate:   2,
+	MaxSegmentSec:     120,
+	SlackSec:          2,
+	DurationTolerance: 0.05,
+}
+
+// Heartbeat reports that the player played From to To, in seconds, at
+// PlaybackRate. Duration is the video length the player reported.
+type Heartbeat struct {
+	From         float64
+	To           float64
+	Duration     float64
+	PlaybackRate float64
+	At           time.Time
+}
+
+// RejectError explains why a heartbeat was rejected.
+type RejectError struct {
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return e.Reason
+}
+
+func reject(format string, args ...interface{}) *RejectError {
+	return &RejectError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Check rejects a heartbeat that claims more playback than could have
+// happened. previous is when the learner's last accepted heartbeat for the
+// video arrived, if any; knownDuration is the video length on record.
+func Check(hb Heartbeat, previous *time.Time, knownDuration *float64, limits Limits) error {
+	if hb.From < 0 || hb.To < hb.From {
+		return reject("Segment must run forwards from a non-negative position")
+	}
+	if hb.Duration <= 0 {
+		return reject("Duration must be positive")
+	}
+	if hb.To > hb.Duration+limits.SlackSec {
+		return reject("Segment ends after the end of the video")
+	}
+	if knownDuration != nil {
+		allowed := math.Max(*knownDuration*limits.DurationTolerance, limits.SlackSec)
+		if math.Abs(hb.Duration-*knownDuration) > allowed {
+			return reject("Reported duration %.0fs does not match the video's %.0fs", hb.Duration, *knownDuration)
+		}
+	}
+	if hb.PlaybackRate > limits.MaxPlaybackRate {
+		return reject("Playback rate %.2gx is faster than the player allows", hb.PlaybackRate)
+	}
+
+	played := hb.To - hb.From
+	if played > limits.MaxSegmentSec {
+		return reject("Segment of %.0fs is longer than one heartbeat can cover", played)
+	}
+	if previous != nil {
+		elapsed := hb.At.Sub(*previous).Seconds()
+		if played > elapsed*limits.MaxPlaybackRate+limits.SlackSec {
+			return reject("Segment of %.0fs was reported %.0fs after the previous heartbeat", played, math.Max(elapsed, 0))
+		}
+	}
+	return nil
+}
+
+// Retention splits the video into equal buckets and reports which ones the
+// merged intervals cover at least half of.
+func Retention(merged []Interval, duration float64, buckets int) []bool {
+	watched := make([]bool, buckets)
+	if duration <= 0 {
+		return watched
+	}
+	size := duration / float64(buckets)
+	for i := range watched {
+		start, end := float64(i)*size, float64(i+1)*size
+		covered := 0.0
+		for _, interval := range merged {
+			covered += math.Max(0, math.Min(end, interval.End)-math.Max(start, interval.Start))
+		}
+		watched[i] = covered >= size/2
+	}
+	return watched
+}
@@ -0,0 +1,57 @@
+package watch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	got := Merge([]Interval{{30, 40}, {0, 10}, {5, 15}, {15, 20}, {50, 50}, {35, 45}})
+	want := []Interval{{0, 20}, {30, 45}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge = %v, want %v", got, want)
+	}
+	if covered := Covered(got); covered != 35 {
+		t.Errorf("Covered = %v, want 35", covered)
+	}
+	if percent := Percent(35, 40); percent != 87 {
+		t.Errorf("Percent = %d, want 87", percent)
+	}
+	if percent := Percent(41, 40); percent != 100 {
+		t.Errorf("Percent over duration = %d, want 100", percent)
+	}
+	if retention := Retention(got, 40, 4); !reflect.DeepEqual(retention, []bool{true, true, false, true}) {
+		t.Errorf("Retention = %v", retention)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	tenAgo := now.Add(-10 * time.Second)
+	known := 600.0
+
+	cases := []struct {
+		name     string
+		hb       Heartbeat
+		previous *time.Time
+		reject   bool
+	}{
+		{"first heartbeat", Heartbeat{From: 0, To: 10, Duration: 600, PlaybackRate: 1, At: now}, nil, false},
+		{"double speed", Heartbeat{From: 10, To: 30, Duration: 600, PlaybackRate: 2, At: now}, &tenAgo, false},
+		{"faster than wall clock", Heartbeat{From: 10, To: 60, Duration: 600, PlaybackRate: 1, At: now}, &tenAgo, true},
+		{"impossible rate", Heartbeat{From: 0, To: 10, Duration: 600, PlaybackRate: 16, At: now}, nil, true},
+		{"oversized segment", Heartbeat{From: 0, To: 300, Duration: 600, PlaybackRate: 1, At: now}, nil, true},
+		{"backwards", Heartbeat{From: 20, To: 10, Duration: 600, At: now}, nil, true},
+		{"past the end", Heartbeat{From: 590, To: 620, Duration: 600, At: now}, nil, true},
+		{"shortened video", Heartbeat{From: 0, To: 10, Duration: 20, At: now}, nil, true},
+	}
+	for _, tc := range cases {
+		err := Check(tc.hb, tc.previous, &known, DefaultLimits)
+		var rejected *RejectError
+		if got := errors.As(err, &rejected); got != tc.reject {
+			t.Errorf("%s: rejected = %v (%v), want %v", tc.name, got, err, tc.reject)
+		}
+	}
+}
@@ -1,5 +1,6 @@
 import { GoogleGenerativeAI } from '@google/generative-ai'
 import { Material, StudyPack, Quiz, QuizQuestion, Flashcard } from '../types'
+import { API_V1_URL } from '../../../lib/axios-client'
 
 const API_KEY = import.meta.env.VITE_GEMINI_API_KEY
 
@@ -230,7 +231,7 @@ const fetchYoutubeTranscript = async (url: string): Promise<string> => {
         console.log('🎬 Fetching YouTube transcript from backend:', url)
 
         // Call our backend endpoint instead of trying to fetch directly (CORS issue)
-        const response = await fetch(`http://localhost:8081/youtube/transcript?url=${encodeURIComponent(url)}`, {
+        const response = await fetch(`${API_V1_URL}/youtube/transcript?url=${encodeURIComponent(url)}`, {
             method: 'GET',
             headers: {
                 'Content-Type': 'application/json',
@@ -227,7 +227,7 @@ export function AssignmentsView() {
 
     try {
       const res = await apiClient.get(`/assignments/course/${courseId}`)
-      const baseAssignments: Assignment[] = (res.data || []).map((a: any) => ({
+      const baseAssignments: Assignment[] = (res.data?.items || []).map((a: any) => ({
         id: String(a.id),
         title: a.title || 'Assignment',
         description: a.instructions || '',
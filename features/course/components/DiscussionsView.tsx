@@ -40,12 +40,40 @@ export function DiscussionsView() {
   const [expandedId, setExpandedId] = useState<string | null>(null)
   const [replyText, setReplyText] = useState('')
 
+  const toggleReplies = async (discussion: Discussion) => {
+    if (expandedId === discussion.id) {
+      setExpandedId(null)
+      return
+    }
+    setExpandedId(discussion.id)
+    if (discussion.replyList) return
+
+    try {
+      const response = await apiClient.get(`/discussions/threads/${discussion.id}`)
+      const replyList = (response.data?.replies || []).map((reply: any) => ({
+        id: String(reply.id),
+        author: reply.creator?.name || 'Unknown',
+        authorRole: reply.creator?.role || 'Student',
+        avatar: `https://ui-avatars.com/api/?name=${encodeURIComponent(reply.creator?.name || 'User')}`,
+        content: reply.body || '',
+        timestamp: reply.createdAt || 'Recently',
+        likes: 0,
+      }))
+      setDiscussions((prev) =>
+        prev.map((d) => (d.id === discussion.id ? { ...d, replyList } : d)),
+      )
+    } catch (error) {
+      console.error('Failed to load replies:', error)
+    }
+  }
+
   const fetchDiscussions = async () => {
     try {
       // Try backend first
       const response = await apiClient.get(`/discussions/threads/course/${courseId}`)
-      const backendThreads = response.data || []
+      const backendThreads = response.data?.items || []
 
+      // The list only carries reply counts; replies load when a thread is expanded.
       const mapped = backendThreads.map((thread: any) => ({
         id: String(thread.id),
         title: thread.title || 'Discussion',
@@ -53,18 +81,9 @@ export function DiscussionsView() {
         authorRole: thread.creator?.role || 'Student',
         avatar: `https://ui-avatars.com/api/?name=${encodeURIComponent(thread.creator?.name || 'User')}`,
         content: thread.body || '',
-        timestamp: thread.created_at || 'Recently',
-        replies: (thread.replies || []).length,
+        timestamp: thread.createdAt || 'Recently',
+        replies: Number(thread.replyCount ?? 0),
         likes: 0,
-        replyList: (thread.replies || []).map((reply: any) => ({
-          id: String(reply.id),
-          author: reply.creator?.name || 'Unknown',
-          authorRole: reply.creator?.role || 'Student',
-          avatar: `https://ui-avatars.com/api/?name=${encodeURIComponent(reply.creator?.name || 'User')}`,
-          content: reply.body || '',
-          timestamp: reply.created_at || 'Recently',
-          likes: 0,
-        })),
       }))
 
       if (mapped.length > 0) {
@@ -211,8 +230,7 @@ export function DiscussionsView() {
   const handlePostReply = async (threadId: string) => {
     if (!replyText.trim()) return
     try {
-      await apiClient.post('/discussions/replies', {
-        threadId,
+      await apiClient.post(`/discussions/threads/${threadId}/replies`, {
         body: replyText,
       })
       setReplyText('')
@@ -311,11 +329,7 @@ export function DiscussionsView() {
                       <span>{discussion.likes}</span>
                     </button>
                     <button
-                      onClick={() =>
-                        setExpandedId(
-                          expandedId === discussion.id ? null : discussion.id,
-                        )
-                      }
+                      onClick={() => toggleReplies(discussion)}
                       className="flex items-center gap-1.5 text-gray-600 dark:text-gray-400 hover:text-indigo-600 dark:hover:text-indigo-400 transition-colors"
                     >
                       <MessageSquare size={16} />
@@ -0,0 +1,1819 @@
+// Code generated by backend-go/cmd/openapi-ts. DO NOT EDIT.
+// Regenerate with: cd backend-go && go run ./cmd/openapi-ts
+
+import apiClient from '../axios-client';
+
+export interface Page<T> {
+    items: T[];
+    total: number;
+    nextCursor: string | null;
+}
+
+export interface ApproveStudyPackRequest {
+    keyPoints?: string[];
+    keyPointsText?: string;
+    notes?: string;
+    summary: string;
+}
+
+export interface AssignReviewerRequest {
+    userId: string;
+}
+
+export interface Assignment {
+    courseId: string;
+    dueAt: string;
+    gradeCategoryId?: string | null;
+    id: string;
+    instructions: string;
+    latePenaltyPerDay: number;
+    latePolicy: string;
+    maxAttempts?: number | null;
+    peerReviewCount?: number | null;
+    peerReviewDueAt?: string | null;
+    peerReviewWeight: number;
+    peerReviewsAssignedAt?: string | null;
+    points: number;
+    rubricId?: string | null;
+    status: string;
+    title: string;
+}
+
+export interface AssignmentExtension {
+    assignmentId: string;
+    dueAt: string;
+    grantedBy: string;
+    id: string;
+    reason?: string | null;
+    studentName?: string;
+    updatedAt: string;
+    userId: string;
+}
+
+export interface AssignmentSummary {
+    dueAt: string;
+    extendedDueAt?: string | null;
+    gradeCategoryId?: string | null;
+    id: string;
+    instructions: string;
+    latePenaltyPerDay: number;
+    latePolicy: string;
+    maxAttempts?: number | null;
+    peerReviewCount?: number | null;
+    peerReviewDueAt?: string | null;
+    peerReviewWeight: number;
+    points: number;
+    rubricId?: string | null;
+    status: string;
+    submission?: Submission | null;
+    submissionCount?: number | null;
+    title: string;
+}
+
+export interface AttachRubricRequest {
+    rubricId: string;
+}
+
+export interface AuthResponse {
+    accessToken: string;
+    refreshToken: string;
+    user: AuthUser;
+}
+
+export interface AuthUser {
+    email: string;
+    id: string;
+    name: string;
+    role: string;
+}
+
+export interface Change {
+    after?: unknown;
+    before?: unknown;
+    field: string;
+    kind: string;
+}
+
+export interface CloneCourseRequest {
+    code: string;
+    description?: string | null;
+    shiftDays?: number | null;
+    termId?: string | null;
+    title?: string | null;
+}
+
+export interface CompletionCriteria {
+    custom: boolean;
+    materialType: string;
+    minMasteredPercent: number;
+    minQuizScore: number;
+    minWatchPercent: number;
+    requireView: boolean;
+}
+
+export interface Course {
+    assignments?: Assignment[];
+    clonedFrom?: string | null;
+    code: string;
+    coverImageUrl?: string | null;
+    createdBy: string;
+    description: string;
+    id: string;
+    metadata?: unknown;
+    modules?: Module[];
+    orgId: string;
+    status: string;
+    termId?: string | null;
+    title: string;
+}
+
+export interface CourseProgressResponse {
+    completedMaterials: number;
+    courseId: string;
+    flashcardSessions: number;
+    lastActivity?: string | null;
+    modules: ModuleProgress[];
+    progressPercentage: number;
+    quizAttempts: number;
+    totalMaterials: number;
+}
+
+export interface CourseProgressSummary {
+    completedMaterials: number;
+    courseId: string;
+    courseTitle: string;
+    progressPercentage: number;
+    totalMaterials: number;
+}
+
+export interface CreateAssignmentRequest {
+    categoryId?: string | null;
+    courseId: string;
+    dueAt: string;
+    instructions: string;
+    latePenaltyPerDay?: number | null;
+    latePolicy?: string | null;
+    maxAttempts?: number | null;
+    points: number;
+    title: string;
+}
+
+export interface CreateCourseRequest {
+    code: string;
+    coverImageUrl?: string | null;
+    description: string;
+    metadata?: Record<string, unknown>;
+    orgId: string;
+    status?: string | null;
+    termId?: string | null;
+    title: string;
+}
+
+export interface CreateModuleRequest {
+    courseId: string;
+    lockedRule?: string | null;
+    order?: number;
+    title: string;
+}
+
+export interface CreateOrganizationRequest {
+    name: string;
+}
+
+export interface CreateReplyRequest {
+    body: string;
+}
+
+export interface CreateThreadRequest {
+    body: string;
+    courseId: string;
+    title: string;
+}
+
+export interface Criterion {
+    description?: string;
+    id?: string;
+    levels: Level[];
+    title: string;
+}
+
+export interface CriterionGrade {
+    comment?: string;
+    criterion: string;
+    criterionId: string;
+    level: string;
+    levelId: string;
+    maxPoints?: number;
+    points?: number;
+}
+
+export interface CriterionSuggestion {
+    criterionId: string;
+    justification: string;
+    levelId: string;
+}
+
+export interface DailyMetric {
+    activationRate: number;
+    date: string;
+    dau: number;
+    quizzesTaken: number;
+    retention7d: number;
+    runsCount: number;
+    wau: number;
+}
+
+export interface DueFlashcard {
+    back: string;
+    courseId: string;
+    front: string;
+    id: string;
+    isNew: boolean;
+    material?: MaterialRef | null;
+    schedule?: FlashcardSchedule | null;
+    studyPackId: string;
+    tags?: unknown;
+}
+
+export interface DueFlashcardsResponse {
+    cards: DueFlashcard[];
+    date: string;
+    newCount: number;
+    reviewCount: number;
+}
+
+export interface DuplicateModuleRequest {
+    courseId?: string | null;
+    title?: string | null;
+}
+
+export interface ErrorResponse {
+    code: string;
+    error: string;
+    fields?: FieldError[];
+}
+
+export interface ExcludePeerReviewRequest {
+    reason?: string;
+}
+
+export interface ExtensionRequest {
+    dueAt: string;
+    reason?: string | null;
+}
+
+export interface FieldError {
+    field?: string;
+    message?: string;
+}
+
+export interface Flashcard {
+    back: string;
+    front: string;
+    id: string;
+    studyPackId: string;
+    tags?: unknown;
+}
+
+export interface FlashcardRequest {
+    back: string;
+    front: string;
+    tags?: string[];
+}
+
+export interface FlashcardSchedule {
+    dueAt: string;
+    ease: number;
+    flashcardId: string;
+    intervalDays: number;
+    lapses: number;
+    lastGrade: string;
+    lastReviewedAt: string;
+    repetitions: number;
+}
+
+export interface FlashcardSession {
+    createdAt: string;
+    durationSec: number;
+    id: string;
+    knownCount: number;
+    material?: MaterialRef | null;
+    studyPackId: string;
+    unknownCount: number;
+    userId: string;
+}
+
+export interface FlashcardSessionRequest {
+    durationSec: number;
+    responses: Record<string, string>;
+    studyPackId: string;
+}
+
+export interface Grade {
+    breakdown: CriterionGrade[];
+    earned?: number;
+    possible?: number;
+    score?: number;
+}
+
+export interface GradeCategory {
+    courseId: string;
+    dropLowest: number;
+    id: string;
+    name: string;
+    position: number;
+    weight: number;
+}
+
+export interface GradeCategoryRequest {
+    dropLowest?: number;
+    name: string;
+    weight?: number;
+}
+
+export interface GradeOverride {
+    id: string;
+    itemId?: string | null;
+    letter?: string | null;
+    overriddenBy: string;
+    percent?: number | null;
+    reason?: string | null;
+    updatedAt: string;
+    userId: string;
+}
+
+export interface GradeOverrideRequest {
+    itemId?: string | null;
+    letter?: string | null;
+    percent?: number | null;
+    reason?: string | null;
+}
+
+export interface GradeScaleRequest {
+    grades?: LetterGrade[];
+}
+
+export interface GradeSubmissionRequest {
+    attemptId?: string | null;
+    criteria?: Selection[];
+    feedback?: string;
+    score?: number | null;
+    suggestionId?: string | null;
+}
+
+export interface Gradebook {
+    categories: GradeCategory[];
+    courseId: string;
+    items: GradebookItem[];
+    rows: GradebookRow[];
+    scale: LetterGrade[];
+}
+
+export interface GradebookCategoryScore {
+    categoryId: string;
+    percent?: number | null;
+}
+
+export interface GradebookItem {
+    categoryId?: string | null;
+    dueAt?: string | null;
+    id: string;
+    points?: number | null;
+    title: string;
+    type: string;
+}
+
+export interface GradebookRow {
+    categories: GradebookCategoryScore[];
+    computedLetter?: string | null;
+    computedPercent?: number | null;
+    email: string;
+    letter?: string | null;
+    name: string;
+    overrides?: GradeOverride[];
+    percent?: number | null;
+    scores: GradebookScore[];
+    userId: string;
+}
+
+export interface GradebookScore {
+    dropped: boolean;
+    earned?: number | null;
+    itemId: string;
+    overridden: boolean;
+    percent?: number | null;
+    possible?: number | null;
+}
+
+export interface GradingSuggestion {
+    attemptId: string;
+    createdAt: string;
+    criteria?: CriterionSuggestion[];
+    feedback: string;
+    id: string;
+    justification?: string | null;
+    model: string;
+    output: string;
+    prompt: string;
+    provider: string;
+    requestedBy: string;
+    reviewedAt?: string | null;
+    reviewedBy?: string | null;
+    rubricGrade?: Grade | null;
+    rubricVersionId?: string | null;
+    score: number;
+    status: string;
+    text: string;
+    textSource: string;
+}
+
+export interface ImportDocumentRequest {
+    courseId: string;
+    fileUrl: string;
+    moduleId?: string | null;
+    title: string;
+}
+
+export interface ImportResponse {
+    material: Material;
+    studyPack: StudyPackRef;
+}
+
+export interface ImportStatusResponse {
+    createdAt: string;
+    publishedAt?: string | null;
+    status: string;
+    studyPackId: string;
+}
+
+export interface ImportYouTubeRequest {
+    courseId: string;
+    moduleId?: string | null;
+    transcript?: string | null;
+    youtubeUrl: string;
+}
+
+export interface Interval {
+    end: number;
+    start: number;
+}
+
+export interface InviteToOrganizationRequest {
+    email: string;
+    role?: string;
+}
+
+export interface LetterGrade {
+    letter: string;
+    min?: number;
+}
+
+export interface Level {
+    description?: string;
+    id?: string;
+    points?: number;
+    title: string;
+}
+
+export interface LogoutRequest {
+    refreshToken: string;
+}
+
+export interface Material {
+    durationSec?: number | null;
+    fileUrl?: string | null;
+    id: string;
+    moduleId: string;
+    position: number;
+    sourceUrl?: string | null;
+    studyPacks?: StudyPack[];
+    title: string;
+    transcriptText?: string | null;
+    type: string;
+}
+
+export interface MaterialProgress {
+    bestQuizScore?: number | null;
+    completed: boolean;
+    flashcards: number;
+    masteredFlashcards: number;
+    materialId: string;
+    progressPercentage: number;
+    title: string;
+    type: string;
+    unmet: string[];
+    viewed: boolean;
+    watchedPercent: number;
+}
+
+export interface MaterialRef {
+    id: string;
+    title: string;
+    type: string;
+}
+
+export interface MeResponse {
+    email: string;
+    id: string;
+    memberships: Membership[];
+    name: string;
+    role: string;
+}
+
+export interface Membership {
+    id: string;
+    orgId: string;
+    organization?: Organization | null;
+    role: string;
+    status: string;
+}
+
+export interface MembershipResponse {
+    email?: string | null;
+    organizationId: string;
+    role: string;
+    status: string;
+    userId?: string | null;
+}
+
+export interface MessageResponse {
+    message: string;
+}
+
+export interface Module {
+    courseId: string;
+    id: string;
+    lockRequirements?: UnlockRule[];
+    locked: boolean;
+    lockedRule?: string | null;
+    materials?: Material[];
+    order: number;
+    title: string;
+}
+
+export interface ModuleProgress {
+    completed: boolean;
+    completedMaterials: number;
+    materials: MaterialProgress[];
+    moduleId: string;
+    progressPercentage: number;
+    title: string;
+    totalMaterials: number;
+}
+
+export interface MoveMaterialRequest {
+    moduleId: string;
+    position?: number | null;
+}
+
+export interface OrgSettings {
+    allowSelfApproval: boolean;
+    courseCodePrefixes: string[];
+    defaultCourseStatus: string;
+    orgId: string;
+    requireReview: boolean;
+    requiredApprovals: number;
+}
+
+export interface Organization {
+    createdAt: string;
+    id: string;
+    name: string;
+    plan: string;
+}
+
+export interface OrganizationSummary {
+    id: string;
+    name: string;
+    plan: string;
+    role?: string;
+}
+
+export interface OrganizerDashboardResponse {
+    activeUsers: number;
+    dailyMetrics: DailyMetric[];
+    quizzesTaken: number;
+    retentionRate: number;
+    studyPacksGenerated: number;
+    totalUsers: number;
+}
+
+export interface PeerReview {
+    assignmentId: string;
+    attemptId: string;
+    comment?: string | null;
+    excluded: boolean;
+    excludedReason?: string | null;
+    id: string;
+    reviewer?: UserRef | null;
+    rubricGrade?: Grade | null;
+    rubricVersionId: string;
+    score?: number | null;
+    status: string;
+    submissionId: string;
+    submittedAt?: string | null;
+}
+
+export interface PeerReviewOverview {
+    assignedAt?: string | null;
+    assignmentId: string;
+    reviewDueAt: string;
+    reviewsPerSubmission: number;
+    submissions: PeerReviewSubmission[];
+    weight: number;
+}
+
+export interface PeerReviewSettingsRequest {
+    reviewDueAt: string;
+    reviewsPerSubmission: number;
+    weight?: number;
+}
+
+export interface PeerReviewSubmission {
+    attemptId: string;
+    name: string;
+    peerScore?: number | null;
+    reviews: PeerReview[];
+    score?: number | null;
+    submissionId: string;
+    teacherScore?: number | null;
+    userId: string;
+}
+
+export interface PeerReviewTask {
+    assignmentId: string;
+    comment?: string | null;
+    criteria: Criterion[];
+    fileUrl?: string | null;
+    id: string;
+    instructions: string;
+    points: number;
+    reviewDueAt: string;
+    rubricGrade?: Grade | null;
+    score?: number | null;
+    status: string;
+    submittedAt?: string | null;
+    text?: string | null;
+    title: string;
+}
+
+export interface Quiz {
+    id: string;
+    maxAttempts?: number | null;
+    questions: QuizQuestion[];
+    revealAfterSubmit: boolean;
+    revealAt?: string | null;
+    shuffleOptions: boolean;
+    shuffleQuestions: boolean;
+    studyPackId: string;
+    timeLimitSec?: number | null;
+    version: number;
+}
+
+export interface QuizAttempt {
+    answers: unknown;
+    createdAt: string;
+    id: string;
+    material?: MaterialRef | null;
+    pointsEarned: number;
+    pointsPossible: number;
+    questionResults?: unknown;
+    quizId: string;
+    quizVersion: number;
+    score: number;
+    status: string;
+    submittedAt?: string | null;
+    userId: string;
+}
+
+export interface QuizAttemptQuestion {
+    id: string;
+    options: string[];
+    prompt: string;
+    type: string;
+}
+
+export interface QuizAttemptResult {
+    pointsEarned: number;
+    pointsPossible: number;
+    questions?: QuizQuestionReview[];
+    revealed: boolean;
+    score: number;
+}
+
+export interface QuizAttemptView {
+    answers: Record<string, unknown>;
+    expiresAt?: string | null;
+    id: string;
+    questions: QuizAttemptQuestion[];
+    quizId: string;
+    quizVersion: number;
+    result?: QuizAttemptResult | null;
+    startedAt?: string | null;
+    status: string;
+    submittedAt?: string | null;
+    timeLimitSec?: number | null;
+}
+
+export interface QuizQuestion {
+    answerKey?: unknown;
+    explanation?: string | null;
+    id: string;
+    options: unknown;
+    points: number;
+    prompt: string;
+    quizId: string;
+    type: string;
+}
+
+export interface QuizQuestionRequest {
+    answerKey?: unknown;
+    explanation?: string | null;
+    options?: string[];
+    points?: number | null;
+    prompt: string;
+    type: string;
+}
+
+export interface QuizQuestionReview {
+    answerKey: unknown;
+    correct: boolean;
+    earned: number;
+    explanation?: string | null;
+    points: number;
+    questionId: string;
+}
+
+export interface QuizSettings {
+    gradeCategoryId?: string | null;
+    maxAttempts?: number | null;
+    quizId: string;
+    revealAfterSubmit: boolean;
+    revealAt?: string | null;
+    shuffleOptions: boolean;
+    shuffleQuestions: boolean;
+    timeLimitSec?: number | null;
+}
+
+export interface RecordQuizAttemptRequest {
+    answers: Record<string, unknown>;
+    quizId: string;
+}
+
+export interface RefreshTokenRequest {
+    refreshToken: string;
+}
+
+export interface RefreshTokenResponse {
+    accessToken: string;
+}
+
+export interface RegenerateStudyPackRequest {
+    notes?: string;
+}
+
+export interface ReorderRequest {
+    ids: string[];
+}
+
+export interface Reply {
+    body: string;
+    createdAt: string;
+    creator?: UserRef | null;
+    id: string;
+    threadId: string;
+}
+
+export interface RequestResubmissionRequest {
+    comment: string;
+}
+
+export interface ReviewActionRequest {
+    notes?: string;
+}
+
+export interface ReviewComment {
+    author?: UserRef | null;
+    body: string;
+    createdAt: string;
+    id: string;
+    keyPointIndex?: number | null;
+    questionId?: string | null;
+    resolvedAt?: string | null;
+    resolvedBy?: string | null;
+    studyPackId: string;
+    target: string;
+}
+
+export interface ReviewCommentRequest {
+    body: string;
+    keyPointIndex?: number | null;
+    questionId?: string | null;
+    target: string;
+}
+
+export interface ReviewDecisionRequest {
+    comment?: string;
+    decision: string;
+}
+
+export interface ReviewFlashcardRequest {
+    grade: string;
+}
+
+export interface ReviewerCalibration {
+    assigned: number;
+    bias?: number | null;
+    compared: number;
+    meanAbsError?: number | null;
+    reviewer: UserRef;
+    submitted: number;
+}
+
+export interface RevisionDiffResponse {
+    base: number;
+    changes: Change[];
+    head: number;
+}
+
+export interface RollbackStudyPackRequest {
+    notes?: string;
+}
+
+export interface Rubric {
+    createdAt: string;
+    createdBy: string;
+    criteria: Criterion[];
+    description?: string | null;
+    id: string;
+    maxPoints: number;
+    orgId: string;
+    title: string;
+    updatedAt: string;
+    version: number;
+}
+
+export interface RubricRequest {
+    criteria: Criterion[];
+    description?: string | null;
+    title: string;
+}
+
+export interface RubricVersion {
+    createdAt: string;
+    createdBy: string;
+    criteria: Criterion[];
+    id: string;
+    maxPoints: number;
+    number: number;
+}
+
+export interface SaveQuizAnswerRequest {
+    answer?: unknown;
+    questionId: string;
+}
+
+export interface Selection {
+    comment?: string;
+    criterionId: string;
+    levelId: string;
+}
+
+export interface SetGradeCategoryRequest {
+    categoryId?: string | null;
+}
+
+export interface SetVideoDurationRequest {
+    durationSec: number;
+}
+
+export interface SignInRequest {
+    email: string;
+    password: string;
+}
+
+export interface SignUpRequest {
+    email: string;
+    name: string;
+    password: string;
+    role?: string;
+}
+
+export interface Snapshot {
+    flashcards: SnapshotFlashcard[];
+    keyPoints: string[];
+    quizzes: SnapshotQuiz[];
+    summary: string;
+}
+
+export interface SnapshotFlashcard {
+    back: string;
+    front: string;
+    id: string;
+    tags?: unknown;
+}
+
+export interface SnapshotQuestion {
+    answerKey?: unknown;
+    explanation?: string | null;
+    id: string;
+    options?: unknown;
+    points: number;
+    prompt: string;
+    type: string;
+}
+
+export interface SnapshotQuiz {
+    id: string;
+    questions: SnapshotQuestion[];
+}
+
+export interface StudyPack {
+    createdAt: string;
+    id: string;
+    materialId: string;
+    publishedAt?: string | null;
+    requiresApproval: boolean;
+    reviewStatus: string;
+    status: string;
+}
+
+export interface StudyPackContent {
+    flashcards: Flashcard[];
+    id: string;
+    material: Material;
+    materialId: string;
+    quizzes: Quiz[];
+    status: string;
+    summary: StudyPackSummary;
+}
+
+export interface StudyPackRef {
+    id: string;
+    status: string;
+}
+
+export interface StudyPackReview {
+    material?: MaterialRef | null;
+    materialId: string;
+    openComments: number;
+    publishedAt?: string | null;
+    reviewStatus: string;
+    reviewers: StudyPackReviewer[];
+    status: string;
+    studyPackId: string;
+    submittedAt?: string | null;
+    submittedBy?: string | null;
+}
+
+export interface StudyPackReviewer {
+    assignedAt: string;
+    decidedAt?: string | null;
+    decision: string;
+    reviewer?: UserRef | null;
+    reviewerId: string;
+}
+
+export interface StudyPackRevision {
+    author?: UserRef | null;
+    authorType: string;
+    createdAt: string;
+    id: string;
+    notes?: string | null;
+    number: number;
+    published: boolean;
+    restoredFrom?: number | null;
+    snapshot?: Snapshot | null;
+    studyPackId: string;
+}
+
+export interface StudyPackSummary {
+    content?: Record<string, unknown>;
+}
+
+export interface Submission {
+    assignmentId: string;
+    attempts?: SubmissionAttempt[];
+    feedback?: string | null;
+    fileUrl?: string | null;
+    grade?: string | null;
+    id: string;
+    isLate: boolean;
+    latePenaltyPercent: number;
+    resubmitComment?: string | null;
+    resubmitRequestedAt?: string | null;
+    status: string;
+    submittedAt: string;
+    userId: string;
+}
+
+export interface SubmissionAttempt {
+    feedback?: string | null;
+    fileUrl?: string | null;
+    gradedAt?: string | null;
+    gradingSuggestionId?: string | null;
+    id: string;
+    isLate: boolean;
+    latePenaltyPercent: number;
+    number: number;
+    peerScore?: number | null;
+    rawScore?: number | null;
+    rubricGrade?: Grade | null;
+    rubricVersionId?: string | null;
+    score?: number | null;
+    submittedAt: string;
+    teacherScore?: number | null;
+    text?: string | null;
+}
+
+export interface SubmitAssignmentRequest {
+    fileUrl?: string | null;
+    text?: string | null;
+}
+
+export interface SubmitPeerReviewRequest {
+    comment?: string;
+    criteria: Selection[];
+}
+
+export interface SuggestGradeRequest {
+    attemptId?: string | null;
+}
+
+export interface SwitchOrganizationResponse {
+    organization: OrganizationSummary;
+    role: string;
+}
+
+export interface Term {
+    archived: boolean;
+    archivedAt?: string | null;
+    endsOn: string;
+    id: string;
+    name: string;
+    orgId: string;
+    startsOn: string;
+}
+
+export interface TermRequest {
+    endsOn: string;
+    name: string;
+    startsOn: string;
+}
+
+export interface Thread {
+    body: string;
+    courseId: string;
+    createdAt: string;
+    creator?: UserRef | null;
+    id: string;
+    replies?: Reply[];
+    title: string;
+}
+
+export interface ThreadSummary {
+    body: string;
+    courseId: string;
+    createdAt: string;
+    creator?: UserRef | null;
+    id: string;
+    replyCount: number;
+    title: string;
+}
+
+export interface TranscriptRequest {
+    videoUrl?: string;
+}
+
+export interface TranscriptResponse {
+    transcript?: string;
+}
+
+export interface TrashDeleter {
+    id: string;
+    name: string;
+}
+
+export interface TrashItemSummary {
+    deletedBy: TrashDeleter;
+    entityId: string;
+    entityType: string;
+    id: string;
+    purgeAfter: string;
+    title: string;
+    trashedAt: string;
+}
+
+export interface TutorChatRequest {
+    courseId: string;
+    query: string;
+}
+
+export interface TutorChatResponse {
+    analyzedMaterialsCount: number;
+    answer: string;
+    model: string;
+    provider: string;
+    sourceReferences: string[];
+}
+
+export interface UnlockRule {
+    assignmentId?: string | null;
+    date?: string | null;
+    minScore?: number | null;
+    moduleId?: string | null;
+    quizId?: string | null;
+    rules?: UnlockRule[];
+    type: string;
+}
+
+export interface UpdateAssignmentRequest {
+    categoryId?: string | null;
+    dueAt?: string | null;
+    instructions?: string | null;
+    latePenaltyPerDay?: number | null;
+    latePolicy?: string | null;
+    maxAttempts?: number | null;
+    points?: number | null;
+    title?: string | null;
+}
+
+export interface UpdateCompletionCriteriaRequest {
+    minMasteredPercent?: number;
+    minQuizScore?: number;
+    minWatchPercent?: number;
+    requireView?: boolean;
+}
+
+export interface UpdateCourseRequest {
+    code?: string | null;
+    coverImageUrl?: string | null;
+    description?: string | null;
+    metadata?: Record<string, unknown>;
+    status?: string | null;
+    title?: string | null;
+}
+
+export interface UpdateGradeCategoryRequest {
+    dropLowest?: number | null;
+    name?: string | null;
+    position?: number | null;
+    weight?: number | null;
+}
+
+export interface UpdateModuleRequest {
+    lockedRule?: string | null;
+    order?: number | null;
+    title?: string | null;
+}
+
+export interface UpdateOrgSettingsRequest {
+    allowSelfApproval?: boolean | null;
+    courseCodePrefixes: string[] | null;
+    defaultCourseStatus?: string | null;
+    requireReview?: boolean | null;
+    requiredApprovals?: number | null;
+}
+
+export interface UpdateQuizSettingsRequest {
+    maxAttempts?: number | null;
+    revealAfterSubmit?: boolean;
+    revealAt?: string | null;
+    shuffleOptions?: boolean;
+    shuffleQuestions?: boolean;
+    timeLimitSec?: number | null;
+}
+
+export interface UpdateTermRequest {
+    endsOn?: string | null;
+    name?: string | null;
+    startsOn?: string | null;
+}
+
+export interface UserRef {
+    id: string;
+    name: string;
+    role: string;
+}
+
+export interface VideoEngagementResponse {
+    averageWatchedPercent: number;
+    completedViewers: number;
+    durationSec?: number | null;
+    materialId: string;
+    rejectedHeartbeats: number;
+    retention: number[];
+    totalWatchSeconds: number;
+    viewers: number;
+}
+
+export interface VideoHeartbeatRequest {
+    durationSec: number;
+    fromSec?: number;
+    playbackRate?: number | null;
+    toSec?: number;
+}
+
+export interface VideoWatch {
+    durationSec?: number | null;
+    furthestSeconds: number;
+    intervals: Interval[];
+    lastHeartbeatAt?: string | null;
+    materialId: string;
+    rejectedHeartbeats: number;
+    watchedPercent: number;
+    watchedSeconds: number;
+}
+
+export interface YouTubeTranscriptResponse {
+    duration: number;
+    language: string;
+    title: string;
+    transcript: string;
+    videoId: string;
+}
+
+/** Study pack draft for review */
+export const getReviewDraft = (materialId: string) =>
+    apiClient.get<Record<string, unknown>>(`/ai/review/${materialId}`).then((res) => res.data);
+
+/** Approve and publish a study pack draft */
+export const approveStudyPack = (materialId: string, body: ApproveStudyPackRequest) =>
+    apiClient.post<Record<string, unknown>>(`/ai/review/${materialId}/approve`, body).then((res) => res.data);
+
+/** Regenerate a study pack draft */
+export const regenerateStudyPack = (materialId: string, body: RegenerateStudyPackRequest) =>
+    apiClient.post<Record<string, unknown>>(`/ai/review/${materialId}/regenerate`, body).then((res) => res.data);
+
+/** Published study pack for a material */
+export const getStudyPack = (materialId: string) =>
+    apiClient.get<StudyPackContent>(`/ai/studypack/${materialId}`).then((res) => res.data);
+
+/** Fetch a video transcript */
+export const fetchTranscript = (body: TranscriptRequest) =>
+    apiClient.post<TranscriptResponse>('/ai/transcript', body).then((res) => res.data);
+
+/** Ask the AI tutor */
+export const tutorChat = (body: TutorChatRequest) =>
+    apiClient.post<TutorChatResponse>('/ai/tutor', body).then((res) => res.data);
+
+/** How learners watched a video */
+export const getVideoEngagement = (materialId: string) =>
+    apiClient.get<VideoEngagementResponse>(`/analytics/materials/${materialId}/video`).then((res) => res.data);
+
+/** Organizer dashboard for the active organization */
+export const getOrganizerDashboard = () =>
+    apiClient.get<OrganizerDashboardResponse>('/analytics/organizer').then((res) => res.data);
+
+/** Record a finished attempt on an untimed quiz */
+export const recordQuizAttempt = (body: RecordQuizAttemptRequest) =>
+    apiClient.post<QuizAttempt>('/analytics/quiz/attempt', body).then((res) => res.data);
+
+/** Student dashboard */
+export const getStudentDashboard = () =>
+    apiClient.get<Record<string, unknown>>('/analytics/student').then((res) => res.data);
+
+/** Teacher dashboard */
+export const getTeacherDashboard = () =>
+    apiClient.get<Record<string, unknown>>('/analytics/teacher').then((res) => res.data);
+
+/** Create an assignment */
+export const createAssignment = (body: CreateAssignmentRequest) =>
+    apiClient.post<Assignment>('/assignments', body).then((res) => res.data);
+
+/** Assignments in a course with the caller's submission state */
+export const listAssignmentsByCourse = (courseId: string, query: { limit?: number; cursor?: string; sort?: string; status?: string; from?: string; to?: string } = {}) =>
+    apiClient.get<Page<AssignmentSummary>>(`/assignments/course/${courseId}`, { params: query }).then((res) => res.data);
+
+/** Assignment detail */
+export const getAssignment = (id: string) =>
+    apiClient.get<Record<string, unknown>>(`/assignments/${id}`).then((res) => res.data);
+
+/** Update an assignment and its late policy */
+export const updateAssignment = (id: string, body: UpdateAssignmentRequest) =>
+    apiClient.put<Assignment>(`/assignments/${id}`, body).then((res) => res.data);
+
+/** Archive an assignment so it takes no more submissions */
+export const archiveAssignment = (id: string) =>
+    apiClient.post<Assignment>(`/assignments/${id}/archive`).then((res) => res.data);
+
+/** Due-date extensions granted on an assignment */
+export const listAssignmentExtensions = (id: string) =>
+    apiClient.get<AssignmentExtension[]>(`/assignments/${id}/extensions`).then((res) => res.data);
+
+/** Give a student a later due date */
+export const grantAssignmentExtension = (id: string, userId: string, body: ExtensionRequest) =>
+    apiClient.put<AssignmentExtension>(`/assignments/${id}/extensions/${userId}`, body).then((res) => res.data);
+
+/** Remove a student's extension */
+export const revokeAssignmentExtension = (id: string, userId: string) =>
+    apiClient.delete<MessageResponse>(`/assignments/${id}/extensions/${userId}`).then((res) => res.data);
+
+/** Turn on peer review or change its settings */
+export const configurePeerReview = (id: string, body: PeerReviewSettingsRequest) =>
+    apiClient.put<Assignment>(`/assignments/${id}/peer-review`, body).then((res) => res.data);
+
+/** Turn off peer review before reviews are handed out */
+export const disablePeerReview = (id: string) =>
+    apiClient.delete<Assignment>(`/assignments/${id}/peer-review`).then((res) => res.data);
+
+/** Every submission with its reviews and blended score */
+export const getPeerReviews = (id: string) =>
+    apiClient.get<PeerReviewOverview>(`/assignments/${id}/peer-reviews`).then((res) => res.data);
+
+/** Hand out the reviews now that the assignment is due */
+export const assignPeerReviews = (id: string) =>
+    apiClient.post<PeerReviewOverview>(`/assignments/${id}/peer-reviews/assign`).then((res) => res.data);
+
+/** How each reviewer's scores compare with the teacher's */
+export const getPeerReviewCalibration = (id: string) =>
+    apiClient.get<ReviewerCalibration[]>(`/assignments/${id}/peer-reviews/calibration`).then((res) => res.data);
+
+/** The reviews the caller has to do */
+export const getMyPeerReviews = (id: string) =>
+    apiClient.get<PeerReviewTask[]>(`/assignments/${id}/peer-reviews/mine`).then((res) => res.data);
+
+/** Grade an assignment with a rubric */
+export const attachRubric = (id: string, body: AttachRubricRequest) =>
+    apiClient.put<Assignment>(`/assignments/${id}/rubric`, body).then((res) => res.data);
+
+/** Stop grading an assignment with a rubric */
+export const detachRubric = (id: string) =>
+    apiClient.delete<Assignment>(`/assignments/${id}/rubric`).then((res) => res.data);
+
+/** Submit an assignment */
+export const submitAssignment = (id: string, body: SubmitAssignmentRequest) =>
+    apiClient.post<Submission>(`/assignments/${id}/submit`, body).then((res) => res.data);
+
+/** Make an archived assignment active again */
+export const unarchiveAssignment = (id: string) =>
+    apiClient.post<Assignment>(`/assignments/${id}/unarchive`).then((res) => res.data);
+
+/** Revoke a refresh token */
+export const logout = (body: LogoutRequest) =>
+    apiClient.post<MessageResponse>('/auth/logout', body).then((res) => res.data);
+
+/** Current user and memberships */
+export const getMe = () =>
+    apiClient.get<MeResponse>('/auth/me').then((res) => res.data);
+
+/** Exchange a refresh token for an access token */
+export const refreshToken = (body: RefreshTokenRequest) =>
+    apiClient.post<RefreshTokenResponse>('/auth/refresh', body).then((res) => res.data);
+
+/** Sign in with email and password */
+export const signIn = (body: SignInRequest) =>
+    apiClient.post<AuthResponse>('/auth/signin', body).then((res) => res.data);
+
+/** Create an account */
+export const signUp = (body: SignUpRequest) =>
+    apiClient.post<AuthResponse>('/auth/signup', body).then((res) => res.data);
+
+/** Create a course */
+export const createCourse = (body: CreateCourseRequest) =>
+    apiClient.post<Course>('/courses', body).then((res) => res.data);
+
+/** Courses in an organization */
+export const listCoursesByOrg = (orgId: string, query: { limit?: number; cursor?: string; sort?: string; status?: string; createdBy?: string; termId?: string } = {}) =>
+    apiClient.get<Page<Course>>(`/courses/org/${orgId}`, { params: query }).then((res) => res.data);
+
+/** Course with modules and materials */
+export const getCourse = (id: string) =>
+    apiClient.get<Course>(`/courses/${id}`).then((res) => res.data);
+
+/** Update a course's details and lifecycle status */
+export const updateCourse = (id: string, body: UpdateCourseRequest) =>
+    apiClient.put<Course>(`/courses/${id}`, body).then((res) => res.data);
+
+/** Update a course's details and lifecycle status (same as PUT) */
+export const patchCourse = (id: string, body: UpdateCourseRequest) =>
+    apiClient.patch<Course>(`/courses/${id}`, body).then((res) => res.data);
+
+/** Move a course to the trash */
+export const deleteCourse = (id: string) =>
+    apiClient.delete<MessageResponse>(`/courses/${id}`).then((res) => res.data);
+
+/** Start a new offering of a course with its content and shifted due dates */
+export const cloneCourse = (id: string, body: CloneCourseRequest) =>
+    apiClient.post<Course>(`/courses/${id}/clone`, body).then((res) => res.data);
+
+/** A course's gradebook categories in order */
+export const listGradeCategories = (id: string) =>
+    apiClient.get<GradeCategory[]>(`/courses/${id}/grade-categories`).then((res) => res.data);
+
+/** Add a weighted gradebook category */
+export const createGradeCategory = (id: string, body: GradeCategoryRequest) =>
+    apiClient.post<GradeCategory>(`/courses/${id}/grade-categories`, body).then((res) => res.data);
+
+/** Replace a course's letter scale */
+export const updateGradeScale = (id: string, body: GradeScaleRequest) =>
+    apiClient.put<LetterGrade[]>(`/courses/${id}/grade-scale`, body).then((res) => res.data);
+
+/** Every enrolled student's grades */
+export const getGradebook = (id: string) =>
+    apiClient.get<Gradebook>(`/courses/${id}/gradebook`).then((res) => res.data);
+
+/** The gradebook as CSV */
+export const exportGradebook = (id: string) =>
+    apiClient.get<string>(`/courses/${id}/gradebook/export`).then((res) => res.data);
+
+/** Override a student's grade on an item or their final grade */
+export const setGradeOverride = (id: string, userId: string, body: GradeOverrideRequest) =>
+    apiClient.put<GradeOverride>(`/courses/${id}/gradebook/overrides/${userId}`, body).then((res) => res.data);
+
+/** Clear a grade override */
+export const clearGradeOverride = (id: string, userId: string, query: { itemId?: string } = {}) =>
+    apiClient.delete<MessageResponse>(`/courses/${id}/gradebook/overrides/${userId}`, { params: query }).then((res) => res.data);
+
+/** Reorder a course's modules */
+export const reorderModules = (id: string, body: ReorderRequest) =>
+    apiClient.put<Module[]>(`/courses/${id}/modules/order`, body).then((res) => res.data);
+
+/** The caller's own grades in a course */
+export const getMyGrades = (id: string) =>
+    apiClient.get<Gradebook>(`/courses/${id}/my-grades`).then((res) => res.data);
+
+/** Restore a course from the trash */
+export const restoreCourse = (id: string) =>
+    apiClient.post<MessageResponse>(`/courses/${id}/restore`).then((res) => res.data);
+
+/** Study packs in a course by review status, by default those awaiting review */
+export const getReviewQueue = (id: string, query: { limit?: number; cursor?: string; sort?: string; status?: string; from?: string; to?: string; reviewer?: string } = {}) =>
+    apiClient.get<Page<StudyPackReview>>(`/courses/${id}/review-queue`, { params: query }).then((res) => res.data);
+
+/** Start a thread */
+export const createThread = (body: CreateThreadRequest) =>
+    apiClient.post<Thread>('/discussions/threads', body).then((res) => res.data);
+
+/** Threads in a course */
+export const listThreadsByCourse = (courseId: string, query: { limit?: number; cursor?: string; sort?: string; createdBy?: string; from?: string; to?: string } = {}) =>
+    apiClient.get<Page<ThreadSummary>>(`/discussions/threads/course/${courseId}`, { params: query }).then((res) => res.data);
+
+/** Thread with replies */
+export const getThread = (id: string) =>
+    apiClient.get<Thread>(`/discussions/threads/${id}`).then((res) => res.data);
+
+/** Reply to a thread */
+export const createReply = (threadId: string, body: CreateReplyRequest) =>
+    apiClient.post<Reply>(`/discussions/threads/${threadId}/replies`, body).then((res) => res.data);
+
+/** Today's review queue across enrolled courses */
+export const getDueFlashcards = (query: { reviewLimit?: string; newLimit?: string } = {}) =>
+    apiClient.get<DueFlashcardsResponse>('/flashcards/due', { params: query }).then((res) => res.data);
+
+/** The caller's flashcard sessions */
+export const listFlashcardSessions = (query: { limit?: number; cursor?: string; sort?: string; from?: string; to?: string } = {}) =>
+    apiClient.get<Page<FlashcardSession>>('/flashcards/sessions', { params: query }).then((res) => res.data);
+
+/** Record a flashcard session */
+export const recordFlashcardSession = (body: FlashcardSessionRequest) =>
+    apiClient.post<FlashcardSession>('/flashcards/sessions', body).then((res) => res.data);
+
+/** Flashcards in a study pack */
+export const listFlashcardsByStudyPack = (studyPackId: string) =>
+    apiClient.get<Flashcard[]>(`/flashcards/studypack/${studyPackId}`).then((res) => res.data);
+
+/** Edit a flashcard */
+export const updateFlashcard = (id: string, body: FlashcardRequest) =>
+    apiClient.put<Flashcard>(`/flashcards/${id}`, body).then((res) => res.data);
+
+/** Delete a flashcard */
+export const deleteFlashcard = (id: string) =>
+    apiClient.delete<MessageResponse>(`/flashcards/${id}`).then((res) => res.data);
+
+/** Grade a flashcard and reschedule it */
+export const reviewFlashcard = (id: string, body: ReviewFlashcardRequest) =>
+    apiClient.post<FlashcardSchedule>(`/flashcards/${id}/review`, body).then((res) => res.data);
+
+/** Rename, reweight or reorder a gradebook category */
+export const updateGradeCategory = (id: string, body: UpdateGradeCategoryRequest) =>
+    apiClient.put<GradeCategory>(`/grade-categories/${id}`, body).then((res) => res.data);
+
+/** Delete a gradebook category, leaving its items uncategorized */
+export const deleteGradeCategory = (id: string) =>
+    apiClient.delete<MessageResponse>(`/grade-categories/${id}`).then((res) => res.data);
+
+/** Dismiss a pending grading suggestion */
+export const dismissGradingSuggestion = (id: string) =>
+    apiClient.post<GradingSuggestion>(`/grading-suggestions/${id}/dismiss`).then((res) => res.data);
+
+/** Import a document as a material */
+export const importDocument = (body: ImportDocumentRequest) =>
+    apiClient.post<ImportResponse>('/imports/document', body).then((res) => res.data);
+
+/** Study pack generation status */
+export const getImportStatus = (materialId: string) =>
+    apiClient.get<ImportStatusResponse>(`/imports/status/${materialId}`).then((res) => res.data);
+
+/** Import a YouTube video as a material */
+export const importYouTube = (body: ImportYouTubeRequest) =>
+    apiClient.post<ImportResponse>('/imports/youtube', body).then((res) => res.data);
+
+/** Set the length of a video material */
+export const setVideoDuration = (id: string, body: SetVideoDurationRequest) =>
+    apiClient.put<Material>(`/materials/${id}/duration`, body).then((res) => res.data);
+
+/** Move a material to another module or position */
+export const moveMaterial = (id: string, body: MoveMaterialRequest) =>
+    apiClient.post<Material>(`/materials/${id}/move`, body).then((res) => res.data);
+
+/** Create a module */
+export const createModule = (body: CreateModuleRequest) =>
+    apiClient.post<Module>('/modules', body).then((res) => res.data);
+
+/** Modules in a course */
+export const listModulesByCourse = (courseId: string, query: { limit?: number; cursor?: string; sort?: string } = {}) =>
+    apiClient.get<Page<Module>>(`/modules/course/${courseId}`, { params: query }).then((res) => res.data);
+
+/** Module with materials */
+export const getModule = (id: string) =>
+    apiClient.get<Module>(`/modules/${id}`).then((res) => res.data);
+
+/** Update a module */
+export const updateModule = (id: string, body: UpdateModuleRequest) =>
+    apiClient.put<Module>(`/modules/${id}`, body).then((res) => res.data);
+
+/** Move a module to the trash */
+export const deleteModule = (id: string) =>
+    apiClient.delete<MessageResponse>(`/modules/${id}`).then((res) => res.data);
+
+/** Deep-copy a module into this or another course */
+export const duplicateModule = (id: string, body: DuplicateModuleRequest) =>
+    apiClient.post<Module>(`/modules/${id}/duplicate`, body).then((res) => res.data);
+
+/** Reorder a module's materials */
+export const reorderMaterials = (id: string, body: ReorderRequest) =>
+    apiClient.put<Module>(`/modules/${id}/materials/order`, body).then((res) => res.data);
+
+/** Restore a module from the trash */
+export const restoreModule = (id: string) =>
+    apiClient.post<MessageResponse>(`/modules/${id}/restore`).then((res) => res.data);
+
+/** Organizations the current user belongs to */
+export const listOrganizations = () =>
+    apiClient.get<OrganizationSummary[]>('/organizations').then((res) => res.data);
+
+/** Create an organization */
+export const createOrganization = (body: CreateOrganizationRequest) =>
+    apiClient.post<Organization>('/organizations', body).then((res) => res.data);
+
+/** Move an organization and its courses to the trash */
+export const deleteOrganization = (id: string) =>
+    apiClient.delete<MessageResponse>(`/organizations/${id}`).then((res) => res.data);
+
+/** Completion criteria for each material type */
+export const getCompletionCriteria = (id: string) =>
+    apiClient.get<CompletionCriteria[]>(`/organizations/${id}/completion-criteria`).then((res) => res.data);
+
+/** Replace the completion criteria for a material type */
+export const updateCompletionCriteria = (id: string, type: string, body: UpdateCompletionCriteriaRequest) =>
+    apiClient.put<CompletionCriteria>(`/organizations/${id}/completion-criteria/${type}`, body).then((res) => res.data);
+
+/** Add a user to an organization */
+export const inviteToOrganization = (id: string, body: InviteToOrganizationRequest) =>
+    apiClient.post<MembershipResponse>(`/organizations/${id}/invite`, body).then((res) => res.data);
+
+/** Join an organization as a student */
+export const joinOrganization = (id: string) =>
+    apiClient.post<MembershipResponse>(`/organizations/${id}/join`).then((res) => res.data);
+
+/** Restore an organization from the trash */
+export const restoreOrganization = (id: string) =>
+    apiClient.post<MessageResponse>(`/organizations/${id}/restore`).then((res) => res.data);
+
+/** An organization's rubrics by title */
+export const listRubrics = (id: string) =>
+    apiClient.get<Rubric[]>(`/organizations/${id}/rubrics`).then((res) => res.data);
+
+/** Add a reusable rubric to an organization */
+export const createRubric = (id: string, body: RubricRequest) =>
+    apiClient.post<Rubric>(`/organizations/${id}/rubrics`, body).then((res) => res.data);
+
+/** An organization's policy settings */
+export const getOrganizationSettings = (id: string) =>
+    apiClient.get<OrgSettings>(`/organizations/${id}/settings`).then((res) => res.data);
+
+/** Change an organization's policy settings */
+export const updateOrganizationSettings = (id: string, body: UpdateOrgSettingsRequest) =>
+    apiClient.patch<OrgSettings>(`/organizations/${id}/settings`, body).then((res) => res.data);
+
+/** Switch the active organization */
+export const switchOrganization = (id: string) =>
+    apiClient.post<SwitchOrganizationResponse>(`/organizations/${id}/switch`).then((res) => res.data);
+
+/** An organization's terms, latest first */
+export const listTerms = (id: string) =>
+    apiClient.get<Term[]>(`/organizations/${id}/terms`).then((res) => res.data);
+
+/** Add a term to an organization */
+export const createTerm = (id: string, body: TermRequest) =>
+    apiClient.post<Term>(`/organizations/${id}/terms`, body).then((res) => res.data);
+
+/** Deleted items in an organization awaiting purge */
+export const listOrganizationTrash = (id: string, query: { limit?: number; cursor?: string; sort?: string; createdBy?: string; from?: string; to?: string } = {}) =>
+    apiClient.get<Page<TrashItemSummary>>(`/organizations/${id}/trash`, { params: query }).then((res) => res.data);
+
+/** Fill out the rubric for an assigned review */
+export const submitPeerReview = (id: string, body: SubmitPeerReviewRequest) =>
+    apiClient.put<PeerReview>(`/peer-reviews/${id}`, body).then((res) => res.data);
+
+/** Leave a review out of the peer average */
+export const excludePeerReview = (id: string, body: ExcludePeerReviewRequest) =>
+    apiClient.post<PeerReview>(`/peer-reviews/${id}/exclude`, body).then((res) => res.data);
+
+/** Count an excluded review again */
+export const includePeerReview = (id: string) =>
+    apiClient.post<PeerReview>(`/peer-reviews/${id}/include`).then((res) => res.data);
+
+/** The caller's completion of a course, by module and material */
+export const getCourseProgress = (courseId: string) =>
+    apiClient.get<CourseProgressResponse>(`/progress/course/${courseId}`).then((res) => res.data);
+
+/** Report a played segment of a video */
+export const recordVideoHeartbeat = (materialId: string, body: VideoHeartbeatRequest) =>
+    apiClient.post<VideoWatch>(`/progress/materials/${materialId}/heartbeat`, body).then((res) => res.data);
+
+/** Mark a material as opened by the caller */
+export const recordMaterialView = (materialId: string) =>
+    apiClient.post<MessageResponse>(`/progress/materials/${materialId}/view`).then((res) => res.data);
+
+/** How much of a video the caller has watched */
+export const getVideoWatch = (materialId: string) =>
+    apiClient.get<VideoWatch>(`/progress/materials/${materialId}/watch`).then((res) => res.data);
+
+/** The caller's progress across the active organization */
+export const getProgressByOrg = () =>
+    apiClient.get<CourseProgressSummary[]>('/progress/org').then((res) => res.data);
+
+/** An attempt with its saved answers and result */
+export const getQuizAttempt = (id: string) =>
+    apiClient.get<QuizAttemptView>(`/quiz-attempts/${id}`).then((res) => res.data);
+
+/** Save one answer on an attempt in progress */
+export const saveQuizAnswer = (id: string, body: SaveQuizAnswerRequest) =>
+    apiClient.put<QuizAttemptView>(`/quiz-attempts/${id}/answers`, body).then((res) => res.data);
+
+/** Submit an attempt for grading */
+export const submitQuizAttempt = (id: string) =>
+    apiClient.post<QuizAttemptView>(`/quiz-attempts/${id}/submit`).then((res) => res.data);
+
+/** Replace a question and return the quiz */
+export const updateQuizQuestion = (id: string, body: QuizQuestionRequest) =>
+    apiClient.put<Quiz>(`/quiz-questions/${id}`, body).then((res) => res.data);
+
+/** Remove a question and return the quiz */
+export const deleteQuizQuestion = (id: string) =>
+    apiClient.delete<Quiz>(`/quiz-questions/${id}`).then((res) => res.data);
+
+/** Start an attempt, or resume the one in progress */
+export const startQuizAttempt = (id: string) =>
+    apiClient.post<QuizAttemptView>(`/quizzes/${id}/attempts`).then((res) => res.data);
+
+/** File a quiz under a gradebook category */
+export const setQuizGradeCategory = (id: string, body: SetGradeCategoryRequest) =>
+    apiClient.put<QuizSettings>(`/quizzes/${id}/grade-category`, body).then((res) => res.data);
+
+/** Add a question and return the quiz */
+export const createQuizQuestion = (id: string, body: QuizQuestionRequest) =>
+    apiClient.post<Quiz>(`/quizzes/${id}/questions`, body).then((res) => res.data);
+
+/** Reorder a quiz's questions */
+export const reorderQuizQuestions = (id: string, body: ReorderRequest) =>
+    apiClient.put<Quiz>(`/quizzes/${id}/questions/order`, body).then((res) => res.data);
+
+/** Attempt settings of a quiz */
+export const getQuizSettings = (id: string) =>
+    apiClient.get<QuizSettings>(`/quizzes/${id}/settings`).then((res) => res.data);
+
+/** Replace the attempt settings of a quiz */
+export const updateQuizSettings = (id: string, body: UpdateQuizSettingsRequest) =>
+    apiClient.put<QuizSettings>(`/quizzes/${id}/settings`, body).then((res) => res.data);
+
+/** Mark a review comment resolved */
+export const resolveReviewComment = (id: string) =>
+    apiClient.post<ReviewComment>(`/review-comments/${id}/resolve`).then((res) => res.data);
+
+/** A rubric with its current criteria */
+export const getRubric = (id: string) =>
+    apiClient.get<Rubric>(`/rubrics/${id}`).then((res) => res.data);
+
+/** Edit a rubric, starting a new version once it has been graded with */
+export const updateRubric = (id: string, body: RubricRequest) =>
+    apiClient.put<Rubric>(`/rubrics/${id}`, body).then((res) => res.data);
+
+/** Delete a rubric no assignment uses */
+export const deleteRubric = (id: string) =>
+    apiClient.delete<MessageResponse>(`/rubrics/${id}`).then((res) => res.data);
+
+/** Every version of a rubric, oldest first */
+export const listRubricVersions = (id: string) =>
+    apiClient.get<RubricVersion[]>(`/rubrics/${id}/versions`).then((res) => res.data);
+
+/** Add a flashcard to a draft study pack */
+export const createFlashcard = (id: string, body: FlashcardRequest) =>
+    apiClient.post<Flashcard>(`/studypacks/${id}/flashcards`, body).then((res) => res.data);
+
+/** Reorder a study pack's flashcards */
+export const reorderFlashcards = (id: string, body: ReorderRequest) =>
+    apiClient.put<Flashcard[]>(`/studypacks/${id}/flashcards/order`, body).then((res) => res.data);
+
+/** Publish a study pack to students */
+export const publishStudyPack = (id: string, body: ReviewActionRequest) =>
+    apiClient.post<StudyPackReview>(`/studypacks/${id}/publish`, body).then((res) => res.data);
+
+/** Add an empty quiz to a draft study pack */
+export const createQuiz = (id: string) =>
+    apiClient.post<Quiz>(`/studypacks/${id}/quizzes`).then((res) => res.data);
+
+/** Review status, reviewers and open comment count */
+export const getStudyPackReview = (id: string) =>
+    apiClient.get<StudyPackReview>(`/studypacks/${id}/review`).then((res) => res.data);
+
+/** Review comments, oldest first */
+export const listReviewComments = (id: string) =>
+    apiClient.get<ReviewComment[]>(`/studypacks/${id}/review/comments`).then((res) => res.data);
+
+/** Comment on the summary, a key point or a question */
+export const createReviewComment = (id: string, body: ReviewCommentRequest) =>
+    apiClient.post<ReviewComment>(`/studypacks/${id}/review/comments`, body).then((res) => res.data);
+
+/** Approve or request changes as an assigned reviewer */
+export const decideStudyPackReview = (id: string, body: ReviewDecisionRequest) =>
+    apiClient.post<StudyPackReview>(`/studypacks/${id}/review/decision`, body).then((res) => res.data);
+
+/** Submit a draft for review */
+export const submitStudyPackForReview = (id: string) =>
+    apiClient.post<StudyPackReview>(`/studypacks/${id}/review/submit`).then((res) => res.data);
+
+/** Return a pack in review to draft */
+export const withdrawStudyPackReview = (id: string) =>
+    apiClient.post<StudyPackReview>(`/studypacks/${id}/review/withdraw`).then((res) => res.data);
+
+/** Assign a reviewer */
+export const assignStudyPackReviewer = (id: string, body: AssignReviewerRequest) =>
+    apiClient.post<StudyPackReview>(`/studypacks/${id}/reviewers`, body).then((res) => res.data);
+
+/** Unassign a reviewer */
+export const removeStudyPackReviewer = (id: string, userId: string) =>
+    apiClient.delete<StudyPackReview>(`/studypacks/${id}/reviewers/${userId}`).then((res) => res.data);
+
+/** A study pack's revision history, without snapshots */
+export const listStudyPackRevisions = (id: string, query: { limit?: number; cursor?: string; sort?: string; from?: string; to?: string } = {}) =>
+    apiClient.get<Page<StudyPackRevision>>(`/studypacks/${id}/revisions`, { params: query }).then((res) => res.data);
+
+/** Field-level changes between two revisions */
+export const diffStudyPackRevisions = (id: string, query: { base?: string; head?: string } = {}) =>
+    apiClient.get<RevisionDiffResponse>(`/studypacks/${id}/revisions/diff`, { params: query }).then((res) => res.data);
+
+/** A revision with its snapshot */
+export const getStudyPackRevision = (id: string, number: string) =>
+    apiClient.get<StudyPackRevision>(`/studypacks/${id}/revisions/${number}`).then((res) => res.data);
+
+/** Restore a revision's content and publish it as a new revision */
+export const rollbackStudyPack = (id: string, number: string, body: RollbackStudyPackRequest) =>
+    apiClient.post<StudyPackRevision>(`/studypacks/${id}/revisions/${number}/rollback`, body).then((res) => res.data);
+
+/** Hide a published study pack and reopen it for editing */
+export const unpublishStudyPack = (id: string) =>
+    apiClient.post<StudyPackReview>(`/studypacks/${id}/unpublish`).then((res) => res.data);
+
+/** List every attempt of a submission */
+export const getSubmissionAttempts = (id: string) =>
+    apiClient.get<SubmissionAttempt[]>(`/submissions/${id}/attempts`).then((res) => res.data);
+
+/** Grade an attempt of a submission, the latest by default */
+export const gradeSubmission = (id: string, body: GradeSubmissionRequest) =>
+    apiClient.put<Record<string, unknown>>(`/submissions/${id}/grade`, body).then((res) => res.data);
+
+/** List the AI grading suggestions for a submission */
+export const listGradingSuggestions = (id: string) =>
+    apiClient.get<GradingSuggestion[]>(`/submissions/${id}/grading-suggestions`).then((res) => res.data);
+
+/** Draft a grade for an attempt with the AI provider */
+export const suggestGrade = (id: string, body: SuggestGradeRequest) =>
+    apiClient.post<GradingSuggestion>(`/submissions/${id}/grading-suggestions`, body).then((res) => res.data);
+
+/** The reviews of a submission */
+export const getSubmissionPeerReviews = (id: string) =>
+    apiClient.get<PeerReview[]>(`/submissions/${id}/peer-reviews`).then((res) => res.data);
+
+/** Ask the student to hand in again */
+export const requestResubmission = (id: string, body: RequestResubmissionRequest) =>
+    apiClient.post<Submission>(`/submissions/${id}/request-resubmission`, body).then((res) => res.data);
+
+/** Rename or reschedule a term */
+export const updateTerm = (id: string, body: UpdateTermRequest) =>
+    apiClient.put<Term>(`/terms/${id}`, body).then((res) => res.data);
+
+/** Make a term's courses read-only */
+export const archiveTerm = (id: string) =>
+    apiClient.post<Term>(`/terms/${id}/archive`).then((res) => res.data);
+
+/** Reopen an archived term */
+export const unarchiveTerm = (id: string) =>
+    apiClient.post<Term>(`/terms/${id}/unarchive`).then((res) => res.data);
+
+/** Fetch a YouTube transcript */
+export const getYouTubeTranscript = (query: { url?: string } = {}) =>
+    apiClient.get<YouTubeTranscriptResponse>('/youtube/transcript', { params: query }).then((res) => res.data);
+
@@ -1,7 +1,10 @@
 import axios from 'axios';
 
+export const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:3000';
+export const API_V1_URL = `${API_BASE_URL}/api/v1`;
+
 const apiClient = axios.create({
-    baseURL: 'http://localhost:8081',
+    baseURL: API_V1_URL,
     headers: {
         'Content-Type': 'application/json',
     },
@@ -18,6 +21,12 @@ apiClient.interceptors.request.use((config) => {
         config.headers['X-Org-ID'] = activeOrgId;
     }
 
+    // One key per logical POST; a retry reuses the same config and therefore
+    // the same key, so the server replays the first response.
+    if (config.method === 'post' && !config.headers['Idempotency-Key']) {
+        config.headers['Idempotency-Key'] = crypto.randomUUID();
+    }
+
     return config;
 });
 
@@ -371,7 +371,7 @@ export function OrgCoursesPage() {
             let realCourses: CourseCard[] = []
             try {
                 const res = await apiClient.get(`/courses/org/${orgId}`)
-                realCourses = (res.data || []).map((course: any) => ({
+                realCourses = (res.data?.items || []).map((course: any) => ({
                     id: String(course.id),
                     title: String(course.title ?? 'Untitled Course'),
                     code: String(course.code ?? 'N/A'),
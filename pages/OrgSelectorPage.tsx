@@ -224,16 +224,7 @@ export function OrgSelectorPage() {
     setDeleteBusy(true)
 
     try {
-      try {
-        await apiClient.delete(`/organizations/${deleteTarget.id}`)
-      } catch (err: any) {
-        // Fallback for environments where DELETE route is not exposed yet.
-        if (err?.response?.status === 404) {
-          await apiClient.post(`/organizations/${deleteTarget.id}/delete`)
-        } else {
-          throw err
-        }
-      }
+      await apiClient.delete(`/organizations/${deleteTarget.id}`)
 
       const activeOrgId = localStorage.getItem('active_org_id')
       if (activeOrgId === deleteTarget.id) {
@@ -12,6 +12,13 @@ import {
     Sparkles
 } from 'lucide-react'
 import apiClient from '../../lib/axios-client'
+import {
+    getQuizAttempt,
+    saveQuizAnswer,
+    startQuizAttempt,
+    submitQuizAttempt,
+    type QuizAttemptView
+} from '../../lib/api/generated'
 import { OrgTopBar } from '../../features/organization/components/OrgTopBar'
 import { useAuth } from '../../features/auth/context/AuthContext'
 import { InstructorReviewPanel } from '../../features/ai-tutor/components/InstructorReviewPanel'
@@ -172,8 +179,8 @@ export function StudyPage() {
                     materialId: materialId,
                     material: {
                         title: packData?.material?.title || 'Study Session',
-                        videoUrl: packData?.material?.videoUrl || '',
-                        transcript: packData?.material?.transcript || '',
+                        videoUrl: packData?.material?.sourceUrl || '',
+                        transcript: packData?.material?.transcriptText || '',
                     },
                     summary: packData?.summary,
                     quizzes: packData?.quizzes || (packData?.quiz ? [packData.quiz] : []),
@@ -396,25 +403,78 @@ export function StudyPage() {
     )
 }
 
+const UUID_PATTERN = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i
+
 function QuizInteraction({ quiz }: { quiz: any }) {
     const [currentIdx, setCurrentIdx] = useState(0)
     const [answers, setAnswers] = useState<any>({})
     const [isFinished, setIsFinished] = useState(false)
     const [isSubmitting, setIsSubmitting] = useState(false)
+    // Server-side attempt; null for sample quizzes that only exist in the browser
+    const [attempt, setAttempt] = useState<QuizAttemptView | null>(null)
+    const [secondsLeft, setSecondsLeft] = useState<number | null>(null)
+
+    useEffect(() => {
+        if (!quiz?.id || !UUID_PATTERN.test(quiz.id)) return
+        startQuizAttempt(quiz.id)
+            .then((started) => {
+                setAttempt(started)
+                setAnswers(started.answers || {})
+                if (started.status === 'SUBMITTED') setIsFinished(true)
+            })
+            .catch((err) => {
+                alert(err?.response?.data?.error || 'Failed to start quiz attempt')
+            })
+    }, [quiz?.id])
 
-    if (!quiz || !quiz.questions?.length) return <div className="p-8">No quiz questions found.</div>
+    useEffect(() => {
+        if (!attempt?.expiresAt || attempt.status !== 'IN_PROGRESS') {
+            setSecondsLeft(null)
+            return
+        }
+        const expiresAt = new Date(attempt.expiresAt).getTime()
+        const tick = () => setSecondsLeft(Math.max(0, Math.round((expiresAt - Date.now()) / 1000)))
+        tick()
+        const timer = setInterval(tick, 1000)
+        return () => clearInterval(timer)
+    }, [attempt?.expiresAt, attempt?.status])
+
+    useEffect(() => {
+        if (secondsLeft === 0 && attempt?.status === 'IN_PROGRESS' && !isSubmitting) {
+            handleFinish()
+        }
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [secondsLeft])
+
+    const questions: any[] = attempt?.questions || quiz?.questions || []
+    if (!questions.length) return <div className="p-8">No quiz questions found.</div>
 
     const handleSelect = (choice: string) => {
-        setAnswers({ ...answers, [quiz.questions[currentIdx].id]: choice })
+        const questionId = questions[currentIdx].id
+        setAnswers({ ...answers, [questionId]: choice })
+        if (attempt) {
+            saveQuizAnswer(attempt.id, { questionId, answer: choice }).catch(async (err) => {
+                if (err?.response?.status === 409) {
+                    // Time ran out or the attempt was submitted elsewhere
+                    const closed = await getQuizAttempt(attempt.id)
+                    setAttempt(closed)
+                    setIsFinished(true)
+                }
+            })
+        }
     }
 
     const handleFinish = async () => {
         setIsSubmitting(true)
         try {
-            await apiClient.post('/analytics/quiz/attempt', {
-                quizId: quiz.id,
-                answers
-            })
+            if (attempt) {
+                setAttempt(await submitQuizAttempt(attempt.id))
+            } else {
+                await apiClient.post('/analytics/quiz/attempt', {
+                    quizId: quiz.id,
+                    answers
+                })
+            }
             setIsFinished(true)
         } catch (err) {
             alert('Failed to save score')
@@ -424,13 +484,34 @@ function QuizInteraction({ quiz }: { quiz: any }) {
     }
 
     if (isFinished) {
+        const result = attempt?.result
         return (
             <div className="p-12 text-center">
                 <div className="w-20 h-20 bg-green-100 dark:bg-green-900/30 rounded-full flex items-center justify-center mx-auto mb-6">
                     <CheckCircle size={40} className="text-green-600" />
                 </div>
                 <h2 className="text-2xl font-bold text-gray-900 dark:text-white mb-2">Quiz Completed!</h2>
+                {result && (
+                    <p className="text-4xl font-bold text-indigo-600 mb-4">
+                        {result.score}% <span className="text-lg text-gray-500">({result.pointsEarned}/{result.pointsPossible} points)</span>
+                    </p>
+                )}
                 <p className="text-gray-500 mb-8">Your analytics have been updated. Great work!</p>
+                {result?.revealed && result.questions && (
+                    <div className="text-left space-y-4 mb-8">
+                        {result.questions.map((review) => {
+                            const question = questions.find((item) => item.id === review.questionId)
+                            return (
+                                <div key={review.questionId} className={`p-4 rounded-xl border-2 ${review.correct ? 'border-green-200' : 'border-red-200'}`}>
+                                    <p className="font-semibold text-gray-900 dark:text-white">{question?.prompt}</p>
+                                    <p className="text-sm font-bold text-gray-700 dark:text-gray-300 mt-1">{review.earned}/{review.points} points</p>
+                                    <p className="text-sm text-gray-500 mt-1">Answer: {String(review.answerKey)}</p>
+                                    {review.explanation && <p className="text-sm text-gray-500 mt-1">{review.explanation}</p>}
+                                </div>
+                            )
+                        })}
+                    </div>
+                )}
                 <button
                     onClick={() => window.location.reload()}
                     className="bg-indigo-600 text-white px-8 py-3 rounded-xl font-bold"
@@ -441,15 +522,20 @@ function QuizInteraction({ quiz }: { quiz: any }) {
         )
     }
 
-    const q = quiz.questions[currentIdx]
+    const q = questions[currentIdx]
     const questionText = q?.prompt || q?.question || 'Question'
 
     return (
         <div className="p-8">
             <div className="flex justify-between items-center mb-12">
-                <span className="text-sm font-bold text-indigo-600 uppercase tracking-widest">Question {currentIdx + 1}/{quiz.questions.length}</span>
+                <span className="text-sm font-bold text-indigo-600 uppercase tracking-widest">Question {currentIdx + 1}/{questions.length}</span>
+                {secondsLeft !== null && (
+                    <span className="text-sm font-bold text-gray-500">
+                        {Math.floor(secondsLeft / 60)}:{String(secondsLeft % 60).padStart(2, '0')} left
+                    </span>
+                )}
                 <div className="h-2 w-48 bg-gray-100 dark:bg-gray-700 rounded-full">
-                    <div className="h-full bg-indigo-600 rounded-full transition-all" style={{ width: `${((currentIdx + 1) / quiz.questions.length) * 100}%` }}></div>
+                    <div className="h-full bg-indigo-600 rounded-full transition-all" style={{ width: `${((currentIdx + 1) / questions.length) * 100}%` }}></div>
                 </div>
             </div>
 
@@ -478,7 +564,7 @@ function QuizInteraction({ quiz }: { quiz: any }) {
                 >
                     Previous
                 </button>
-                {currentIdx === quiz.questions.length - 1 ? (
+                {currentIdx === questions.length - 1 ? (
                     <button
                         disabled={!answers[q.id] || isSubmitting}
                         onClick={handleFinish}
@@ -2,6 +2,7 @@
 
 interface ImportMetaEnv {
     readonly VITE_GEMINI_API_KEY: string
+    readonly VITE_API_URL?: string
     // Add other env variables here as needed
 }
 